@@ -1,11 +1,37 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/cativovo/budget-tracker/internal/attachment"
+	"github.com/cativovo/budget-tracker/internal/category"
 	"github.com/cativovo/budget-tracker/internal/config"
-	"github.com/cativovo/budget-tracker/internal/repository"
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/forecast"
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/cativovo/budget-tracker/internal/idempotency"
+	"github.com/cativovo/budget-tracker/internal/localfs"
+	internallogger "github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/cativovo/budget-tracker/internal/offline"
+	"github.com/cativovo/budget-tracker/internal/reconciliation"
+	"github.com/cativovo/budget-tracker/internal/rule"
 	"github.com/cativovo/budget-tracker/internal/server"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/trend"
+	"github.com/cativovo/budget-tracker/internal/validator"
+	"github.com/cativovo/budget-tracker/internal/webhook"
 	"go.uber.org/zap"
 )
 
@@ -20,20 +46,273 @@ func main() {
 
 	logger.Infow("Config details", "config", cfg)
 
-	r, err := repository.NewRepository(cfg.DBPath)
+	db, err := sqlite.NewDB(cfg.DBPath)
 	if err != nil {
 		logger.Fatal(err)
 	}
-	defer r.Close()
 
-	if err := r.Migrate(logger); err != nil {
+	if err := db.Migrate(logger); err != nil {
 		logger.Fatal(err)
 	}
 
+	eventBroker := event.NewBroker()
+	db.SetPublisher(eventBroker)
+
+	v := validator.NewValidator()
+
+	categoryRepository := sqlite.NewCategoryRepository(db)
+	expenseRepository := sqlite.NewExpenseRepository(db, categoryRepository)
+	ruleRepository := sqlite.NewRuleRepository(db, categoryRepository)
+	goalRepository := sqlite.NewGoalRepository(db)
+	accountRepository := sqlite.NewAccountRepository(db)
+	reconciliationRepository := sqlite.NewReconciliationRepository(db, accountRepository)
+	attachmentRepository := sqlite.NewAttachmentRepository(db)
+	anomalyRepository := sqlite.NewAnomalyRepository(db)
+	trendRepository := sqlite.NewTrendRepository(db)
+	userRepository := sqlite.NewUserRepository(db)
+	notificationRepository := sqlite.NewNotificationRepository(db)
+	webhookRepository := sqlite.NewWebhookRepository(db)
+	offlineRepository := sqlite.NewOfflineRepository(db)
+	idempotencyRepository := sqlite.NewIdempotencyRepository(db)
+	tokenRepository := sqlite.NewTokenRepository(db)
+
+	attachmentStorage, err := localfs.NewStorage(cfg.AttachmentDir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	webhookService := webhook.NewService(&webhookRepository, &http.Client{Timeout: 10 * time.Second}, v)
+	ruleService := rule.NewService(&ruleRepository, v)
+	anomalyService := anomaly.NewService(&anomalyRepository)
+	expenseService := expense.NewService(&expenseRepository, v, ruleService, anomalyService, webhookService)
+	categoryService := category.NewService(&categoryRepository, v, webhookService, expenseService)
+	goalService := goal.NewService(&goalRepository, v)
+	accountService := account.NewService(&accountRepository, v)
+	reconciliationService := reconciliation.NewService(&reconciliationRepository, v)
+	attachmentService := attachment.NewService(&attachmentRepository, attachmentStorage, cfg.MaxAttachmentSize)
+	forecastService := forecast.NewService(&expenseRepository, accountService, v)
+	trendService := trend.NewService(&trendRepository, v)
+	offlineService := offline.NewService(&offlineRepository, v, webhookService)
+	idempotencyService := idempotency.NewService(&idempotencyRepository, cfg.IdempotencyTTL)
+	tokenService := token.NewService(&tokenRepository, v)
+
+	notificationChannels := []notification.Channel{
+		notification.NewWebhookChannel(&http.Client{Timeout: 10 * time.Second}),
+	}
+	if cfg.SMTPHost != "" {
+		notificationChannels = append(notificationChannels, notification.NewEmailChannel(notification.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     int(cfg.SMTPPort),
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}))
+	}
+	notificationService := notification.NewService(
+		&notificationRepository,
+		categoryService,
+		&expenseRepository,
+		&userRepository,
+		notificationChannels,
+		v,
+	)
+
+	// a second signal kills the app right away
+	signalCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	jobsCtx, stopJobs := context.WithCancel(internallogger.ContextWithLogger(context.Background(), logger))
+	var jobs sync.WaitGroup
+	runJob := func(job func(ctx context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(jobsCtx)
+		}()
+	}
+	runJob(func(ctx context.Context) {
+		purgeTrash(ctx, cfg.TrashRetention, expenseService, categoryService, attachmentService)
+	})
+	runJob(func(ctx context.Context) {
+		checkNotifications(ctx, notificationService)
+	})
+	runJob(func(ctx context.Context) {
+		deliverWebhooks(ctx, webhookService)
+	})
+	runJob(func(ctx context.Context) {
+		purgeIdempotencyKeys(ctx, idempotencyService)
+	})
+
 	s := server.NewServer(server.Resource{
-		Logger:     logger,
-		Repository: r,
+		Config:                cfg,
+		Logger:                logger,
+		ExpenseService:        expenseService,
+		CategoryService:       categoryService,
+		RuleService:           ruleService,
+		GoalService:           goalService,
+		AccountService:        accountService,
+		ReconciliationService: reconciliationService,
+		AttachmentService:     attachmentService,
+		ForecastService:       forecastService,
+		AnomalyService:        anomalyService,
+		TrendService:          trendService,
+		NotificationService:   notificationService,
+		WebhookService:        webhookService,
+		OfflineService:        offlineService,
+		EventBroker:           eventBroker,
+		IdempotencyService:    idempotencyService,
+		TokenService:          tokenService,
 	})
 
-	logger.Fatal(s.Start(fmt.Sprintf(":%s", cfg.Port)))
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- s.Start(fmt.Sprintf(":%s", cfg.Port))
+	}()
+
+	var failed bool
+	select {
+	case err := <-serverErr:
+		logger.Errorw("Server stopped", "error", err)
+		failed = true
+	case <-signalCtx.Done():
+		logger.Info("Shutting down")
+	}
+	stop()
+
+	// the requests finish their writes before the jobs and the database stop
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		logger.Errorw("Failed to wait for the requests in progress", "error", err)
+		failed = true
+	}
+
+	stopJobs()
+	jobs.Wait()
+
+	if err := db.Close(); err != nil {
+		logger.Errorw("Failed to close the database", "error", err)
+		failed = true
+	}
+
+	logger.Info("Stopped")
+	if failed {
+		logger.Sync()
+		os.Exit(1)
+	}
+}
+
+// purgeTrash permanently removes the expenses and categories that have been
+// in the trash longer than the retention, along with the attachments left
+// without an owner. It runs once on startup and then every hour until ctx is
+// done.
+func purgeTrash(ctx context.Context, retention time.Duration, es expense.Service, cs category.Service, as attachment.Service) {
+	logger := internallogger.FromContext(ctx)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-retention)
+
+		expenseCount, err := es.PurgeDeletedExpenses(ctx, before)
+		if err != nil {
+			logger.Errorw("Failed to purge deleted expenses", "error", err)
+		}
+
+		categoryCount, err := cs.PurgeDeletedCategories(ctx, before)
+		if err != nil {
+			logger.Errorw("Failed to purge deleted categories", "error", err)
+		}
+
+		attachmentCount, err := as.PurgeOrphanedAttachments(ctx)
+		if err != nil {
+			logger.Errorw("Failed to purge orphaned attachments", "error", err)
+		}
+
+		logger.Infow(
+			"Purged trash",
+			"before", before,
+			"expense_count", expenseCount,
+			"category_count", categoryCount,
+			"attachment_count", attachmentCount,
+		)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkNotifications creates and sends the notifications that are due for
+// every user. It runs once on startup and then every hour until ctx is done.
+func checkNotifications(ctx context.Context, ns notification.Service) {
+	logger := internallogger.FromContext(ctx)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if err := ns.CheckAll(ctx); err != nil {
+			logger.Errorw("Failed to check notifications", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverWebhooks sends the queued webhook deliveries that are due. It runs
+// every few seconds until ctx is done.
+func deliverWebhooks(ctx context.Context, ws webhook.Service) {
+	logger := internallogger.FromContext(ctx)
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		count, err := ws.DeliverDue(ctx)
+		if err != nil {
+			logger.Errorw("Failed to deliver webhooks", "error", err)
+			continue
+		}
+		if count > 0 {
+			logger.Infow("Delivered webhooks", "count", count)
+		}
+	}
+}
+
+// purgeIdempotencyKeys deletes the idempotency keys that expired. It runs once
+// on startup and then every hour until ctx is done.
+func purgeIdempotencyKeys(ctx context.Context, is idempotency.Service) {
+	logger := internallogger.FromContext(ctx)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		count, err := is.PurgeExpiredKeys(ctx)
+		if err != nil {
+			logger.Errorw("Failed to purge expired idempotency keys", "error", err)
+		} else {
+			logger.Infow("Purged expired idempotency keys", "count", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
@@ -49,7 +49,11 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	defer r.Close()
+	defer func() {
+		if err := r.Close(); err != nil {
+			logger.Errorw("Failed to close the database", "error", err)
+		}
+	}()
 
 	if flags.Clean {
 		cleanDB(r.NonConcurrentDB(), logger)
@@ -0,0 +1,125 @@
+// Command token issues a personal access token for a user. The API only
+// creates tokens for requests that are already signed in, this is how the
+// first one is made.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/config"
+	internallogger "github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/cativovo/budget-tracker/internal/validator"
+	"go.uber.org/zap"
+)
+
+type flags struct {
+	UserID    string        `json:"user_id"`
+	UserName  string        `json:"user_name"`
+	UserEmail string        `json:"user_email"`
+	Name      string        `json:"name"`
+	Scopes    string        `json:"scopes"`
+	ExpiresIn time.Duration `json:"expires_in"`
+}
+
+func getFlags() flags {
+	userIDPtr := flag.String("user", "", "ID of the user the token is for")
+	userNamePtr := flag.String("user-name", "", "name of the user, the user is created if it doesn't exist")
+	userEmailPtr := flag.String("user-email", "", "email of the user, the user is created if it doesn't exist")
+	namePtr := flag.String("name", "cli", "name of the token")
+	scopesPtr := flag.String("scopes", "read,write", "comma separated scopes of the token, read or write")
+	expiresInPtr := flag.Duration("expires-in", 0, "how long until the token expires, 0 never expires")
+	flag.Parse()
+
+	return flags{
+		UserID:    *userIDPtr,
+		UserName:  *userNamePtr,
+		UserEmail: *userEmailPtr,
+		Name:      *namePtr,
+		Scopes:    *scopesPtr,
+		ExpiresIn: *expiresInPtr,
+	}
+}
+
+func main() {
+	logger := zap.Must(zap.NewProduction()).Sugar()
+	defer logger.Sync()
+
+	flags := getFlags()
+	logger.Infow("Flags", "flags", flags)
+
+	if flags.UserID == "" {
+		logger.Fatal("-user is required")
+	}
+
+	cfg, err := config.LoadConfig(logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	db, err := sqlite.NewDB(cfg.DBPath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Errorw("Failed to close the database", "error", err)
+		}
+	}()
+
+	if err := db.Migrate(logger); err != nil {
+		logger.Fatal(err)
+	}
+
+	v := validator.NewValidator()
+	userRepository := sqlite.NewUserRepository(db)
+	tokenRepository := sqlite.NewTokenRepository(db)
+	userService := user.NewService(&userRepository, v)
+	tokenService := token.NewService(&tokenRepository, v)
+
+	ctx := internallogger.ContextWithLogger(context.Background(), logger)
+
+	u, err := userService.UserByID(ctx, flags.UserID)
+	if internal.GetErrorCode(err) == internal.ErrorCodeNotFound && flags.UserEmail != "" {
+		u, err = userService.Create(ctx, user.CreateUserReq{
+			ID:    flags.UserID,
+			Name:  flags.UserName,
+			Email: flags.UserEmail,
+		})
+	}
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	var scopes []token.Scope
+	for _, s := range strings.Split(flags.Scopes, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, token.Scope(s))
+		}
+	}
+
+	var expiresAt *time.Time
+	if flags.ExpiresIn > 0 {
+		t := time.Now().Add(flags.ExpiresIn)
+		expiresAt = &t
+	}
+
+	t, err := tokenService.CreateToken(user.ContextWithUser(ctx, u), token.CreateTokenReq{
+		Name:      flags.Name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		logger.Fatal(internal.GetErrorMessage(err))
+	}
+
+	// the secret is only shown once, on stdout so it can be piped
+	fmt.Println(t.Secret)
+}
@@ -0,0 +1,61 @@
+package account
+
+import "time"
+
+type AccountType string
+
+const (
+	AccountTypeCash       AccountType = "cash"
+	AccountTypeBank       AccountType = "bank"
+	AccountTypeCreditCard AccountType = "credit_card"
+)
+
+// Account is where the money of the user is kept. The balance of a credit
+// card is negative while it has debt.
+type Account struct {
+	ID             string
+	Name           string
+	Type           AccountType
+	OpeningBalance int64
+	// ISO 4217 currency code.
+	Currency string
+	// Opening balance plus the transfers in, minus the transfers out and the
+	// expenses paid with the account.
+	Balance   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Transfer moves money between two accounts of the user. Transfers are not
+// spending, they only change the balance of the accounts.
+type Transfer struct {
+	ID            string
+	FromAccountID string
+	ToAccountID   string
+	Amount        int64
+	Date          time.Time
+	Note          string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type LedgerEntryKind string
+
+const (
+	LedgerEntryKindExpense     LedgerEntryKind = "expense"
+	LedgerEntryKindTransferIn  LedgerEntryKind = "transfer_in"
+	LedgerEntryKindTransferOut LedgerEntryKind = "transfer_out"
+)
+
+// LedgerEntry is a change to the balance of an account along with the
+// balance right after it.
+type LedgerEntry struct {
+	Kind LedgerEntryKind
+	// ID of the expense or transfer.
+	ReferenceID string
+	Name        string
+	// Negative when money leaves the account.
+	Amount  int64
+	Date    time.Time
+	Balance int64
+}
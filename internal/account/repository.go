@@ -0,0 +1,15 @@
+package account
+
+import "context"
+
+type Repository interface {
+	AccountByID(ctx context.Context, id string) (Account, error)
+	ListAccounts(ctx context.Context) ([]Account, error)
+	CreateAccount(ctx context.Context, c CreateAccountReq) (Account, error)
+	UpdateAccount(ctx context.Context, u UpdateAccountReq) (Account, error)
+	DeleteAccount(ctx context.Context, id string) error
+	ListTransfers(ctx context.Context, accountID string) ([]Transfer, error)
+	CreateTransfer(ctx context.Context, c CreateTransferReq) (Transfer, error)
+	DeleteTransfer(ctx context.Context, id string) error
+	ListLedgerEntries(ctx context.Context, l ListLedgerEntriesReq) ([]LedgerEntry, error)
+}
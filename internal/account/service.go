@@ -0,0 +1,147 @@
+package account
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	AccountByID(ctx context.Context, id string) (Account, error)
+	ListAccounts(ctx context.Context) ([]Account, error)
+	CreateAccount(ctx context.Context, c CreateAccountReq) (Account, error)
+	UpdateAccount(ctx context.Context, u UpdateAccountReq) (Account, error)
+	DeleteAccount(ctx context.Context, id string) error
+	ListTransfers(ctx context.Context, accountID string) ([]Transfer, error)
+	CreateTransfer(ctx context.Context, c CreateTransferReq) (Transfer, error)
+	DeleteTransfer(ctx context.Context, id string) error
+	ListLedgerEntries(ctx context.Context, l ListLedgerEntriesReq) ([]LedgerEntry, error)
+}
+
+type CreateAccountReq struct {
+	Name           string      `json:"name" validate:"required"`
+	Type           AccountType `json:"type" validate:"required,oneof=cash bank credit_card"`
+	OpeningBalance int64       `json:"opening_balance"`
+	Currency       string      `json:"currency" validate:"required,iso4217"`
+}
+
+// UpdateAccountReq can't change the currency since the existing amounts are
+// in that currency.
+type UpdateAccountReq struct {
+	ID             string       `json:"id" validate:"required"`
+	Name           *string      `json:"name" validate:"omitempty,min=1"`
+	Type           *AccountType `json:"type" validate:"omitempty,oneof=cash bank credit_card"`
+	OpeningBalance *int64       `json:"opening_balance"`
+}
+
+type CreateTransferReq struct {
+	FromAccountID string `json:"from_account_id" validate:"required"`
+	ToAccountID   string `json:"to_account_id" validate:"required,nefield=FromAccountID"`
+	Amount        int64  `json:"amount" validate:"gt=0"`
+	Date          string `json:"date" validate:"required,datetime=2006-01-02"`
+	Note          string `json:"note"`
+}
+
+// ListLedgerEntriesReq lists the entries of an account. The dates are
+// optional, the balances are always computed from the opening balance.
+type ListLedgerEntriesReq struct {
+	AccountID string `json:"account_id" validate:"required"`
+	StartDate string `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type service struct {
+	r Repository
+	v *validator.Validator
+}
+
+func NewService(r Repository, v *validator.Validator) Service {
+	return &service{
+		r: r,
+		v: v,
+	}
+}
+
+func (s *service) AccountByID(ctx context.Context, id string) (Account, error) {
+	if id == "" {
+		return Account{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.AccountByID(ctx, id)
+}
+
+func (s *service) ListAccounts(ctx context.Context) ([]Account, error) {
+	return s.r.ListAccounts(ctx)
+}
+
+func (s *service) CreateAccount(ctx context.Context, c CreateAccountReq) (Account, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Account{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.CreateAccount(ctx, c)
+}
+
+func (s *service) UpdateAccount(ctx context.Context, u UpdateAccountReq) (Account, error) {
+	if err := s.v.Struct(u); err != nil {
+		return Account{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.UpdateAccount(ctx, u)
+}
+
+func (s *service) DeleteAccount(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteAccount(ctx, id)
+}
+
+func (s *service) ListTransfers(ctx context.Context, accountID string) ([]Transfer, error) {
+	if accountID == "" {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Account ID is required")
+	}
+	return s.r.ListTransfers(ctx, accountID)
+}
+
+// CreateTransfer only allows transfers between accounts with the same
+// currency since there are no exchange rates.
+func (s *service) CreateTransfer(ctx context.Context, c CreateTransferReq) (Transfer, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Transfer{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	from, err := s.r.AccountByID(ctx, c.FromAccountID)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("account.Service.CreateTransfer: %w", err)
+	}
+
+	to, err := s.r.AccountByID(ctx, c.ToAccountID)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("account.Service.CreateTransfer: %w", err)
+	}
+
+	if from.Currency != to.Currency {
+		return Transfer{}, internal.NewError(internal.ErrorCodeInvalid, "Can't transfer between accounts with different currencies")
+	}
+
+	return s.r.CreateTransfer(ctx, c)
+}
+
+func (s *service) DeleteTransfer(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteTransfer(ctx, id)
+}
+
+func (s *service) ListLedgerEntries(ctx context.Context, l ListLedgerEntriesReq) ([]LedgerEntry, error) {
+	if err := s.v.Struct(l); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if l.StartDate != "" && l.EndDate != "" && l.StartDate > l.EndDate {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'start_date' must be before 'end_date'")
+	}
+
+	return s.r.ListLedgerEntries(ctx, l)
+}
@@ -0,0 +1,130 @@
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	// KindOutlier is an expense far above what is usually spent in its
+	// category.
+	KindOutlier Kind = "outlier"
+	// KindDuplicate is an expense that looks like another one with the same
+	// name and amount charged a few days apart.
+	KindDuplicate Kind = "duplicate"
+)
+
+const (
+	// HistoryDays is how many days of past expenses a new expense is compared
+	// with.
+	HistoryDays = 180
+	// An expense is an outlier when its amount is at least this many times
+	// the median of its category.
+	outlierFactor = 3
+	// The median of a category with fewer expenses than this is not reliable
+	// enough to find outliers.
+	minOutlierHistory = 5
+	// Expenses this many days apart, or less, can be duplicates.
+	DuplicateWindowDays = 3
+)
+
+// Expense is what the detection needs to know about an expense.
+type Expense struct {
+	ID         string
+	Name       string
+	Amount     int64
+	Date       time.Time
+	CategoryID string
+}
+
+// Finding is an anomaly found in an expense.
+type Finding struct {
+	Kind Kind
+	// How far off the expense is. For outliers it's the amount divided by the
+	// median of the category, for duplicates it's always 1.
+	Score  float64
+	Reason string
+	// The expense the duplicate looks like.
+	RelatedExpenseID *string
+}
+
+// Flag is a stored finding waiting to be reviewed by the user.
+type Flag struct {
+	ID               string
+	Expense          Expense
+	Kind             Kind
+	Score            float64
+	Reason           string
+	RelatedExpenseID *string
+	ReviewedAt       *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Detect compares the expense with the past expenses of the user. The
+// expense itself is skipped if it's in the history.
+func Detect(e Expense, history []Expense) []Finding {
+	var findings []Finding
+
+	var categoryAmounts []int64
+	var duplicate *Expense
+	for _, h := range history {
+		if h.ID == e.ID {
+			continue
+		}
+
+		if h.CategoryID == e.CategoryID && h.Date.Before(e.Date) {
+			categoryAmounts = append(categoryAmounts, h.Amount)
+		}
+
+		if duplicate == nil && isDuplicate(e, h) {
+			duplicate = &h
+		}
+	}
+
+	if len(categoryAmounts) >= minOutlierHistory {
+		m := median(categoryAmounts)
+		if m > 0 && e.Amount >= m*outlierFactor {
+			score := math.Round(float64(e.Amount)/float64(m)*100) / 100
+			findings = append(findings, Finding{
+				Kind:   KindOutlier,
+				Score:  score,
+				Reason: fmt.Sprintf("Amount is %.2fx the median of %d for the category", score, m),
+			})
+		}
+	}
+
+	if duplicate != nil {
+		findings = append(findings, Finding{
+			Kind:             KindDuplicate,
+			Score:            1,
+			Reason:           fmt.Sprintf("Same name and amount as an expense on %s", duplicate.Date.Format(time.DateOnly)),
+			RelatedExpenseID: &duplicate.ID,
+		})
+	}
+
+	return findings
+}
+
+func isDuplicate(a, b Expense) bool {
+	days := math.Abs(a.Date.Sub(b.Date).Hours() / 24)
+	return a.Amount == b.Amount &&
+		days <= DuplicateWindowDays &&
+		strings.EqualFold(strings.TrimSpace(a.Name), strings.TrimSpace(b.Name))
+}
+
+func median(values []int64) int64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
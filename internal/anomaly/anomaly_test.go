@@ -0,0 +1,87 @@
+package anomaly_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	history := []anomaly.Expense{
+		newExpense(t, "1", "Groceries", 1000, "2006-01-02", "food"),
+		newExpense(t, "2", "Groceries", 1200, "2006-01-09", "food"),
+		newExpense(t, "3", "Lunch", 500, "2006-01-10", "food"),
+		newExpense(t, "4", "Groceries", 900, "2006-01-16", "food"),
+		newExpense(t, "5", "Dinner", 1500, "2006-01-20", "food"),
+		newExpense(t, "6", "Netflix", 500, "2006-01-28", "subscriptions"),
+	}
+
+	tests := map[string]struct {
+		expense anomaly.Expense
+		want    []anomaly.Finding
+	}{
+		"normal": {
+			expense: newExpense(t, "new", "Groceries", 1100, "2006-01-30", "food"),
+		},
+		"outlier": {
+			expense: newExpense(t, "new", "Party", 3000, "2006-01-30", "food"),
+			want: []anomaly.Finding{
+				{
+					Kind:   anomaly.KindOutlier,
+					Score:  3,
+					Reason: "Amount is 3.00x the median of 1000 for the category",
+				},
+			},
+		},
+		"not enough history for outliers": {
+			expense: newExpense(t, "new", "Spotify", 5000, "2006-01-30", "subscriptions"),
+		},
+		"duplicate": {
+			expense: newExpense(t, "new", "netflix", 500, "2006-01-31", "subscriptions"),
+			want: []anomaly.Finding{
+				{
+					Kind:             anomaly.KindDuplicate,
+					Score:            1,
+					Reason:           "Same name and amount as an expense on 2006-01-28",
+					RelatedExpenseID: toPtr(t, "6"),
+				},
+			},
+		},
+		"same name and amount outside the window": {
+			expense: newExpense(t, "new", "Netflix", 500, "2006-02-28", "subscriptions"),
+		},
+		"the expense itself is skipped": {
+			expense: history[5],
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, anomaly.Detect(test.expense, history))
+		})
+	}
+}
+
+func newExpense(t *testing.T, id, name string, amount int64, date, categoryID string) anomaly.Expense {
+	t.Helper()
+
+	d, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return anomaly.Expense{
+		ID:         id,
+		Name:       name,
+		Amount:     amount,
+		Date:       d,
+		CategoryID: categoryID,
+	}
+}
+
+func toPtr[T any](t *testing.T, v T) *T {
+	t.Helper()
+	return &v
+}
@@ -0,0 +1,14 @@
+package anomaly
+
+import (
+	"context"
+)
+
+type Repository interface {
+	// ListExpenses lists the expenses of the user, that are not in the trash,
+	// within the date range.
+	ListExpenses(ctx context.Context, l ListExpensesReq) ([]Expense, error)
+	CreateFlags(ctx context.Context, expenseID string, findings []Finding) ([]Flag, error)
+	ListFlags(ctx context.Context, l ListFlagsReq) ([]Flag, error)
+	ReviewFlag(ctx context.Context, id string) (Flag, error)
+}
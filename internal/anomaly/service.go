@@ -0,0 +1,75 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+)
+
+type Service interface {
+	// ScoreExpense flags the anomalies found in the expense.
+	ScoreExpense(ctx context.Context, e Expense) ([]Flag, error)
+	ListFlags(ctx context.Context, l ListFlagsReq) ([]Flag, error)
+	// ReviewFlag marks the flag as reviewed which takes it off the review
+	// queue.
+	ReviewFlag(ctx context.Context, id string) (Flag, error)
+}
+
+// ListExpensesReq is built by the service from the date of the scored
+// expense, it isn't validated. The dates are formatted as 2006-01-02.
+type ListExpensesReq struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+type ListFlagsReq struct {
+	// List the flags that were already reviewed instead of the ones waiting
+	// for review.
+	Reviewed bool `json:"reviewed"`
+	internal.ListOptions
+}
+
+type service struct {
+	r Repository
+}
+
+func NewService(r Repository) Service {
+	return &service{
+		r: r,
+	}
+}
+
+func (s *service) ScoreExpense(ctx context.Context, e Expense) ([]Flag, error) {
+	history, err := s.r.ListExpenses(ctx, ListExpensesReq{
+		StartDate: e.Date.AddDate(0, 0, -HistoryDays).Format(time.DateOnly),
+		EndDate:   e.Date.AddDate(0, 0, DuplicateWindowDays).Format(time.DateOnly),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("anomaly.Service.ScoreExpense: %w", err)
+	}
+
+	findings := Detect(e, history)
+	if len(findings) == 0 {
+		return nil, nil
+	}
+
+	flags, err := s.r.CreateFlags(ctx, e.ID, findings)
+	if err != nil {
+		return nil, fmt.Errorf("anomaly.Service.ScoreExpense: %w", err)
+	}
+
+	return flags, nil
+}
+
+func (s *service) ListFlags(ctx context.Context, l ListFlagsReq) ([]Flag, error) {
+	return s.r.ListFlags(ctx, l)
+}
+
+func (s *service) ReviewFlag(ctx context.Context, id string) (Flag, error) {
+	if id == "" {
+		return Flag{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.ReviewFlag(ctx, id)
+}
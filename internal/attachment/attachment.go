@@ -0,0 +1,128 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	// decoders of the image types that get thumbnails
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Attachment is a receipt attached to an expense or an expense group.
+type Attachment struct {
+	ID string
+	// Only one of ExpenseID and ExpenseGroupID is set.
+	ExpenseID      *string
+	ExpenseGroupID *string
+	FileName       string
+	// Sniffed from the content, the type declared by the client is ignored.
+	ContentType  string
+	Size         int64
+	HasThumbnail bool
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Storage keeps the content of the attachments.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns an error wrapping fs.ErrNotExist if there's nothing stored
+	// under the key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete does nothing if there's nothing stored under the key.
+	Delete(ctx context.Context, key string) error
+}
+
+const (
+	ThumbnailContentType = "image/jpeg"
+	// Thumbnails fit in a square of this size.
+	thumbnailSize = 256
+	// Images with more pixels than this don't get a thumbnail so a small
+	// file can't make the server decode a huge image.
+	maxThumbnailSourcePixels = 40_000_000
+)
+
+var allowedContentTypes = []string{
+	"application/pdf",
+	"image/gif",
+	"image/jpeg",
+	"image/png",
+	"image/webp",
+}
+
+// SniffContentType detects the type of the content. The second return value
+// is false if the type is not allowed as an attachment.
+func SniffContentType(content []byte) (string, bool) {
+	contentType := http.DetectContentType(content)
+	return contentType, slices.Contains(allowedContentTypes, contentType)
+}
+
+// Thumbnail scales the image down to fit a thumbnail and encodes it as JPEG.
+// The second return value is false if the content is not an image that can
+// be decoded.
+func Thumbnail(content []byte) ([]byte, bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil || cfg.Width*cfg.Height > maxThumbnailSourcePixels {
+		return nil, false
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, false
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, false
+	}
+
+	tw, th := w, h
+	if w > thumbnailSize || h > thumbnailSize {
+		if w >= h {
+			tw, th = thumbnailSize, max(h*thumbnailSize/w, 1)
+		} else {
+			tw, th = max(w*thumbnailSize/h, 1), thumbnailSize
+		}
+	}
+
+	// nearest neighbor is good enough for a preview
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := range th {
+		for x := range tw {
+			c := color.RGBAModel.Convert(src.At(bounds.Min.X+x*w/tw, bounds.Min.Y+y*h/th)).(color.RGBA)
+			// JPEG has no transparency, blend it with white. The color is
+			// alpha-premultiplied so adding what's left of the alpha is enough.
+			c.R += 255 - c.A
+			c.G += 255 - c.A
+			c.B += 255 - c.A
+			c.A = 255
+			dst.SetRGBA(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, false
+	}
+
+	return buf.Bytes(), true
+}
+
+// ContentKey is the storage key of the content of the attachment.
+func ContentKey(id string) string {
+	return id
+}
+
+// ThumbnailKey is the storage key of the thumbnail of the attachment.
+func ThumbnailKey(id string) string {
+	return id + ".thumb"
+}
@@ -0,0 +1,113 @@
+package attachment_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal/attachment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSniffContentType(t *testing.T) {
+	tests := map[string]struct {
+		content         []byte
+		wantContentType string
+		wantAllowed     bool
+	}{
+		"png": {
+			content:         encodePNG(t, 1, 1),
+			wantContentType: "image/png",
+			wantAllowed:     true,
+		},
+		"pdf": {
+			content:         []byte("%PDF-1.7\n"),
+			wantContentType: "application/pdf",
+			wantAllowed:     true,
+		},
+		"html": {
+			content:         []byte("<!DOCTYPE html><html></html>"),
+			wantContentType: "text/html; charset=utf-8",
+			wantAllowed:     false,
+		},
+		"plain text": {
+			content:         []byte("receipt"),
+			wantContentType: "text/plain; charset=utf-8",
+			wantAllowed:     false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			contentType, allowed := attachment.SniffContentType(test.content)
+			assert.Equal(t, test.wantContentType, contentType)
+			assert.Equal(t, test.wantAllowed, allowed)
+		})
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := map[string]struct {
+		width      int
+		height     int
+		wantWidth  int
+		wantHeight int
+	}{
+		"landscape": {
+			width:      1024,
+			height:     512,
+			wantWidth:  256,
+			wantHeight: 128,
+		},
+		"portrait": {
+			width:      300,
+			height:     600,
+			wantWidth:  128,
+			wantHeight: 256,
+		},
+		"small images are not scaled up": {
+			width:      100,
+			height:     50,
+			wantWidth:  100,
+			wantHeight: 50,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			thumbnail, ok := attachment.Thumbnail(encodePNG(t, test.width, test.height))
+			assert.True(t, ok)
+
+			img, err := jpeg.Decode(bytes.NewReader(thumbnail))
+			assert.Nil(t, err)
+			assert.Equal(t, test.wantWidth, img.Bounds().Dx())
+			assert.Equal(t, test.wantHeight, img.Bounds().Dy())
+		})
+	}
+
+	t.Run("not an image", func(t *testing.T) {
+		_, ok := attachment.Thumbnail([]byte("%PDF-1.7\n"))
+		assert.False(t, ok)
+	})
+}
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,13 @@
+package attachment
+
+import "context"
+
+type Repository interface {
+	AttachmentByID(ctx context.Context, id string) (Attachment, error)
+	ListAttachments(ctx context.Context, l ListAttachmentsReq) ([]Attachment, error)
+	CreateAttachment(ctx context.Context, c CreateAttachmentReq) (Attachment, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	// DeleteOrphanedAttachments deletes the attachments of every user whose
+	// expense or expense group no longer exists.
+	DeleteOrphanedAttachments(ctx context.Context) ([]Attachment, error)
+}
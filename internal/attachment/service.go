@@ -0,0 +1,237 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+)
+
+type Service interface {
+	ListAttachments(ctx context.Context, l ListAttachmentsReq) ([]Attachment, error)
+	UploadAttachment(ctx context.Context, u UploadAttachmentReq) (Attachment, error)
+	// OpenAttachment returns the attachment along with its content. The
+	// caller has to close the content.
+	OpenAttachment(ctx context.Context, id string) (Attachment, io.ReadCloser, error)
+	// OpenThumbnail is like OpenAttachment but returns the thumbnail.
+	OpenThumbnail(ctx context.Context, id string) (Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, id string) error
+	PurgeOrphanedAttachments(ctx context.Context) (int64, error)
+}
+
+// ListAttachmentsReq lists the attachments of either an expense or an
+// expense group.
+type ListAttachmentsReq struct {
+	ExpenseID      string `json:"expense_id"`
+	ExpenseGroupID string `json:"expense_group_id"`
+}
+
+type UploadAttachmentReq struct {
+	ExpenseID      string
+	ExpenseGroupID string
+	FileName       string
+	Content        io.Reader
+}
+
+type CreateAttachmentReq struct {
+	ExpenseID      string
+	ExpenseGroupID string
+	FileName       string
+	ContentType    string
+	Size           int64
+	HasThumbnail   bool
+}
+
+type service struct {
+	r       Repository
+	s       Storage
+	maxSize int64
+}
+
+// NewService creates a service that rejects uploads bigger than maxSize
+// bytes.
+func NewService(r Repository, s Storage, maxSize int64) Service {
+	return &service{
+		r:       r,
+		s:       s,
+		maxSize: maxSize,
+	}
+}
+
+func (s *service) ListAttachments(ctx context.Context, l ListAttachmentsReq) ([]Attachment, error) {
+	if err := validateOwner(l.ExpenseID, l.ExpenseGroupID); err != nil {
+		return nil, err
+	}
+	return s.r.ListAttachments(ctx, l)
+}
+
+// UploadAttachment stores the content and a thumbnail if the content is an
+// image.
+func (s *service) UploadAttachment(ctx context.Context, u UploadAttachmentReq) (Attachment, error) {
+	if err := validateOwner(u.ExpenseID, u.ExpenseGroupID); err != nil {
+		return Attachment{}, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(u.Content, s.maxSize+1))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("attachment.Service.UploadAttachment: ReadAll: %w", err)
+	}
+	if int64(len(content)) > s.maxSize {
+		return Attachment{}, internal.NewErrorf(internal.ErrorCodeInvalid, "File is too large, the limit is %d bytes", s.maxSize)
+	}
+	if len(content) == 0 {
+		return Attachment{}, internal.NewError(internal.ErrorCodeInvalid, "File is empty")
+	}
+
+	contentType, ok := SniffContentType(content)
+	if !ok {
+		return Attachment{}, internal.NewErrorf(internal.ErrorCodeInvalid, "Files of type '%s' can't be attached", contentType)
+	}
+
+	thumbnail, hasThumbnail := Thumbnail(content)
+
+	a, err := s.r.CreateAttachment(ctx, CreateAttachmentReq{
+		ExpenseID:      u.ExpenseID,
+		ExpenseGroupID: u.ExpenseGroupID,
+		FileName:       cleanFileName(u.FileName),
+		ContentType:    contentType,
+		Size:           int64(len(content)),
+		HasThumbnail:   hasThumbnail,
+	})
+	if err != nil {
+		return Attachment{}, fmt.Errorf("attachment.Service.UploadAttachment: %w", err)
+	}
+
+	err = s.s.Put(ctx, ContentKey(a.ID), bytes.NewReader(content))
+	if err == nil && hasThumbnail {
+		err = s.s.Put(ctx, ThumbnailKey(a.ID), bytes.NewReader(thumbnail))
+	}
+	if err != nil {
+		s.cleanUp(ctx, a)
+		if deleteErr := s.r.DeleteAttachment(ctx, a.ID); deleteErr != nil {
+			err = errors.Join(err, deleteErr)
+		}
+		return Attachment{}, fmt.Errorf("attachment.Service.UploadAttachment: %w", err)
+	}
+
+	return a, nil
+}
+
+func (s *service) OpenAttachment(ctx context.Context, id string) (Attachment, io.ReadCloser, error) {
+	return s.open(ctx, id, false)
+}
+
+func (s *service) OpenThumbnail(ctx context.Context, id string) (Attachment, io.ReadCloser, error) {
+	return s.open(ctx, id, true)
+}
+
+func (s *service) open(ctx context.Context, id string, thumbnail bool) (Attachment, io.ReadCloser, error) {
+	if id == "" {
+		return Attachment{}, nil, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+
+	a, err := s.r.AttachmentByID(ctx, id)
+	if err != nil {
+		return Attachment{}, nil, fmt.Errorf("attachment.Service.open: %w", err)
+	}
+
+	key := ContentKey(a.ID)
+	if thumbnail {
+		if !a.HasThumbnail {
+			return Attachment{}, nil, internal.NewError(internal.ErrorCodeNotFound, "Attachment has no thumbnail")
+		}
+		key = ThumbnailKey(a.ID)
+	}
+
+	rc, err := s.s.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Attachment{}, nil, internal.NewError(internal.ErrorCodeNotFound, "Attachment not found")
+		}
+		return Attachment{}, nil, fmt.Errorf("attachment.Service.open: %w", err)
+	}
+
+	return a, rc, nil
+}
+
+func (s *service) DeleteAttachment(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+
+	a, err := s.r.AttachmentByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("attachment.Service.DeleteAttachment: %w", err)
+	}
+
+	if err := s.r.DeleteAttachment(ctx, a.ID); err != nil {
+		return fmt.Errorf("attachment.Service.DeleteAttachment: %w", err)
+	}
+
+	s.cleanUp(ctx, a)
+	return nil
+}
+
+// PurgeOrphanedAttachments removes the attachments, and their content, of
+// the expenses and expense groups that were permanently deleted.
+func (s *service) PurgeOrphanedAttachments(ctx context.Context) (int64, error) {
+	attachments, err := s.r.DeleteOrphanedAttachments(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("attachment.Service.PurgeOrphanedAttachments: %w", err)
+	}
+
+	for _, a := range attachments {
+		s.cleanUp(ctx, a)
+	}
+
+	return int64(len(attachments)), nil
+}
+
+// cleanUp deletes the stored content of the attachment. Failures are only
+// logged, leftover files don't break anything.
+func (s *service) cleanUp(ctx context.Context, a Attachment) {
+	logger := logger.FromContext(ctx)
+
+	keys := []string{ContentKey(a.ID)}
+	if a.HasThumbnail {
+		keys = append(keys, ThumbnailKey(a.ID))
+	}
+
+	for _, key := range keys {
+		if err := s.s.Delete(ctx, key); err != nil {
+			logger.Errorw("Failed to delete attachment content", "key", key, "error", err)
+		}
+	}
+}
+
+func validateOwner(expenseID, expenseGroupID string) error {
+	if (expenseID == "") == (expenseGroupID == "") {
+		return internal.NewError(internal.ErrorCodeInvalid, "Either 'expense_id' or 'expense_group_id' is required")
+	}
+	return nil
+}
+
+// cleanFileName keeps the base name without control characters, it ends up
+// in the Content-Disposition header of the downloads.
+func cleanFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+
+	return name
+}
@@ -1,12 +1,140 @@
 package category
 
-import "time"
+import (
+	"math"
+	"time"
+)
+
+// Events published when the categories change.
+const (
+	EventCategoryCreated = "category.created"
+	EventCategoryUpdated = "category.updated"
+	EventCategoryDeleted = "category.deleted"
+)
 
 type Category struct {
-	ID        string
-	Name      string
-	Color     string
-	Icon      string
+	ID    string
+	Name  string
+	Color string
+	Icon  string
+	// Bumped on every change, used to detect concurrent edits.
+	Version   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Nil for top level categories.
+	ParentID *string
+}
+
+// CategoryNode is a category with its subcategories.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode
+}
+
+// NewTree arranges the categories by their parent. Categories whose parent is
+// not in the list are treated as top level categories.
+func NewTree(categories []Category) []CategoryNode {
+	ids := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		ids[c.ID] = true
+	}
+
+	children := make(map[string][]Category)
+	var roots []Category
+	for _, c := range categories {
+		if c.ParentID == nil || !ids[*c.ParentID] {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var build func(c Category) CategoryNode
+	build = func(c Category) CategoryNode {
+		node := CategoryNode{Category: c}
+		for _, child := range children[c.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	tree := make([]CategoryNode, 0, len(roots))
+	for _, r := range roots {
+		tree = append(tree, build(r))
+	}
+
+	return tree
+}
+
+// CategoryTotal is the spending of a category within a date range. RollupTotal
+// includes the spending of all the subcategories.
+type CategoryTotal struct {
+	Category
+	Total       int64
+	RollupTotal int64
+}
+
+type DeletedCategory struct {
+	Category
+	DeletedAt time.Time
+}
+
+// UncategorizedName is the name of the category that receives the expenses
+// of a deleted category when DeleteStrategyUncategorized is used.
+const UncategorizedName = "Uncategorized"
+
+// DeleteStrategy decides what happens to the expenses of a deleted category.
+type DeleteStrategy string
+
+const (
+	// Move the expenses to another category.
+	DeleteStrategyReassign DeleteStrategy = "reassign"
+	// Move the expenses to the Uncategorized category, creating it if needed.
+	DeleteStrategyUncategorized DeleteStrategy = "uncategorized"
+	// Delete the expenses together with the category.
+	DeleteStrategyCascade DeleteStrategy = "cascade"
+)
+
+type DeleteCategoryResult struct {
+	// Number of expenses that were moved or deleted.
+	AffectedExpenses int64
+	// IDs of the expenses that were moved or deleted.
+	AffectedExpenseIDs []string
+}
+
+type MergeCategoriesResult struct {
+	MovedExpenses      int64
+	MovedExpenseIDs    []string
+	MovedRules         int64
+	MovedSubcategories int64
+	// 1 if the budget of the source became the budget of the target. It's
+	// dropped when the target already has one.
+	MovedBudgets int64
+}
+
+// Budget is the amount the user plans to spend on a category every month.
+// It covers the subcategories too.
+type Budget struct {
+	CategoryID string
+	Amount     int64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// BudgetStatus is the spending of a budgeted category, subcategories
+// included, within a month.
+type BudgetStatus struct {
+	Category Category
+	Amount   int64
+	Spent    int64
+}
+
+// Percent is how much of the budget has been spent, it can go over 100.
+func (b BudgetStatus) Percent() float64 {
+	if b.Amount <= 0 {
+		return 0
+	}
+
+	percent := float64(b.Spent) / float64(b.Amount) * 100
+	return math.Round(percent*100) / 100
 }
@@ -2,6 +2,7 @@ package category
 
 import (
 	"context"
+	"time"
 
 	"github.com/cativovo/budget-tracker/internal"
 )
@@ -11,5 +12,15 @@ type Repository interface {
 	ListCategories(ctx context.Context, lo internal.ListOptions) ([]Category, error)
 	CreateCategory(ctx context.Context, c CreateCategoryReq) (Category, error)
 	UpdateCategory(ctx context.Context, u UpdateCategoryReq) (Category, error)
-	DeleteCategory(ctx context.Context, id string) error
+	DeleteCategory(ctx context.Context, d DeleteCategoryReq) (DeleteCategoryResult, error)
+	ListAllCategories(ctx context.Context) ([]Category, error)
+	MoveCategory(ctx context.Context, m MoveCategoryReq) (Category, error)
+	MergeCategories(ctx context.Context, m MergeCategoriesReq) (MergeCategoriesResult, error)
+	ListCategoryTotals(ctx context.Context, t CategoryTotalsReq) ([]CategoryTotal, error)
+	ListDeletedCategories(ctx context.Context, lo internal.ListOptions) ([]DeletedCategory, error)
+	RestoreCategory(ctx context.Context, id string) (Category, error)
+	PurgeDeletedCategories(ctx context.Context, before time.Time) (int64, error)
+	SetBudget(ctx context.Context, b SetBudgetReq) (Budget, error)
+	DeleteBudget(ctx context.Context, categoryID string) error
+	ListBudgetStatuses(ctx context.Context, b BudgetStatusesReq) ([]BudgetStatus, error)
 }
@@ -2,22 +2,49 @@ package category
 
 import (
 	"context"
+	"time"
 
 	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
 	"github.com/cativovo/budget-tracker/internal/validator"
 )
 
 type Service interface {
+	CategoryByID(ctx context.Context, id string) (Category, error)
 	ListCategories(ctx context.Context, lo internal.ListOptions) ([]Category, error)
 	CreateCategory(ctx context.Context, c CreateCategoryReq) (Category, error)
 	UpdateCategory(ctx context.Context, u UpdateCategoryReq) (Category, error)
-	DeleteCategory(ctx context.Context, id string) error
+	DeleteCategory(ctx context.Context, d DeleteCategoryReq) (DeleteCategoryResult, error)
+	CategoryTree(ctx context.Context) ([]CategoryNode, error)
+	MoveCategory(ctx context.Context, m MoveCategoryReq) (Category, error)
+	MergeCategories(ctx context.Context, m MergeCategoriesReq) (MergeCategoriesResult, error)
+	ListCategoryTotals(ctx context.Context, t CategoryTotalsReq) ([]CategoryTotal, error)
+	ListDeletedCategories(ctx context.Context, lo internal.ListOptions) ([]DeletedCategory, error)
+	RestoreCategory(ctx context.Context, id string) (Category, error)
+	PurgeDeletedCategories(ctx context.Context, before time.Time) (int64, error)
+	// SetBudget creates or replaces the monthly budget of the category.
+	SetBudget(ctx context.Context, b SetBudgetReq) (Budget, error)
+	DeleteBudget(ctx context.Context, categoryID string) error
+	ListBudgetStatuses(ctx context.Context, b BudgetStatusesReq) ([]BudgetStatus, error)
+}
+
+// EventDispatcher tells the webhooks of the user about the changes.
+type EventDispatcher interface {
+	Dispatch(ctx context.Context, event string, resourceID string, data any) error
+}
+
+// ExpenseNotifier tells the webhooks of the user about the expenses that were
+// moved or trashed with their category.
+type ExpenseNotifier interface {
+	ExpensesMoved(ctx context.Context, ids []string)
+	ExpensesDeleted(ctx context.Context, ids []string)
 }
 
 type CreateCategoryReq struct {
-	Name  string `json:"name" validate:"required"`
-	Color string `json:"color" validate:"required,hexcolor"`
-	Icon  string `json:"icon" validate:"required"`
+	Name     string  `json:"name" validate:"required"`
+	Color    string  `json:"color" validate:"required,hexcolor"`
+	Icon     string  `json:"icon" validate:"required"`
+	ParentID *string `json:"parent_id"`
 }
 
 type UpdateCategoryReq struct {
@@ -25,20 +52,76 @@ type UpdateCategoryReq struct {
 	Name  *string `json:"name"`
 	Color *string `json:"color" validate:"hexcolor"`
 	Icon  *string `json:"icon"`
+	// The category is only updated if it's still at this version.
+	Version *int64 `json:"version"`
+}
+
+// MoveCategoryReq moves a category, along with its subcategories, under
+// another category. A nil ParentID makes it a top level category.
+type MoveCategoryReq struct {
+	ID       string  `json:"id" validate:"required"`
+	ParentID *string `json:"parent_id"`
+}
+
+// MergeCategoriesReq merges the source category into the target category.
+type MergeCategoriesReq struct {
+	SourceID string `json:"source_id" validate:"required"`
+	TargetID string `json:"target_id" validate:"required"`
+}
+
+type CategoryTotalsReq struct {
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+}
+
+type DeleteCategoryReq struct {
+	ID               string         `json:"id" validate:"required"`
+	Strategy         DeleteStrategy `json:"strategy" validate:"required,oneof=reassign uncategorized cascade"`
+	TargetCategoryID string         `json:"target_category_id" validate:"required_if=Strategy reassign"`
+	// The category is only deleted if it's still at this version.
+	Version *int64 `json:"version"`
+}
+
+type SetBudgetReq struct {
+	CategoryID string `json:"category_id" validate:"required"`
+	Amount     int64  `json:"amount" validate:"gt=0"`
+}
+
+type BudgetStatusesReq struct {
+	Month string `json:"month" validate:"required,datetime=2006-01"`
 }
 
 type service struct {
-	r Repository
-	v *validator.Validator
+	r  Repository
+	v  *validator.Validator
+	ed EventDispatcher
+	en ExpenseNotifier
 }
 
-func NewService(r Repository, v *validator.Validator) Service {
+func NewService(r Repository, v *validator.Validator, ed EventDispatcher, en ExpenseNotifier) Service {
 	return &service{
-		r: r,
-		v: v,
+		r:  r,
+		v:  v,
+		ed: ed,
+		en: en,
 	}
 }
 
+// dispatch tells the webhooks about a change that was already saved, failing
+// to do so doesn't fail the change.
+func (s *service) dispatch(ctx context.Context, event string, id string, data any) {
+	if err := s.ed.Dispatch(ctx, event, id, data); err != nil {
+		logger.FromContext(ctx).Errorw("Failed to dispatch event", "event", event, "id", id, "error", err)
+	}
+}
+
+func (s *service) CategoryByID(ctx context.Context, id string) (Category, error) {
+	if id == "" {
+		return Category{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.CategoryByID(ctx, id)
+}
+
 func (s *service) ListCategories(ctx context.Context, lo internal.ListOptions) ([]Category, error) {
 	return s.r.ListCategories(ctx, lo)
 }
@@ -47,7 +130,14 @@ func (s *service) CreateCategory(ctx context.Context, c CreateCategoryReq) (Cate
 	if err := s.v.Struct(c); err != nil {
 		return Category{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
 	}
-	return s.r.CreateCategory(ctx, c)
+
+	result, err := s.r.CreateCategory(ctx, c)
+	if err != nil {
+		return Category{}, err
+	}
+
+	s.dispatch(ctx, EventCategoryCreated, result.ID, result)
+	return result, nil
 }
 
 func (s *service) UpdateCategory(ctx context.Context, u UpdateCategoryReq) (Category, error) {
@@ -59,9 +149,141 @@ func (s *service) UpdateCategory(ctx context.Context, u UpdateCategoryReq) (Cate
 		return Category{}, internal.NewError(internal.ErrorCodeInvalid, "Must update at least one field")
 	}
 
-	return s.r.UpdateCategory(ctx, u)
+	result, err := s.r.UpdateCategory(ctx, u)
+	if err != nil {
+		return Category{}, err
+	}
+
+	s.dispatch(ctx, EventCategoryUpdated, result.ID, result)
+	return result, nil
+}
+
+func (s *service) DeleteCategory(ctx context.Context, d DeleteCategoryReq) (DeleteCategoryResult, error) {
+	if err := s.v.Struct(d); err != nil {
+		return DeleteCategoryResult{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if d.Strategy == DeleteStrategyReassign && d.TargetCategoryID == d.ID {
+		return DeleteCategoryResult{}, internal.NewError(internal.ErrorCodeInvalid, "Can't reassign expenses to the deleted category")
+	}
+
+	result, err := s.r.DeleteCategory(ctx, d)
+	if err != nil {
+		return DeleteCategoryResult{}, err
+	}
+
+	s.dispatch(ctx, EventCategoryDeleted, d.ID, nil)
+	if d.Strategy == DeleteStrategyCascade {
+		s.en.ExpensesDeleted(ctx, result.AffectedExpenseIDs)
+	} else {
+		s.en.ExpensesMoved(ctx, result.AffectedExpenseIDs)
+	}
+
+	return result, nil
 }
 
-func (s *service) DeleteCategory(ctx context.Context, id string) error {
-	return s.r.DeleteCategory(ctx, id)
+func (s *service) CategoryTree(ctx context.Context) ([]CategoryNode, error) {
+	categories, err := s.r.ListAllCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewTree(categories), nil
+}
+
+func (s *service) MoveCategory(ctx context.Context, m MoveCategoryReq) (Category, error) {
+	if err := s.v.Struct(m); err != nil {
+		return Category{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if m.ParentID != nil && *m.ParentID == m.ID {
+		return Category{}, internal.NewError(internal.ErrorCodeInvalid, "Category can't be its own parent")
+	}
+
+	result, err := s.r.MoveCategory(ctx, m)
+	if err != nil {
+		return Category{}, err
+	}
+
+	s.dispatch(ctx, EventCategoryUpdated, result.ID, result)
+	return result, nil
+}
+
+// MergeCategories moves everything that references the source category to the
+// target category and deletes the source category. It's used to clean up near
+// duplicates like "Grocery" and "Groceries".
+func (s *service) MergeCategories(ctx context.Context, m MergeCategoriesReq) (MergeCategoriesResult, error) {
+	if err := s.v.Struct(m); err != nil {
+		return MergeCategoriesResult{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if m.SourceID == m.TargetID {
+		return MergeCategoriesResult{}, internal.NewError(internal.ErrorCodeInvalid, "Can't merge a category into itself")
+	}
+
+	result, err := s.r.MergeCategories(ctx, m)
+	if err != nil {
+		return MergeCategoriesResult{}, err
+	}
+
+	s.dispatch(ctx, EventCategoryDeleted, m.SourceID, nil)
+	s.en.ExpensesMoved(ctx, result.MovedExpenseIDs)
+
+	return result, nil
+}
+
+func (s *service) ListCategoryTotals(ctx context.Context, t CategoryTotalsReq) ([]CategoryTotal, error) {
+	if err := s.v.Struct(t); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if t.StartDate > t.EndDate {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'start_date' must be before 'end_date'")
+	}
+
+	return s.r.ListCategoryTotals(ctx, t)
+}
+
+func (s *service) ListDeletedCategories(ctx context.Context, lo internal.ListOptions) ([]DeletedCategory, error) {
+	return s.r.ListDeletedCategories(ctx, lo)
+}
+
+func (s *service) RestoreCategory(ctx context.Context, id string) (Category, error) {
+	if id == "" {
+		return Category{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+
+	result, err := s.r.RestoreCategory(ctx, id)
+	if err != nil {
+		return Category{}, err
+	}
+
+	s.dispatch(ctx, EventCategoryCreated, result.ID, result)
+	return result, nil
+}
+
+// PurgeDeletedCategories permanently removes the categories, together with
+// their expenses, that were moved to the trash before the given time.
+func (s *service) PurgeDeletedCategories(ctx context.Context, before time.Time) (int64, error) {
+	return s.r.PurgeDeletedCategories(ctx, before)
+}
+
+func (s *service) SetBudget(ctx context.Context, b SetBudgetReq) (Budget, error) {
+	if err := s.v.Struct(b); err != nil {
+		return Budget{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.SetBudget(ctx, b)
+}
+
+func (s *service) DeleteBudget(ctx context.Context, categoryID string) error {
+	if categoryID == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "Category ID is required")
+	}
+	return s.r.DeleteBudget(ctx, categoryID)
+}
+
+func (s *service) ListBudgetStatuses(ctx context.Context, b BudgetStatusesReq) ([]BudgetStatus, error) {
+	if err := s.v.Struct(b); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.ListBudgetStatuses(ctx, b)
 }
@@ -2,7 +2,13 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -12,10 +18,89 @@ type Config struct {
 	Env    string
 	Port   string
 	DBPath string
+	// How long deleted expenses and categories are kept in the trash before
+	// they are permanently removed.
+	TrashRetention time.Duration
+	// Directory where the attachment files are stored.
+	AttachmentDir string
+	// Maximum size of an attachment in bytes.
+	MaxAttachmentSize int64
+	// SMTP server the email notifications are sent through. Email
+	// notifications are turned off when the host is empty.
+	SMTPHost     string
+	SMTPPort     int64
+	SMTPUsername string
+	SMTPPassword string `json:"-"`
+	SMTPFrom     string
+	// How long the responses of requests with an Idempotency-Key are kept
+	// for replay.
+	IdempotencyTTL time.Duration
+	// Requests a client can make per minute, and at once, to the read (GET,
+	// HEAD and OPTIONS) and the write routes. A rate of 0 turns the limit off.
+	ReadRateLimit  int64
+	ReadRateBurst  int64
+	WriteRateLimit int64
+	WriteRateBurst int64
+	// Maximum size of a request body in bytes, 0 turns the limit off.
+	// Attachment uploads are limited by MaxAttachmentSize instead.
+	MaxBodySize int64
+	// Origins allowed to call the API from the browser, "*" allows every
+	// origin but can't be used with the credentials. CORS is turned off when
+	// there's none.
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSAllowCredentials bool
+	// How long the browsers can cache the answer to a preflight request.
+	CORSMaxAge time.Duration
+	// Security headers set on every response, an empty value leaves the
+	// header out.
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+	// Key the CSRF tokens are signed with. A random key is used when it's
+	// empty, the tokens then don't survive a restart.
+	CSRFSecret string `json:"-"`
+	// Timeouts of the HTTP server, 0 turns a timeout off. The write timeout
+	// doesn't cut the event streams, they clear it once when they start.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	// How long the requests in progress have to finish once the server is
+	// asked to stop.
+	ShutdownTimeout time.Duration
+	// Certificate and key files of HTTPS. HTTPS is turned off when they are
+	// empty and TLSSelfSigned is false.
+	TLSCertFile string
+	TLSKeyFile  string
+	// Serve HTTPS with a generated self-signed certificate for TLSHosts. It's
+	// saved to the certificate and key files when they are set, so it only
+	// needs to be trusted once.
+	TLSSelfSigned bool
+	TLSHosts      []string
+	// Port of the listener that redirects HTTP to HTTPS, empty turns it off.
+	HTTPRedirectPort string
+	// How long the browsers must only use HTTPS once they have seen it, 0
+	// turns HSTS off.
+	HSTSMaxAge time.Duration
+}
+
+// TLSEnabled reports whether the server serves HTTPS.
+func (c Config) TLSEnabled() bool {
+	return c.TLSSelfSigned || c.TLSCertFile != "" || c.TLSKeyFile != ""
 }
 
 const envKey = "BUDGET_TRACKER_ENV"
 
+// defaultContentSecurityPolicy lets the API docs load Stoplight Elements from
+// unpkg.
+const defaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' https://unpkg.com; " +
+	"style-src 'self' 'unsafe-inline' https://unpkg.com; " +
+	"img-src 'self' data: blob:; " +
+	"frame-ancestors 'none'"
+
 // https://github.com/joho/godotenv?tab=readme-ov-file#precedence--conventions
 func loadEnv(logger *zap.SugaredLogger) []string {
 	var loadedFiles []string
@@ -79,9 +164,234 @@ func LoadConfig(logger *zap.SugaredLogger) (Config, error) {
 		logger.Info("env vars loaded from", f)
 	}
 
+	trashRetention, err := durationFromEnv("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxAttachmentSize, err := int64FromEnv("MAX_ATTACHMENT_SIZE", 10<<20)
+	if err != nil {
+		return Config{}, err
+	}
+
+	idempotencyTTL, err := durationFromEnv("IDEMPOTENCY_TTL", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	readRateLimit, err := int64FromEnv("READ_RATE_LIMIT", 600)
+	if err != nil {
+		return Config{}, err
+	}
+
+	readRateBurst, err := int64FromEnv("READ_RATE_BURST", 60)
+	if err != nil {
+		return Config{}, err
+	}
+
+	writeRateLimit, err := int64FromEnv("WRITE_RATE_LIMIT", 120)
+	if err != nil {
+		return Config{}, err
+	}
+
+	writeRateBurst, err := int64FromEnv("WRITE_RATE_BURST", 20)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxBodySize, err := int64FromEnv("MAX_BODY_SIZE", 1<<20)
+	if err != nil {
+		return Config{}, err
+	}
+
+	corsAllowCredentials, err := boolFromEnv("CORS_ALLOW_CREDENTIALS", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	corsAllowedOrigins := stringsFromEnv("CORS_ALLOWED_ORIGINS", nil)
+	// every site could send the requests with the cookies of the user
+	if corsAllowCredentials && slices.Contains(corsAllowedOrigins, "*") {
+		return Config{}, errors.New("CORS_ALLOW_CREDENTIALS can't be used with the \"*\" origin of CORS_ALLOWED_ORIGINS")
+	}
+
+	corsMaxAge, err := durationFromEnv("CORS_MAX_AGE", 10*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	readHeaderTimeout, err := durationFromEnv("READ_HEADER_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	readTimeout, err := durationFromEnv("READ_TIMEOUT", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	writeTimeout, err := durationFromEnv("WRITE_TIMEOUT", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	idleTimeout, err := durationFromEnv("IDLE_TIMEOUT", 2*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+
+	shutdownTimeout, err := durationFromEnv("SHUTDOWN_TIMEOUT", 30*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+
+	tlsSelfSigned, err := boolFromEnv("TLS_SELF_SIGNED", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	hstsMaxAge, err := durationFromEnv("HSTS_MAX_AGE", 180*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+
+	smtpPort, err := int64FromEnv("SMTP_PORT", 587)
+	if err != nil {
+		return Config{}, err
+	}
+
+	attachmentDir := os.Getenv("ATTACHMENT_DIR")
+	if attachmentDir == "" {
+		attachmentDir = "attachments"
+	}
+
 	return Config{
-		Port:   os.Getenv("PORT"),
-		DBPath: os.Getenv("DB_PATH"),
-		Env:    os.Getenv(envKey),
+		Port:               os.Getenv("PORT"),
+		DBPath:             os.Getenv("DB_PATH"),
+		Env:                os.Getenv(envKey),
+		TrashRetention:     trashRetention,
+		AttachmentDir:      attachmentDir,
+		MaxAttachmentSize:  maxAttachmentSize,
+		SMTPHost:           os.Getenv("SMTP_HOST"),
+		SMTPPort:           smtpPort,
+		SMTPUsername:       os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:           os.Getenv("SMTP_FROM"),
+		IdempotencyTTL:     idempotencyTTL,
+		ReadRateLimit:      readRateLimit,
+		ReadRateBurst:      readRateBurst,
+		WriteRateLimit:     writeRateLimit,
+		WriteRateBurst:     writeRateBurst,
+		MaxBodySize:        maxBodySize,
+		CORSAllowedOrigins: corsAllowedOrigins,
+		CORSAllowedMethods: stringsFromEnv("CORS_ALLOWED_METHODS", []string{
+			http.MethodGet,
+			http.MethodPost,
+			http.MethodPatch,
+			http.MethodPut,
+			http.MethodDelete,
+		}),
+		CORSAllowedHeaders: stringsFromEnv("CORS_ALLOWED_HEADERS", []string{
+			"Authorization",
+			"Content-Type",
+			"If-Match",
+			"Idempotency-Key",
+			"Last-Event-ID",
+			"X-CSRF-Token",
+		}),
+		CORSAllowCredentials:  corsAllowCredentials,
+		CORSMaxAge:            corsMaxAge,
+		ContentSecurityPolicy: stringFromEnv("CONTENT_SECURITY_POLICY", defaultContentSecurityPolicy),
+		FrameOptions:          stringFromEnv("FRAME_OPTIONS", "DENY"),
+		ReferrerPolicy:        stringFromEnv("REFERRER_POLICY", "strict-origin-when-cross-origin"),
+		CSRFSecret:            os.Getenv("CSRF_SECRET"),
+		ReadHeaderTimeout:     readHeaderTimeout,
+		ReadTimeout:           readTimeout,
+		WriteTimeout:          writeTimeout,
+		IdleTimeout:           idleTimeout,
+		ShutdownTimeout:       shutdownTimeout,
+		TLSCertFile:           os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            os.Getenv("TLS_KEY_FILE"),
+		TLSSelfSigned:         tlsSelfSigned,
+		TLSHosts:              stringsFromEnv("TLS_HOSTS", []string{"localhost", "127.0.0.1", "::1"}),
+		HTTPRedirectPort:      os.Getenv("HTTP_REDIRECT_PORT"),
+		HSTSMaxAge:            hstsMaxAge,
 	}, nil
 }
+
+// durationFromEnv parses the env var as a time.Duration, e.g. "720h". The
+// fallback is used when the env var is not set.
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return d, nil
+}
+
+// int64FromEnv parses the env var as an int64. The fallback is used when the
+// env var is not set.
+func int64FromEnv(key string, fallback int64) (int64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return n, nil
+}
+
+// boolFromEnv parses the env var as a bool, e.g. "true". The fallback is used
+// when the env var is not set.
+func boolFromEnv(key string, fallback bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return b, nil
+}
+
+// stringFromEnv returns the env var. Unlike os.Getenv, the fallback is only
+// used when the env var is not set so it can be set to empty.
+func stringFromEnv(key string, fallback string) string {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	return v
+}
+
+// stringsFromEnv splits the comma separated env var, e.g. "GET, POST". The
+// fallback is used when the env var is not set.
+func stringsFromEnv(key string, fallback []string) []string {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	var result []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
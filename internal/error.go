@@ -11,7 +11,11 @@ const (
 	ErrorCodeInvalid  ErrorCode = "invalid"
 	ErrorCodeNotFound ErrorCode = "not_found"
 	ErrorCodeConflict ErrorCode = "conflict"
-	ErrorCodeInternal ErrorCode = "internal"
+	// The resource changed since the version the client expected.
+	ErrorCodePreconditionFailed ErrorCode = "precondition_failed"
+	// The credentials of the request are missing or invalid.
+	ErrorCodeUnauthorized ErrorCode = "unauthorized"
+	ErrorCodeInternal     ErrorCode = "internal"
 )
 
 type Error struct {
@@ -0,0 +1,164 @@
+package event
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// historySize is how many of the latest events of a user are kept so
+	// a client that reconnects can catch up.
+	historySize = 256
+	// bufferSize is how many events a subscriber can fall behind before it
+	// is dropped.
+	bufferSize = 64
+)
+
+// Event is a change to one of the resources of a user. The type is one of
+// the event constants of the resource's package, e.g. expense.created.
+type Event struct {
+	ID         int64
+	Type       string
+	ResourceID string
+	CreatedAt  time.Time
+}
+
+// Publisher publishes the changes of a user.
+type Publisher interface {
+	Publish(userID, typ, resourceID string)
+}
+
+// Broker is an in-process pub/sub of the changes of every user. Event IDs
+// increase across users and start from the time the broker was created so
+// the IDs of an earlier process can be told apart.
+type Broker struct {
+	mu      sync.Mutex
+	firstID int64
+	lastID  int64
+	streams map[string]*stream
+}
+
+var _ Publisher = (*Broker)(nil)
+
+type stream struct {
+	history []Event
+	// dropped is the ID of the newest event that fell out of the history.
+	dropped     int64
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	firstID := time.Now().UnixMicro()
+
+	return &Broker{
+		firstID: firstID,
+		lastID:  firstID - 1,
+		streams: make(map[string]*stream),
+	}
+}
+
+func (b *Broker) stream(userID string) *stream {
+	s, ok := b.streams[userID]
+	if !ok {
+		s = &stream{subscribers: make(map[*Subscription]struct{})}
+		b.streams[userID] = s
+	}
+	return s
+}
+
+// Publish sends the event to the subscribers of the user. Subscribers that
+// fell too far behind are dropped instead of blocking the publisher.
+func (b *Broker) Publish(userID, typ, resourceID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e := Event{
+		ID:         b.lastID,
+		Type:       typ,
+		ResourceID: resourceID,
+		CreatedAt:  time.Now(),
+	}
+
+	s := b.stream(userID)
+	s.history = append(s.history, e)
+	if len(s.history) > historySize {
+		s.dropped = s.history[0].ID
+		s.history = s.history[1:]
+	}
+
+	for sub := range s.subscribers {
+		select {
+		case sub.events <- e:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Subscription receives the events of a user until it is closed.
+type Subscription struct {
+	// Missed are the events published after the last event ID the client
+	// has seen.
+	Missed []Event
+	// Stale is true when the events after the last event ID the client has
+	// seen are no longer known. The client has to refetch its data instead
+	// of catching up.
+	Stale bool
+	// LastID is the ID of the latest event when the subscription started.
+	LastID int64
+
+	events chan Event
+	broker *Broker
+	userID string
+}
+
+// Subscribe starts receiving the events of the user. lastEventID is the ID
+// of the last event the client has seen, zero if it hasn't seen any.
+func (b *Broker) Subscribe(userID string, lastEventID int64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stream(userID)
+	sub := &Subscription{
+		LastID: b.lastID,
+		events: make(chan Event, bufferSize),
+		broker: b,
+		userID: userID,
+	}
+
+	if lastEventID > 0 {
+		if lastEventID < b.firstID-1 || lastEventID > b.lastID || lastEventID < s.dropped {
+			sub.Stale = true
+		} else {
+			for _, e := range s.history {
+				if e.ID > lastEventID {
+					sub.Missed = append(sub.Missed, e)
+				}
+			}
+		}
+	}
+
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Events is closed when the subscription is closed or when it fell too far
+// behind.
+func (sub *Subscription) Events() <-chan Event {
+	return sub.events
+}
+
+func (sub *Subscription) Close() {
+	b := sub.broker
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stream(sub.userID)
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
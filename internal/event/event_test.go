@@ -0,0 +1,96 @@
+package event_test
+
+import (
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("subscribers only get the events of their user", func(t *testing.T) {
+		b := event.NewBroker()
+
+		sub1 := b.Subscribe("1", 0)
+		defer sub1.Close()
+		sub2 := b.Subscribe("2", 0)
+		defer sub2.Close()
+
+		b.Publish("1", "expense.created", "a")
+
+		e := <-sub1.Events()
+		assert.Equal(t, "expense.created", e.Type)
+		assert.Equal(t, "a", e.ResourceID)
+		assert.Greater(t, e.ID, sub1.LastID)
+
+		assert.Empty(t, sub2.Events())
+	})
+
+	t.Run("resume after the last event id", func(t *testing.T) {
+		b := event.NewBroker()
+
+		b.Publish("1", "expense.created", "a")
+		sub := b.Subscribe("1", 0)
+		sub.Close()
+		first := sub.LastID
+
+		b.Publish("1", "expense.updated", "a")
+		b.Publish("2", "expense.created", "b")
+		b.Publish("1", "expense.deleted", "a")
+
+		resumed := b.Subscribe("1", first)
+		defer resumed.Close()
+
+		assert.False(t, resumed.Stale)
+		assert.Len(t, resumed.Missed, 2)
+		assert.Equal(t, "expense.updated", resumed.Missed[0].Type)
+		assert.Equal(t, "expense.deleted", resumed.Missed[1].Type)
+	})
+
+	t.Run("unknown last event id is stale", func(t *testing.T) {
+		b := event.NewBroker()
+		b.Publish("1", "expense.created", "a")
+
+		previousRun := b.Subscribe("1", 1)
+		defer previousRun.Close()
+		assert.True(t, previousRun.Stale)
+		assert.Empty(t, previousRun.Missed)
+
+		future := b.Subscribe("1", previousRun.LastID+1)
+		defer future.Close()
+		assert.True(t, future.Stale)
+	})
+
+	t.Run("last event id older than the history is stale", func(t *testing.T) {
+		b := event.NewBroker()
+
+		b.Publish("1", "expense.created", "a")
+		sub := b.Subscribe("1", 0)
+		sub.Close()
+
+		for range 300 {
+			b.Publish("1", "expense.updated", "a")
+		}
+
+		resumed := b.Subscribe("1", sub.LastID)
+		defer resumed.Close()
+		assert.True(t, resumed.Stale)
+	})
+
+	t.Run("slow subscribers are dropped", func(t *testing.T) {
+		b := event.NewBroker()
+
+		sub := b.Subscribe("1", 0)
+		defer sub.Close()
+
+		for range 100 {
+			b.Publish("1", "expense.updated", "a")
+		}
+
+		count := 0
+		for range sub.Events() {
+			count++
+		}
+		assert.Less(t, count, 100)
+	})
+}
@@ -4,19 +4,80 @@ import (
 	"time"
 
 	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/rule"
+)
+
+// Events published when the expenses and the expense groups change.
+const (
+	EventExpenseCreated      = "expense.created"
+	EventExpenseUpdated      = "expense.updated"
+	EventExpenseDeleted      = "expense.deleted"
+	EventExpenseGroupCreated = "expense_group.created"
+	EventExpenseGroupUpdated = "expense_group.updated"
+	EventExpenseGroupDeleted = "expense_group.deleted"
 )
 
 type Expense struct {
-	ID        string
-	Name      string
-	Amount    int64
-	Date      time.Time
-	Note      string
-	Category  category.Category
+	ID       string
+	Name     string
+	Amount   int64
+	Date     time.Time
+	Note     string
+	Category category.Category
+	Tags     []string
+	// Account that paid for the expense. Nil if it was not recorded.
+	AccountID *string
+	// Reconciled expenses can't be changed.
+	ReconciledAt *time.Time
+	// Bumped on every change, used to detect concurrent edits.
+	Version   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
+type DeletedExpense struct {
+	Expense
+	DeletedAt time.Time
+}
+
+// RuleChange is what re-running the rules changed, or would change on a dry
+// run, on an expense.
+type RuleChange struct {
+	ExpenseID string
+	Before    rule.Target
+	After     rule.Target
+}
+
+type BatchOperationType string
+
+const (
+	BatchOperationCreate BatchOperationType = "create"
+	BatchOperationUpdate BatchOperationType = "update"
+	BatchOperationDelete BatchOperationType = "delete"
+)
+
+type BatchStatus string
+
+const (
+	BatchStatusSucceeded BatchStatus = "succeeded"
+	BatchStatusFailed    BatchStatus = "failed"
+	// The operation succeeded but was undone since another operation of the
+	// all-or-nothing batch failed.
+	BatchStatusRolledBack BatchStatus = "rolled_back"
+)
+
+// BatchResult is the outcome of an operation of a batch.
+type BatchResult struct {
+	Type BatchOperationType
+	// Empty for a create that didn't succeed.
+	ID     string
+	Status BatchStatus
+	// Why the operation failed.
+	Error *string
+	// Expense as saved. Nil unless a create or an update succeeded.
+	Expense *Expense
+}
+
 type ExpenseGroup struct {
 	ID        string
 	Name      string
@@ -2,6 +2,7 @@ package expense
 
 import (
 	"context"
+	"time"
 
 	"github.com/cativovo/budget-tracker/internal"
 )
@@ -10,10 +11,19 @@ type Repository interface {
 	ExpenseByID(ctx context.Context, id string) (Expense, error)
 	ExpenseGroupByID(ctx context.Context, id string) (ExpenseGroup, error)
 	ListExpenseSummaries(ctx context.Context, lo internal.ListOptions) ([]ExpenseSummary, error)
+	ListExpenses(ctx context.Context, l ListExpensesReq) ([]Expense, error)
 	CreateExpense(ctx context.Context, e CreateExpenseReq) (Expense, error)
 	CreateExpenseGroup(ctx context.Context, e CreateExpenseGroupReq) (ExpenseGroup, error)
 	UpdateExpense(ctx context.Context, u UpdateExpenseReq) (Expense, error)
-	DeleteExpense(ctx context.Context, id string) error
+	// DeleteExpense reports whether the expense was moved to the trash,
+	// deleting an expense that doesn't exist is not an error.
+	DeleteExpense(ctx context.Context, d DeleteExpenseReq) (bool, error)
+	// BatchExpenses runs the operations of the batch, or the bulk update, in
+	// a single transaction.
+	BatchExpenses(ctx context.Context, b BatchReq) ([]BatchResult, error)
+	ListDeletedExpenses(ctx context.Context, lo internal.ListOptions) ([]DeletedExpense, error)
+	RestoreExpense(ctx context.Context, id string) (Expense, error)
+	PurgeDeletedExpenses(ctx context.Context, before time.Time) (int64, error)
 	UpdateExpenseGroup(ctx context.Context, u UpdateExpenseGroupReq) (ExpenseGroup, error)
 	DeleteExpenseGroup(ctx context.Context, id string) error
 }
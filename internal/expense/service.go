@@ -2,62 +2,477 @@ package expense
 
 import (
 	"context"
+	"time"
 
 	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/rule"
 	"github.com/cativovo/budget-tracker/internal/validator"
 )
 
 type Service interface {
+	ExpenseByID(ctx context.Context, id string) (Expense, error)
 	ListExpenseSummaries(ctx context.Context, lo internal.ListOptions) ([]ExpenseSummary, error)
 	CreateExpense(ctx context.Context, c CreateExpenseReq) (Expense, error)
 	UpdateExpense(ctx context.Context, u UpdateExpenseReq) (Expense, error)
+	DeleteExpense(ctx context.Context, d DeleteExpenseReq) error
+	BatchExpenses(ctx context.Context, b BatchReq) ([]BatchResult, error)
+	ListDeletedExpenses(ctx context.Context, lo internal.ListOptions) ([]DeletedExpense, error)
+	RestoreExpense(ctx context.Context, id string) (Expense, error)
+	PurgeDeletedExpenses(ctx context.Context, before time.Time) (int64, error)
+	RerunRules(ctx context.Context, rr RerunRulesReq) ([]RuleChange, error)
+	// ExpensesMoved tells the webhooks about the expenses a change of their
+	// category moved to another category.
+	ExpensesMoved(ctx context.Context, ids []string)
+	// ExpensesDeleted tells the webhooks about the expenses that were trashed
+	// with their category.
+	ExpensesDeleted(ctx context.Context, ids []string)
+}
+
+// RuleApplier applies the categorization rules of the user.
+type RuleApplier interface {
+	ApplyRules(ctx context.Context, t rule.Target) (rule.Target, error)
+}
+
+// AnomalyScorer flags the unusual expenses.
+type AnomalyScorer interface {
+	ScoreExpense(ctx context.Context, e anomaly.Expense) ([]anomaly.Flag, error)
+}
+
+// EventDispatcher tells the webhooks of the user about the changes.
+type EventDispatcher interface {
+	Dispatch(ctx context.Context, event string, resourceID string, data any) error
 }
 
 type service struct {
-	r Repository
-	v *validator.Validator
+	r  Repository
+	v  *validator.Validator
+	ra RuleApplier
+	as AnomalyScorer
+	ed EventDispatcher
 }
 
-func NewService(r Repository, v *validator.Validator) Service {
+func NewService(r Repository, v *validator.Validator, ra RuleApplier, as AnomalyScorer, ed EventDispatcher) Service {
 	return &service{
-		r: r,
-		v: v,
+		r:  r,
+		v:  v,
+		ra: ra,
+		as: as,
+		ed: ed,
+	}
+}
+
+// dispatch tells the webhooks about a change that was already saved, failing
+// to do so doesn't fail the change.
+func (s *service) dispatch(ctx context.Context, event string, id string, data any) {
+	if err := s.ed.Dispatch(ctx, event, id, data); err != nil {
+		logger.FromContext(ctx).Errorw("Failed to dispatch event", "event", event, "id", id, "error", err)
 	}
 }
 
+func (s *service) ExpenseByID(ctx context.Context, id string) (Expense, error) {
+	if id == "" {
+		return Expense{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.ExpenseByID(ctx, id)
+}
+
 func (s *service) ListExpenseSummaries(ctx context.Context, lo internal.ListOptions) ([]ExpenseSummary, error) {
 	return s.r.ListExpenseSummaries(ctx, lo)
 }
 
 type CreateExpenseReq struct {
-	Name       string `json:"name" validate:"required"`
-	Amount     int64  `json:"amount" validate:"gt=0"`
-	Date       string `json:"date" validate:"required,datetime=2006-01-02"`
-	CategoryID string `json:"category_id" validate:"required"`
-	Note       string `json:"note"`
+	Name   string `json:"name" validate:"required"`
+	Amount int64  `json:"amount" validate:"gt=0"`
+	Date   string `json:"date" validate:"required,datetime=2006-01-02"`
+	// Can be left empty when a rule assigns the category.
+	CategoryID string   `json:"category_id"`
+	Note       string   `json:"note"`
+	Tags       []string `json:"tags" validate:"dive,required"`
+	AccountID  *string  `json:"account_id"`
 }
 
+// CreateExpense applies the rules of the user before saving the expense. A
+// category given by the user takes precedence over the one from the rules.
+// The saved expense is then scored for anomalies, failing to score it doesn't
+// fail the creation.
 func (s *service) CreateExpense(ctx context.Context, c CreateExpenseReq) (Expense, error) {
 	if err := s.v.Struct(c); err != nil {
 		return Expense{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
 	}
-	return s.r.CreateExpense(ctx, c)
+
+	c, err := s.applyRules(ctx, c)
+	if err != nil {
+		return Expense{}, err
+	}
+
+	e, err := s.r.CreateExpense(ctx, c)
+	if err != nil {
+		return Expense{}, err
+	}
+
+	s.scoreExpense(ctx, e)
+	s.dispatch(ctx, EventExpenseCreated, e.ID, e)
+	return e, nil
+}
+
+// applyRules fills in the expense to create with what the rules of the user
+// assign it.
+func (s *service) applyRules(ctx context.Context, c CreateExpenseReq) (CreateExpenseReq, error) {
+	date, err := time.Parse(time.DateOnly, c.Date)
+	if err != nil {
+		return CreateExpenseReq{}, internal.NewError(internal.ErrorCodeInvalid, "'date' must have a valid date value")
+	}
+
+	t, err := s.ra.ApplyRules(ctx, rule.Target{
+		Name:       c.Name,
+		Note:       c.Note,
+		Amount:     c.Amount,
+		Date:       date,
+		CategoryID: c.CategoryID,
+		Tags:       c.Tags,
+	})
+	if err != nil {
+		return CreateExpenseReq{}, err
+	}
+
+	c.Name = t.Name
+	c.Tags = t.Tags
+	if c.CategoryID == "" {
+		c.CategoryID = t.CategoryID
+	}
+
+	if c.CategoryID == "" {
+		return CreateExpenseReq{}, internal.NewError(internal.ErrorCodeInvalid, "'category_id' is required")
+	}
+
+	return c, nil
+}
+
+// scoreExpense flags the saved expense if it's unusual.
+func (s *service) scoreExpense(ctx context.Context, e Expense) {
+	_, err := s.as.ScoreExpense(ctx, anomaly.Expense{
+		ID:         e.ID,
+		Name:       e.Name,
+		Amount:     e.Amount,
+		Date:       e.Date,
+		CategoryID: e.Category.ID,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Errorw("Failed to score expense for anomalies", "expense_id", e.ID, "error", err)
+	}
 }
 
 type UpdateExpenseReq struct {
-	ID         string  `json:"id" validate:"required"`
-	Name       *string `json:"name"`
-	Amount     *int64  `json:"amount" validate:"gt=0"`
-	Date       *string `json:"date" validate:"datetime=2006-01-02"`
-	CategoryID *string `json:"category_id"`
-	Note       *string `json:"note"`
+	ID         string    `json:"id" validate:"required"`
+	Name       *string   `json:"name"`
+	Amount     *int64    `json:"amount" validate:"omitempty,gt=0"`
+	Date       *string   `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	CategoryID *string   `json:"category_id"`
+	Note       *string   `json:"note"`
+	Tags       *[]string `json:"tags"`
+	AccountID  *string   `json:"account_id"`
+	// The expense is only updated if it's still at this version.
+	Version *int64 `json:"version"`
 }
 
 func (s *service) UpdateExpense(ctx context.Context, u UpdateExpenseReq) (Expense, error) {
 	if err := s.v.Struct(u); err != nil {
 		return Expense{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
 	}
-	return s.r.UpdateExpense(ctx, u)
+
+	e, err := s.r.UpdateExpense(ctx, u)
+	if err != nil {
+		return Expense{}, err
+	}
+
+	s.dispatch(ctx, EventExpenseUpdated, e.ID, e)
+	return e, nil
+}
+
+type DeleteExpenseReq struct {
+	ID string `json:"id" validate:"required"`
+	// The expense is only deleted if it's still at this version.
+	Version *int64 `json:"version"`
+}
+
+func (s *service) DeleteExpense(ctx context.Context, d DeleteExpenseReq) error {
+	if err := s.v.Struct(d); err != nil {
+		return internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	trashed, err := s.r.DeleteExpense(ctx, d)
+	if err != nil {
+		return err
+	}
+
+	if trashed {
+		s.dispatch(ctx, EventExpenseDeleted, d.ID, nil)
+	}
+	return nil
+}
+
+type BatchOperation struct {
+	Type   BatchOperationType `json:"type" validate:"required,oneof=create update delete"`
+	Create *CreateExpenseReq  `json:"create" validate:"required_if=Type create"`
+	Update *UpdateExpenseReq  `json:"update" validate:"required_if=Type update"`
+	Delete *DeleteExpenseReq  `json:"delete" validate:"required_if=Type delete"`
+}
+
+// ExpenseFilter selects the expenses of a bulk update. Empty fields match
+// every expense, reconciled expenses never match.
+type ExpenseFilter struct {
+	StartDate  string `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+	EndDate    string `json:"end_date" validate:"omitempty,datetime=2006-01-02"`
+	CategoryID string `json:"category_id"`
+	AccountID  string `json:"account_id"`
+	// Matches the expenses whose name contains it.
+	Search string `json:"search"`
+}
+
+func (f ExpenseFilter) empty() bool {
+	return f == ExpenseFilter{}
+}
+
+// ExpensePatch is the change a bulk update makes to every expense it
+// selects.
+type ExpensePatch struct {
+	Name       *string   `json:"name"`
+	Amount     *int64    `json:"amount" validate:"omitempty,gt=0"`
+	Date       *string   `json:"date" validate:"omitempty,datetime=2006-01-02"`
+	CategoryID *string   `json:"category_id"`
+	Note       *string   `json:"note"`
+	Tags       *[]string `json:"tags"`
+	AccountID  *string   `json:"account_id"`
+}
+
+func (p ExpensePatch) empty() bool {
+	return p == ExpensePatch{}
+}
+
+// UpdateReq is the update the patch makes to the expense.
+func (p ExpensePatch) UpdateReq(id string) UpdateExpenseReq {
+	return UpdateExpenseReq{
+		ID:         id,
+		Name:       p.Name,
+		Amount:     p.Amount,
+		Date:       p.Date,
+		CategoryID: p.CategoryID,
+		Note:       p.Note,
+		Tags:       p.Tags,
+		AccountID:  p.AccountID,
+	}
+}
+
+// BatchReq is either a list of operations or a bulk update of the expenses
+// matching the filter.
+type BatchReq struct {
+	// Run in order, an operation can depend on an earlier one.
+	Operations []BatchOperation `json:"operations" validate:"max=500,dive"`
+	Filter     *ExpenseFilter   `json:"filter" validate:"required_with=Patch"`
+	Patch      *ExpensePatch    `json:"patch" validate:"required_with=Filter"`
+	// Roll back every operation if one of them fails.
+	AllOrNothing bool `json:"all_or_nothing"`
+}
+
+// BatchExpenses runs the operations, or the bulk update, in a single
+// transaction. A failed operation doesn't stop the others unless the batch is
+// all-or-nothing, every operation is then rolled back.
+func (s *service) BatchExpenses(ctx context.Context, b BatchReq) ([]BatchResult, error) {
+	if err := s.v.Struct(&b); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	switch {
+	case len(b.Operations) > 0 && b.Filter != nil:
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'operations' can't be used with 'filter'")
+	case len(b.Operations) == 0 && b.Filter == nil:
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Either 'operations' or 'filter' is required")
+	case b.Filter != nil && b.Filter.empty():
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'filter' must match on at least one field")
+	case b.Patch != nil && b.Patch.empty():
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'patch' must change at least one field")
+	}
+
+	operations := make([]BatchOperation, 0, len(b.Operations))
+	for i, o := range b.Operations {
+		if o.Type == BatchOperationCreate {
+			c, err := s.applyRules(ctx, *o.Create)
+			if err != nil {
+				if internal.GetErrorCode(err) == internal.ErrorCodeInvalid {
+					return nil, internal.NewErrorf(internal.ErrorCodeInvalid, "Operation %d: %s", i, internal.GetErrorMessage(err))
+				}
+				return nil, err
+			}
+			o.Create = &c
+		}
+		operations = append(operations, o)
+	}
+	b.Operations = operations
+
+	results, err := s.r.BatchExpenses(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Status != BatchStatusSucceeded {
+			continue
+		}
+
+		switch r.Type {
+		case BatchOperationCreate:
+			s.scoreExpense(ctx, *r.Expense)
+			s.dispatch(ctx, EventExpenseCreated, r.ID, *r.Expense)
+		case BatchOperationUpdate:
+			s.dispatch(ctx, EventExpenseUpdated, r.ID, *r.Expense)
+		case BatchOperationDelete:
+			s.dispatch(ctx, EventExpenseDeleted, r.ID, nil)
+		}
+	}
+
+	return results, nil
+}
+
+func (s *service) ListDeletedExpenses(ctx context.Context, lo internal.ListOptions) ([]DeletedExpense, error) {
+	return s.r.ListDeletedExpenses(ctx, lo)
+}
+
+func (s *service) RestoreExpense(ctx context.Context, id string) (Expense, error) {
+	if id == "" {
+		return Expense{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+
+	e, err := s.r.RestoreExpense(ctx, id)
+	if err != nil {
+		return Expense{}, err
+	}
+
+	s.dispatch(ctx, EventExpenseCreated, e.ID, e)
+	return e, nil
+}
+
+func (s *service) ExpensesMoved(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		e, err := s.r.ExpenseByID(ctx, id)
+		if err != nil {
+			logger.FromContext(ctx).Errorw("Failed to find moved expense", "id", id, "error", err)
+			continue
+		}
+
+		s.dispatch(ctx, EventExpenseUpdated, e.ID, e)
+	}
+}
+
+func (s *service) ExpensesDeleted(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		s.dispatch(ctx, EventExpenseDeleted, id, nil)
+	}
+}
+
+// PurgeDeletedExpenses permanently removes the expenses that were moved to
+// the trash before the given time.
+func (s *service) PurgeDeletedExpenses(ctx context.Context, before time.Time) (int64, error) {
+	return s.r.PurgeDeletedExpenses(ctx, before)
+}
+
+type ListExpensesReq struct {
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+}
+
+type RerunRulesReq struct {
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	// Only report what would change.
+	DryRun bool `json:"dry_run"`
+}
+
+// RerunRules applies the current rules to the existing expenses within the
+// date range. Unlike CreateExpense, the category from the rules replaces the
+// category of the expense. Reconciled expenses are skipped. The expenses are
+// updated in a single transaction, none is if one of them changed since the
+// rules ran.
+func (s *service) RerunRules(ctx context.Context, rr RerunRulesReq) ([]RuleChange, error) {
+	if err := s.v.Struct(rr); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	expenses, err := s.r.ListExpenses(ctx, ListExpensesReq{
+		StartDate: rr.StartDate,
+		EndDate:   rr.EndDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []RuleChange
+	var operations []BatchOperation
+	for _, e := range expenses {
+		if e.ReconciledAt != nil {
+			continue
+		}
+
+		before := rule.Target{
+			Name:       e.Name,
+			Note:       e.Note,
+			Amount:     e.Amount,
+			Date:       e.Date,
+			CategoryID: e.Category.ID,
+			Tags:       e.Tags,
+		}
+
+		after, err := s.ra.ApplyRules(ctx, before)
+		if err != nil {
+			return nil, err
+		}
+
+		if !rule.Changed(before, after) {
+			continue
+		}
+
+		changes = append(changes, RuleChange{
+			ExpenseID: e.ID,
+			Before:    before,
+			After:     after,
+		})
+		operations = append(operations, BatchOperation{
+			Type: BatchOperationUpdate,
+			Update: &UpdateExpenseReq{
+				ID:         e.ID,
+				Name:       &after.Name,
+				CategoryID: &after.CategoryID,
+				Tags:       &after.Tags,
+				// the rules ran against this version
+				Version: &e.Version,
+			},
+		})
+	}
+
+	if rr.DryRun || len(operations) == 0 {
+		return changes, nil
+	}
+
+	results, err := s.r.BatchExpenses(ctx, BatchReq{
+		Operations:   operations,
+		AllOrNothing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Status == BatchStatusFailed {
+			return nil, internal.NewErrorf(internal.ErrorCodeConflict, "Expense %s: %s", r.ID, *r.Error)
+		}
+	}
+
+	for _, r := range results {
+		s.dispatch(ctx, EventExpenseUpdated, r.ID, *r.Expense)
+	}
+
+	return changes, nil
 }
 
 type CreateExpenseGroupReq struct {
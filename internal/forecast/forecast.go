@@ -0,0 +1,332 @@
+package forecast
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+)
+
+const (
+	// HistoryMonths is how many months of past expenses the forecast is based
+	// on.
+	HistoryMonths = 6
+	// Number of days before today the moving average of the spending covers.
+	movingAverageDays = 90
+	// An expense is recurring when it shows up once a month for at least this
+	// many months in a row.
+	minRecurringMonths = 3
+	// Gap in days between two occurrences of a monthly expense.
+	minRecurringGap = 20
+	maxRecurringGap = 40
+	// Amounts of a recurring expense may differ from the median by this much.
+	recurringAmountTolerance = 0.2
+	// A recurring expense that hasn't shown up for this many days is
+	// considered stopped.
+	maxRecurringInactiveDays = 45
+)
+
+// RecurringItem is an expense that is paid every month for about the same
+// amount, e.g. rent or a subscription.
+type RecurringItem struct {
+	// Name of the latest occurrence.
+	Name     string
+	Category category.Category
+	// Median amount of the occurrences.
+	Amount int64
+	// Date of the latest occurrence.
+	LastDate time.Time
+	// Number of occurrences found.
+	Occurrences int
+}
+
+// CategoryForecast is the estimated spending of a category by the end of the
+// month.
+type CategoryForecast struct {
+	Category category.Category
+	// Spending so far this month, including today.
+	Spent int64
+	// Recurring items still expected this month.
+	Recurring int64
+	// Spent plus the remaining days at the moving average plus Recurring.
+	Projected int64
+}
+
+// CashFlowDay is the estimated spending of a day.
+type CashFlowDay struct {
+	Date time.Time
+	// Estimated from the moving average of the non recurring expenses.
+	Variable int64
+	// Recurring items expected on the day.
+	Recurring int64
+	// Total spending from the first day of the forecast up to this day.
+	Cumulative int64
+}
+
+// DetectRecurring finds the expenses that are paid monthly and are still
+// active as of today. The expenses must be ordered by date.
+func DetectRecurring(expenses []expense.Expense, today time.Time) []RecurringItem {
+	groups := make(map[string][]expense.Expense)
+	var keys []string
+	for _, e := range expenses {
+		key := recurringKey(e)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], e)
+	}
+
+	var items []RecurringItem
+	for _, key := range keys {
+		occurrences := groups[key]
+		if len(occurrences) < minRecurringMonths {
+			continue
+		}
+
+		last := occurrences[len(occurrences)-1]
+		if daysBetween(last.Date, today) > maxRecurringInactiveDays {
+			continue
+		}
+
+		monthly := true
+		for i := 1; i < len(occurrences); i++ {
+			gap := daysBetween(occurrences[i-1].Date, occurrences[i].Date)
+			if gap < minRecurringGap || gap > maxRecurringGap {
+				monthly = false
+				break
+			}
+		}
+		if !monthly {
+			continue
+		}
+
+		amounts := make([]int64, 0, len(occurrences))
+		for _, e := range occurrences {
+			amounts = append(amounts, e.Amount)
+		}
+		amount := median(amounts)
+
+		similar := true
+		for _, a := range amounts {
+			if math.Abs(float64(a-amount)) > float64(amount)*recurringAmountTolerance {
+				similar = false
+				break
+			}
+		}
+		if !similar {
+			continue
+		}
+
+		items = append(items, RecurringItem{
+			Name:        last.Name,
+			Category:    last.Category,
+			Amount:      amount,
+			LastDate:    last.Date,
+			Occurrences: len(occurrences),
+		})
+	}
+
+	return items
+}
+
+// DueDates returns the dates the item is due after its last date up to end,
+// the ones that are overdue included.
+func (r RecurringItem) DueDates(end time.Time) []time.Time {
+	var dates []time.Time
+	for i := 1; ; i++ {
+		d := addMonths(r.LastDate, i)
+		if d.After(end) {
+			break
+		}
+		dates = append(dates, d)
+	}
+	return dates
+}
+
+// NextDates returns the dates the item is expected within start and end. An
+// occurrence that is already due but hasn't been paid yet is expected on
+// start.
+func (r RecurringItem) NextDates(start, end time.Time) []time.Time {
+	dates := r.DueDates(end)
+	for i, d := range dates {
+		if d.Before(start) {
+			dates[i] = start
+		}
+	}
+	return dates
+}
+
+// EndOfMonth estimates the spending of every category by the end of the month
+// of today. The expenses must be ordered by date and should cover
+// HistoryMonths up to today.
+func EndOfMonth(expenses []expense.Expense, today time.Time) []CategoryForecast {
+	today = truncateDay(today)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	remainingDays := daysBetween(today, monthEnd)
+
+	recurring := DetectRecurring(expenses, today)
+	averages := dailyAverages(expenses, recurring, today)
+
+	forecasts := make(map[string]*CategoryForecast)
+	forecastOf := func(c category.Category) *CategoryForecast {
+		f, ok := forecasts[c.ID]
+		if !ok {
+			f = &CategoryForecast{Category: c}
+			forecasts[c.ID] = f
+		}
+		return f
+	}
+
+	for _, e := range expenses {
+		if !e.Date.Before(monthStart) && !e.Date.After(today) {
+			forecastOf(e.Category).Spent += e.Amount
+		}
+	}
+
+	for _, r := range recurring {
+		dates := r.NextDates(today.AddDate(0, 0, 1), monthEnd)
+		forecastOf(r.Category).Recurring += r.Amount * int64(len(dates))
+	}
+
+	for _, a := range averages {
+		f := forecastOf(a.category)
+		f.Projected += int64(math.Round(a.perDay * float64(remainingDays)))
+	}
+
+	result := make([]CategoryForecast, 0, len(forecasts))
+	for _, f := range forecasts {
+		f.Projected += f.Spent + f.Recurring
+		result = append(result, *f)
+	}
+
+	slices.SortFunc(result, func(a, b CategoryForecast) int {
+		if c := cmp.Compare(b.Projected, a.Projected); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Category.Name, b.Category.Name)
+	})
+
+	return result
+}
+
+// CashFlow estimates the spending of each of the given number of days after
+// today. The expenses must be ordered by date and should cover HistoryMonths
+// up to today.
+func CashFlow(expenses []expense.Expense, today time.Time, days int) []CashFlowDay {
+	today = truncateDay(today)
+	start := today.AddDate(0, 0, 1)
+	end := today.AddDate(0, 0, days)
+
+	recurring := DetectRecurring(expenses, today)
+
+	var perDay float64
+	for _, a := range dailyAverages(expenses, recurring, today) {
+		perDay += a.perDay
+	}
+
+	recurringByDate := make(map[time.Time]int64)
+	for _, r := range recurring {
+		for _, d := range r.NextDates(start, end) {
+			recurringByDate[d] += r.Amount
+		}
+	}
+
+	result := make([]CashFlowDay, 0, days)
+	var cumulative, variableSoFar int64
+	for i := range days {
+		date := start.AddDate(0, 0, i)
+
+		// rounding the running total keeps the days from drifting away from
+		// the average
+		variable := int64(math.Round(perDay*float64(i+1))) - variableSoFar
+		variableSoFar += variable
+
+		cumulative += variable + recurringByDate[date]
+		result = append(result, CashFlowDay{
+			Date:       date,
+			Variable:   variable,
+			Recurring:  recurringByDate[date],
+			Cumulative: cumulative,
+		})
+	}
+
+	return result
+}
+
+type dailyAverage struct {
+	category category.Category
+	perDay   float64
+}
+
+// dailyAverages is the moving average per day of the non recurring spending
+// of every category over the days before today.
+func dailyAverages(expenses []expense.Expense, recurring []RecurringItem, today time.Time) []dailyAverage {
+	start := today.AddDate(0, 0, -movingAverageDays)
+
+	isRecurring := make(map[string]bool, len(recurring))
+	for _, r := range recurring {
+		isRecurring[recurringKey(expense.Expense{Name: r.Name, Category: r.Category})] = true
+	}
+
+	totals := make(map[string]*dailyAverage)
+	var ids []string
+	for _, e := range expenses {
+		if e.Date.Before(start) || !e.Date.Before(today) || isRecurring[recurringKey(e)] {
+			continue
+		}
+
+		a, ok := totals[e.Category.ID]
+		if !ok {
+			a = &dailyAverage{category: e.Category}
+			totals[e.Category.ID] = a
+			ids = append(ids, e.Category.ID)
+		}
+		a.perDay += float64(e.Amount)
+	}
+
+	result := make([]dailyAverage, 0, len(ids))
+	for _, id := range ids {
+		a := totals[id]
+		a.perDay /= movingAverageDays
+		result = append(result, *a)
+	}
+
+	return result
+}
+
+// recurringKey groups the occurrences of a recurring expense.
+func recurringKey(e expense.Expense) string {
+	return e.Category.ID + "\x00" + strings.ToLower(strings.TrimSpace(e.Name))
+}
+
+func median(values []int64) int64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// addMonths keeps the day of the month, clamped to the last day of shorter
+// months, e.g. Jan 31 plus a month is Feb 28.
+func addMonths(t time.Time, months int) time.Time {
+	firstOfMonth := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	return firstOfMonth.AddDate(0, 0, min(t.Day(), lastDay)-1)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(truncateDay(b).Sub(truncateDay(a)).Hours() / 24)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,178 @@
+package forecast_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/forecast"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	housing = category.Category{ID: "housing", Name: "Housing"}
+	food    = category.Category{ID: "food", Name: "Food"}
+)
+
+func TestDetectRecurring(t *testing.T) {
+	today := date(t, "2006-04-20")
+
+	tests := map[string]struct {
+		expenses []expense.Expense
+		want     []forecast.RecurringItem
+	}{
+		"monthly with similar amounts": {
+			expenses: []expense.Expense{
+				newExpense(t, "Netflix", 500, "2006-01-15", food),
+				newExpense(t, "netflix ", 550, "2006-02-15", food),
+				newExpense(t, "Netflix", 500, "2006-03-14", food),
+				newExpense(t, "Netflix", 450, "2006-04-15", food),
+			},
+			want: []forecast.RecurringItem{
+				{
+					Name:        "Netflix",
+					Category:    food,
+					Amount:      500,
+					LastDate:    date(t, "2006-04-15"),
+					Occurrences: 4,
+				},
+			},
+		},
+		"not enough months": {
+			expenses: []expense.Expense{
+				newExpense(t, "Netflix", 500, "2006-03-15", food),
+				newExpense(t, "Netflix", 500, "2006-04-15", food),
+			},
+		},
+		"more than once a month": {
+			expenses: []expense.Expense{
+				newExpense(t, "Lunch", 500, "2006-02-15", food),
+				newExpense(t, "Lunch", 500, "2006-03-01", food),
+				newExpense(t, "Lunch", 500, "2006-03-15", food),
+				newExpense(t, "Lunch", 500, "2006-04-15", food),
+			},
+		},
+		"amounts differ too much": {
+			expenses: []expense.Expense{
+				newExpense(t, "Groceries", 500, "2006-02-15", food),
+				newExpense(t, "Groceries", 1500, "2006-03-15", food),
+				newExpense(t, "Groceries", 500, "2006-04-15", food),
+			},
+		},
+		"stopped": {
+			expenses: []expense.Expense{
+				newExpense(t, "Gym", 500, "2006-01-01", food),
+				newExpense(t, "Gym", 500, "2006-02-01", food),
+				newExpense(t, "Gym", 500, "2006-03-01", food),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, forecast.DetectRecurring(test.expenses, today))
+		})
+	}
+}
+
+func TestNextDates(t *testing.T) {
+	r := forecast.RecurringItem{LastDate: date(t, "2006-01-31")}
+
+	assert.Equal(
+		t,
+		[]time.Time{date(t, "2006-02-28"), date(t, "2006-03-31"), date(t, "2006-04-30")},
+		r.NextDates(date(t, "2006-02-01"), date(t, "2006-05-15")),
+	)
+
+	// overdue is expected on the start
+	assert.Equal(
+		t,
+		[]time.Time{date(t, "2006-03-05"), date(t, "2006-03-31")},
+		r.NextDates(date(t, "2006-03-05"), date(t, "2006-03-31")),
+	)
+}
+
+func TestEndOfMonth(t *testing.T) {
+	today := date(t, "2006-04-20")
+
+	expenses := []expense.Expense{
+		newExpense(t, "Rent", 10000, "2006-01-25", housing),
+		newExpense(t, "Groceries", 900, "2006-02-10", food),
+		newExpense(t, "Rent", 10000, "2006-02-25", housing),
+		newExpense(t, "Groceries", 900, "2006-03-10", food),
+		newExpense(t, "Rent", 10000, "2006-03-25", housing),
+		newExpense(t, "Groceries", 3600, "2006-04-05", food),
+		// today doesn't count toward the average
+		newExpense(t, "Snacks", 100, "2006-04-20", food),
+	}
+
+	assert.Equal(t, []forecast.CategoryForecast{
+		{
+			Category:  housing,
+			Spent:     0,
+			Recurring: 10000,
+			Projected: 10000,
+		},
+		{
+			Category:  food,
+			Spent:     3700,
+			Recurring: 0,
+			// (900 + 900 + 3600) / 90 days * 10 days left
+			Projected: 3700 + 600,
+		},
+	}, forecast.EndOfMonth(expenses, today))
+}
+
+func TestCashFlow(t *testing.T) {
+	today := date(t, "2006-04-20")
+
+	expenses := []expense.Expense{
+		newExpense(t, "Rent", 10000, "2006-02-01", housing),
+		newExpense(t, "Rent", 10000, "2006-03-01", housing),
+		newExpense(t, "Groceries", 100, "2006-03-25", food),
+		newExpense(t, "Rent", 10000, "2006-04-01", housing),
+		newExpense(t, "Groceries", 200, "2006-04-10", food),
+	}
+
+	days := forecast.CashFlow(expenses, today, 45)
+	assert.Len(t, days, 45)
+	assert.Equal(t, date(t, "2006-04-21"), days[0].Date)
+	assert.Equal(t, date(t, "2006-06-04"), days[44].Date)
+
+	var variable, recurring int64
+	for _, d := range days {
+		variable += d.Variable
+		recurring += d.Recurring
+		assert.Equal(t, variable+recurring, d.Cumulative)
+	}
+	// 300 / 90 days * 45 days
+	assert.Equal(t, int64(150), variable)
+	assert.Equal(t, int64(20000), recurring)
+
+	assert.Equal(t, int64(10000), days[10].Recurring)
+	assert.Equal(t, date(t, "2006-05-01"), days[10].Date)
+	assert.Equal(t, int64(10000), days[41].Recurring)
+	assert.Equal(t, date(t, "2006-06-01"), days[41].Date)
+}
+
+func newExpense(t *testing.T, name string, amount int64, d string, c category.Category) expense.Expense {
+	t.Helper()
+	return expense.Expense{
+		Name:     name,
+		Amount:   amount,
+		Date:     date(t, d),
+		Category: c,
+	}
+}
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
@@ -0,0 +1,126 @@
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	EndOfMonth(ctx context.Context) ([]CategoryForecast, error)
+	CashFlow(ctx context.Context, c CashFlowReq) (CashFlowForecast, error)
+}
+
+// ExpenseLister lists the past expenses of the user.
+type ExpenseLister interface {
+	ListExpenses(ctx context.Context, l expense.ListExpensesReq) ([]expense.Expense, error)
+}
+
+// AccountFinder finds an account of the user.
+type AccountFinder interface {
+	AccountByID(ctx context.Context, id string) (account.Account, error)
+}
+
+type CashFlowReq struct {
+	// Only the expenses of the account are used and the balance of the
+	// account is projected. Empty for all the expenses.
+	AccountID string `json:"account_id"`
+	Days      int    `json:"days" validate:"min=1,max=365"`
+}
+
+type CashFlowForecast struct {
+	// Balance of the account today. Nil when the forecast is not for an
+	// account.
+	StartingBalance *int64
+	Days            []CashFlowDay
+}
+
+// Balance is the projected balance of the account at the end of the day.
+func (c CashFlowForecast) Balance(d CashFlowDay) *int64 {
+	if c.StartingBalance == nil {
+		return nil
+	}
+
+	balance := *c.StartingBalance - d.Cumulative
+	return &balance
+}
+
+type service struct {
+	el ExpenseLister
+	af AccountFinder
+	v  *validator.Validator
+}
+
+func NewService(el ExpenseLister, af AccountFinder, v *validator.Validator) Service {
+	return &service{
+		el: el,
+		af: af,
+		v:  v,
+	}
+}
+
+func (s *service) EndOfMonth(ctx context.Context) ([]CategoryForecast, error) {
+	today := time.Now()
+
+	expenses, err := s.history(ctx, today, "")
+	if err != nil {
+		return nil, fmt.Errorf("forecast.Service.EndOfMonth: %w", err)
+	}
+
+	return EndOfMonth(expenses, today), nil
+}
+
+func (s *service) CashFlow(ctx context.Context, c CashFlowReq) (CashFlowForecast, error) {
+	if err := s.v.Struct(c); err != nil {
+		return CashFlowForecast{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	var forecast CashFlowForecast
+	if c.AccountID != "" {
+		a, err := s.af.AccountByID(ctx, c.AccountID)
+		if err != nil {
+			return CashFlowForecast{}, fmt.Errorf("forecast.Service.CashFlow: %w", err)
+		}
+		forecast.StartingBalance = &a.Balance
+	}
+
+	today := time.Now()
+
+	expenses, err := s.history(ctx, today, c.AccountID)
+	if err != nil {
+		return CashFlowForecast{}, fmt.Errorf("forecast.Service.CashFlow: %w", err)
+	}
+
+	forecast.Days = CashFlow(expenses, today, c.Days)
+	return forecast, nil
+}
+
+// history lists the expenses the forecast is based on, only the ones of the
+// account if accountID is not empty.
+func (s *service) history(ctx context.Context, today time.Time, accountID string) ([]expense.Expense, error) {
+	expenses, err := s.el.ListExpenses(ctx, expense.ListExpensesReq{
+		StartDate: today.AddDate(0, -HistoryMonths, 0).Format(time.DateOnly),
+		EndDate:   today.Format(time.DateOnly),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if accountID == "" {
+		return expenses, nil
+	}
+
+	result := make([]expense.Expense, 0, len(expenses))
+	for _, e := range expenses {
+		if e.AccountID != nil && *e.AccountID == accountID {
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
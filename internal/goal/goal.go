@@ -0,0 +1,80 @@
+package goal
+
+import (
+	"math"
+	"time"
+)
+
+// Goal is an amount the user is saving up for by a deadline.
+type Goal struct {
+	ID           string
+	Name         string
+	TargetAmount int64
+	Deadline     time.Time
+	// Sum of the contributions.
+	SavedAmount int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type ContributionSource string
+
+const (
+	ContributionSourceIncome   ContributionSource = "income"
+	ContributionSourceTransfer ContributionSource = "transfer"
+)
+
+// Contribution is money put toward a goal. It is linked to the income or
+// transfer record the money came from.
+type Contribution struct {
+	ID         string
+	GoalID     string
+	Amount     int64
+	Date       time.Time
+	Note       string
+	SourceType ContributionSource
+	// ID of the income or transfer record.
+	SourceID  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RemainingAmount is the amount still needed to reach the target.
+func (g Goal) RemainingAmount() int64 {
+	return max(g.TargetAmount-g.SavedAmount, 0)
+}
+
+// Progress is the percentage of the target that has been saved, capped at 100.
+func (g Goal) Progress() float64 {
+	if g.TargetAmount <= 0 {
+		return 100
+	}
+
+	progress := float64(g.SavedAmount) / float64(g.TargetAmount) * 100
+	return math.Round(min(progress, 100)*100) / 100
+}
+
+// RequiredMonthlyContribution is the amount that has to be saved every month,
+// starting from now, to reach the target by the deadline. The month of the
+// deadline counts, so a deadline within the current month needs the whole
+// remaining amount.
+func (g Goal) RequiredMonthlyContribution(now time.Time) int64 {
+	remaining := g.RemainingAmount()
+	if remaining == 0 {
+		return 0
+	}
+
+	months := int64(monthsBetween(now, g.Deadline)) + 1
+	if months < 1 {
+		months = 1
+	}
+
+	// round up so the target is reached on time
+	return (remaining + months - 1) / months
+}
+
+// monthsBetween counts the calendar months from the month of a to the month of
+// b. It is negative if b is before a.
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
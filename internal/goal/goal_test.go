@@ -0,0 +1,89 @@
+package goal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	tests := map[string]struct {
+		goal goal.Goal
+		want float64
+	}{
+		"nothing saved": {
+			goal: goal.Goal{TargetAmount: 1000},
+			want: 0,
+		},
+		"partially saved": {
+			goal: goal.Goal{TargetAmount: 3000, SavedAmount: 1000},
+			want: 33.33,
+		},
+		"saved more than the target": {
+			goal: goal.Goal{TargetAmount: 1000, SavedAmount: 1500},
+			want: 100,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.goal.Progress())
+		})
+	}
+}
+
+func TestRequiredMonthlyContribution(t *testing.T) {
+	now := time.Date(2006, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		goal goal.Goal
+		want int64
+	}{
+		"deadline this month": {
+			goal: goal.Goal{
+				TargetAmount: 1000,
+				SavedAmount:  400,
+				Deadline:     time.Date(2006, time.January, 31, 0, 0, 0, 0, time.UTC),
+			},
+			want: 600,
+		},
+		"deadline next year": {
+			goal: goal.Goal{
+				TargetAmount: 12000,
+				Deadline:     time.Date(2006, time.December, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 1000,
+		},
+		"rounds up": {
+			goal: goal.Goal{
+				TargetAmount: 1000,
+				Deadline:     time.Date(2006, time.March, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 334,
+		},
+		"deadline has passed": {
+			goal: goal.Goal{
+				TargetAmount: 1000,
+				SavedAmount:  200,
+				Deadline:     time.Date(2005, time.June, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 800,
+		},
+		"target reached": {
+			goal: goal.Goal{
+				TargetAmount: 1000,
+				SavedAmount:  1000,
+				Deadline:     time.Date(2006, time.December, 1, 0, 0, 0, 0, time.UTC),
+			},
+			want: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.goal.RequiredMonthlyContribution(now))
+		})
+	}
+}
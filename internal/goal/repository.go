@@ -0,0 +1,14 @@
+package goal
+
+import "context"
+
+type Repository interface {
+	GoalByID(ctx context.Context, id string) (Goal, error)
+	ListGoals(ctx context.Context) ([]Goal, error)
+	CreateGoal(ctx context.Context, c CreateGoalReq) (Goal, error)
+	UpdateGoal(ctx context.Context, u UpdateGoalReq) (Goal, error)
+	DeleteGoal(ctx context.Context, id string) error
+	ListContributions(ctx context.Context, goalID string) ([]Contribution, error)
+	CreateContribution(ctx context.Context, c CreateContributionReq) (Contribution, error)
+	DeleteContribution(ctx context.Context, d DeleteContributionReq) error
+}
@@ -0,0 +1,114 @@
+package goal
+
+import (
+	"context"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	GoalByID(ctx context.Context, id string) (Goal, error)
+	ListGoals(ctx context.Context) ([]Goal, error)
+	CreateGoal(ctx context.Context, c CreateGoalReq) (Goal, error)
+	UpdateGoal(ctx context.Context, u UpdateGoalReq) (Goal, error)
+	DeleteGoal(ctx context.Context, id string) error
+	ListContributions(ctx context.Context, goalID string) ([]Contribution, error)
+	CreateContribution(ctx context.Context, c CreateContributionReq) (Contribution, error)
+	DeleteContribution(ctx context.Context, d DeleteContributionReq) error
+}
+
+type CreateGoalReq struct {
+	Name         string `json:"name" validate:"required"`
+	TargetAmount int64  `json:"target_amount" validate:"required,gt=0"`
+	Deadline     string `json:"deadline" validate:"required,datetime=2006-01-02"`
+}
+
+type UpdateGoalReq struct {
+	ID           string  `json:"id" validate:"required"`
+	Name         *string `json:"name" validate:"omitempty,min=1"`
+	TargetAmount *int64  `json:"target_amount" validate:"omitempty,gt=0"`
+	Deadline     *string `json:"deadline" validate:"omitempty,datetime=2006-01-02"`
+}
+
+type CreateContributionReq struct {
+	GoalID     string             `json:"goal_id" validate:"required"`
+	Amount     int64              `json:"amount" validate:"required,gt=0"`
+	Date       string             `json:"date" validate:"required,datetime=2006-01-02"`
+	Note       string             `json:"note"`
+	SourceType ContributionSource `json:"source_type" validate:"required,oneof=income transfer"`
+	// Transfers must belong to the user and can be split across goals up to
+	// their amount. Incomes aren't tracked, their ID is kept as is and can be
+	// linked only once.
+	SourceID string `json:"source_id" validate:"required"`
+}
+
+type DeleteContributionReq struct {
+	GoalID string `json:"goal_id" validate:"required"`
+	ID     string `json:"id" validate:"required"`
+}
+
+type service struct {
+	r Repository
+	v *validator.Validator
+}
+
+func NewService(r Repository, v *validator.Validator) Service {
+	return &service{
+		r: r,
+		v: v,
+	}
+}
+
+func (s *service) GoalByID(ctx context.Context, id string) (Goal, error) {
+	if id == "" {
+		return Goal{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.GoalByID(ctx, id)
+}
+
+func (s *service) ListGoals(ctx context.Context) ([]Goal, error) {
+	return s.r.ListGoals(ctx)
+}
+
+func (s *service) CreateGoal(ctx context.Context, c CreateGoalReq) (Goal, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Goal{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.CreateGoal(ctx, c)
+}
+
+func (s *service) UpdateGoal(ctx context.Context, u UpdateGoalReq) (Goal, error) {
+	if err := s.v.Struct(u); err != nil {
+		return Goal{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.UpdateGoal(ctx, u)
+}
+
+func (s *service) DeleteGoal(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteGoal(ctx, id)
+}
+
+func (s *service) ListContributions(ctx context.Context, goalID string) ([]Contribution, error) {
+	if goalID == "" {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Goal ID is required")
+	}
+	return s.r.ListContributions(ctx, goalID)
+}
+
+func (s *service) CreateContribution(ctx context.Context, c CreateContributionReq) (Contribution, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Contribution{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.CreateContribution(ctx, c)
+}
+
+func (s *service) DeleteContribution(ctx context.Context, d DeleteContributionReq) error {
+	if err := s.v.Struct(d); err != nil {
+		return internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.DeleteContribution(ctx, d)
+}
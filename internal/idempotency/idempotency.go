@@ -0,0 +1,25 @@
+package idempotency
+
+import (
+	"net/http"
+	"time"
+)
+
+// Record is a key along with the request it was first used for.
+type Record struct {
+	Key string
+	// Hash of the method, path and body of the request.
+	RequestHash string
+	// Nil while the request is being handled.
+	Response  *Response
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Response is what the request of a key was answered with. It's replayed to
+// requests that repeat the key.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
@@ -0,0 +1,19 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	// ReserveKey saves the key for a request unless the user already has it.
+	// A key that expired or whose request was abandoned is taken over. The
+	// returned bool reports whether the key was reserved, the record of the
+	// key is returned when it wasn't.
+	ReserveKey(ctx context.Context, r ReserveKeyReq) (Record, bool, error)
+	SaveResponse(ctx context.Context, key string, r Response) error
+	DeleteKey(ctx context.Context, key string) error
+	// PurgeExpiredKeys deletes the keys of every user that expired before the
+	// given time.
+	PurgeExpiredKeys(ctx context.Context, before time.Time) (int64, error)
+}
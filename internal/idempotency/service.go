@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+)
+
+// abandonAfter is how long a request can be handled before its key can be
+// taken over, e.g. when the server stopped in the middle of it.
+const abandonAfter = time.Minute
+
+const maxKeyLength = 255
+
+type Service interface {
+	// Begin reserves the key for a request. It returns the saved response
+	// instead when the key was already used for the same request, and an
+	// error when it was used for a different one or its request is still
+	// being handled.
+	Begin(ctx context.Context, key string, requestHash string) (*Response, error)
+	// Complete saves the response of the request the key was reserved for.
+	Complete(ctx context.Context, key string, r Response) error
+	// Release frees the key so the request can be retried.
+	Release(ctx context.Context, key string) error
+	PurgeExpiredKeys(ctx context.Context) (int64, error)
+}
+
+type ReserveKeyReq struct {
+	Key         string
+	RequestHash string
+	ExpiresAt   time.Time
+	// Keys still waiting for the response of a request made before it are
+	// taken over.
+	AbandonedBefore time.Time
+}
+
+type service struct {
+	r   Repository
+	ttl time.Duration
+}
+
+// NewService creates a Service that keeps the keys for ttl.
+func NewService(r Repository, ttl time.Duration) Service {
+	return &service{
+		r:   r,
+		ttl: ttl,
+	}
+}
+
+func (s *service) Begin(ctx context.Context, key string, requestHash string) (*Response, error) {
+	if len(key) > maxKeyLength {
+		return nil, internal.NewErrorf(internal.ErrorCodeInvalid, "Idempotency-Key must be at most %d characters", maxKeyLength)
+	}
+
+	now := time.Now()
+	record, reserved, err := s.r.ReserveKey(ctx, ReserveKeyReq{
+		Key:             key,
+		RequestHash:     requestHash,
+		ExpiresAt:       now.Add(s.ttl),
+		AbandonedBefore: now.Add(-abandonAfter),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Idempotency-Key was already used for a different request")
+	}
+	if record.Response == nil {
+		return nil, internal.NewError(internal.ErrorCodeConflict, "A request with the same Idempotency-Key is still being handled")
+	}
+
+	return record.Response, nil
+}
+
+func (s *service) Complete(ctx context.Context, key string, r Response) error {
+	return s.r.SaveResponse(ctx, key, r)
+}
+
+func (s *service) Release(ctx context.Context, key string) error {
+	return s.r.DeleteKey(ctx, key)
+}
+
+func (s *service) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	return s.r.PurgeExpiredKeys(ctx, time.Now())
+}
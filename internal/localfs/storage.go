@@ -0,0 +1,97 @@
+// Package localfs stores files on the local filesystem.
+package localfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/cativovo/budget-tracker/internal/attachment"
+)
+
+// Storage keeps each file under a directory, named after its key.
+type Storage struct {
+	dir string
+}
+
+var _ attachment.Storage = (*Storage)(nil)
+
+// NewStorage creates the directory if it doesn't exist.
+func NewStorage(dir string) (*Storage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("localfs.NewStorage: MkdirAll: %w", err)
+	}
+
+	return &Storage{
+		dir: dir,
+	}, nil
+}
+
+// Put writes to a temporary file first so a failed write never leaves a
+// partial file under the key.
+func (s *Storage) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("localfs.Storage.Put: %w", err)
+	}
+
+	f, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("localfs.Storage.Put: CreateTemp: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("localfs.Storage.Put: Copy: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("localfs.Storage.Put: Close: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("localfs.Storage.Put: Rename: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, fmt.Errorf("localfs.Storage.Open: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("localfs.Storage.Open: %w", err)
+	}
+
+	return f, nil
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return fmt.Errorf("localfs.Storage.Delete: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("localfs.Storage.Delete: %w", err)
+	}
+
+	return nil
+}
+
+// path makes sure the key can't point outside of the directory.
+func (s *Storage) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
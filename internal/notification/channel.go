@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/user"
+)
+
+// Channel delivers notifications outside of the inbox.
+type Channel interface {
+	Name() string
+	// Enabled reports whether the user wants notifications over the channel.
+	Enabled(u user.User, p Preferences) bool
+	Send(ctx context.Context, u user.User, p Preferences, n Notification) error
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// EmailChannel sends the notifications to the email of the user over SMTP.
+type EmailChannel struct {
+	cfg SMTPConfig
+}
+
+var _ Channel = (*EmailChannel)(nil)
+
+func NewEmailChannel(cfg SMTPConfig) *EmailChannel {
+	return &EmailChannel{
+		cfg: cfg,
+	}
+}
+
+func (ec *EmailChannel) Name() string {
+	return "email"
+}
+
+func (ec *EmailChannel) Enabled(u user.User, p Preferences) bool {
+	return p.EmailEnabled && u.Email != ""
+}
+
+func (ec *EmailChannel) Send(ctx context.Context, u user.User, p Preferences, n Notification) error {
+	var auth smtp.Auth
+	if ec.cfg.Username != "" {
+		auth = smtp.PlainAuth("", ec.cfg.Username, ec.cfg.Password, ec.cfg.Host)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", ec.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", u.Email)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Title))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	addr := net.JoinHostPort(ec.cfg.Host, strconv.Itoa(ec.cfg.Port))
+	if err := smtp.SendMail(addr, auth, ec.cfg.From, []string{u.Email}, msg.Bytes()); err != nil {
+		return fmt.Errorf("notification.EmailChannel.Send: SendMail: %w", err)
+	}
+
+	return nil
+}
+
+// WebhookChannel POSTs the notifications as JSON to the URL the user set.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+var _ Channel = (*WebhookChannel)(nil)
+
+func NewWebhookChannel(client *http.Client) *WebhookChannel {
+	return &WebhookChannel{
+		client: client,
+	}
+}
+
+func (wc *WebhookChannel) Name() string {
+	return "webhook"
+}
+
+func (wc *WebhookChannel) Enabled(u user.User, p Preferences) bool {
+	return p.WebhookURL != ""
+}
+
+type webhookPayload struct {
+	ID        string    `json:"id"`
+	Kind      Kind      `json:"kind"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (wc *WebhookChannel) Send(ctx context.Context, u user.User, p Preferences, n Notification) error {
+	body, err := json.Marshal(webhookPayload{
+		ID:        n.ID,
+		Kind:      n.Kind,
+		Title:     n.Title,
+		Message:   n.Message,
+		CreatedAt: n.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("notification.WebhookChannel.Send: Marshal: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notification.WebhookChannel.Send: NewRequest: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := wc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notification.WebhookChannel.Send: Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification.WebhookChannel.Send: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package notification_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+var testUser = user.User{
+	ID:    "1",
+	Name:  "Alex Albon",
+	Email: "alexalbon@williams.com",
+}
+
+var testNotification = notification.Notification{
+	ID:      "123",
+	Kind:    notification.KindBudget,
+	Title:   "Food is over its budget",
+	Message: "1200 of 1000 has been spent on Food this month.",
+}
+
+// fakeSMTPServer accepts a single mail and sends what it received to mail.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mail     chan smtpMail
+}
+
+type smtpMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	s := &fakeSMTPServer{
+		listener: l,
+		mail:     make(chan smtpMail, 1),
+	}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		conn.Write([]byte(line + "\r\n"))
+	}
+
+	var m smtpMail
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			m.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			m.to = append(m.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			m.data = data.String()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			s.mail <- m
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestEmailChannel(t *testing.T) {
+	s := newFakeSMTPServer(t)
+	defer s.listener.Close()
+
+	ec := notification.NewEmailChannel(notification.SMTPConfig{
+		Host: "127.0.0.1",
+		Port: s.port(),
+		From: "budget-tracker@localhost",
+	})
+
+	assert.False(t, ec.Enabled(testUser, notification.Preferences{}))
+	assert.True(t, ec.Enabled(testUser, notification.Preferences{EmailEnabled: true}))
+
+	err := ec.Send(context.Background(), testUser, notification.Preferences{EmailEnabled: true}, testNotification)
+	assert.Nil(t, err)
+
+	select {
+	case m := <-s.mail:
+		assert.Equal(t, "budget-tracker@localhost", m.from)
+		assert.Equal(t, []string{testUser.Email}, m.to)
+		assert.Contains(t, m.data, "Subject: "+testNotification.Title)
+		assert.Contains(t, m.data, testNotification.Message)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+}
+
+func TestWebhookChannel(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		wantErr bool
+	}{
+		"accepted": {
+			status: http.StatusNoContent,
+		},
+		"rejected": {
+			status:  http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got map[string]any
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				assert.Nil(t, json.NewDecoder(r.Body).Decode(&got))
+				w.WriteHeader(test.status)
+			}))
+			defer ts.Close()
+
+			wc := notification.NewWebhookChannel(ts.Client())
+			p := notification.Preferences{WebhookURL: ts.URL}
+
+			assert.False(t, wc.Enabled(testUser, notification.Preferences{}))
+			assert.True(t, wc.Enabled(testUser, p))
+
+			err := wc.Send(context.Background(), testUser, p, testNotification)
+			if test.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			assert.Equal(t, testNotification.ID, got["id"])
+			assert.Equal(t, string(testNotification.Kind), got["kind"])
+			assert.Equal(t, testNotification.Title, got["title"])
+			assert.Equal(t, testNotification.Message, got["message"])
+		})
+	}
+}
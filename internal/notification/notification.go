@@ -0,0 +1,132 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/forecast"
+)
+
+type Kind string
+
+const (
+	KindBudget     Kind = "budget"
+	KindBillDue    Kind = "bill_due"
+	KindInactivity Kind = "inactivity"
+)
+
+// Budget thresholds, in percent, that are notified. The highest threshold
+// reached is the one notified.
+var budgetThresholds = []float64{100, 80}
+
+// Notification is stored in the inbox of the user and sent over the channels
+// the user turned on.
+type Notification struct {
+	ID      string
+	Kind    Kind
+	Title   string
+	Message string
+	// Notifications with the same key are only sent once.
+	DedupKey  string
+	ReadAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Preferences decide which notifications the user gets and where.
+type Preferences struct {
+	BudgetAlerts  bool
+	BillReminders bool
+	// How many days before a recurring bill is due the reminder is sent.
+	BillReminderDays int
+	// Remind the user when nothing was logged for this many days. 0 turns it
+	// off.
+	InactivityDays int
+	// Send the notifications to the email of the user.
+	EmailEnabled bool
+	// POST the notifications to this URL. Empty turns it off.
+	WebhookURL string
+}
+
+// DefaultPreferences are used until the user saves their own.
+var DefaultPreferences = Preferences{
+	BudgetAlerts:     true,
+	BillReminders:    true,
+	BillReminderDays: 3,
+}
+
+// BudgetNotifications notifies the budgets that reached a threshold within the
+// month.
+func BudgetNotifications(statuses []category.BudgetStatus, month time.Time) []Notification {
+	var result []Notification
+	for _, s := range statuses {
+		percent := s.Percent()
+		for _, threshold := range budgetThresholds {
+			if percent < threshold {
+				continue
+			}
+
+			title := fmt.Sprintf("%s is at %.0f%% of its budget", s.Category.Name, threshold)
+			if threshold >= 100 {
+				title = fmt.Sprintf("%s is over its budget", s.Category.Name)
+			}
+
+			result = append(result, Notification{
+				Kind:     KindBudget,
+				Title:    title,
+				Message:  fmt.Sprintf("%d of %d has been spent on %s this month.", s.Spent, s.Amount, s.Category.Name),
+				DedupKey: fmt.Sprintf("budget:%s:%s:%.0f", s.Category.ID, month.Format("2006-01"), threshold),
+			})
+			break
+		}
+	}
+
+	return result
+}
+
+// BillNotifications notifies the recurring expenses expected within the next
+// days, today included. An overdue one is expected today but keeps the key of
+// its due date, it's only notified once.
+func BillNotifications(items []forecast.RecurringItem, today time.Time, days int) []Notification {
+	var result []Notification
+	for _, item := range items {
+		for _, due := range item.DueDates(today.AddDate(0, 0, days)) {
+			d := due
+			if d.Before(today) {
+				d = today
+			}
+
+			result = append(result, Notification{
+				Kind:    KindBillDue,
+				Title:   fmt.Sprintf("%s is due on %s", item.Name, d.Format(time.DateOnly)),
+				Message: fmt.Sprintf("%s of about %d is expected on %s.", item.Name, item.Amount, d.Format(time.DateOnly)),
+				DedupKey: fmt.Sprintf(
+					"bill_due:%s:%s:%s",
+					item.Category.ID,
+					strings.ToLower(strings.TrimSpace(item.Name)),
+					due.Format(time.DateOnly),
+				),
+			})
+		}
+	}
+
+	return result
+}
+
+// InactivityNotification reminds the user to log their expenses when the
+// last one is at least the given number of days old.
+func InactivityNotification(lastExpense time.Time, today time.Time, days int) (Notification, bool) {
+	if days <= 0 || today.Sub(lastExpense) < time.Duration(days)*24*time.Hour {
+		return Notification{}, false
+	}
+
+	return Notification{
+		Kind:    KindInactivity,
+		Title:   "No expenses logged lately",
+		Message: fmt.Sprintf("Nothing has been logged since %s.", lastExpense.Format(time.DateOnly)),
+		// one reminder per gap
+		DedupKey: fmt.Sprintf("inactivity:%s", lastExpense.Format(time.DateOnly)),
+	}, true
+}
@@ -0,0 +1,161 @@
+package notification_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/forecast"
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	food = category.Category{ID: "food", Name: "Food"}
+	rent = category.Category{ID: "rent", Name: "Rent"}
+)
+
+func TestBudgetNotifications(t *testing.T) {
+	month := date(t, "2006-04-01")
+
+	tests := map[string]struct {
+		statuses []category.BudgetStatus
+		want     []string
+	}{
+		"under 80%": {
+			statuses: []category.BudgetStatus{
+				{Category: food, Amount: 1000, Spent: 799},
+			},
+		},
+		"at 80%": {
+			statuses: []category.BudgetStatus{
+				{Category: food, Amount: 1000, Spent: 800},
+			},
+			want: []string{"budget:food:2006-04:80"},
+		},
+		"over 100% only notifies the highest threshold": {
+			statuses: []category.BudgetStatus{
+				{Category: food, Amount: 1000, Spent: 1200},
+				{Category: rent, Amount: 1000, Spent: 900},
+			},
+			want: []string{"budget:food:2006-04:100", "budget:rent:2006-04:80"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := notification.BudgetNotifications(test.statuses, month)
+			assert.Equal(t, test.want, dedupKeys(got))
+			for _, n := range got {
+				assert.Equal(t, notification.KindBudget, n.Kind)
+			}
+		})
+	}
+}
+
+func TestBillNotifications(t *testing.T) {
+	today := date(t, "2006-04-28")
+
+	tests := map[string]struct {
+		items []forecast.RecurringItem
+		days  int
+		want  []string
+	}{
+		"due within the days": {
+			items: []forecast.RecurringItem{
+				{Name: "Rent ", Category: rent, Amount: 1000, LastDate: date(t, "2006-03-30")},
+			},
+			days: 3,
+			want: []string{"bill_due:rent:rent:2006-04-30"},
+		},
+		"due after the days": {
+			items: []forecast.RecurringItem{
+				{Name: "Rent", Category: rent, Amount: 1000, LastDate: date(t, "2006-04-02")},
+			},
+			days: 3,
+		},
+		"overdue is due today": {
+			items: []forecast.RecurringItem{
+				{Name: "Netflix", Category: food, Amount: 500, LastDate: date(t, "2006-03-20")},
+			},
+			days: 0,
+			want: []string{"bill_due:food:netflix:2006-04-20"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := notification.BillNotifications(test.items, today, test.days)
+			assert.Equal(t, test.want, dedupKeys(got))
+		})
+	}
+}
+
+func TestBillNotificationsOverdueOnConsecutiveDays(t *testing.T) {
+	items := []forecast.RecurringItem{
+		{Name: "Netflix", Category: food, Amount: 500, LastDate: date(t, "2006-03-20")},
+	}
+
+	keys := map[string]bool{}
+	for _, today := range []time.Time{date(t, "2006-04-28"), date(t, "2006-04-29")} {
+		got := notification.BillNotifications(items, today, 0)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "Netflix is due on "+today.Format(time.DateOnly), got[0].Title)
+		keys[got[0].DedupKey] = true
+	}
+
+	assert.Equal(t, map[string]bool{"bill_due:food:netflix:2006-04-20": true}, keys)
+}
+
+func TestInactivityNotification(t *testing.T) {
+	today := date(t, "2006-04-28")
+
+	tests := map[string]struct {
+		last   string
+		days   int
+		wantOK bool
+		want   string
+	}{
+		"turned off": {
+			last: "2006-01-01",
+			days: 0,
+		},
+		"logged recently": {
+			last: "2006-04-26",
+			days: 3,
+		},
+		"nothing logged for days": {
+			last:   "2006-04-25",
+			days:   3,
+			wantOK: true,
+			want:   "inactivity:2006-04-25",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := notification.InactivityNotification(date(t, test.last), today, test.days)
+			assert.Equal(t, test.wantOK, ok)
+			assert.Equal(t, test.want, got.DedupKey)
+		})
+	}
+}
+
+func dedupKeys(notifications []notification.Notification) []string {
+	var keys []string
+	for _, n := range notifications {
+		keys = append(keys, n.DedupKey)
+	}
+	return keys
+}
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
@@ -0,0 +1,15 @@
+package notification
+
+import "context"
+
+type Repository interface {
+	// PreferencesOf returns DefaultPreferences if the user hasn't saved any.
+	PreferencesOf(ctx context.Context) (Preferences, error)
+	UpdatePreferences(ctx context.Context, u UpdatePreferencesReq) (Preferences, error)
+	// CreateNotification stores the notification in the inbox. It returns
+	// false, and stores nothing, if a notification with the same dedup key
+	// already exists.
+	CreateNotification(ctx context.Context, n Notification) (Notification, bool, error)
+	ListNotifications(ctx context.Context, l ListNotificationsReq) ([]Notification, error)
+	MarkRead(ctx context.Context, id string) (Notification, error)
+}
@@ -0,0 +1,222 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/forecast"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	// Check creates the notifications that are due for the user and sends
+	// the new ones over the channels the user turned on. Notifications that
+	// were already created are not sent again.
+	Check(ctx context.Context) ([]Notification, error)
+	// CheckAll runs Check for every user.
+	CheckAll(ctx context.Context) error
+	ListNotifications(ctx context.Context, l ListNotificationsReq) ([]Notification, error)
+	MarkRead(ctx context.Context, id string) (Notification, error)
+	PreferencesOf(ctx context.Context) (Preferences, error)
+	UpdatePreferences(ctx context.Context, u UpdatePreferencesReq) (Preferences, error)
+}
+
+// BudgetLister lists the budgets of the user along with their spending.
+type BudgetLister interface {
+	ListBudgetStatuses(ctx context.Context, b category.BudgetStatusesReq) ([]category.BudgetStatus, error)
+}
+
+// ExpenseLister lists the past expenses of the user.
+type ExpenseLister interface {
+	ListExpenses(ctx context.Context, l expense.ListExpensesReq) ([]expense.Expense, error)
+}
+
+// UserLister lists every user.
+type UserLister interface {
+	ListUsers(ctx context.Context) ([]user.User, error)
+}
+
+type ListNotificationsReq struct {
+	// Only list the notifications that were not read yet.
+	Unread bool `json:"unread"`
+	internal.ListOptions
+}
+
+type UpdatePreferencesReq struct {
+	BudgetAlerts     bool   `json:"budget_alerts"`
+	BillReminders    bool   `json:"bill_reminders"`
+	BillReminderDays int    `json:"bill_reminder_days" validate:"min=0,max=30"`
+	InactivityDays   int    `json:"inactivity_days" validate:"min=0,max=365"`
+	EmailEnabled     bool   `json:"email_enabled"`
+	WebhookURL       string `json:"webhook_url" validate:"omitempty,http_url"`
+}
+
+type service struct {
+	r        Repository
+	bl       BudgetLister
+	el       ExpenseLister
+	ul       UserLister
+	channels []Channel
+	v        *validator.Validator
+}
+
+func NewService(r Repository, bl BudgetLister, el ExpenseLister, ul UserLister, channels []Channel, v *validator.Validator) Service {
+	return &service{
+		r:        r,
+		bl:       bl,
+		el:       el,
+		ul:       ul,
+		channels: channels,
+		v:        v,
+	}
+}
+
+func (s *service) Check(ctx context.Context) ([]Notification, error) {
+	u := user.FromContext(ctx)
+
+	p, err := s.r.PreferencesOf(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("notification.Service.Check: %w", err)
+	}
+
+	drafts, err := s.drafts(ctx, p, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("notification.Service.Check: %w", err)
+	}
+
+	var result []Notification
+	for _, d := range drafts {
+		n, created, err := s.r.CreateNotification(ctx, d)
+		if err != nil {
+			return nil, fmt.Errorf("notification.Service.Check: %w", err)
+		}
+		if !created {
+			continue
+		}
+
+		s.send(ctx, u, p, n)
+		result = append(result, n)
+	}
+
+	return result, nil
+}
+
+// drafts builds the notifications that are due according to the
+// preferences.
+func (s *service) drafts(ctx context.Context, p Preferences, today time.Time) ([]Notification, error) {
+	var result []Notification
+
+	if p.BudgetAlerts {
+		month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		statuses, err := s.bl.ListBudgetStatuses(ctx, category.BudgetStatusesReq{
+			Month: month.Format("2006-01"),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, BudgetNotifications(statuses, month)...)
+	}
+
+	if !p.BillReminders && p.InactivityDays <= 0 {
+		return result, nil
+	}
+
+	expenses, err := s.el.ListExpenses(ctx, expense.ListExpensesReq{
+		StartDate: today.AddDate(0, -forecast.HistoryMonths, 0).Format(time.DateOnly),
+		EndDate:   today.Format(time.DateOnly),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.BillReminders {
+		result = append(result, BillNotifications(forecast.DetectRecurring(expenses, today), today, p.BillReminderDays)...)
+	}
+
+	if p.InactivityDays > 0 && len(expenses) > 0 {
+		last := expenses[0].Date
+		for _, e := range expenses[1:] {
+			if e.Date.After(last) {
+				last = e.Date
+			}
+		}
+
+		if n, ok := InactivityNotification(last, today, p.InactivityDays); ok {
+			result = append(result, n)
+		}
+	}
+
+	return result, nil
+}
+
+// send sends the notification over the channels the user turned on. A
+// channel that fails doesn't stop the others since the notification is
+// already in the inbox.
+func (s *service) send(ctx context.Context, u user.User, p Preferences, n Notification) {
+	logger := logger.FromContext(ctx)
+
+	for _, c := range s.channels {
+		if !c.Enabled(u, p) {
+			continue
+		}
+
+		if err := c.Send(ctx, u, p, n); err != nil {
+			logger.Errorw(
+				"Failed to send notification",
+				"channel", c.Name(),
+				"notification_id", n.ID,
+				"error", err,
+			)
+		}
+	}
+}
+
+func (s *service) CheckAll(ctx context.Context) error {
+	logger := logger.FromContext(ctx)
+
+	users, err := s.ul.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("notification.Service.CheckAll: %w", err)
+	}
+
+	for _, u := range users {
+		notifications, err := s.Check(user.ContextWithUser(ctx, u))
+		if err != nil {
+			logger.Errorw("Failed to check notifications", "user_id", u.ID, "error", err)
+			continue
+		}
+
+		logger.Infow("Checked notifications", "user_id", u.ID, "count", len(notifications))
+	}
+
+	return nil
+}
+
+func (s *service) ListNotifications(ctx context.Context, l ListNotificationsReq) ([]Notification, error) {
+	return s.r.ListNotifications(ctx, l)
+}
+
+func (s *service) MarkRead(ctx context.Context, id string) (Notification, error) {
+	if id == "" {
+		return Notification{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.MarkRead(ctx, id)
+}
+
+func (s *service) PreferencesOf(ctx context.Context) (Preferences, error) {
+	return s.r.PreferencesOf(ctx)
+}
+
+func (s *service) UpdatePreferences(ctx context.Context, u UpdatePreferencesReq) (Preferences, error) {
+	if err := s.v.Struct(u); err != nil {
+		return Preferences{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.UpdatePreferences(ctx, u)
+}
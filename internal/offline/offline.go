@@ -0,0 +1,190 @@
+package offline
+
+import (
+	"time"
+)
+
+type Resource string
+
+const (
+	ResourceExpense      Resource = "expense"
+	ResourceExpenseGroup Resource = "expense_group"
+	ResourceCategory     Resource = "category"
+)
+
+// ServerReplica is the key of the server's counter in the version vectors.
+// The server's counter is the version of the row, it is bumped on every
+// write no matter where it comes from.
+const ServerReplica = "server"
+
+// VersionVector counts the changes each replica, the server or a device, made
+// to a record.
+type VersionVector map[string]int64
+
+// Merge returns the highest counter of every replica in v and o.
+func (v VersionVector) Merge(o VersionVector) VersionVector {
+	result := make(VersionVector, len(v)+len(o))
+	for k, n := range v {
+		result[k] = n
+	}
+	for k, n := range o {
+		if n > result[k] {
+			result[k] = n
+		}
+	}
+	return result
+}
+
+// Devices returns the vector without the server's counter, which is stored
+// as the version of the row.
+func (v VersionVector) Devices() VersionVector {
+	result := make(VersionVector, len(v))
+	for k, n := range v {
+		if k != ServerReplica {
+			result[k] = n
+		}
+	}
+	return result
+}
+
+type Expense struct {
+	Name           string   `json:"name" validate:"required"`
+	Amount         int64    `json:"amount" validate:"gt=0"`
+	Date           string   `json:"date" validate:"required,datetime=2006-01-02"`
+	Note           string   `json:"note"`
+	CategoryID     string   `json:"category_id" validate:"required"`
+	Tags           []string `json:"tags"`
+	AccountID      *string  `json:"account_id"`
+	ExpenseGroupID *string  `json:"expense_group_id"`
+}
+
+type ExpenseGroup struct {
+	Name string `json:"name" validate:"required"`
+	Date string `json:"date" validate:"required,datetime=2006-01-02"`
+	Note string `json:"note"`
+}
+
+type Category struct {
+	Name     string  `json:"name" validate:"required"`
+	Color    string  `json:"color" validate:"required,hexcolor"`
+	Icon     string  `json:"icon" validate:"required"`
+	ParentID *string `json:"parent_id"`
+}
+
+// Record is the state of a synced row. Only the field of its resource is set
+// and none is set if it was deleted.
+type Record struct {
+	Resource      Resource
+	ID            string
+	Deleted       bool
+	VersionVector VersionVector
+	UpdatedAt     time.Time
+	Expense       *Expense
+	ExpenseGroup  *ExpenseGroup
+	Category      *Category
+}
+
+type ChangeStatus string
+
+const (
+	ChangeStatusApplied  ChangeStatus = "applied"
+	ChangeStatusConflict ChangeStatus = "conflict"
+	ChangeStatusRejected ChangeStatus = "rejected"
+)
+
+// Resolution tells which side of a conflict was kept.
+type Resolution string
+
+const (
+	ResolutionClient Resolution = "client"
+	ResolutionServer Resolution = "server"
+)
+
+type ChangeResult struct {
+	Resource   Resource
+	ID         string
+	Status     ChangeStatus
+	Resolution *Resolution
+	// Why the change was rejected.
+	Error *string
+	// Event of the change if it was written, empty otherwise.
+	Event string
+	// Record as stored after the change. Nil if the change was rejected
+	// before the record could be read.
+	Record *Record
+}
+
+type PullResult struct {
+	Records []Record
+	// Token to pull the changes after these records.
+	Token   string
+	HasMore bool
+}
+
+// Decision is what to do with a pushed change.
+type Decision int
+
+const (
+	// DecisionApply writes the change, nothing changed the record since the
+	// client last saw it.
+	DecisionApply Decision = iota
+	// DecisionSkip leaves the record alone, it already has the change.
+	DecisionSkip
+	// DecisionClientWins writes the change even though the record was changed
+	// by someone else, the client changed it last.
+	DecisionClientWins
+	// DecisionServerWins keeps the record as it is.
+	DecisionServerWins
+)
+
+// ServerState is the record a change is pushed to.
+type ServerState struct {
+	Exists bool
+	// In the trash, or deleted for good if it doesn't exist.
+	Deleted       bool
+	VersionVector VersionVector
+	UpdatedAt     time.Time
+}
+
+// Decide resolves a change pushed by the device against the server's state
+// of the record. Concurrent changes are resolved by last-writer-wins on
+// their updated_at, except deletes which always win.
+func Decide(s ServerState, c PushChange, deviceID string) Decision {
+	if !s.Exists {
+		switch {
+		case c.Deleted:
+			return DecisionSkip
+		case c.VersionVector[ServerReplica] == 0:
+			// created by the device
+			return DecisionApply
+		default:
+			// the server deleted it for good
+			return DecisionServerWins
+		}
+	}
+
+	if c.VersionVector[deviceID] <= s.VersionVector[deviceID] {
+		// the change is pushed again
+		return DecisionSkip
+	}
+
+	if s.Deleted {
+		if c.Deleted {
+			return DecisionSkip
+		}
+		return DecisionServerWins
+	}
+
+	if c.VersionVector[ServerReplica] >= s.VersionVector[ServerReplica] {
+		return DecisionApply
+	}
+
+	if c.Deleted {
+		// deletes win even against newer edits
+		return DecisionClientWins
+	}
+	if c.UpdatedAt.After(s.UpdatedAt) {
+		return DecisionClientWins
+	}
+	return DecisionServerWins
+}
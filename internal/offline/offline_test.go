@@ -0,0 +1,135 @@
+package offline_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/offline"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionVector(t *testing.T) {
+	v := offline.VersionVector{offline.ServerReplica: 3, "phone": 2}
+	o := offline.VersionVector{offline.ServerReplica: 1, "phone": 4, "laptop": 1}
+
+	assert.Equal(t, offline.VersionVector{offline.ServerReplica: 3, "phone": 4, "laptop": 1}, v.Merge(o))
+	assert.Equal(t, offline.VersionVector{"phone": 2}, v.Devices())
+}
+
+func TestDecide(t *testing.T) {
+	now := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	server := offline.ServerState{
+		Exists:        true,
+		VersionVector: offline.VersionVector{offline.ServerReplica: 3, "phone": 2},
+		UpdatedAt:     now,
+	}
+
+	tests := map[string]struct {
+		server offline.ServerState
+		change offline.PushChange
+		want   offline.Decision
+	}{
+		"create": {
+			server: offline.ServerState{},
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{"phone": 1},
+			},
+			want: offline.DecisionApply,
+		},
+		"delete a record the server doesn't have": {
+			server: offline.ServerState{},
+			change: offline.PushChange{
+				Deleted:       true,
+				VersionVector: offline.VersionVector{offline.ServerReplica: 3, "phone": 3},
+			},
+			want: offline.DecisionSkip,
+		},
+		"update a record deleted for good": {
+			server: offline.ServerState{},
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 3, "phone": 3},
+			},
+			want: offline.DecisionServerWins,
+		},
+		"update without conflict": {
+			server: server,
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 3, "phone": 3},
+				UpdatedAt:     now.Add(-time.Hour),
+			},
+			want: offline.DecisionApply,
+		},
+		"pushed again": {
+			server: server,
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 2, "phone": 2},
+				UpdatedAt:     now.Add(time.Hour),
+			},
+			want: offline.DecisionSkip,
+		},
+		"conflict won by the client": {
+			server: server,
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 2, "phone": 3},
+				UpdatedAt:     now.Add(time.Minute),
+			},
+			want: offline.DecisionClientWins,
+		},
+		"conflict won by the server": {
+			server: server,
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 2, "phone": 3},
+				UpdatedAt:     now.Add(-time.Minute),
+			},
+			want: offline.DecisionServerWins,
+		},
+		"tie is won by the server": {
+			server: server,
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 2, "phone": 3},
+				UpdatedAt:     now,
+			},
+			want: offline.DecisionServerWins,
+		},
+		"delete conflicting with a newer edit": {
+			server: server,
+			change: offline.PushChange{
+				Deleted:       true,
+				VersionVector: offline.VersionVector{offline.ServerReplica: 2, "phone": 3},
+				UpdatedAt:     now.Add(-time.Minute),
+			},
+			want: offline.DecisionClientWins,
+		},
+		"update a deleted record": {
+			server: offline.ServerState{
+				Exists:        true,
+				Deleted:       true,
+				VersionVector: offline.VersionVector{offline.ServerReplica: 4},
+				UpdatedAt:     now,
+			},
+			change: offline.PushChange{
+				VersionVector: offline.VersionVector{offline.ServerReplica: 4, "phone": 1},
+				UpdatedAt:     now.Add(time.Hour),
+			},
+			want: offline.DecisionServerWins,
+		},
+		"delete a deleted record": {
+			server: offline.ServerState{
+				Exists:        true,
+				Deleted:       true,
+				VersionVector: offline.VersionVector{offline.ServerReplica: 4},
+			},
+			change: offline.PushChange{
+				Deleted:       true,
+				VersionVector: offline.VersionVector{offline.ServerReplica: 3, "phone": 1},
+			},
+			want: offline.DecisionSkip,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, offline.Decide(test.server, test.change, "phone"))
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package offline
+
+import "context"
+
+type Repository interface {
+	// PushChanges applies the changes in order in a single transaction. A
+	// change that can't be applied is rejected without affecting the others.
+	PushChanges(ctx context.Context, p PushReq) ([]ChangeResult, error)
+	// ListChanges lists the records of the user that changed after the
+	// given seq, oldest change first.
+	ListChanges(ctx context.Context, after int64, limit int) ([]Change, error)
+}
@@ -0,0 +1,151 @@
+package offline
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	// Push applies the changes a device made while offline. Conflicting
+	// changes are resolved by Decide and reported in the results.
+	Push(ctx context.Context, p PushReq) ([]ChangeResult, error)
+	// Pull lists the records that changed after the token. An empty token
+	// pulls every record.
+	Pull(ctx context.Context, p PullReq) (PullResult, error)
+}
+
+type PushReq struct {
+	// Device that made the changes, the key of its counter in the version
+	// vectors.
+	DeviceID string       `json:"device_id" validate:"required,max=64,ne=server"`
+	Changes  []PushChange `json:"changes" validate:"required,min=1,max=100,dive"`
+}
+
+type PushChange struct {
+	Resource Resource `json:"resource" validate:"required,oneof=expense expense_group category"`
+	// Generated by the device for the records it creates.
+	ID      string `json:"id" validate:"required,max=64"`
+	Deleted bool   `json:"deleted"`
+	// Vector of the record the device last pulled with the counter of the
+	// device bumped for every change it made since.
+	VersionVector VersionVector `json:"version_vector" validate:"required"`
+	// When the device made the change. The latest change wins a conflict.
+	UpdatedAt    time.Time     `json:"updated_at" validate:"required"`
+	Expense      *Expense      `json:"expense" validate:"required_if=Resource expense Deleted false"`
+	ExpenseGroup *ExpenseGroup `json:"expense_group" validate:"required_if=Resource expense_group Deleted false"`
+	Category     *Category     `json:"category" validate:"required_if=Resource category Deleted false"`
+}
+
+type PullReq struct {
+	Token string `json:"token"`
+	Limit int    `json:"limit" validate:"min=1,max=500"`
+}
+
+// Change is a record along with its position in the change feed.
+type Change struct {
+	Seq    int64
+	Record Record
+}
+
+// EventDispatcher tells the webhooks of the user about the changes.
+type EventDispatcher interface {
+	Dispatch(ctx context.Context, event string, resourceID string, data any) error
+}
+
+type service struct {
+	r  Repository
+	v  *validator.Validator
+	ed EventDispatcher
+}
+
+func NewService(r Repository, v *validator.Validator, ed EventDispatcher) Service {
+	return &service{
+		r:  r,
+		v:  v,
+		ed: ed,
+	}
+}
+
+func (s *service) Push(ctx context.Context, p PushReq) ([]ChangeResult, error) {
+	if err := s.v.Struct(p); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	for _, c := range p.Changes {
+		if c.VersionVector[p.DeviceID] < 1 {
+			return nil, internal.NewErrorf(internal.ErrorCodeInvalid, "Version vector of %s %s must count the change of the device", c.Resource, c.ID)
+		}
+	}
+
+	results, err := s.r.PushChanges(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		if r.Event == "" {
+			continue
+		}
+
+		var data any
+		if r.Record != nil {
+			switch {
+			case r.Record.Expense != nil:
+				data = *r.Record.Expense
+			case r.Record.ExpenseGroup != nil:
+				data = *r.Record.ExpenseGroup
+			case r.Record.Category != nil:
+				data = *r.Record.Category
+			}
+		}
+
+		// the changes are already saved, failing to tell the webhooks
+		// doesn't fail them
+		if err := s.ed.Dispatch(ctx, r.Event, r.ID, data); err != nil {
+			logger.FromContext(ctx).Errorw("Failed to dispatch event", "event", r.Event, "id", r.ID, "error", err)
+		}
+	}
+
+	return results, nil
+}
+
+func (s *service) Pull(ctx context.Context, p PullReq) (PullResult, error) {
+	if err := s.v.Struct(p); err != nil {
+		return PullResult{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	var after int64
+	if p.Token != "" {
+		seq, err := strconv.ParseInt(p.Token, 10, 64)
+		if err != nil || seq < 0 {
+			return PullResult{}, internal.NewError(internal.ErrorCodeInvalid, "Invalid change token")
+		}
+		after = seq
+	}
+
+	// one more to know if there are more
+	changes, err := s.r.ListChanges(ctx, after, p.Limit+1)
+	if err != nil {
+		return PullResult{}, err
+	}
+
+	result := PullResult{
+		Records: make([]Record, 0, min(len(changes), p.Limit)),
+		HasMore: len(changes) > p.Limit,
+	}
+	for i, c := range changes {
+		if i == p.Limit {
+			break
+		}
+		result.Records = append(result.Records, c.Record)
+		after = c.Seq
+	}
+	result.Token = strconv.FormatInt(after, 10)
+
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package reconciliation
+
+import "time"
+
+// Session reconciles an account against a statement. The expenses of the
+// account are marked as cleared until the cleared balance matches the
+// statement balance, then the session is completed and the cleared expenses
+// are locked.
+type Session struct {
+	ID               string
+	AccountID        string
+	StatementDate    time.Time
+	StatementBalance int64
+	// Opening balance of the account plus the transfers up to the statement
+	// date, minus the cleared expenses.
+	ClearedBalance int64
+	// Nil while the session is open.
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Difference is what is left to reconcile, zero when the cleared balance
+// matches the statement.
+func (s Session) Difference() int64 {
+	return s.StatementBalance - s.ClearedBalance
+}
+
+func (s Session) Completed() bool {
+	return s.CompletedAt != nil
+}
+
+// Item is an expense of the account that can be cleared in the session.
+type Item struct {
+	ExpenseID string
+	Name      string
+	Amount    int64
+	Date      time.Time
+	Cleared   bool
+}
@@ -0,0 +1,13 @@
+package reconciliation
+
+import "context"
+
+type Repository interface {
+	SessionByID(ctx context.Context, id string) (Session, error)
+	ListSessions(ctx context.Context, accountID string) ([]Session, error)
+	CreateSession(ctx context.Context, c CreateSessionReq) (Session, error)
+	DeleteSession(ctx context.Context, id string) error
+	ListItems(ctx context.Context, sessionID string) ([]Item, error)
+	MarkCleared(ctx context.Context, m MarkClearedReq) (Session, error)
+	CompleteSession(ctx context.Context, id string) (Session, error)
+}
@@ -0,0 +1,97 @@
+package reconciliation
+
+import (
+	"context"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	SessionByID(ctx context.Context, id string) (Session, error)
+	ListSessions(ctx context.Context, accountID string) ([]Session, error)
+	CreateSession(ctx context.Context, c CreateSessionReq) (Session, error)
+	DeleteSession(ctx context.Context, id string) error
+	ListItems(ctx context.Context, sessionID string) ([]Item, error)
+	MarkCleared(ctx context.Context, m MarkClearedReq) (Session, error)
+	CompleteSession(ctx context.Context, id string) (Session, error)
+}
+
+type CreateSessionReq struct {
+	AccountID        string `json:"account_id" validate:"required"`
+	StatementDate    string `json:"statement_date" validate:"required,datetime=2006-01-02"`
+	StatementBalance int64  `json:"statement_balance"`
+}
+
+// MarkClearedReq marks the expenses as cleared, or takes them out of the
+// session when Cleared is false.
+type MarkClearedReq struct {
+	SessionID  string   `json:"session_id" validate:"required"`
+	ExpenseIDs []string `json:"expense_ids" validate:"required,min=1,dive,required"`
+	Cleared    bool     `json:"cleared"`
+}
+
+type service struct {
+	r Repository
+	v *validator.Validator
+}
+
+func NewService(r Repository, v *validator.Validator) Service {
+	return &service{
+		r: r,
+		v: v,
+	}
+}
+
+func (s *service) SessionByID(ctx context.Context, id string) (Session, error) {
+	if id == "" {
+		return Session{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.SessionByID(ctx, id)
+}
+
+func (s *service) ListSessions(ctx context.Context, accountID string) ([]Session, error) {
+	if accountID == "" {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Account ID is required")
+	}
+	return s.r.ListSessions(ctx, accountID)
+}
+
+func (s *service) CreateSession(ctx context.Context, c CreateSessionReq) (Session, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Session{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.CreateSession(ctx, c)
+}
+
+// DeleteSession cancels an open session, the expenses cleared in it are no
+// longer cleared.
+func (s *service) DeleteSession(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteSession(ctx, id)
+}
+
+func (s *service) ListItems(ctx context.Context, sessionID string) ([]Item, error) {
+	if sessionID == "" {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Session ID is required")
+	}
+	return s.r.ListItems(ctx, sessionID)
+}
+
+func (s *service) MarkCleared(ctx context.Context, m MarkClearedReq) (Session, error) {
+	if err := s.v.Struct(m); err != nil {
+		return Session{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.MarkCleared(ctx, m)
+}
+
+// CompleteSession locks the cleared expenses. The cleared balance has to
+// match the statement balance.
+func (s *service) CompleteSession(ctx context.Context, id string) (Session, error) {
+	if id == "" {
+		return Session{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.CompleteSession(ctx, id)
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -50,7 +51,6 @@ func (r *Repository) NonConcurrentDB() *sqlx.DB {
 	return r.nonConcurrentDB
 }
 
-func (r *Repository) Close() {
-	r.concurrentDB.Close()
-	r.nonConcurrentDB.Close()
+func (r *Repository) Close() error {
+	return errors.Join(r.concurrentDB.Close(), r.nonConcurrentDB.Close())
 }
@@ -0,0 +1,9 @@
+package rule
+
+import "context"
+
+type Repository interface {
+	ListRules(ctx context.Context) ([]Rule, error)
+	CreateRule(ctx context.Context, c CreateRuleReq) (Rule, error)
+	DeleteRule(ctx context.Context, id string) error
+}
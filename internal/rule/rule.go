@@ -0,0 +1,157 @@
+package rule
+
+import (
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rule changes the expenses that match all of its conditions. Empty
+// conditions always match.
+type Rule struct {
+	ID         string
+	Name       string
+	Priority   int
+	Conditions Conditions
+	Actions    Actions
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type Conditions struct {
+	// Case insensitive substring of the name.
+	NameContains string
+	// Case insensitive substring of the note.
+	NoteContains string
+	// Regular expression matched against the name.
+	NameRegex  string
+	MinAmount  *int64
+	MaxAmount  *int64
+	DaysOfWeek []time.Weekday
+}
+
+type Actions struct {
+	CategoryID *string
+	Rename     *string
+	AppendTags []string
+}
+
+// Target is the part of an expense that rules match against and change.
+type Target struct {
+	Name       string
+	Note       string
+	Amount     int64
+	Date       time.Time
+	CategoryID string
+	Tags       []string
+}
+
+func (r Rule) Matches(t Target) bool {
+	return compile(r).matches(t)
+}
+
+// compiledRule is a rule with its regular expression compiled once, to match
+// it against many targets.
+type compiledRule struct {
+	Rule
+	// nil if the rule has no regular expression or it's invalid.
+	nameRegex *regexp.Regexp
+}
+
+func compile(r Rule) compiledRule {
+	cr := compiledRule{Rule: r}
+	if r.Conditions.NameRegex != "" {
+		if re, err := regexp.Compile(r.Conditions.NameRegex); err == nil {
+			cr.nameRegex = re
+		}
+	}
+	return cr
+}
+
+func (r compiledRule) matches(t Target) bool {
+	c := r.Conditions
+
+	if c.NameContains != "" && !strings.Contains(strings.ToLower(t.Name), strings.ToLower(c.NameContains)) {
+		return false
+	}
+
+	if c.NoteContains != "" && !strings.Contains(strings.ToLower(t.Note), strings.ToLower(c.NoteContains)) {
+		return false
+	}
+
+	// an invalid regular expression never matches
+	if c.NameRegex != "" && (r.nameRegex == nil || !r.nameRegex.MatchString(t.Name)) {
+		return false
+	}
+
+	if c.MinAmount != nil && t.Amount < *c.MinAmount {
+		return false
+	}
+
+	if c.MaxAmount != nil && t.Amount > *c.MaxAmount {
+		return false
+	}
+
+	if len(c.DaysOfWeek) > 0 && !slices.Contains(c.DaysOfWeek, t.Date.Weekday()) {
+		return false
+	}
+
+	return true
+}
+
+// Apply runs the rules in priority order, lowest value first. When several
+// matching rules assign a category or rename the expense, the one with the
+// lowest priority value wins. Tags of every matching rule are appended. It
+// returns the IDs of the rules that matched.
+func Apply(rules []Rule, t Target) (Target, []string) {
+	sorted := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		sorted = append(sorted, compile(r))
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	result := t
+	result.Tags = slices.Clone(t.Tags)
+
+	var (
+		matched     []string
+		categorized bool
+		renamed     bool
+	)
+	for _, r := range sorted {
+		if !r.matches(t) {
+			continue
+		}
+
+		matched = append(matched, r.ID)
+
+		if r.Actions.CategoryID != nil && !categorized {
+			result.CategoryID = *r.Actions.CategoryID
+			categorized = true
+		}
+
+		if r.Actions.Rename != nil && !renamed {
+			result.Name = *r.Actions.Rename
+			renamed = true
+		}
+
+		for _, tag := range r.Actions.AppendTags {
+			if !slices.Contains(result.Tags, tag) {
+				result.Tags = append(result.Tags, tag)
+			}
+		}
+	}
+
+	return result, matched
+}
+
+// Changed reports whether applying the rules changed the target.
+func Changed(before, after Target) bool {
+	return before.Name != after.Name ||
+		before.CategoryID != after.CategoryID ||
+		!slices.Equal(before.Tags, after.Tags)
+}
@@ -0,0 +1,143 @@
+package rule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/rule"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	// Monday
+	date := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC)
+	target := rule.Target{
+		Name:   "Starbucks Coffee",
+		Note:   "with the team",
+		Amount: 500,
+		Date:   date,
+	}
+
+	tests := map[string]struct {
+		conditions rule.Conditions
+		want       bool
+	}{
+		"name contains, case insensitive": {
+			conditions: rule.Conditions{NameContains: "starbucks"},
+			want:       true,
+		},
+		"name doesn't contain": {
+			conditions: rule.Conditions{NameContains: "tea"},
+			want:       false,
+		},
+		"note contains": {
+			conditions: rule.Conditions{NoteContains: "TEAM"},
+			want:       true,
+		},
+		"name regex": {
+			conditions: rule.Conditions{NameRegex: `^Star\w+ Coffee$`},
+			want:       true,
+		},
+		"name regex doesn't match": {
+			conditions: rule.Conditions{NameRegex: `^Coffee`},
+			want:       false,
+		},
+		"invalid regex never matches": {
+			conditions: rule.Conditions{NameRegex: `(`},
+			want:       false,
+		},
+		"within amount range": {
+			conditions: rule.Conditions{MinAmount: toPtr(t, int64(500)), MaxAmount: toPtr(t, int64(1000))},
+			want:       true,
+		},
+		"below amount range": {
+			conditions: rule.Conditions{MinAmount: toPtr(t, int64(501))},
+			want:       false,
+		},
+		"above amount range": {
+			conditions: rule.Conditions{MaxAmount: toPtr(t, int64(499))},
+			want:       false,
+		},
+		"day of week": {
+			conditions: rule.Conditions{DaysOfWeek: []time.Weekday{time.Sunday, time.Monday}},
+			want:       true,
+		},
+		"other day of week": {
+			conditions: rule.Conditions{DaysOfWeek: []time.Weekday{time.Saturday}},
+			want:       false,
+		},
+		"all conditions must match": {
+			conditions: rule.Conditions{NameContains: "starbucks", MaxAmount: toPtr(t, int64(100))},
+			want:       false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := rule.Rule{Conditions: test.conditions}
+			assert.Equal(t, test.want, r.Matches(target))
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	target := rule.Target{
+		Name:       "SBUX 1234",
+		Amount:     500,
+		CategoryID: "uncategorized",
+		Tags:       []string{"card"},
+	}
+
+	rules := []rule.Rule{
+		{
+			ID:         "3",
+			Priority:   3,
+			Conditions: rule.Conditions{NameContains: "sbux"},
+			Actions: rule.Actions{
+				CategoryID: toPtr(t, "drinks"),
+				AppendTags: []string{"card", "treat"},
+			},
+		},
+		{
+			ID:         "1",
+			Priority:   1,
+			Conditions: rule.Conditions{NameRegex: `^SBUX`},
+			Actions: rule.Actions{
+				CategoryID: toPtr(t, "coffee"),
+				Rename:     toPtr(t, "Starbucks"),
+				AppendTags: []string{"coffee"},
+			},
+		},
+		{
+			ID:         "2",
+			Priority:   2,
+			Conditions: rule.Conditions{MinAmount: toPtr(t, int64(1000))},
+			Actions: rule.Actions{
+				Rename: toPtr(t, "Expensive"),
+			},
+		},
+	}
+
+	got, matched := rule.Apply(rules, target)
+	assert.Equal(t, rule.Target{
+		Name:       "Starbucks",
+		Amount:     500,
+		CategoryID: "coffee",
+		Tags:       []string{"card", "coffee", "treat"},
+	}, got)
+	assert.Equal(t, []string{"1", "3"}, matched)
+	assert.True(t, rule.Changed(target, got))
+
+	// the target is not modified
+	assert.Equal(t, []string{"card"}, target.Tags)
+
+	got, matched = rule.Apply(nil, target)
+	assert.Equal(t, target, got)
+	assert.Empty(t, matched)
+	assert.False(t, rule.Changed(target, got))
+}
+
+func toPtr[T any](t *testing.T, v T) *T {
+	t.Helper()
+	return &v
+}
@@ -0,0 +1,92 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	ListRules(ctx context.Context) ([]Rule, error)
+	CreateRule(ctx context.Context, c CreateRuleReq) (Rule, error)
+	DeleteRule(ctx context.Context, id string) error
+	ApplyRules(ctx context.Context, t Target) (Target, error)
+}
+
+type CreateRuleReq struct {
+	Name         string         `json:"name" validate:"required"`
+	Priority     int            `json:"priority" validate:"gte=0"`
+	NameContains string         `json:"name_contains"`
+	NoteContains string         `json:"note_contains"`
+	NameRegex    string         `json:"name_regex"`
+	MinAmount    *int64         `json:"min_amount" validate:"omitempty,gte=0"`
+	MaxAmount    *int64         `json:"max_amount" validate:"omitempty,gte=0"`
+	DaysOfWeek   []time.Weekday `json:"days_of_week" validate:"dive,gte=0,lte=6"`
+	CategoryID   *string        `json:"category_id"`
+	Rename       *string        `json:"rename" validate:"omitempty,min=1"`
+	AppendTags   []string       `json:"append_tags" validate:"dive,required"`
+}
+
+type service struct {
+	r Repository
+	v *validator.Validator
+}
+
+func NewService(r Repository, v *validator.Validator) Service {
+	return &service{
+		r: r,
+		v: v,
+	}
+}
+
+func (s *service) ListRules(ctx context.Context) ([]Rule, error) {
+	return s.r.ListRules(ctx)
+}
+
+func (s *service) CreateRule(ctx context.Context, c CreateRuleReq) (Rule, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Rule{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if c.NameRegex != "" {
+		if _, err := regexp.Compile(c.NameRegex); err != nil {
+			return Rule{}, internal.NewErrorf(internal.ErrorCodeInvalid, "'name_regex' is invalid: %s", err)
+		}
+	}
+
+	if c.MinAmount != nil && c.MaxAmount != nil && *c.MinAmount > *c.MaxAmount {
+		return Rule{}, internal.NewError(internal.ErrorCodeInvalid, "'min_amount' must be less than or equal to 'max_amount'")
+	}
+
+	if c.NameContains == "" && c.NoteContains == "" && c.NameRegex == "" && c.MinAmount == nil && c.MaxAmount == nil && len(c.DaysOfWeek) == 0 {
+		return Rule{}, internal.NewError(internal.ErrorCodeInvalid, "Must have at least one condition")
+	}
+
+	if c.CategoryID == nil && c.Rename == nil && len(c.AppendTags) == 0 {
+		return Rule{}, internal.NewError(internal.ErrorCodeInvalid, "Must have at least one action")
+	}
+
+	return s.r.CreateRule(ctx, c)
+}
+
+func (s *service) DeleteRule(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteRule(ctx, id)
+}
+
+// ApplyRules applies the rules of the user to the target.
+func (s *service) ApplyRules(ctx context.Context, t Target) (Target, error) {
+	rules, err := s.r.ListRules(ctx)
+	if err != nil {
+		return Target{}, fmt.Errorf("rule.Service.ApplyRules: %w", err)
+	}
+
+	result, _ := Apply(rules, t)
+	return result, nil
+}
@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type accountResource struct {
+	accountService account.Service
+}
+
+func (ar accountResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/accounts", ar.listAccounts)
+	huma.Post(h, "/accounts", ar.createAccount)
+	huma.Get(h, "/accounts/{id}", ar.accountByID)
+	huma.Patch(h, "/accounts/{id}", ar.updateAccount)
+	huma.Delete(h, "/accounts/{id}", ar.deleteAccount)
+	huma.Get(h, "/accounts/{id}/transfers", ar.listTransfers)
+	huma.Get(h, "/accounts/{id}/ledger", ar.listLedgerEntries)
+	huma.Post(h, "/transfers", ar.createTransfer)
+	huma.Delete(h, "/transfers/{id}", ar.deleteTransfer)
+}
+
+type accountBody struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type" enum:"cash,bank,credit_card"`
+	OpeningBalance int64     `json:"opening_balance"`
+	Currency       string    `json:"currency"`
+	Balance        int64     `json:"balance"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func toAccountBody(a account.Account) accountBody {
+	return accountBody{
+		ID:             a.ID,
+		Name:           a.Name,
+		Type:           string(a.Type),
+		OpeningBalance: a.OpeningBalance,
+		Currency:       a.Currency,
+		Balance:        a.Balance,
+		CreatedAt:      a.CreatedAt,
+		UpdatedAt:      a.UpdatedAt,
+	}
+}
+
+type accountOutput struct {
+	Body accountBody
+}
+
+type listAccountsOutput struct {
+	Body []accountBody
+}
+
+func (ar accountResource) listAccounts(ctx context.Context, _ *struct{}) (*listAccountsOutput, error) {
+	accounts, err := ar.accountService.ListAccounts(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listAccountsOutput{
+		Body: make([]accountBody, 0, len(accounts)),
+	}
+	for _, a := range accounts {
+		resp.Body = append(resp.Body, toAccountBody(a))
+	}
+
+	return resp, nil
+}
+
+type createAccountInput struct {
+	Body struct {
+		Name           string `json:"name"`
+		Type           string `json:"type" enum:"cash,bank,credit_card"`
+		OpeningBalance int64  `json:"opening_balance,omitempty"`
+		Currency       string `json:"currency" doc:"ISO 4217 currency code"`
+	}
+}
+
+func (ar accountResource) createAccount(ctx context.Context, i *createAccountInput) (*accountOutput, error) {
+	a, err := ar.accountService.CreateAccount(ctx, account.CreateAccountReq{
+		Name:           i.Body.Name,
+		Type:           account.AccountType(i.Body.Type),
+		OpeningBalance: i.Body.OpeningBalance,
+		Currency:       i.Body.Currency,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &accountOutput{Body: toAccountBody(a)}, nil
+}
+
+type accountByIDInput struct {
+	ID string `path:"id"`
+}
+
+func (ar accountResource) accountByID(ctx context.Context, i *accountByIDInput) (*accountOutput, error) {
+	a, err := ar.accountService.AccountByID(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &accountOutput{Body: toAccountBody(a)}, nil
+}
+
+type updateAccountInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		Name           *string `json:"name,omitempty"`
+		Type           *string `json:"type,omitempty" enum:"cash,bank,credit_card"`
+		OpeningBalance *int64  `json:"opening_balance,omitempty"`
+	}
+}
+
+func (ar accountResource) updateAccount(ctx context.Context, i *updateAccountInput) (*accountOutput, error) {
+	var accountType *account.AccountType
+	if i.Body.Type != nil {
+		t := account.AccountType(*i.Body.Type)
+		accountType = &t
+	}
+
+	a, err := ar.accountService.UpdateAccount(ctx, account.UpdateAccountReq{
+		ID:             i.ID,
+		Name:           i.Body.Name,
+		Type:           accountType,
+		OpeningBalance: i.Body.OpeningBalance,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &accountOutput{Body: toAccountBody(a)}, nil
+}
+
+type deleteAccountInput struct {
+	ID string `path:"id"`
+}
+
+func (ar accountResource) deleteAccount(ctx context.Context, i *deleteAccountInput) (*struct{}, error) {
+	if err := ar.accountService.DeleteAccount(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type transferBody struct {
+	ID            string    `json:"id"`
+	FromAccountID string    `json:"from_account_id"`
+	ToAccountID   string    `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Date          string    `json:"date" format:"date"`
+	Note          string    `json:"note"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func toTransferBody(t account.Transfer) transferBody {
+	return transferBody{
+		ID:            t.ID,
+		FromAccountID: t.FromAccountID,
+		ToAccountID:   t.ToAccountID,
+		Amount:        t.Amount,
+		Date:          t.Date.Format(time.DateOnly),
+		Note:          t.Note,
+		CreatedAt:     t.CreatedAt,
+		UpdatedAt:     t.UpdatedAt,
+	}
+}
+
+type listTransfersInput struct {
+	ID string `path:"id"`
+}
+
+type listTransfersOutput struct {
+	Body []transferBody
+}
+
+func (ar accountResource) listTransfers(ctx context.Context, i *listTransfersInput) (*listTransfersOutput, error) {
+	transfers, err := ar.accountService.ListTransfers(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listTransfersOutput{
+		Body: make([]transferBody, 0, len(transfers)),
+	}
+	for _, t := range transfers {
+		resp.Body = append(resp.Body, toTransferBody(t))
+	}
+
+	return resp, nil
+}
+
+type createTransferInput struct {
+	Body struct {
+		FromAccountID string `json:"from_account_id"`
+		ToAccountID   string `json:"to_account_id"`
+		Amount        int64  `json:"amount"`
+		Date          string `json:"date" format:"date"`
+		Note          string `json:"note,omitempty"`
+	}
+}
+
+type transferOutput struct {
+	Body transferBody
+}
+
+func (ar accountResource) createTransfer(ctx context.Context, i *createTransferInput) (*transferOutput, error) {
+	t, err := ar.accountService.CreateTransfer(ctx, account.CreateTransferReq(i.Body))
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &transferOutput{Body: toTransferBody(t)}, nil
+}
+
+type deleteTransferInput struct {
+	ID string `path:"id"`
+}
+
+func (ar accountResource) deleteTransfer(ctx context.Context, i *deleteTransferInput) (*struct{}, error) {
+	if err := ar.accountService.DeleteTransfer(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type listLedgerEntriesInput struct {
+	ID        string `path:"id"`
+	StartDate string `query:"start_date" format:"date"`
+	EndDate   string `query:"end_date" format:"date"`
+}
+
+type ledgerEntryBody struct {
+	Kind        string `json:"kind" enum:"expense,transfer_in,transfer_out"`
+	ReferenceID string `json:"reference_id" doc:"ID of the expense or transfer"`
+	Name        string `json:"name"`
+	Amount      int64  `json:"amount" doc:"Negative when money leaves the account"`
+	Date        string `json:"date" format:"date"`
+	Balance     int64  `json:"balance" doc:"Balance of the account after the entry"`
+}
+
+type listLedgerEntriesOutput struct {
+	Body []ledgerEntryBody
+}
+
+func (ar accountResource) listLedgerEntries(ctx context.Context, i *listLedgerEntriesInput) (*listLedgerEntriesOutput, error) {
+	entries, err := ar.accountService.ListLedgerEntries(ctx, account.ListLedgerEntriesReq{
+		AccountID: i.ID,
+		StartDate: i.StartDate,
+		EndDate:   i.EndDate,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listLedgerEntriesOutput{
+		Body: make([]ledgerEntryBody, 0, len(entries)),
+	}
+	for _, e := range entries {
+		resp.Body = append(resp.Body, ledgerEntryBody{
+			Kind:        string(e.Kind),
+			ReferenceID: e.ReferenceID,
+			Name:        e.Name,
+			Amount:      e.Amount,
+			Date:        e.Date.Format(time.DateOnly),
+			Balance:     e.Balance,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type anomalyResource struct {
+	anomalyService anomaly.Service
+}
+
+func (ar anomalyResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/anomalies", ar.listFlags)
+	huma.Post(h, "/anomalies/{id}/review", ar.reviewFlag)
+}
+
+type flagExpenseBody struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Amount     int64  `json:"amount"`
+	Date       string `json:"date" format:"date"`
+	CategoryID string `json:"category_id"`
+}
+
+type flagBody struct {
+	ID               string          `json:"id"`
+	Expense          flagExpenseBody `json:"expense"`
+	Kind             string          `json:"kind" enum:"outlier,duplicate"`
+	Score            float64         `json:"score" doc:"For outliers, the amount divided by the median of the category"`
+	Reason           string          `json:"reason"`
+	RelatedExpenseID *string         `json:"related_expense_id" doc:"The expense a duplicate looks like"`
+	ReviewedAt       *time.Time      `json:"reviewed_at"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+func toFlagBody(f anomaly.Flag) flagBody {
+	return flagBody{
+		ID: f.ID,
+		Expense: flagExpenseBody{
+			ID:         f.Expense.ID,
+			Name:       f.Expense.Name,
+			Amount:     f.Expense.Amount,
+			Date:       f.Expense.Date.Format(time.DateOnly),
+			CategoryID: f.Expense.CategoryID,
+		},
+		Kind:             string(f.Kind),
+		Score:            f.Score,
+		Reason:           f.Reason,
+		RelatedExpenseID: f.RelatedExpenseID,
+		ReviewedAt:       f.ReviewedAt,
+		CreatedAt:        f.CreatedAt,
+		UpdatedAt:        f.UpdatedAt,
+	}
+}
+
+type listFlagsInput struct {
+	Reviewed bool `query:"reviewed" doc:"List the reviewed flags instead of the review queue"`
+	Limit    int  `query:"limit" default:"20" minimum:"1" maximum:"100"`
+	Offset   int  `query:"offset" minimum:"0"`
+}
+
+type listFlagsOutput struct {
+	Body []flagBody
+}
+
+func (ar anomalyResource) listFlags(ctx context.Context, i *listFlagsInput) (*listFlagsOutput, error) {
+	flags, err := ar.anomalyService.ListFlags(ctx, anomaly.ListFlagsReq{
+		Reviewed: i.Reviewed,
+		ListOptions: internal.ListOptions{
+			Limit:  i.Limit,
+			Offset: i.Offset,
+		},
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listFlagsOutput{
+		Body: make([]flagBody, 0, len(flags)),
+	}
+	for _, f := range flags {
+		resp.Body = append(resp.Body, toFlagBody(f))
+	}
+
+	return resp, nil
+}
+
+type reviewFlagInput struct {
+	ID string `path:"id"`
+}
+
+type flagOutput struct {
+	Body flagBody
+}
+
+func (ar anomalyResource) reviewFlag(ctx context.Context, i *reviewFlagInput) (*flagOutput, error) {
+	f, err := ar.anomalyService.ReviewFlag(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &flagOutput{Body: toFlagBody(f)}, nil
+}
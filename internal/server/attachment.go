@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"io"
+	"mime"
+	"strconv"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/attachment"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type attachmentResource struct {
+	attachmentService attachment.Service
+}
+
+func (ar attachmentResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/expenses/{id}/attachments", ar.listExpenseAttachments)
+	huma.Post(h, "/expenses/{id}/attachments", ar.uploadExpenseAttachment)
+	huma.Get(h, "/expense-groups/{id}/attachments", ar.listExpenseGroupAttachments)
+	huma.Post(h, "/expense-groups/{id}/attachments", ar.uploadExpenseGroupAttachment)
+	huma.Get(h, "/attachments/{id}", ar.downloadAttachment)
+	huma.Get(h, "/attachments/{id}/thumbnail", ar.downloadThumbnail)
+	huma.Delete(h, "/attachments/{id}", ar.deleteAttachment)
+}
+
+type attachmentBody struct {
+	ID             string    `json:"id"`
+	ExpenseID      *string   `json:"expense_id"`
+	ExpenseGroupID *string   `json:"expense_group_id"`
+	FileName       string    `json:"file_name"`
+	ContentType    string    `json:"content_type"`
+	Size           int64     `json:"size" doc:"Size in bytes"`
+	HasThumbnail   bool      `json:"has_thumbnail"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func toAttachmentBody(a attachment.Attachment) attachmentBody {
+	return attachmentBody(a)
+}
+
+type attachmentOutput struct {
+	Body attachmentBody
+}
+
+type listAttachmentsInput struct {
+	ID string `path:"id"`
+}
+
+type listAttachmentsOutput struct {
+	Body []attachmentBody
+}
+
+func (ar attachmentResource) listExpenseAttachments(ctx context.Context, i *listAttachmentsInput) (*listAttachmentsOutput, error) {
+	return ar.listAttachments(ctx, attachment.ListAttachmentsReq{ExpenseID: i.ID})
+}
+
+func (ar attachmentResource) listExpenseGroupAttachments(ctx context.Context, i *listAttachmentsInput) (*listAttachmentsOutput, error) {
+	return ar.listAttachments(ctx, attachment.ListAttachmentsReq{ExpenseGroupID: i.ID})
+}
+
+func (ar attachmentResource) listAttachments(ctx context.Context, l attachment.ListAttachmentsReq) (*listAttachmentsOutput, error) {
+	attachments, err := ar.attachmentService.ListAttachments(ctx, l)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listAttachmentsOutput{
+		Body: make([]attachmentBody, 0, len(attachments)),
+	}
+	for _, a := range attachments {
+		resp.Body = append(resp.Body, toAttachmentBody(a))
+	}
+
+	return resp, nil
+}
+
+type uploadAttachmentInput struct {
+	ID      string `path:"id"`
+	RawBody huma.MultipartFormFiles[struct {
+		File huma.FormFile `form:"file" required:"true" doc:"PDF or image (JPEG, PNG, GIF, WebP)"`
+	}]
+}
+
+func (ar attachmentResource) uploadExpenseAttachment(ctx context.Context, i *uploadAttachmentInput) (*attachmentOutput, error) {
+	return ar.uploadAttachment(ctx, attachment.UploadAttachmentReq{ExpenseID: i.ID}, i)
+}
+
+func (ar attachmentResource) uploadExpenseGroupAttachment(ctx context.Context, i *uploadAttachmentInput) (*attachmentOutput, error) {
+	return ar.uploadAttachment(ctx, attachment.UploadAttachmentReq{ExpenseGroupID: i.ID}, i)
+}
+
+func (ar attachmentResource) uploadAttachment(ctx context.Context, u attachment.UploadAttachmentReq, i *uploadAttachmentInput) (*attachmentOutput, error) {
+	f := i.RawBody.Data().File
+	defer f.Close()
+
+	u.FileName = f.Filename
+	u.Content = f
+
+	a, err := ar.attachmentService.UploadAttachment(ctx, u)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &attachmentOutput{Body: toAttachmentBody(a)}, nil
+}
+
+type downloadAttachmentInput struct {
+	ID string `path:"id"`
+}
+
+type downloadAttachmentOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	ContentLength      string `header:"Content-Length"`
+	ContentTypeOptions string `header:"X-Content-Type-Options"`
+	Body               func(ctx huma.Context)
+}
+
+func (ar attachmentResource) downloadAttachment(ctx context.Context, i *downloadAttachmentInput) (*downloadAttachmentOutput, error) {
+	a, content, err := ar.attachmentService.OpenAttachment(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	return &downloadAttachmentOutput{
+		ContentType:        a.ContentType,
+		ContentDisposition: mime.FormatMediaType("attachment", map[string]string{"filename": a.FileName}),
+		ContentLength:      strconv.FormatInt(a.Size, 10),
+		ContentTypeOptions: "nosniff",
+		Body:               streamContent(content),
+	}, nil
+}
+
+func (ar attachmentResource) downloadThumbnail(ctx context.Context, i *downloadAttachmentInput) (*downloadAttachmentOutput, error) {
+	_, content, err := ar.attachmentService.OpenThumbnail(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	return &downloadAttachmentOutput{
+		ContentType:        attachment.ThumbnailContentType,
+		ContentDisposition: "inline",
+		ContentTypeOptions: "nosniff",
+		Body:               streamContent(content),
+	}, nil
+}
+
+// streamContent writes the content to the response and closes it.
+func streamContent(content io.ReadCloser) func(ctx huma.Context) {
+	return func(ctx huma.Context) {
+		defer content.Close()
+
+		if _, err := io.Copy(ctx.BodyWriter(), content); err != nil {
+			getLogger(ctx.Context()).Errorw("Failed to write attachment content", "error", err)
+		}
+	}
+}
+
+type deleteAttachmentInput struct {
+	ID string `path:"id"`
+}
+
+func (ar attachmentResource) deleteAttachment(ctx context.Context, i *deleteAttachmentInput) (*struct{}, error) {
+	if err := ar.attachmentService.DeleteAttachment(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
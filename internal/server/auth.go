@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// bearerSecurityScheme is the name of the personal access tokens in the
+// OpenAPI.
+const bearerSecurityScheme = "bearer"
+
+// authenticate signs in the requests that carry a personal access token in the
+// Authorization header. Requests without one are rejected unless their path
+// starts with one of the public paths.
+func authenticate(ts token.Service, publicPaths []string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !hasBearerToken(r) {
+				for _, p := range publicPaths {
+					if strings.HasPrefix(r.URL.Path, p) {
+						next.ServeHTTP(w, r)
+						return
+					}
+				}
+
+				w.Header().Set(headerWWWAuthenticate, "Bearer")
+				writeError(w, huma.Error401Unauthorized("Authentication is required"))
+				return
+			}
+
+			_, secret, _ := strings.Cut(r.Header.Get(headerAuthorization), " ")
+			t, u, err := ts.Authenticate(r.Context(), strings.TrimSpace(secret))
+			if err != nil {
+				if internal.GetErrorCode(err) != internal.ErrorCodeUnauthorized {
+					getLogger(r.Context()).Errorw("Failed to authenticate token", "error", err)
+					writeError(w, huma.Error500InternalServerError("Internal server error"))
+					return
+				}
+
+				w.Header().Set(headerWWWAuthenticate, `Bearer error="invalid_token"`)
+				writeError(w, huma.Error401Unauthorized(internal.GetErrorMessage(err)))
+				return
+			}
+
+			ctx := user.ContextWithUser(r.Context(), u)
+			ctx = token.ContextWithToken(ctx, t)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// declareScope documents the scope a token needs for the operation, read for
+// the read only methods and write for the rest, unless the operation declares
+// its own.
+func declareScope(_ *huma.OpenAPI, op *huma.Operation) {
+	if op.Security != nil {
+		return
+	}
+	op.Security = []map[string][]string{
+		{bearerSecurityScheme: {string(operationScope(op))}},
+	}
+}
+
+// operationScope is the scope a token needs for the operation.
+func operationScope(op *huma.Operation) token.Scope {
+	for _, s := range op.Security {
+		for _, scope := range s[bearerSecurityScheme] {
+			if token.Scope(scope) == token.ScopeWrite {
+				return token.ScopeWrite
+			}
+		}
+	}
+	if len(op.Security) > 0 || isReadMethod(op.Method) {
+		return token.ScopeRead
+	}
+	return token.ScopeWrite
+}
+
+// enforceScopes rejects the token authenticated requests whose token lacks
+// the scope of the operation.
+func enforceScopes(api huma.API) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		t, ok := token.FromContext(ctx.Context())
+		if !ok {
+			next(ctx)
+			return
+		}
+
+		scope := operationScope(ctx.Operation())
+		if !t.Allows(scope) {
+			ctx.SetHeader(headerWWWAuthenticate, `Bearer error="insufficient_scope", scope="`+string(scope)+`"`)
+			huma.WriteErr(api, ctx, http.StatusForbidden, "Token doesn't have the "+string(scope)+" scope")
+			return
+		}
+
+		next(ctx)
+	}
+}
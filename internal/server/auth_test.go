@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// memoryTokenService knows the tokens by their secret for the tests.
+type memoryTokenService struct {
+	token.Service
+	tokens map[string]token.Token
+}
+
+func (s *memoryTokenService) Authenticate(ctx context.Context, secret string) (token.Token, user.User, error) {
+	t, ok := s.tokens[secret]
+	if !ok {
+		return token.Token{}, user.User{}, internal.NewError(internal.ErrorCodeUnauthorized, "Token is invalid or expired")
+	}
+	return t, user.User{ID: "user1"}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := &memoryTokenService{
+		tokens: map[string]token.Token{
+			"bt_read":  {ID: "1", Scopes: []token.Scope{token.ScopeRead}},
+			"bt_write": {ID: "2", Scopes: []token.Scope{token.ScopeWrite}},
+		},
+	}
+
+	router := chi.NewRouter()
+	router.Use(requestLogger(zap.NewNop().Sugar()))
+	router.Use(authenticate(s, []string{"/public"}))
+
+	humaConfig := huma.DefaultConfig("Test", "0.0.1")
+	humaConfig.OnAddOperation = append(humaConfig.OnAddOperation, declareScope)
+	api := humachi.New(router, humaConfig)
+	api.UseMiddleware(enforceScopes(api))
+
+	type output struct {
+		Body struct {
+			UserID string `json:"user_id"`
+		}
+	}
+	handler := func(ctx context.Context, i *struct{}) (*output, error) {
+		resp := &output{}
+		if u, ok := user.FromContextOK(ctx); ok {
+			resp.Body.UserID = u.ID
+		}
+		return resp, nil
+	}
+	huma.Get(api, "/expenses", handler)
+	huma.Post(api, "/expenses", handler)
+	huma.Get(api, "/public", handler)
+	huma.Register(api, huma.Operation{
+		Method:   http.MethodGet,
+		Path:     "/export",
+		Security: []map[string][]string{{bearerSecurityScheme: {string(token.ScopeWrite)}}},
+	}, handler)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		want          int
+		wantChallenge string
+	}{
+		{
+			name:          "no token",
+			method:        http.MethodPost,
+			path:          "/expenses",
+			want:          http.StatusUnauthorized,
+			wantChallenge: "Bearer",
+		},
+		{
+			name:   "public without token",
+			method: http.MethodGet,
+			path:   "/public",
+			want:   http.StatusOK,
+		},
+		{
+			name:          "unknown token",
+			method:        http.MethodGet,
+			path:          "/expenses",
+			authorization: "Bearer bt_unknown",
+			want:          http.StatusUnauthorized,
+			wantChallenge: `Bearer error="invalid_token"`,
+		},
+		{
+			name:          "read token reads",
+			method:        http.MethodGet,
+			path:          "/expenses",
+			authorization: "Bearer bt_read",
+			want:          http.StatusOK,
+		},
+		{
+			name:          "read token can't write",
+			method:        http.MethodPost,
+			path:          "/expenses",
+			authorization: "Bearer bt_read",
+			want:          http.StatusForbidden,
+			wantChallenge: `Bearer error="insufficient_scope", scope="write"`,
+		},
+		{
+			name:          "read token can't use write operation",
+			method:        http.MethodGet,
+			path:          "/export",
+			authorization: "Bearer bt_read",
+			want:          http.StatusForbidden,
+			wantChallenge: `Bearer error="insufficient_scope", scope="write"`,
+		},
+		{
+			name:          "write token reads",
+			method:        http.MethodGet,
+			path:          "/expenses",
+			authorization: "bearer bt_write",
+			want:          http.StatusOK,
+		},
+		{
+			name:          "write token writes",
+			method:        http.MethodPost,
+			path:          "/expenses",
+			authorization: "Bearer bt_write",
+			want:          http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				r.Header.Set(headerAuthorization, tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.want, w.Code)
+			assert.Equal(t, tt.wantChallenge, w.Header().Get(headerWWWAuthenticate))
+			if tt.want == http.StatusOK && tt.authorization != "" {
+				assert.Contains(t, w.Body.String(), `"user_id":"user1"`)
+			}
+		})
+	}
+}
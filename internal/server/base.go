@@ -2,9 +2,31 @@ package server
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 
-	"github.com/cativovo/budget-tracker/internal/repository"
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/cativovo/budget-tracker/internal/attachment"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/config"
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/forecast"
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/cativovo/budget-tracker/internal/idempotency"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/cativovo/budget-tracker/internal/offline"
+	"github.com/cativovo/budget-tracker/internal/reconciliation"
+	"github.com/cativovo/budget-tracker/internal/rule"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/trend"
+	"github.com/cativovo/budget-tracker/internal/webhook"
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
@@ -13,26 +35,52 @@ import (
 )
 
 type Resource struct {
-	Logger *zap.SugaredLogger
-	//  make interface to make it easier to test
-	Repository *repository.Repository
+	Config                config.Config
+	Logger                *zap.SugaredLogger
+	ExpenseService        expense.Service
+	CategoryService       category.Service
+	RuleService           rule.Service
+	GoalService           goal.Service
+	AccountService        account.Service
+	ReconciliationService reconciliation.Service
+	AttachmentService     attachment.Service
+	ForecastService       forecast.Service
+	AnomalyService        anomaly.Service
+	TrendService          trend.Service
+	NotificationService   notification.Service
+	WebhookService        webhook.Service
+	OfflineService        offline.Service
+	EventBroker           *event.Broker
+	IdempotencyService    idempotency.Service
+	TokenService          token.Service
 }
 
+// multipartOverhead is the room an upload has for the multipart boundaries and
+// headers on top of the file.
+const multipartOverhead = 1 << 20
+
 type Server struct {
-	resource Resource
-	router   *chi.Mux
+	resource   Resource
+	router     *chi.Mux
+	httpServer *http.Server
+	// Redirects HTTP to HTTPS, nil when there's no redirect listener.
+	redirectServer *http.Server
 }
 
-type ctxKey string
-
-const (
-	ctxKeyLogger ctxKey = "logger"
-)
-
 func NewServer(r Resource) *Server {
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
 	router.Use(setResponseRequestID)
+	var strictTransportSecurity string
+	if r.Config.TLSEnabled() && r.Config.HSTSMaxAge > 0 {
+		strictTransportSecurity = fmt.Sprintf("max-age=%d", int64(r.Config.HSTSMaxAge.Seconds()))
+	}
+	router.Use(securityHeaders(securityPolicy{
+		ContentSecurityPolicy:   r.Config.ContentSecurityPolicy,
+		FrameOptions:            r.Config.FrameOptions,
+		ReferrerPolicy:          r.Config.ReferrerPolicy,
+		StrictTransportSecurity: strictTransportSecurity,
+	}))
 	router.Use(middleware.RealIP)
 	router.Use(requestLogger(r.Logger))
 	router.Use(recoverer)
@@ -40,26 +88,221 @@ func NewServer(r Resource) *Server {
 
 	router.Handle("/*", spaHandler())
 
-	router.Route("/api", func(r chi.Router) {
-		config := huma.DefaultConfig("My Api", "0.0.1")
-		config.Servers = []*huma.Server{
+	var readLimiter, writeLimiter *rateLimiter
+	if r.Config.ReadRateLimit > 0 {
+		readLimiter = newRateLimiter(r.Config.ReadRateLimit, r.Config.ReadRateBurst)
+	}
+	if r.Config.WriteRateLimit > 0 {
+		writeLimiter = newRateLimiter(r.Config.WriteRateLimit, r.Config.WriteRateBurst)
+	}
+
+	csrfSecret := []byte(r.Config.CSRFSecret)
+	if len(csrfSecret) == 0 {
+		r.Logger.Warn("CSRF_SECRET is not set, the CSRF tokens won't survive a restart")
+		csrfSecret = make([]byte, 32)
+		rand.Read(csrfSecret)
+	}
+	csrf := newCSRFProtection(csrfSecret)
+
+	httpServer := &http.Server{
+		Handler:           router,
+		ReadHeaderTimeout: r.Config.ReadHeaderTimeout,
+		ReadTimeout:       r.Config.ReadTimeout,
+		WriteTimeout:      r.Config.WriteTimeout,
+		IdleTimeout:       r.Config.IdleTimeout,
+		ErrorLog:          zap.NewStdLog(r.Logger.Desugar()),
+	}
+
+	// the event streams never go idle, Shutdown would wait for them until it
+	// times out
+	stopping := make(chan struct{})
+	httpServer.RegisterOnShutdown(func() {
+		close(stopping)
+	})
+
+	var redirectServer *http.Server
+	if r.Config.TLSEnabled() && r.Config.HTTPRedirectPort != "" {
+		redirectServer = &http.Server{
+			Addr:              ":" + r.Config.HTTPRedirectPort,
+			Handler:           redirectToHTTPS(r.Config.Port),
+			ReadHeaderTimeout: r.Config.ReadHeaderTimeout,
+			ReadTimeout:       r.Config.ReadTimeout,
+			WriteTimeout:      r.Config.WriteTimeout,
+			IdleTimeout:       r.Config.IdleTimeout,
+			ErrorLog:          httpServer.ErrorLog,
+		}
+	}
+
+	router.Route("/api", func(router chi.Router) {
+		// before the limits so the browsers can read their errors
+		router.Use(cors(corsPolicy{
+			AllowedOrigins:   r.Config.CORSAllowedOrigins,
+			AllowedMethods:   r.Config.CORSAllowedMethods,
+			AllowedHeaders:   r.Config.CORSAllowedHeaders,
+			AllowCredentials: r.Config.CORSAllowCredentials,
+			MaxAge:           r.Config.CORSMaxAge,
+		}))
+		// before the limits so they are kept per user
+		router.Use(authenticate(r.TokenService, []string{
+			"/api/csrf-token",
+			// the docs of the API
+			"/api/docs",
+			"/api/openapi",
+			"/api/schemas/",
+		}))
+		router.Use(rateLimited(readLimiter, writeLimiter))
+		router.Use(csrf.protect)
+		router.Use(limitBody(r.Config.MaxBodySize, r.Config.MaxAttachmentSize+multipartOverhead, []string{
+			"POST /api/expenses/{id}/attachments",
+			"POST /api/expense-groups/{id}/attachments",
+		}))
+		router.Use(idempotent(r.IdempotencyService))
+
+		humaConfig := huma.DefaultConfig("My Api", "0.0.1")
+		humaConfig.Servers = []*huma.Server{
 			{URL: "/api"},
 		}
-		api := humachi.New(r, config)
+		humaConfig.Components.SecuritySchemes = map[string]*huma.SecurityScheme{
+			bearerSecurityScheme: {
+				Type:        "http",
+				Scheme:      "bearer",
+				Description: "Personal access token created with POST /tokens",
+			},
+		}
+		humaConfig.OnAddOperation = append(humaConfig.OnAddOperation, declareScope)
+		api := humachi.New(router, humaConfig)
+		api.UseMiddleware(enforceScopes(api))
 
 		entryResource{}.mountRoutes(api)
+		csrfResource{
+			csrf: csrf,
+		}.mountRoutes(api)
+		expenseResource{
+			expenseService: r.ExpenseService,
+		}.mountRoutes(api)
+		categoryResource{
+			categoryService: r.CategoryService,
+		}.mountRoutes(api)
+		ruleResource{
+			ruleService:    r.RuleService,
+			expenseService: r.ExpenseService,
+		}.mountRoutes(api)
+		accountResource{
+			accountService: r.AccountService,
+		}.mountRoutes(api)
+		reconciliationResource{
+			reconciliationService: r.ReconciliationService,
+		}.mountRoutes(api)
+		attachmentResource{
+			attachmentService: r.AttachmentService,
+		}.mountRoutes(api)
+		forecastResource{
+			forecastService: r.ForecastService,
+		}.mountRoutes(api)
+		goalResource{
+			goalService: r.GoalService,
+		}.mountRoutes(api)
+		trendResource{
+			trendService: r.TrendService,
+		}.mountRoutes(api)
+		anomalyResource{
+			anomalyService: r.AnomalyService,
+		}.mountRoutes(api)
+		notificationResource{
+			notificationService: r.NotificationService,
+		}.mountRoutes(api)
+		webhookResource{
+			webhookService: r.WebhookService,
+		}.mountRoutes(api)
+		offlineResource{
+			offlineService: r.OfflineService,
+		}.mountRoutes(api)
+		eventResource{
+			broker:   r.EventBroker,
+			stopping: stopping,
+		}.mountRoutes(api)
+		tokenResource{
+			tokenService: r.TokenService,
+		}.mountRoutes(api)
+		trashResource{
+			expenseService:  r.ExpenseService,
+			categoryService: r.CategoryService,
+		}.mountRoutes(api)
 	})
 
 	return &Server{
-		resource: r,
-		router:   router,
+		resource:       r,
+		router:         router,
+		httpServer:     httpServer,
+		redirectServer: redirectServer,
 	}
 }
 
-func (s Server) Start(addr string) error {
-	return http.ListenAndServe(addr, s.router)
+// Start serves the requests, over HTTPS if it's turned on, until Shutdown is
+// called, it returns nil then.
+func (s *Server) Start(addr string) error {
+	logger := s.resource.Logger
+
+	if s.resource.Config.TLSEnabled() {
+		cert, err := loadCertificate(s.resource.Config, logger)
+		if err != nil {
+			return err
+		}
+		s.httpServer.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	if s.redirectServer != nil {
+		redirectLn, err := net.Listen("tcp", s.redirectServer.Addr)
+		if err != nil {
+			ln.Close()
+			return err
+		}
+
+		logger.Infow("Redirecting HTTP to HTTPS", "addr", s.redirectServer.Addr)
+		go func() {
+			if err := s.redirectServer.Serve(redirectLn); !errors.Is(err, http.ErrServerClosed) {
+				logger.Errorw("Redirect listener stopped", "error", err)
+			}
+		}()
+	}
+
+	logger.Infow("Listening", "addr", addr, "tls", s.httpServer.TLSConfig != nil)
+	return s.serve(ln)
+}
+
+func (s *Server) serve(ln net.Listener) error {
+	var err error
+	if s.httpServer.TLSConfig != nil {
+		// the certificate is in TLSConfig
+		err = s.httpServer.ServeTLS(ln, "", "")
+	} else {
+		err = s.httpServer.Serve(ln)
+	}
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting requests, ends the event streams and waits for the
+// requests in progress to finish or ctx to be done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	var redirectErr error
+	if s.redirectServer != nil {
+		redirectErr = s.redirectServer.Shutdown(ctx)
+	}
+	return errors.Join(s.httpServer.Shutdown(ctx), redirectErr)
 }
 
 func getLogger(ctx context.Context) *zap.SugaredLogger {
-	return ctx.Value(ctxKeyLogger).(*zap.SugaredLogger)
+	return logger.FromContext(ctx)
 }
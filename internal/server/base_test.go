@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestShutdown(t *testing.T) {
+	s := NewServer(Resource{
+		Logger:      zap.NewNop().Sugar(),
+		EventBroker: event.NewBroker(),
+	})
+
+	started := make(chan struct{})
+	s.router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.serve(ln)
+	}()
+
+	type result struct {
+		body string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		done <- result{body: string(b), err: err}
+	}()
+
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.Nil(t, s.Shutdown(ctx))
+
+	// the request in progress was drained
+	r := <-done
+	assert.Nil(t, r.err)
+	assert.Equal(t, "done", r.body)
+	assert.Nil(t, <-serveErr)
+
+	_, err = http.Get("http://" + ln.Addr().String() + "/slow")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,369 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type categoryResource struct {
+	categoryService category.Service
+}
+
+func (cr categoryResource) mountRoutes(h huma.API) {
+	huma.Post(h, "/categories", cr.createCategory)
+	huma.Get(h, "/categories/tree", cr.categoryTree)
+	huma.Get(h, "/categories/totals", cr.listCategoryTotals)
+	huma.Put(h, "/categories/{id}/parent", cr.moveCategory)
+	huma.Post(h, "/categories/{id}/merge", cr.mergeCategories)
+	huma.Get(h, "/categories/{id}", cr.categoryByID)
+	huma.Patch(h, "/categories/{id}", cr.updateCategory)
+	huma.Delete(h, "/categories/{id}", cr.deleteCategory)
+	huma.Get(h, "/categories/budgets", cr.listBudgetStatuses)
+	huma.Put(h, "/categories/{id}/budget", cr.setBudget)
+	huma.Delete(h, "/categories/{id}/budget", cr.deleteBudget)
+}
+
+type categoryBody struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	Icon      string    `json:"icon"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ParentID  *string   `json:"parent_id"`
+}
+
+func toCategoryBody(c category.Category) categoryBody {
+	return categoryBody{
+		ID:        c.ID,
+		Name:      c.Name,
+		Color:     c.Color,
+		Icon:      c.Icon,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		ParentID:  c.ParentID,
+	}
+}
+
+type categoryOutput struct {
+	Body categoryBody
+}
+
+type createCategoryInput struct {
+	Body struct {
+		Name     string  `json:"name"`
+		Color    string  `json:"color"`
+		Icon     string  `json:"icon"`
+		ParentID *string `json:"parent_id,omitempty"`
+	}
+}
+
+func (cr categoryResource) createCategory(ctx context.Context, i *createCategoryInput) (*categoryOutput, error) {
+	c, err := cr.categoryService.CreateCategory(ctx, category.CreateCategoryReq{
+		Name:     i.Body.Name,
+		Color:    i.Body.Color,
+		Icon:     i.Body.Icon,
+		ParentID: i.Body.ParentID,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &categoryOutput{Body: toCategoryBody(c)}, nil
+}
+
+type versionedCategoryOutput struct {
+	ETag string `header:"ETag"`
+	Body categoryBody
+}
+
+type categoryByIDInput struct {
+	ID string `path:"id"`
+}
+
+func (cr categoryResource) categoryByID(ctx context.Context, i *categoryByIDInput) (*versionedCategoryOutput, error) {
+	c, err := cr.categoryService.CategoryByID(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &versionedCategoryOutput{
+		ETag: etag(c.Version),
+		Body: toCategoryBody(c),
+	}, nil
+}
+
+type updateCategoryInput struct {
+	ID      string `path:"id"`
+	IfMatch string `header:"If-Match" doc:"ETag of the category when it was read, * to update it whatever its version"`
+	Body    struct {
+		Name  *string `json:"name,omitempty"`
+		Color *string `json:"color,omitempty"`
+		Icon  *string `json:"icon,omitempty"`
+	}
+}
+
+func (cr categoryResource) updateCategory(ctx context.Context, i *updateCategoryInput) (*versionedCategoryOutput, error) {
+	version, err := ifMatchVersion(i.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cr.categoryService.UpdateCategory(ctx, category.UpdateCategoryReq{
+		ID:      i.ID,
+		Name:    i.Body.Name,
+		Color:   i.Body.Color,
+		Icon:    i.Body.Icon,
+		Version: version,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &versionedCategoryOutput{
+		ETag: etag(c.Version),
+		Body: toCategoryBody(c),
+	}, nil
+}
+
+type categoryNodeBody struct {
+	categoryBody
+	Children []categoryNodeBody `json:"children"`
+}
+
+func toCategoryNodeBody(n category.CategoryNode) categoryNodeBody {
+	children := make([]categoryNodeBody, 0, len(n.Children))
+	for _, c := range n.Children {
+		children = append(children, toCategoryNodeBody(c))
+	}
+
+	return categoryNodeBody{
+		categoryBody: toCategoryBody(n.Category),
+		Children:     children,
+	}
+}
+
+type categoryTreeOutput struct {
+	Body []categoryNodeBody
+}
+
+func (cr categoryResource) categoryTree(ctx context.Context, _ *struct{}) (*categoryTreeOutput, error) {
+	tree, err := cr.categoryService.CategoryTree(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &categoryTreeOutput{
+		Body: make([]categoryNodeBody, 0, len(tree)),
+	}
+	for _, n := range tree {
+		resp.Body = append(resp.Body, toCategoryNodeBody(n))
+	}
+
+	return resp, nil
+}
+
+type listCategoryTotalsInput struct {
+	StartDate string `query:"start_date" required:"true" format:"date"`
+	EndDate   string `query:"end_date" required:"true" format:"date"`
+}
+
+type categoryTotalBody struct {
+	categoryBody
+	Total       int64 `json:"total" doc:"Spending of the category itself"`
+	RollupTotal int64 `json:"rollup_total" doc:"Spending of the category and all its subcategories"`
+}
+
+type listCategoryTotalsOutput struct {
+	Body []categoryTotalBody
+}
+
+func (cr categoryResource) listCategoryTotals(ctx context.Context, i *listCategoryTotalsInput) (*listCategoryTotalsOutput, error) {
+	totals, err := cr.categoryService.ListCategoryTotals(ctx, category.CategoryTotalsReq{
+		StartDate: i.StartDate,
+		EndDate:   i.EndDate,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listCategoryTotalsOutput{
+		Body: make([]categoryTotalBody, 0, len(totals)),
+	}
+	for _, t := range totals {
+		resp.Body = append(resp.Body, categoryTotalBody{
+			categoryBody: toCategoryBody(t.Category),
+			Total:        t.Total,
+			RollupTotal:  t.RollupTotal,
+		})
+	}
+
+	return resp, nil
+}
+
+type moveCategoryInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		ParentID *string `json:"parent_id" nullable:"true" doc:"New parent of the category, null to make it a top level category"`
+	}
+}
+
+func (cr categoryResource) moveCategory(ctx context.Context, i *moveCategoryInput) (*categoryOutput, error) {
+	c, err := cr.categoryService.MoveCategory(ctx, category.MoveCategoryReq{
+		ID:       i.ID,
+		ParentID: i.Body.ParentID,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &categoryOutput{Body: toCategoryBody(c)}, nil
+}
+
+type deleteCategoryInput struct {
+	ID               string `path:"id"`
+	Strategy         string `query:"strategy" required:"true" enum:"reassign,uncategorized,cascade" doc:"What happens to the expenses of the category"`
+	TargetCategoryID string `query:"target_category_id" doc:"Category that receives the expenses when the strategy is reassign"`
+	IfMatch          string `header:"If-Match" doc:"ETag of the category when it was read, * to delete it whatever its version"`
+}
+
+type deleteCategoryOutput struct {
+	Body struct {
+		AffectedExpenses int64 `json:"affected_expenses" doc:"Number of expenses that were moved or deleted"`
+	}
+}
+
+func (cr categoryResource) deleteCategory(ctx context.Context, i *deleteCategoryInput) (*deleteCategoryOutput, error) {
+	version, err := ifMatchVersion(i.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := cr.categoryService.DeleteCategory(ctx, category.DeleteCategoryReq{
+		ID:               i.ID,
+		Strategy:         category.DeleteStrategy(i.Strategy),
+		TargetCategoryID: i.TargetCategoryID,
+		Version:          version,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &deleteCategoryOutput{}
+	resp.Body.AffectedExpenses = result.AffectedExpenses
+	return resp, nil
+}
+
+type mergeCategoriesInput struct {
+	ID   string `path:"id" doc:"Category to merge, it's deleted after the merge"`
+	Body struct {
+		TargetID string `json:"target_id" doc:"Category that receives everything from the merged category"`
+	}
+}
+
+type mergeCategoriesOutput struct {
+	Body struct {
+		MovedExpenses      int64 `json:"moved_expenses"`
+		MovedRules         int64 `json:"moved_rules"`
+		MovedSubcategories int64 `json:"moved_subcategories"`
+		MovedBudgets       int64 `json:"moved_budgets" doc:"The budget of the source is dropped when the target already has one"`
+	}
+}
+
+func (cr categoryResource) mergeCategories(ctx context.Context, i *mergeCategoriesInput) (*mergeCategoriesOutput, error) {
+	result, err := cr.categoryService.MergeCategories(ctx, category.MergeCategoriesReq{
+		SourceID: i.ID,
+		TargetID: i.Body.TargetID,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &mergeCategoriesOutput{}
+	resp.Body.MovedExpenses = result.MovedExpenses
+	resp.Body.MovedRules = result.MovedRules
+	resp.Body.MovedSubcategories = result.MovedSubcategories
+	resp.Body.MovedBudgets = result.MovedBudgets
+	return resp, nil
+}
+
+type setBudgetInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		Amount int64 `json:"amount" doc:"Amount to spend on the category, subcategories included, every month"`
+	}
+}
+
+type budgetOutput struct {
+	Body struct {
+		CategoryID string    `json:"category_id"`
+		Amount     int64     `json:"amount"`
+		CreatedAt  time.Time `json:"created_at"`
+		UpdatedAt  time.Time `json:"updated_at"`
+	}
+}
+
+func (cr categoryResource) setBudget(ctx context.Context, i *setBudgetInput) (*budgetOutput, error) {
+	b, err := cr.categoryService.SetBudget(ctx, category.SetBudgetReq{
+		CategoryID: i.ID,
+		Amount:     i.Body.Amount,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &budgetOutput{}
+	resp.Body.CategoryID = b.CategoryID
+	resp.Body.Amount = b.Amount
+	resp.Body.CreatedAt = b.CreatedAt
+	resp.Body.UpdatedAt = b.UpdatedAt
+	return resp, nil
+}
+
+type deleteBudgetInput struct {
+	ID string `path:"id"`
+}
+
+func (cr categoryResource) deleteBudget(ctx context.Context, i *deleteBudgetInput) (*struct{}, error) {
+	if err := cr.categoryService.DeleteBudget(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type listBudgetStatusesInput struct {
+	Month string `query:"month" required:"true" pattern:"^\\d{4}-\\d{2}$" example:"2006-01"`
+}
+
+type budgetStatusBody struct {
+	Category categoryBody `json:"category"`
+	Amount   int64        `json:"amount"`
+	Spent    int64        `json:"spent" doc:"Spending of the category and its subcategories within the month"`
+	Percent  float64      `json:"percent" doc:"Percentage of the budget that has been spent"`
+}
+
+type listBudgetStatusesOutput struct {
+	Body []budgetStatusBody
+}
+
+func (cr categoryResource) listBudgetStatuses(ctx context.Context, i *listBudgetStatusesInput) (*listBudgetStatusesOutput, error) {
+	statuses, err := cr.categoryService.ListBudgetStatuses(ctx, category.BudgetStatusesReq{
+		Month: i.Month,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listBudgetStatusesOutput{
+		Body: make([]budgetStatusBody, 0, len(statuses)),
+	}
+	for _, s := range statuses {
+		resp.Body = append(resp.Body, budgetStatusBody{
+			Category: toCategoryBody(s.Category),
+			Amount:   s.Amount,
+			Spent:    s.Spent,
+			Percent:  s.Percent(),
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+const csrfCookieName = "csrf_token"
+
+// csrfProtection guards the cookie authenticated requests with signed double
+// submit tokens. The token is sent in a cookie and again in the X-CSRF-Token
+// header, other sites can make the browser send the cookie but can't read it
+// to set the header. The signature only shows the token was issued by the
+// server, it isn't bound to a session so it doesn't stop a sibling subdomain
+// from planting a token it got from the server.
+type csrfProtection struct {
+	secret []byte
+}
+
+func newCSRFProtection(secret []byte) csrfProtection {
+	return csrfProtection{
+		secret: secret,
+	}
+}
+
+// newToken is a random value and its signature.
+func (c csrfProtection) newToken() (string, error) {
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		return "", fmt.Errorf("server.csrfProtection.newToken: Read: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(value)
+	return encoded + "." + c.sign(encoded), nil
+}
+
+func (c csrfProtection) sign(value string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c csrfProtection) valid(token string) bool {
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(c.sign(value)))
+}
+
+// protect rejects the unsafe requests whose X-CSRF-Token header doesn't match
+// their token cookie. Bearer token clients and requests without cookies are
+// exempt, the browsers don't send either on their own.
+func (c csrfProtection) protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isReadMethod(r.Method) || hasBearerToken(r) || len(r.Cookies()) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(csrfCookieName)
+		header := r.Header.Get(headerXCSRFToken)
+		if err != nil || header == "" ||
+			subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) != 1 ||
+			!c.valid(header) {
+			writeError(w, huma.Error403Forbidden("CSRF token is missing or invalid"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasBearerToken(r *http.Request) bool {
+	scheme, _, _ := strings.Cut(r.Header.Get(headerAuthorization), " ")
+	return strings.EqualFold(scheme, "Bearer")
+}
+
+type csrfResource struct {
+	csrf csrfProtection
+}
+
+func (cr csrfResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/csrf-token", cr.csrfToken)
+}
+
+type csrfTokenInput struct {
+	secure bool
+}
+
+func (i *csrfTokenInput) Resolve(ctx huma.Context) []error {
+	i.secure = ctx.TLS() != nil || ctx.Header(headerXForwardedProto) == "https"
+	return nil
+}
+
+type csrfTokenOutput struct {
+	SetCookie    http.Cookie `header:"Set-Cookie"`
+	CacheControl string      `header:"Cache-Control"`
+	Body         struct {
+		Token string `json:"token" doc:"Send it in the X-CSRF-Token header of the POST, PUT, PATCH and DELETE requests"`
+	}
+}
+
+func (cr csrfResource) csrfToken(ctx context.Context, i *csrfTokenInput) (*csrfTokenOutput, error) {
+	token, err := cr.csrf.newToken()
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &csrfTokenOutput{
+		SetCookie: http.Cookie{
+			Name:     csrfCookieName,
+			Value:    token,
+			Path:     "/",
+			Secure:   i.secure,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		},
+		CacheControl: "no-store",
+	}
+	resp.Body.Token = token
+
+	return resp, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCSRF(t *testing.T) {
+	csrf := newCSRFProtection([]byte("secret"))
+
+	var calls int
+	router := chi.NewRouter()
+	router.Use(requestLogger(zap.NewNop().Sugar()))
+	router.Use(csrf.protect)
+	api := humachi.New(router, huma.DefaultConfig("Test", "0.0.1"))
+	csrfResource{csrf: csrf}.mountRoutes(api)
+	router.Post("/expenses", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/csrf-token", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get(headerCacheControl))
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.True(t, csrf.valid(body.Token))
+
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	cookie := cookies[0]
+	assert.Equal(t, csrfCookieName, cookie.Name)
+	assert.Equal(t, body.Token, cookie.Value)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+
+	other, err := newCSRFProtection([]byte("other secret")).newToken()
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name          string
+		cookie        string
+		header        string
+		authorization string
+		want          int
+	}{
+		{
+			name:   "matching token",
+			cookie: body.Token,
+			header: body.Token,
+			want:   http.StatusOK,
+		},
+		{
+			name:   "missing header",
+			cookie: body.Token,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "different token",
+			cookie: body.Token,
+			header: other,
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "token signed with other secret",
+			cookie: other,
+			header: other,
+			want:   http.StatusForbidden,
+		},
+		{
+			name: "no cookies",
+			want: http.StatusOK,
+		},
+		{
+			name:          "bearer token",
+			cookie:        body.Token,
+			authorization: "Bearer abc",
+			want:          http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls = 0
+
+			r := httptest.NewRequest(http.MethodPost, "/expenses", nil)
+			if test.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: csrfCookieName, Value: test.cookie})
+			}
+			if test.header != "" {
+				r.Header.Set(headerXCSRFToken, test.header)
+			}
+			if test.authorization != "" {
+				r.Header.Set(headerAuthorization, test.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, test.want, w.Code)
+			if test.want == http.StatusOK {
+				assert.Equal(t, 1, calls)
+			} else {
+				assert.Equal(t, 0, calls)
+			}
+		})
+	}
+}
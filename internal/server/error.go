@@ -0,0 +1,40 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// toHumaError maps the error codes of the services to the status codes of
+// the api. Internal errors are logged and their details are not exposed.
+func toHumaError(ctx context.Context, err error) error {
+	message := internal.GetErrorMessage(err)
+
+	switch internal.GetErrorCode(err) {
+	case internal.ErrorCodeInvalid:
+		return huma.Error400BadRequest(message)
+	case internal.ErrorCodeNotFound:
+		return huma.Error404NotFound(message)
+	case internal.ErrorCodeConflict:
+		return huma.Error409Conflict(message)
+	case internal.ErrorCodePreconditionFailed:
+		return huma.Error412PreconditionFailed(message)
+	case internal.ErrorCodeUnauthorized:
+		return huma.Error401Unauthorized(message)
+	default:
+		getLogger(ctx).Errorw("Internal server error", "error", err)
+		return huma.Error500InternalServerError("Internal server error")
+	}
+}
+
+// writeError writes the error the way huma does for the middlewares that
+// respond before reaching the api.
+func writeError(w http.ResponseWriter, err huma.StatusError) {
+	w.Header().Set(headerContentType, "application/problem+json")
+	w.WriteHeader(err.GetStatus())
+	json.NewEncoder(w).Encode(err)
+}
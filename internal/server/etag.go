@@ -0,0 +1,39 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// etag is the ETag of a resource at the version.
+func etag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion parses the If-Match header of a change into the version the
+// resource must still be at. A nil version matches any version, "*" asks for
+// it. The header is required so changes don't silently overwrite each other.
+func ifMatchVersion(ifMatch string) (*int64, error) {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" {
+		return nil, huma.NewError(http.StatusPreconditionRequired, "If-Match header is required")
+	}
+	if ifMatch == "*" {
+		return nil, nil
+	}
+
+	// weak ETags never match a change
+	unquoted, err := strconv.Unquote(ifMatch)
+	if err != nil || !strings.HasPrefix(ifMatch, `"`) {
+		return nil, huma.Error412PreconditionFailed("If-Match doesn't match the current version")
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return nil, huma.Error412PreconditionFailed("If-Match doesn't match the current version")
+	}
+
+	return &version, nil
+}
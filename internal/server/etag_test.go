@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	version := int64(3)
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    *int64
+		status  int
+	}{
+		{
+			name:    "etag",
+			ifMatch: etag(version),
+			want:    &version,
+		},
+		{
+			name:    "any version",
+			ifMatch: "*",
+		},
+		{
+			name:   "missing",
+			status: http.StatusPreconditionRequired,
+		},
+		{
+			name:    "weak etag",
+			ifMatch: `W/"3"`,
+			status:  http.StatusPreconditionFailed,
+		},
+		{
+			name:    "not a version",
+			ifMatch: `"abc"`,
+			status:  http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ifMatchVersion(test.ifMatch)
+			if test.status != 0 {
+				var se huma.StatusError
+				assert.ErrorAs(t, err, &se)
+				assert.Equal(t, test.status, se.GetStatus())
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
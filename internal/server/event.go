@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/sse"
+)
+
+// heartbeatInterval keeps idle streams from being closed by proxies and
+// finds the clients that went away.
+const heartbeatInterval = 30 * time.Second
+
+type eventResource struct {
+	broker *event.Broker
+	// Closed when the server shuts down.
+	stopping <-chan struct{}
+}
+
+func (er eventResource) mountRoutes(h huma.API) {
+	sse.Register(h, huma.Operation{
+		OperationID: "stream-events",
+		Method:      http.MethodGet,
+		Path:        "/events",
+		Summary:     "Stream events",
+		Description: "Streams the changes to the expenses and categories of the user. A client that reconnects with the Last-Event-ID header gets the events it missed, or a reset event if they are no longer known.",
+		Middlewares: huma.Middlewares{clearWriteDeadline},
+	}, map[string]any{
+		"change": changeEvent{},
+		"reset":  resetEvent{},
+		"ping":   pingEvent{},
+	}, er.streamEvents)
+}
+
+// clearWriteDeadline lets the stream outlive the write timeout of the server,
+// which is set for the whole response.
+func clearWriteDeadline(ctx huma.Context, next func(huma.Context)) {
+	if w, ok := ctx.BodyWriter().(http.ResponseWriter); ok {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			getLogger(ctx.Context()).Warnw("Failed to clear the write deadline of the event stream", "error", err)
+		}
+	}
+	next(ctx)
+}
+
+type changeEvent struct {
+	Type       string    `json:"type" doc:"expense.created, expense.updated, expense.deleted, category.created, category.updated or category.deleted"`
+	ResourceID string    `json:"resource_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func toChangeEvent(e event.Event) changeEvent {
+	return changeEvent{
+		Type:       e.Type,
+		ResourceID: e.ResourceID,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+type resetEvent struct {
+	Message string `json:"message"`
+}
+
+type pingEvent struct{}
+
+type streamEventsInput struct {
+	LastEventID int64 `header:"Last-Event-ID" doc:"ID of the last event the client has seen. Browsers send it when they reconnect"`
+}
+
+func (er eventResource) streamEvents(ctx context.Context, i *streamEventsInput, send sse.Sender) {
+	u := user.FromContext(ctx)
+	logger := getLogger(ctx)
+
+	sub := er.broker.Subscribe(u.ID, i.LastEventID)
+	defer sub.Close()
+
+	if sub.Stale {
+		err := send(sse.Message{
+			ID:   int(sub.LastID),
+			Data: resetEvent{Message: "Missed events are no longer available, refetch the data"},
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	for _, e := range sub.Missed {
+		if err := send(sse.Message{ID: int(e.ID), Data: toChangeEvent(e)}); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-er.stopping:
+			// the client reconnects to another server or once it's back
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				// the client fell behind, it catches up when it reconnects
+				logger.Infow("Dropped slow event stream", "user_id", u.ID)
+				return
+			}
+			if err := send(sse.Message{ID: int(e.ID), Data: toChangeEvent(e)}); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := send.Data(pingEvent{}); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStreamEvents(t *testing.T) {
+	broker := event.NewBroker()
+
+	router := chi.NewRouter()
+	router.Use(requestLogger(zap.NewNop().Sugar()))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := user.ContextWithUser(r.Context(), user.User{ID: "1"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	api := humachi.New(router, huma.DefaultConfig("Test", "0.0.1"))
+	eventResource{broker: broker}.mountRoutes(api)
+
+	stream := func(lastEventID int64) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+		if lastEventID > 0 {
+			r.Header.Set("Last-Event-ID", fmt.Sprint(lastEventID))
+		}
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, r)
+
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		return w.Body.String()
+	}
+
+	broker.Publish("1", "expense.created", "a")
+	seen := broker.Subscribe("1", 0)
+	seen.Close()
+
+	broker.Publish("1", "expense.updated", "a")
+	broker.Publish("2", "expense.created", "b")
+
+	t.Run("new clients only get new events", func(t *testing.T) {
+		assert.Empty(t, stream(0))
+	})
+
+	t.Run("resume from the last event id", func(t *testing.T) {
+		body := stream(seen.LastID)
+
+		assert.Contains(t, body, "event: change\n")
+		assert.Contains(t, body, `"type":"expense.updated","resource_id":"a"`)
+		assert.NotContains(t, body, `"resource_id":"b"`)
+		assert.NotContains(t, body, "expense.created")
+	})
+
+	t.Run("reset when the missed events are unknown", func(t *testing.T) {
+		body := stream(1)
+
+		assert.Contains(t, body, "event: reset\n")
+		assert.NotContains(t, body, "event: change\n")
+	})
+}
+
+func TestStreamEventsOutlivesWriteTimeout(t *testing.T) {
+	broker := event.NewBroker()
+
+	router := chi.NewRouter()
+	router.Use(requestLogger(zap.NewNop().Sugar()))
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := user.ContextWithUser(r.Context(), user.User{ID: "1"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	api := humachi.New(router, huma.DefaultConfig("Test", "0.0.1"))
+	eventResource{broker: broker}.mountRoutes(api)
+
+	srv := httptest.NewUnstartedServer(router)
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	// the headers are only sent with the first event
+	go func() {
+		time.Sleep(3 * srv.Config.WriteTimeout)
+		broker.Publish("1", "expense.created", "a")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body := bufio.NewReader(res.Body)
+	_, err = body.ReadString('\n')
+	assert.Nil(t, err)
+	line, err := body.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "event: change\n", line)
+}
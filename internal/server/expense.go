@@ -0,0 +1,316 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type expenseResource struct {
+	expenseService expense.Service
+}
+
+func (er expenseResource) mountRoutes(h huma.API) {
+	huma.Post(h, "/expenses", er.createExpense)
+	huma.Post(h, "/expenses/batch", er.batchExpenses)
+	huma.Get(h, "/expenses/{id}", er.expenseByID)
+	huma.Patch(h, "/expenses/{id}", er.updateExpense)
+	huma.Delete(h, "/expenses/{id}", er.deleteExpense)
+}
+
+type expenseBody struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Amount    int64        `json:"amount"`
+	Date      string       `json:"date"`
+	Note      string       `json:"note"`
+	Category  categoryBody `json:"category"`
+	Tags      []string     `json:"tags"`
+	AccountID *string      `json:"account_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+func toExpenseBody(e expense.Expense) expenseBody {
+	return expenseBody{
+		ID:        e.ID,
+		Name:      e.Name,
+		Amount:    e.Amount,
+		Date:      e.Date.Format(time.DateOnly),
+		Note:      e.Note,
+		Category:  toCategoryBody(e.Category),
+		Tags:      e.Tags,
+		AccountID: e.AccountID,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+type expenseOutput struct {
+	Body expenseBody
+}
+
+type createExpenseInput struct {
+	Body struct {
+		Name       string   `json:"name"`
+		Amount     int64    `json:"amount"`
+		Date       string   `json:"date" format:"date"`
+		CategoryID string   `json:"category_id,omitempty" doc:"Can be omitted when a rule assigns the category"`
+		Note       string   `json:"note,omitempty"`
+		Tags       []string `json:"tags,omitempty"`
+		AccountID  *string  `json:"account_id,omitempty" doc:"Account that paid for the expense"`
+	}
+}
+
+func (er expenseResource) createExpense(ctx context.Context, i *createExpenseInput) (*expenseOutput, error) {
+	e, err := er.expenseService.CreateExpense(ctx, expense.CreateExpenseReq{
+		Name:       i.Body.Name,
+		Amount:     i.Body.Amount,
+		Date:       i.Body.Date,
+		CategoryID: i.Body.CategoryID,
+		Note:       i.Body.Note,
+		Tags:       i.Body.Tags,
+		AccountID:  i.Body.AccountID,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &expenseOutput{Body: toExpenseBody(e)}, nil
+}
+
+type versionedExpenseOutput struct {
+	ETag string `header:"ETag"`
+	Body expenseBody
+}
+
+type expenseByIDInput struct {
+	ID string `path:"id"`
+}
+
+func (er expenseResource) expenseByID(ctx context.Context, i *expenseByIDInput) (*versionedExpenseOutput, error) {
+	e, err := er.expenseService.ExpenseByID(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &versionedExpenseOutput{
+		ETag: etag(e.Version),
+		Body: toExpenseBody(e),
+	}, nil
+}
+
+type updateExpenseInput struct {
+	ID      string `path:"id"`
+	IfMatch string `header:"If-Match" doc:"ETag of the expense when it was read, * to update it whatever its version"`
+	Body    struct {
+		Name       *string  `json:"name,omitempty"`
+		Amount     *int64   `json:"amount,omitempty"`
+		Date       *string  `json:"date,omitempty" format:"date"`
+		CategoryID *string  `json:"category_id,omitempty"`
+		Note       *string  `json:"note,omitempty"`
+		Tags       []string `json:"tags,omitempty"`
+		AccountID  *string  `json:"account_id,omitempty"`
+	}
+}
+
+func (er expenseResource) updateExpense(ctx context.Context, i *updateExpenseInput) (*versionedExpenseOutput, error) {
+	version, err := ifMatchVersion(i.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	u := expense.UpdateExpenseReq{
+		ID:         i.ID,
+		Name:       i.Body.Name,
+		Amount:     i.Body.Amount,
+		Date:       i.Body.Date,
+		CategoryID: i.Body.CategoryID,
+		Note:       i.Body.Note,
+		AccountID:  i.Body.AccountID,
+		Version:    version,
+	}
+	if i.Body.Tags != nil {
+		u.Tags = &i.Body.Tags
+	}
+
+	e, err := er.expenseService.UpdateExpense(ctx, u)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &versionedExpenseOutput{
+		ETag: etag(e.Version),
+		Body: toExpenseBody(e),
+	}, nil
+}
+
+type deleteExpenseInput struct {
+	ID      string `path:"id"`
+	IfMatch string `header:"If-Match" doc:"ETag of the expense when it was read, * to delete it whatever its version"`
+}
+
+func (er expenseResource) deleteExpense(ctx context.Context, i *deleteExpenseInput) (*struct{}, error) {
+	version, err := ifMatchVersion(i.IfMatch)
+	if err != nil {
+		return nil, err
+	}
+
+	err = er.expenseService.DeleteExpense(ctx, expense.DeleteExpenseReq{
+		ID:      i.ID,
+		Version: version,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type expensePatchBody struct {
+	Name       *string  `json:"name,omitempty"`
+	Amount     *int64   `json:"amount,omitempty"`
+	Date       *string  `json:"date,omitempty" format:"date"`
+	CategoryID *string  `json:"category_id,omitempty"`
+	Note       *string  `json:"note,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	AccountID  *string  `json:"account_id,omitempty"`
+}
+
+func (b expensePatchBody) toExpensePatch() expense.ExpensePatch {
+	p := expense.ExpensePatch{
+		Name:       b.Name,
+		Amount:     b.Amount,
+		Date:       b.Date,
+		CategoryID: b.CategoryID,
+		Note:       b.Note,
+		AccountID:  b.AccountID,
+	}
+	if b.Tags != nil {
+		p.Tags = &b.Tags
+	}
+	return p
+}
+
+func (b expensePatchBody) toCreateExpenseReq() expense.CreateExpenseReq {
+	c := expense.CreateExpenseReq{
+		Tags:      b.Tags,
+		AccountID: b.AccountID,
+	}
+	if b.Name != nil {
+		c.Name = *b.Name
+	}
+	if b.Amount != nil {
+		c.Amount = *b.Amount
+	}
+	if b.Date != nil {
+		c.Date = *b.Date
+	}
+	if b.CategoryID != nil {
+		c.CategoryID = *b.CategoryID
+	}
+	if b.Note != nil {
+		c.Note = *b.Note
+	}
+	return c
+}
+
+type batchOperationBody struct {
+	Type    string           `json:"type" enum:"create,update,delete"`
+	ID      string           `json:"id,omitempty" doc:"Expense to update or delete"`
+	Version *int64           `json:"version,omitempty" doc:"Only update or delete the expense if it's still at this version"`
+	Expense expensePatchBody `json:"expense,omitempty" doc:"Expense to create or the fields to update"`
+}
+
+func (b batchOperationBody) toBatchOperation() expense.BatchOperation {
+	o := expense.BatchOperation{Type: expense.BatchOperationType(b.Type)}
+	switch o.Type {
+	case expense.BatchOperationCreate:
+		c := b.Expense.toCreateExpenseReq()
+		o.Create = &c
+	case expense.BatchOperationUpdate:
+		u := b.Expense.toExpensePatch().UpdateReq(b.ID)
+		u.Version = b.Version
+		o.Update = &u
+	case expense.BatchOperationDelete:
+		o.Delete = &expense.DeleteExpenseReq{
+			ID:      b.ID,
+			Version: b.Version,
+		}
+	}
+	return o
+}
+
+type expenseFilterBody struct {
+	StartDate  string `json:"start_date,omitempty" format:"date"`
+	EndDate    string `json:"end_date,omitempty" format:"date"`
+	CategoryID string `json:"category_id,omitempty"`
+	AccountID  string `json:"account_id,omitempty"`
+	Search     string `json:"search,omitempty" doc:"Matches the expenses whose name contains it"`
+}
+
+type batchResultBody struct {
+	Type    string       `json:"type" enum:"create,update,delete"`
+	ID      string       `json:"id,omitempty"`
+	Status  string       `json:"status" enum:"succeeded,failed,rolled_back" doc:"rolled_back when the operation succeeded but another operation of the all-or-nothing batch failed"`
+	Error   *string      `json:"error" doc:"Why the operation failed"`
+	Expense *expenseBody `json:"expense" doc:"Expense as saved by a create or an update"`
+}
+
+type batchExpensesInput struct {
+	Body struct {
+		Operations   []batchOperationBody `json:"operations,omitempty" maxItems:"500" doc:"Run in order, an operation can depend on an earlier one"`
+		Filter       *expenseFilterBody   `json:"filter,omitempty" doc:"Updates the expenses matching it with the patch instead of running operations, reconciled expenses never match"`
+		Patch        *expensePatchBody    `json:"patch,omitempty" doc:"Changes made to the expenses matching the filter"`
+		AllOrNothing bool                 `json:"all_or_nothing,omitempty" doc:"Roll back every operation if one of them fails"`
+	}
+}
+
+type batchExpensesOutput struct {
+	Body struct {
+		Results []batchResultBody `json:"results" doc:"In the order of the operations, or of the matched expenses, oldest first"`
+	}
+}
+
+func (er expenseResource) batchExpenses(ctx context.Context, i *batchExpensesInput) (*batchExpensesOutput, error) {
+	b := expense.BatchReq{
+		AllOrNothing: i.Body.AllOrNothing,
+	}
+	for _, o := range i.Body.Operations {
+		b.Operations = append(b.Operations, o.toBatchOperation())
+	}
+	if f := i.Body.Filter; f != nil {
+		b.Filter = &expense.ExpenseFilter{
+			StartDate:  f.StartDate,
+			EndDate:    f.EndDate,
+			CategoryID: f.CategoryID,
+			AccountID:  f.AccountID,
+			Search:     f.Search,
+		}
+	}
+	if p := i.Body.Patch; p != nil {
+		patch := p.toExpensePatch()
+		b.Patch = &patch
+	}
+
+	results, err := er.expenseService.BatchExpenses(ctx, b)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &batchExpensesOutput{}
+	resp.Body.Results = make([]batchResultBody, 0, len(results))
+	for _, r := range results {
+		body := batchResultBody{
+			Type:   string(r.Type),
+			ID:     r.ID,
+			Status: string(r.Status),
+			Error:  r.Error,
+		}
+		if r.Expense != nil {
+			e := toExpenseBody(*r.Expense)
+			body.Expense = &e
+		}
+		resp.Body.Results = append(resp.Body.Results, body)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/forecast"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type forecastResource struct {
+	forecastService forecast.Service
+}
+
+func (fr forecastResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/forecast/end-of-month", fr.endOfMonth)
+	huma.Get(h, "/forecast/cash-flow", fr.cashFlow)
+}
+
+type categoryForecastBody struct {
+	Category  categoryBody `json:"category"`
+	Spent     int64        `json:"spent" doc:"Spending so far this month"`
+	Recurring int64        `json:"recurring" doc:"Recurring expenses still expected this month"`
+	Projected int64        `json:"projected" doc:"Estimated spending by the end of the month"`
+}
+
+type endOfMonthOutput struct {
+	Body []categoryForecastBody
+}
+
+func (fr forecastResource) endOfMonth(ctx context.Context, _ *struct{}) (*endOfMonthOutput, error) {
+	forecasts, err := fr.forecastService.EndOfMonth(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &endOfMonthOutput{
+		Body: make([]categoryForecastBody, 0, len(forecasts)),
+	}
+	for _, f := range forecasts {
+		resp.Body = append(resp.Body, categoryForecastBody{
+			Category:  toCategoryBody(f.Category),
+			Spent:     f.Spent,
+			Recurring: f.Recurring,
+			Projected: f.Projected,
+		})
+	}
+
+	return resp, nil
+}
+
+type cashFlowInput struct {
+	AccountID string `query:"account_id" doc:"Only use the expenses of the account and project its balance"`
+	Days      int    `query:"days" default:"90" minimum:"1" maximum:"365"`
+}
+
+type cashFlowDayBody struct {
+	Date       string `json:"date" format:"date"`
+	Variable   int64  `json:"variable" doc:"Estimated from the moving average of the non recurring expenses"`
+	Recurring  int64  `json:"recurring" doc:"Recurring expenses expected on the day"`
+	Cumulative int64  `json:"cumulative" doc:"Total spending from the first day of the forecast up to this day"`
+	Balance    *int64 `json:"balance,omitempty" doc:"Projected balance of the account at the end of the day"`
+}
+
+type cashFlowOutput struct {
+	Body struct {
+		StartingBalance *int64            `json:"starting_balance,omitempty" doc:"Balance of the account today"`
+		Days            []cashFlowDayBody `json:"days"`
+	}
+}
+
+func (fr forecastResource) cashFlow(ctx context.Context, i *cashFlowInput) (*cashFlowOutput, error) {
+	f, err := fr.forecastService.CashFlow(ctx, forecast.CashFlowReq{
+		AccountID: i.AccountID,
+		Days:      i.Days,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &cashFlowOutput{}
+	resp.Body.StartingBalance = f.StartingBalance
+	resp.Body.Days = make([]cashFlowDayBody, 0, len(f.Days))
+	for _, d := range f.Days {
+		resp.Body.Days = append(resp.Body.Days, cashFlowDayBody{
+			Date:       d.Date.Format(time.DateOnly),
+			Variable:   d.Variable,
+			Recurring:  d.Recurring,
+			Cumulative: d.Cumulative,
+			Balance:    f.Balance(d),
+		})
+	}
+
+	return resp, nil
+}
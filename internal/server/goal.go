@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type goalResource struct {
+	goalService goal.Service
+}
+
+func (gr goalResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/goals", gr.listGoals)
+	huma.Post(h, "/goals", gr.createGoal)
+	huma.Get(h, "/goals/{id}", gr.goalByID)
+	huma.Patch(h, "/goals/{id}", gr.updateGoal)
+	huma.Delete(h, "/goals/{id}", gr.deleteGoal)
+	huma.Get(h, "/goals/{id}/contributions", gr.listContributions)
+	huma.Post(h, "/goals/{id}/contributions", gr.createContribution)
+	huma.Delete(h, "/goals/{id}/contributions/{contributionId}", gr.deleteContribution)
+}
+
+type goalBody struct {
+	ID                          string    `json:"id"`
+	Name                        string    `json:"name"`
+	TargetAmount                int64     `json:"target_amount"`
+	Deadline                    string    `json:"deadline" format:"date"`
+	SavedAmount                 int64     `json:"saved_amount"`
+	RemainingAmount             int64     `json:"remaining_amount"`
+	Progress                    float64   `json:"progress" doc:"Percentage of the target that has been saved"`
+	RequiredMonthlyContribution int64     `json:"required_monthly_contribution" doc:"Amount to save every month to reach the target by the deadline"`
+	CreatedAt                   time.Time `json:"created_at"`
+	UpdatedAt                   time.Time `json:"updated_at"`
+}
+
+func toGoalBody(g goal.Goal) goalBody {
+	return goalBody{
+		ID:                          g.ID,
+		Name:                        g.Name,
+		TargetAmount:                g.TargetAmount,
+		Deadline:                    g.Deadline.Format(time.DateOnly),
+		SavedAmount:                 g.SavedAmount,
+		RemainingAmount:             g.RemainingAmount(),
+		Progress:                    g.Progress(),
+		RequiredMonthlyContribution: g.RequiredMonthlyContribution(time.Now()),
+		CreatedAt:                   g.CreatedAt,
+		UpdatedAt:                   g.UpdatedAt,
+	}
+}
+
+type goalOutput struct {
+	Body goalBody
+}
+
+type listGoalsOutput struct {
+	Body []goalBody
+}
+
+func (gr goalResource) listGoals(ctx context.Context, _ *struct{}) (*listGoalsOutput, error) {
+	goals, err := gr.goalService.ListGoals(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listGoalsOutput{
+		Body: make([]goalBody, 0, len(goals)),
+	}
+	for _, g := range goals {
+		resp.Body = append(resp.Body, toGoalBody(g))
+	}
+
+	return resp, nil
+}
+
+type createGoalInput struct {
+	Body struct {
+		Name         string `json:"name"`
+		TargetAmount int64  `json:"target_amount"`
+		Deadline     string `json:"deadline" format:"date"`
+	}
+}
+
+func (gr goalResource) createGoal(ctx context.Context, i *createGoalInput) (*goalOutput, error) {
+	g, err := gr.goalService.CreateGoal(ctx, goal.CreateGoalReq(i.Body))
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &goalOutput{Body: toGoalBody(g)}, nil
+}
+
+type goalByIDInput struct {
+	ID string `path:"id"`
+}
+
+func (gr goalResource) goalByID(ctx context.Context, i *goalByIDInput) (*goalOutput, error) {
+	g, err := gr.goalService.GoalByID(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &goalOutput{Body: toGoalBody(g)}, nil
+}
+
+type updateGoalInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		Name         *string `json:"name,omitempty"`
+		TargetAmount *int64  `json:"target_amount,omitempty"`
+		Deadline     *string `json:"deadline,omitempty" format:"date"`
+	}
+}
+
+func (gr goalResource) updateGoal(ctx context.Context, i *updateGoalInput) (*goalOutput, error) {
+	g, err := gr.goalService.UpdateGoal(ctx, goal.UpdateGoalReq{
+		ID:           i.ID,
+		Name:         i.Body.Name,
+		TargetAmount: i.Body.TargetAmount,
+		Deadline:     i.Body.Deadline,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &goalOutput{Body: toGoalBody(g)}, nil
+}
+
+type deleteGoalInput struct {
+	ID string `path:"id"`
+}
+
+func (gr goalResource) deleteGoal(ctx context.Context, i *deleteGoalInput) (*struct{}, error) {
+	if err := gr.goalService.DeleteGoal(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type contributionBody struct {
+	ID         string    `json:"id"`
+	GoalID     string    `json:"goal_id"`
+	Amount     int64     `json:"amount"`
+	Date       string    `json:"date" format:"date"`
+	Note       string    `json:"note"`
+	SourceType string    `json:"source_type" enum:"income,transfer"`
+	SourceID   string    `json:"source_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func toContributionBody(c goal.Contribution) contributionBody {
+	return contributionBody{
+		ID:         c.ID,
+		GoalID:     c.GoalID,
+		Amount:     c.Amount,
+		Date:       c.Date.Format(time.DateOnly),
+		Note:       c.Note,
+		SourceType: string(c.SourceType),
+		SourceID:   c.SourceID,
+		CreatedAt:  c.CreatedAt,
+		UpdatedAt:  c.UpdatedAt,
+	}
+}
+
+type listContributionsInput struct {
+	ID string `path:"id"`
+}
+
+type listContributionsOutput struct {
+	Body []contributionBody
+}
+
+func (gr goalResource) listContributions(ctx context.Context, i *listContributionsInput) (*listContributionsOutput, error) {
+	contributions, err := gr.goalService.ListContributions(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listContributionsOutput{
+		Body: make([]contributionBody, 0, len(contributions)),
+	}
+	for _, c := range contributions {
+		resp.Body = append(resp.Body, toContributionBody(c))
+	}
+
+	return resp, nil
+}
+
+type createContributionInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		Amount     int64  `json:"amount"`
+		Date       string `json:"date" format:"date"`
+		Note       string `json:"note,omitempty"`
+		SourceType string `json:"source_type" enum:"income,transfer" doc:"Kind of record the money came from"`
+		SourceID   string `json:"source_id" minLength:"1" doc:"ID of the income record, or of a transfer of the user. An income is linked once, a transfer up to its amount"`
+	}
+}
+
+type contributionOutput struct {
+	Body contributionBody
+}
+
+func (gr goalResource) createContribution(ctx context.Context, i *createContributionInput) (*contributionOutput, error) {
+	c, err := gr.goalService.CreateContribution(ctx, goal.CreateContributionReq{
+		GoalID:     i.ID,
+		Amount:     i.Body.Amount,
+		Date:       i.Body.Date,
+		Note:       i.Body.Note,
+		SourceType: goal.ContributionSource(i.Body.SourceType),
+		SourceID:   i.Body.SourceID,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &contributionOutput{Body: toContributionBody(c)}, nil
+}
+
+type deleteContributionInput struct {
+	ID             string `path:"id"`
+	ContributionID string `path:"contributionId"`
+}
+
+func (gr goalResource) deleteContribution(ctx context.Context, i *deleteContributionInput) (*struct{}, error) {
+	err := gr.goalService.DeleteContribution(ctx, goal.DeleteContributionReq{
+		GoalID: i.ID,
+		ID:     i.ContributionID,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
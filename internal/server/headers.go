@@ -17,6 +17,7 @@ const (
 	headerContentType         = "Content-Type"
 	headerCookie              = "Cookie"
 	headerSetCookie           = "Set-Cookie"
+	headerETag                = "ETag"
 	headerIfModifiedSince     = "If-Modified-Since"
 	headerLastModified        = "Last-Modified"
 	headerLocation            = "Location"
@@ -38,6 +39,8 @@ const (
 	headerOrigin              = "Origin"
 	headerCacheControl        = "Cache-Control"
 	headerConnection          = "Connection"
+	headerIdempotencyKey      = "Idempotency-Key"
+	headerIdempotentReplayed  = "Idempotent-Replayed"
 
 	// Access control
 	headerAccessControlRequestMethod    = "Access-Control-Request-Method"
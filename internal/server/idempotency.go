@@ -0,0 +1,142 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/idempotency"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Response headers that belong to a single request and are not replayed.
+var unreplayedHeaders = []string{
+	headerXRequestID,
+	headerContentEncoding,
+	headerContentLength,
+	headerVary,
+	headerAccessControlAllowOrigin,
+	headerAccessControlAllowCredentials,
+	headerAccessControlExposeHeaders,
+}
+
+// idempotent makes the POST, PATCH and DELETE requests with an
+// Idempotency-Key header safe to retry. The response of the first request is
+// saved and replayed to the requests that repeat its key and body. Failed
+// requests (5xx) are not saved so they can be retried.
+func idempotent(s idempotency.Service) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(headerIdempotencyKey)
+			if key == "" || !acceptsIdempotencyKey(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				if isBodyTooLarge(err) {
+					writeError(w, huma.NewError(http.StatusRequestEntityTooLarge, "Request body is too large"))
+					return
+				}
+				writeError(w, huma.Error400BadRequest("Failed to read the request body"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			saved, err := s.Begin(ctx, key, requestHash(r, body))
+			if err != nil {
+				switch internal.GetErrorCode(err) {
+				case internal.ErrorCodeInvalid:
+					writeError(w, huma.Error422UnprocessableEntity(internal.GetErrorMessage(err)))
+				case internal.ErrorCodeConflict:
+					writeError(w, huma.Error409Conflict(internal.GetErrorMessage(err)))
+				default:
+					getLogger(ctx).Errorw("Failed to begin idempotent request", "error", err)
+					writeError(w, huma.Error500InternalServerError("Internal server error"))
+				}
+				return
+			}
+
+			if saved != nil {
+				for k, v := range saved.Header {
+					w.Header()[k] = v
+				}
+				w.Header().Set(headerIdempotentReplayed, "true")
+				w.WriteHeader(saved.StatusCode)
+				w.Write(saved.Body)
+				return
+			}
+
+			// the key is saved or released even if the client went away
+			ctx = context.WithoutCancel(ctx)
+
+			completed := false
+			defer func() {
+				// released when the handler panicked or failed
+				if completed {
+					return
+				}
+				if err := s.Release(ctx, key); err != nil {
+					getLogger(ctx).Errorw("Failed to release idempotency key", "error", err)
+				}
+			}()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			var recorded bytes.Buffer
+			ww.Tee(&recorded)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+
+			header := ww.Header().Clone()
+			for _, h := range unreplayedHeaders {
+				header.Del(h)
+			}
+
+			err = s.Complete(ctx, key, idempotency.Response{
+				StatusCode: status,
+				Header:     header,
+				Body:       recorded.Bytes(),
+			})
+			if err != nil {
+				getLogger(ctx).Errorw("Failed to save idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+func acceptsIdempotencyKey(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// requestHash identifies a request by its method, path and body.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method)
+	io.WriteString(h, " ")
+	io.WriteString(h, r.URL.RequestURI())
+	io.WriteString(h, "\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/idempotency"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// memoryIdempotencyService keeps the keys in memory for the tests.
+type memoryIdempotencyService struct {
+	records map[string]idempotency.Record
+}
+
+func (s *memoryIdempotencyService) Begin(ctx context.Context, key string, requestHash string) (*idempotency.Response, error) {
+	r, ok := s.records[key]
+	if !ok {
+		s.records[key] = idempotency.Record{Key: key, RequestHash: requestHash}
+		return nil, nil
+	}
+	if r.RequestHash != requestHash {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "Idempotency-Key was already used for a different request")
+	}
+	if r.Response == nil {
+		return nil, internal.NewError(internal.ErrorCodeConflict, "A request with the same Idempotency-Key is still being handled")
+	}
+	return r.Response, nil
+}
+
+func (s *memoryIdempotencyService) Complete(ctx context.Context, key string, resp idempotency.Response) error {
+	r := s.records[key]
+	r.Response = &resp
+	s.records[key] = r
+	return nil
+}
+
+func (s *memoryIdempotencyService) Release(ctx context.Context, key string) error {
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryIdempotencyService) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestIdempotent(t *testing.T) {
+	s := &memoryIdempotencyService{records: map[string]idempotency.Record{}}
+
+	var calls int
+	router := chi.NewRouter()
+	router.Use(requestLogger(zap.NewNop().Sugar()))
+	router.Use(idempotent(s))
+	router.Post("/expenses", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set(headerContentType, "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":"%d"}`, calls)
+	})
+	router.Post("/fail", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	router.Get("/expenses", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	do := func(method string, path string, key string, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(headerIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		calls = 0
+
+		w := do(http.MethodPost, "/expenses", "a", `{"name":"Lunch"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"id":"1"}`, w.Body.String())
+		assert.Empty(t, w.Header().Get(headerIdempotentReplayed))
+
+		w = do(http.MethodPost, "/expenses", "a", `{"name":"Lunch"}`)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, `{"id":"1"}`, w.Body.String())
+		assert.Equal(t, "application/json", w.Header().Get(headerContentType))
+		assert.Equal(t, "true", w.Header().Get(headerIdempotentReplayed))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("different body", func(t *testing.T) {
+		w := do(http.MethodPost, "/expenses", "a", `{"name":"Dinner"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get(headerContentType))
+		assert.Contains(t, w.Body.String(), "Idempotency-Key was already used for a different request")
+	})
+
+	t.Run("in progress", func(t *testing.T) {
+		s.records["b"] = idempotency.Record{Key: "b", RequestHash: requestHash(httptest.NewRequest(http.MethodPost, "/expenses", nil), []byte("{}"))}
+
+		w := do(http.MethodPost, "/expenses", "b", `{}`)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("failed requests can be retried", func(t *testing.T) {
+		calls = 0
+
+		w := do(http.MethodPost, "/fail", "c", `{}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, s.records, "c")
+
+		do(http.MethodPost, "/fail", "c", `{}`)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("ignored without a key or for reads", func(t *testing.T) {
+		calls = 0
+
+		do(http.MethodPost, "/expenses", "", `{}`)
+		do(http.MethodPost, "/expenses", "", `{}`)
+		do(http.MethodGet, "/expenses", "d", "")
+		do(http.MethodGet, "/expenses", "d", "")
+		assert.Equal(t, 4, calls)
+		assert.NotContains(t, s.records, "d")
+	})
+}
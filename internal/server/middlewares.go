@@ -1,11 +1,11 @@
 package server
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"time"
 
+	internallogger "github.com/cativovo/budget-tracker/internal/logger"
 	"github.com/go-chi/chi/v5/middleware"
 	"go.uber.org/zap"
 )
@@ -47,7 +47,7 @@ func requestLogger(parentLogger *zap.SugaredLogger) func(next http.Handler) http
 				)
 			}()
 
-			ctx := context.WithValue(r.Context(), ctxKeyLogger, logger)
+			ctx := internallogger.ContextWithLogger(r.Context(), logger)
 			next.ServeHTTP(ww, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type notificationResource struct {
+	notificationService notification.Service
+}
+
+func (nr notificationResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/notifications", nr.listNotifications)
+	huma.Post(h, "/notifications/check", nr.check)
+	huma.Post(h, "/notifications/{id}/read", nr.markRead)
+	huma.Get(h, "/notifications/preferences", nr.preferences)
+	huma.Put(h, "/notifications/preferences", nr.updatePreferences)
+}
+
+type notificationBody struct {
+	ID        string     `json:"id"`
+	Kind      string     `json:"kind" enum:"budget,bill_due,inactivity"`
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func toNotificationBody(n notification.Notification) notificationBody {
+	return notificationBody{
+		ID:        n.ID,
+		Kind:      string(n.Kind),
+		Title:     n.Title,
+		Message:   n.Message,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
+	}
+}
+
+type listNotificationsInput struct {
+	Unread bool `query:"unread" doc:"Only list the notifications that were not read yet"`
+	Limit  int  `query:"limit" default:"20" minimum:"1" maximum:"100"`
+	Offset int  `query:"offset" minimum:"0"`
+}
+
+type listNotificationsOutput struct {
+	Body []notificationBody
+}
+
+func (nr notificationResource) listNotifications(ctx context.Context, i *listNotificationsInput) (*listNotificationsOutput, error) {
+	notifications, err := nr.notificationService.ListNotifications(ctx, notification.ListNotificationsReq{
+		Unread: i.Unread,
+		ListOptions: internal.ListOptions{
+			Limit:  i.Limit,
+			Offset: i.Offset,
+		},
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listNotificationsOutput{
+		Body: make([]notificationBody, 0, len(notifications)),
+	}
+	for _, n := range notifications {
+		resp.Body = append(resp.Body, toNotificationBody(n))
+	}
+
+	return resp, nil
+}
+
+func (nr notificationResource) check(ctx context.Context, i *struct{}) (*listNotificationsOutput, error) {
+	notifications, err := nr.notificationService.Check(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listNotificationsOutput{
+		Body: make([]notificationBody, 0, len(notifications)),
+	}
+	for _, n := range notifications {
+		resp.Body = append(resp.Body, toNotificationBody(n))
+	}
+
+	return resp, nil
+}
+
+type markReadInput struct {
+	ID string `path:"id"`
+}
+
+type notificationOutput struct {
+	Body notificationBody
+}
+
+func (nr notificationResource) markRead(ctx context.Context, i *markReadInput) (*notificationOutput, error) {
+	n, err := nr.notificationService.MarkRead(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &notificationOutput{Body: toNotificationBody(n)}, nil
+}
+
+type preferencesBody struct {
+	BudgetAlerts     bool   `json:"budget_alerts" doc:"Notify when a budget reaches 80% and 100%"`
+	BillReminders    bool   `json:"bill_reminders" doc:"Notify when a recurring expense is due"`
+	BillReminderDays int    `json:"bill_reminder_days" minimum:"0" maximum:"30" doc:"How many days before a recurring expense is due to notify"`
+	InactivityDays   int    `json:"inactivity_days" minimum:"0" maximum:"365" doc:"Notify when no expense was logged for this many days, 0 to turn off"`
+	EmailEnabled     bool   `json:"email_enabled"`
+	WebhookURL       string `json:"webhook_url" doc:"POST the notifications to this URL, empty to turn off"`
+}
+
+func toPreferencesBody(p notification.Preferences) preferencesBody {
+	return preferencesBody(p)
+}
+
+type preferencesOutput struct {
+	Body preferencesBody
+}
+
+func (nr notificationResource) preferences(ctx context.Context, i *struct{}) (*preferencesOutput, error) {
+	p, err := nr.notificationService.PreferencesOf(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &preferencesOutput{Body: toPreferencesBody(p)}, nil
+}
+
+type updatePreferencesInput struct {
+	Body preferencesBody
+}
+
+func (nr notificationResource) updatePreferences(ctx context.Context, i *updatePreferencesInput) (*preferencesOutput, error) {
+	p, err := nr.notificationService.UpdatePreferences(ctx, notification.UpdatePreferencesReq(i.Body))
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &preferencesOutput{Body: toPreferencesBody(p)}, nil
+}
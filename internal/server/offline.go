@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/offline"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type offlineResource struct {
+	offlineService offline.Service
+}
+
+func (or offlineResource) mountRoutes(h huma.API) {
+	huma.Post(h, "/sync/push", or.push)
+	huma.Get(h, "/sync/pull", or.pull)
+}
+
+type syncExpenseBody struct {
+	Name           string   `json:"name"`
+	Amount         int64    `json:"amount"`
+	Date           string   `json:"date" format:"date"`
+	Note           string   `json:"note,omitempty"`
+	CategoryID     string   `json:"category_id"`
+	Tags           []string `json:"tags,omitempty"`
+	AccountID      *string  `json:"account_id,omitempty"`
+	ExpenseGroupID *string  `json:"expense_group_id,omitempty"`
+}
+
+type syncExpenseGroupBody struct {
+	Name string `json:"name"`
+	Date string `json:"date" format:"date"`
+	Note string `json:"note,omitempty"`
+}
+
+type syncCategoryBody struct {
+	Name     string  `json:"name"`
+	Color    string  `json:"color"`
+	Icon     string  `json:"icon"`
+	ParentID *string `json:"parent_id,omitempty"`
+}
+
+type recordBody struct {
+	Resource      string                `json:"resource" enum:"expense,expense_group,category"`
+	ID            string                `json:"id"`
+	Deleted       bool                  `json:"deleted"`
+	VersionVector map[string]int64      `json:"version_vector" doc:"Changes made to the record by each device, the server's count is under the server key"`
+	UpdatedAt     time.Time             `json:"updated_at" doc:"Zero for records deleted for good"`
+	Expense       *syncExpenseBody      `json:"expense,omitempty"`
+	ExpenseGroup  *syncExpenseGroupBody `json:"expense_group,omitempty"`
+	Category      *syncCategoryBody     `json:"category,omitempty"`
+}
+
+func toRecordBody(r offline.Record) recordBody {
+	body := recordBody{
+		Resource:      string(r.Resource),
+		ID:            r.ID,
+		Deleted:       r.Deleted,
+		VersionVector: r.VersionVector,
+		UpdatedAt:     r.UpdatedAt,
+	}
+
+	if e := r.Expense; e != nil {
+		body.Expense = &syncExpenseBody{
+			Name:           e.Name,
+			Amount:         e.Amount,
+			Date:           e.Date,
+			Note:           e.Note,
+			CategoryID:     e.CategoryID,
+			Tags:           e.Tags,
+			AccountID:      e.AccountID,
+			ExpenseGroupID: e.ExpenseGroupID,
+		}
+	}
+	if g := r.ExpenseGroup; g != nil {
+		body.ExpenseGroup = &syncExpenseGroupBody{
+			Name: g.Name,
+			Date: g.Date,
+			Note: g.Note,
+		}
+	}
+	if c := r.Category; c != nil {
+		body.Category = &syncCategoryBody{
+			Name:     c.Name,
+			Color:    c.Color,
+			Icon:     c.Icon,
+			ParentID: c.ParentID,
+		}
+	}
+
+	return body
+}
+
+type pushChangeBody struct {
+	Resource      string                `json:"resource" enum:"expense,expense_group,category"`
+	ID            string                `json:"id" maxLength:"64" doc:"Generated by the device for the records it creates"`
+	Deleted       bool                  `json:"deleted,omitempty"`
+	VersionVector map[string]int64      `json:"version_vector" doc:"Vector of the record when the device last pulled it, with the count of the device bumped for every change it made since"`
+	UpdatedAt     time.Time             `json:"updated_at" doc:"When the device made the change, the latest change wins a conflict"`
+	Expense       *syncExpenseBody      `json:"expense,omitempty" doc:"Required unless deleted"`
+	ExpenseGroup  *syncExpenseGroupBody `json:"expense_group,omitempty" doc:"Required unless deleted"`
+	Category      *syncCategoryBody     `json:"category,omitempty" doc:"Required unless deleted"`
+}
+
+func toPushChange(b pushChangeBody) offline.PushChange {
+	c := offline.PushChange{
+		Resource:      offline.Resource(b.Resource),
+		ID:            b.ID,
+		Deleted:       b.Deleted,
+		VersionVector: b.VersionVector,
+		UpdatedAt:     b.UpdatedAt,
+	}
+
+	if e := b.Expense; e != nil {
+		c.Expense = &offline.Expense{
+			Name:           e.Name,
+			Amount:         e.Amount,
+			Date:           e.Date,
+			Note:           e.Note,
+			CategoryID:     e.CategoryID,
+			Tags:           e.Tags,
+			AccountID:      e.AccountID,
+			ExpenseGroupID: e.ExpenseGroupID,
+		}
+	}
+	if g := b.ExpenseGroup; g != nil {
+		c.ExpenseGroup = &offline.ExpenseGroup{
+			Name: g.Name,
+			Date: g.Date,
+			Note: g.Note,
+		}
+	}
+	if cat := b.Category; cat != nil {
+		c.Category = &offline.Category{
+			Name:     cat.Name,
+			Color:    cat.Color,
+			Icon:     cat.Icon,
+			ParentID: cat.ParentID,
+		}
+	}
+
+	return c
+}
+
+type changeResultBody struct {
+	Resource   string      `json:"resource" enum:"expense,expense_group,category"`
+	ID         string      `json:"id"`
+	Status     string      `json:"status" enum:"applied,conflict,rejected"`
+	Resolution *string     `json:"resolution" enum:"client,server" doc:"Which side of the conflict was kept"`
+	Error      *string     `json:"error" doc:"Why the change was rejected"`
+	Record     *recordBody `json:"record" doc:"Record as stored after the change"`
+}
+
+type pushInput struct {
+	Body struct {
+		DeviceID string           `json:"device_id" maxLength:"64"`
+		Changes  []pushChangeBody `json:"changes" minItems:"1" maxItems:"100" doc:"Applied in order, a change can depend on an earlier one"`
+	}
+}
+
+type pushOutput struct {
+	Body struct {
+		Results []changeResultBody `json:"results"`
+	}
+}
+
+func (or offlineResource) push(ctx context.Context, i *pushInput) (*pushOutput, error) {
+	p := offline.PushReq{
+		DeviceID: i.Body.DeviceID,
+		Changes:  make([]offline.PushChange, 0, len(i.Body.Changes)),
+	}
+	for _, c := range i.Body.Changes {
+		p.Changes = append(p.Changes, toPushChange(c))
+	}
+
+	results, err := or.offlineService.Push(ctx, p)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &pushOutput{}
+	resp.Body.Results = make([]changeResultBody, 0, len(results))
+	for _, r := range results {
+		body := changeResultBody{
+			Resource: string(r.Resource),
+			ID:       r.ID,
+			Status:   string(r.Status),
+			Error:    r.Error,
+		}
+		if r.Resolution != nil {
+			resolution := string(*r.Resolution)
+			body.Resolution = &resolution
+		}
+		if r.Record != nil {
+			record := toRecordBody(*r.Record)
+			body.Record = &record
+		}
+		resp.Body.Results = append(resp.Body.Results, body)
+	}
+
+	return resp, nil
+}
+
+type pullInput struct {
+	Token string `query:"token" doc:"Token of the last pull, empty to pull every record"`
+	Limit int    `query:"limit" default:"100" minimum:"1" maximum:"500"`
+}
+
+type pullOutput struct {
+	Body struct {
+		Records []recordBody `json:"records" doc:"Oldest change first"`
+		Token   string       `json:"token" doc:"Pull with it to get the changes after these records"`
+		HasMore bool         `json:"has_more"`
+	}
+}
+
+func (or offlineResource) pull(ctx context.Context, i *pullInput) (*pullOutput, error) {
+	result, err := or.offlineService.Pull(ctx, offline.PullReq{
+		Token: i.Token,
+		Limit: i.Limit,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &pullOutput{}
+	resp.Body.Records = make([]recordBody, 0, len(result.Records))
+	for _, r := range result.Records {
+		resp.Body.Records = append(resp.Body.Records, toRecordBody(r))
+	}
+	resp.Body.Token = result.Token
+	resp.Body.HasMore = result.HasMore
+
+	return resp, nil
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// rateLimiter is a token bucket per client. A bucket holds up to burst tokens
+// and refills at perMinute tokens a minute, every request takes a token.
+type rateLimiter struct {
+	perMinute int64
+	burst     int64
+	now       func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int64, burst int64) *rateLimiter {
+	return &rateLimiter{
+		perMinute: perMinute,
+		burst:     max(burst, 1),
+		now:       time.Now,
+		buckets:   make(map[string]*tokenBucket),
+	}
+}
+
+// take takes a token from the bucket of the key. When the bucket is empty it
+// returns how long until the next token.
+func (l *rateLimiter) take(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(float64(l.burst), b.tokens+now.Sub(b.last).Minutes()*float64(l.perMinute))
+	b.last = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / float64(l.perMinute) * float64(time.Minute))
+		return false, wait
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep forgets the buckets that refilled, they are the same as new ones. It
+// runs at most once a minute.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(float64(l.burst) / float64(l.perMinute) * float64(time.Minute))
+	for k, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// rateLimited limits the requests of every client, the signed in user or else
+// the IP address. The read and write routes have their own limits, a nil
+// limiter doesn't limit its routes.
+func rateLimited(read *rateLimiter, write *rateLimiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := write
+			if isReadMethod(r.Method) {
+				l = read
+			}
+			if l == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ok, wait := l.take(rateLimitKey(r))
+			if !ok {
+				seconds := int64(math.Ceil(wait.Seconds()))
+				w.Header().Set(headerRetryAfter, strconv.FormatInt(max(seconds, 1), 10))
+				writeError(w, huma.Error429TooManyRequests("Too many requests"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isReadMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+// rateLimitKey identifies the client of the request. The remote address is
+// the one set by middleware.RealIP, it has no port when it was taken from the
+// headers.
+func rateLimitKey(r *http.Request) string {
+	if u, ok := user.FromContextOK(r.Context()); ok {
+		return "user:" + u.ID
+	}
+
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return "ip:" + ip
+}
+
+// limitBody rejects the request bodies larger than maxSize, or maxUploadSize
+// for the upload routes. The routes are ServeMux patterns like
+// "POST /api/expenses/{id}/attachments". A size of 0 doesn't limit the
+// bodies.
+func limitBody(maxSize int64, maxUploadSize int64, uploadRoutes []string) func(next http.Handler) http.Handler {
+	uploads := http.NewServeMux()
+	for _, p := range uploadRoutes {
+		uploads.Handle(p, http.NotFoundHandler())
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := maxSize
+			if _, pattern := uploads.Handler(r); pattern != "" {
+				limit = maxUploadSize
+			}
+
+			if limit <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > limit {
+				writeError(w, huma.NewError(http.StatusRequestEntityTooLarge, "Request body is too large"))
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isBodyTooLarge reports whether reading the body failed since it went past
+// the limit of limitBody.
+func isBodyTooLarge(err error) bool {
+	var maxBytesError *http.MaxBytesError
+	return errors.As(err, &maxBytesError)
+}
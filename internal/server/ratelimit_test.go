@@ -0,0 +1,126 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter(60, 2)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.take("a")
+	assert.True(t, ok)
+	ok, _ = l.take("a")
+	assert.True(t, ok)
+
+	ok, wait := l.take("a")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// every client has its own bucket
+	ok, _ = l.take("b")
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, wait = l.take("a")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = l.take("a")
+	assert.True(t, ok)
+
+	t.Run("refilled buckets are forgotten", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		l.take("c")
+		assert.Len(t, l.buckets, 1)
+		assert.Contains(t, l.buckets, "c")
+	})
+}
+
+func TestRateLimited(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(middleware.RealIP)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if id := r.Header.Get("User"); id != "" {
+				r = r.WithContext(user.ContextWithUser(r.Context(), user.User{ID: id}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	router.Use(rateLimited(newRateLimiter(60, 2), newRateLimiter(60, 1)))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+	router.Post("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	do := func(method string, ip string, userID string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/", nil)
+		r.Header.Set(headerXRealIP, ip)
+		if userID != "" {
+			r.Header.Set("User", userID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("reads and writes have their own limits", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "10.0.0.1", "").Code)
+
+		w := do(http.MethodPost, "10.0.0.1", "")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get(headerRetryAfter))
+		assert.Equal(t, "application/problem+json", w.Header().Get(headerContentType))
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "10.0.0.1", "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "10.0.0.1", "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "10.0.0.1", "").Code)
+	})
+
+	t.Run("users are limited apart from their IP address", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "10.0.0.1", "1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "10.0.0.2", "1").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "10.0.0.2", "").Code)
+	})
+}
+
+func TestLimitBody(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(limitBody(4, 8, []string{"POST /uploads/{id}"}))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Body.Read(make([]byte, 16)); isBodyTooLarge(err) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}
+	router.Post("/", handler)
+	router.Post("/uploads/{id}", handler)
+
+	do := func(path string, body string, contentType string, chunked bool) int {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set(headerContentType, contentType)
+		if chunked {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, do("/", "1234", "application/json", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do("/", "12345", "application/json", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do("/", "12345", "application/json", true))
+	// multipart bodies of other routes have the usual limit
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do("/", "12345", "multipart/form-data; boundary=x", false))
+	assert.Equal(t, http.StatusOK, do("/uploads/1", "12345678", "multipart/form-data; boundary=x", false))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do("/uploads/1", "123456789", "multipart/form-data; boundary=x", false))
+}
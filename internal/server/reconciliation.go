@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/reconciliation"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type reconciliationResource struct {
+	reconciliationService reconciliation.Service
+}
+
+func (rr reconciliationResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/accounts/{id}/reconciliations", rr.listSessions)
+	huma.Post(h, "/accounts/{id}/reconciliations", rr.createSession)
+	huma.Get(h, "/reconciliations/{id}", rr.sessionByID)
+	huma.Delete(h, "/reconciliations/{id}", rr.deleteSession)
+	huma.Get(h, "/reconciliations/{id}/items", rr.listItems)
+	huma.Post(h, "/reconciliations/{id}/cleared", rr.markCleared)
+	huma.Post(h, "/reconciliations/{id}/complete", rr.completeSession)
+}
+
+type sessionBody struct {
+	ID               string     `json:"id"`
+	AccountID        string     `json:"account_id"`
+	StatementDate    string     `json:"statement_date" format:"date"`
+	StatementBalance int64      `json:"statement_balance"`
+	ClearedBalance   int64      `json:"cleared_balance"`
+	Difference       int64      `json:"difference" doc:"Statement balance minus the cleared balance, the session can be completed when it's zero"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func toSessionBody(s reconciliation.Session) sessionBody {
+	return sessionBody{
+		ID:               s.ID,
+		AccountID:        s.AccountID,
+		StatementDate:    s.StatementDate.Format(time.DateOnly),
+		StatementBalance: s.StatementBalance,
+		ClearedBalance:   s.ClearedBalance,
+		Difference:       s.Difference(),
+		CompletedAt:      s.CompletedAt,
+		CreatedAt:        s.CreatedAt,
+		UpdatedAt:        s.UpdatedAt,
+	}
+}
+
+type sessionOutput struct {
+	Body sessionBody
+}
+
+type listSessionsInput struct {
+	ID string `path:"id"`
+}
+
+type listSessionsOutput struct {
+	Body []sessionBody
+}
+
+func (rr reconciliationResource) listSessions(ctx context.Context, i *listSessionsInput) (*listSessionsOutput, error) {
+	sessions, err := rr.reconciliationService.ListSessions(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listSessionsOutput{
+		Body: make([]sessionBody, 0, len(sessions)),
+	}
+	for _, s := range sessions {
+		resp.Body = append(resp.Body, toSessionBody(s))
+	}
+
+	return resp, nil
+}
+
+type createSessionInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		StatementDate    string `json:"statement_date" format:"date" doc:"End date of the statement"`
+		StatementBalance int64  `json:"statement_balance" doc:"Ending balance of the statement"`
+	}
+}
+
+func (rr reconciliationResource) createSession(ctx context.Context, i *createSessionInput) (*sessionOutput, error) {
+	s, err := rr.reconciliationService.CreateSession(ctx, reconciliation.CreateSessionReq{
+		AccountID:        i.ID,
+		StatementDate:    i.Body.StatementDate,
+		StatementBalance: i.Body.StatementBalance,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &sessionOutput{Body: toSessionBody(s)}, nil
+}
+
+type sessionByIDInput struct {
+	ID string `path:"id"`
+}
+
+func (rr reconciliationResource) sessionByID(ctx context.Context, i *sessionByIDInput) (*sessionOutput, error) {
+	s, err := rr.reconciliationService.SessionByID(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &sessionOutput{Body: toSessionBody(s)}, nil
+}
+
+type deleteSessionInput struct {
+	ID string `path:"id"`
+}
+
+func (rr reconciliationResource) deleteSession(ctx context.Context, i *deleteSessionInput) (*struct{}, error) {
+	if err := rr.reconciliationService.DeleteSession(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type listItemsInput struct {
+	ID string `path:"id"`
+}
+
+type itemBody struct {
+	ExpenseID string `json:"expense_id"`
+	Name      string `json:"name"`
+	Amount    int64  `json:"amount"`
+	Date      string `json:"date" format:"date"`
+	Cleared   bool   `json:"cleared"`
+}
+
+type listItemsOutput struct {
+	Body []itemBody
+}
+
+func (rr reconciliationResource) listItems(ctx context.Context, i *listItemsInput) (*listItemsOutput, error) {
+	items, err := rr.reconciliationService.ListItems(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listItemsOutput{
+		Body: make([]itemBody, 0, len(items)),
+	}
+	for _, item := range items {
+		resp.Body = append(resp.Body, itemBody{
+			ExpenseID: item.ExpenseID,
+			Name:      item.Name,
+			Amount:    item.Amount,
+			Date:      item.Date.Format(time.DateOnly),
+			Cleared:   item.Cleared,
+		})
+	}
+
+	return resp, nil
+}
+
+type markClearedInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		ExpenseIDs []string `json:"expense_ids"`
+		Cleared    bool     `json:"cleared" doc:"False takes the expenses out of the session"`
+	}
+}
+
+func (rr reconciliationResource) markCleared(ctx context.Context, i *markClearedInput) (*sessionOutput, error) {
+	s, err := rr.reconciliationService.MarkCleared(ctx, reconciliation.MarkClearedReq{
+		SessionID:  i.ID,
+		ExpenseIDs: i.Body.ExpenseIDs,
+		Cleared:    i.Body.Cleared,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &sessionOutput{Body: toSessionBody(s)}, nil
+}
+
+type completeSessionInput struct {
+	ID string `path:"id"`
+}
+
+func (rr reconciliationResource) completeSession(ctx context.Context, i *completeSessionInput) (*sessionOutput, error) {
+	s, err := rr.reconciliationService.CompleteSession(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &sessionOutput{Body: toSessionBody(s)}, nil
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/rule"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type ruleResource struct {
+	ruleService    rule.Service
+	expenseService expense.Service
+}
+
+func (rr ruleResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/rules", rr.listRules)
+	huma.Post(h, "/rules", rr.createRule)
+	huma.Delete(h, "/rules/{id}", rr.deleteRule)
+	huma.Post(h, "/rules/rerun", rr.rerunRules)
+}
+
+type ruleBody struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name"`
+	Priority     int            `json:"priority" doc:"Rules with lower priority run first"`
+	NameContains string         `json:"name_contains,omitempty"`
+	NoteContains string         `json:"note_contains,omitempty"`
+	NameRegex    string         `json:"name_regex,omitempty"`
+	MinAmount    *int64         `json:"min_amount,omitempty"`
+	MaxAmount    *int64         `json:"max_amount,omitempty"`
+	DaysOfWeek   []time.Weekday `json:"days_of_week,omitempty" doc:"0 is Sunday"`
+	CategoryID   *string        `json:"category_id,omitempty"`
+	Rename       *string        `json:"rename,omitempty"`
+	AppendTags   []string       `json:"append_tags,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+func toRuleBody(r rule.Rule) ruleBody {
+	return ruleBody{
+		ID:           r.ID,
+		Name:         r.Name,
+		Priority:     r.Priority,
+		NameContains: r.Conditions.NameContains,
+		NoteContains: r.Conditions.NoteContains,
+		NameRegex:    r.Conditions.NameRegex,
+		MinAmount:    r.Conditions.MinAmount,
+		MaxAmount:    r.Conditions.MaxAmount,
+		DaysOfWeek:   r.Conditions.DaysOfWeek,
+		CategoryID:   r.Actions.CategoryID,
+		Rename:       r.Actions.Rename,
+		AppendTags:   r.Actions.AppendTags,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+type listRulesOutput struct {
+	Body []ruleBody
+}
+
+func (rr ruleResource) listRules(ctx context.Context, _ *struct{}) (*listRulesOutput, error) {
+	rules, err := rr.ruleService.ListRules(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listRulesOutput{
+		Body: make([]ruleBody, 0, len(rules)),
+	}
+	for _, r := range rules {
+		resp.Body = append(resp.Body, toRuleBody(r))
+	}
+
+	return resp, nil
+}
+
+type createRuleInput struct {
+	Body struct {
+		Name         string         `json:"name"`
+		Priority     int            `json:"priority"`
+		NameContains string         `json:"name_contains,omitempty"`
+		NoteContains string         `json:"note_contains,omitempty"`
+		NameRegex    string         `json:"name_regex,omitempty"`
+		MinAmount    *int64         `json:"min_amount,omitempty"`
+		MaxAmount    *int64         `json:"max_amount,omitempty"`
+		DaysOfWeek   []time.Weekday `json:"days_of_week,omitempty" doc:"0 is Sunday"`
+		CategoryID   *string        `json:"category_id,omitempty"`
+		Rename       *string        `json:"rename,omitempty"`
+		AppendTags   []string       `json:"append_tags,omitempty"`
+	}
+}
+
+type ruleOutput struct {
+	Body ruleBody
+}
+
+func (rr ruleResource) createRule(ctx context.Context, i *createRuleInput) (*ruleOutput, error) {
+	r, err := rr.ruleService.CreateRule(ctx, rule.CreateRuleReq(i.Body))
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &ruleOutput{Body: toRuleBody(r)}, nil
+}
+
+type deleteRuleInput struct {
+	ID string `path:"id"`
+}
+
+func (rr ruleResource) deleteRule(ctx context.Context, i *deleteRuleInput) (*struct{}, error) {
+	if err := rr.ruleService.DeleteRule(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type rerunRulesInput struct {
+	Body struct {
+		StartDate string `json:"start_date" format:"date"`
+		EndDate   string `json:"end_date" format:"date"`
+		DryRun    bool   `json:"dry_run,omitempty" doc:"Only preview the changes"`
+	}
+}
+
+type ruleTargetBody struct {
+	Name       string   `json:"name"`
+	CategoryID string   `json:"category_id"`
+	Tags       []string `json:"tags"`
+}
+
+type ruleChangeBody struct {
+	ExpenseID string         `json:"expense_id"`
+	Before    ruleTargetBody `json:"before"`
+	After     ruleTargetBody `json:"after"`
+}
+
+type rerunRulesOutput struct {
+	Body struct {
+		DryRun  bool             `json:"dry_run"`
+		Changes []ruleChangeBody `json:"changes"`
+	}
+}
+
+func (rr ruleResource) rerunRules(ctx context.Context, i *rerunRulesInput) (*rerunRulesOutput, error) {
+	changes, err := rr.expenseService.RerunRules(ctx, expense.RerunRulesReq{
+		StartDate: i.Body.StartDate,
+		EndDate:   i.Body.EndDate,
+		DryRun:    i.Body.DryRun,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &rerunRulesOutput{}
+	resp.Body.DryRun = i.Body.DryRun
+	resp.Body.Changes = make([]ruleChangeBody, 0, len(changes))
+	for _, c := range changes {
+		resp.Body.Changes = append(resp.Body.Changes, ruleChangeBody{
+			ExpenseID: c.ExpenseID,
+			Before: ruleTargetBody{
+				Name:       c.Before.Name,
+				CategoryID: c.Before.CategoryID,
+				Tags:       c.Before.Tags,
+			},
+			After: ruleTargetBody{
+				Name:       c.After.Name,
+				CategoryID: c.After.CategoryID,
+				Tags:       c.After.Tags,
+			},
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type securityPolicy struct {
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+	// Only sent over HTTPS, the browsers ignore it over HTTP.
+	StrictTransportSecurity string
+}
+
+// securityHeaders sets the headers of the policy on every response. Empty
+// values are left out.
+func securityHeaders(p securityPolicy) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set(headerXContentTypeOptions, "nosniff")
+			if p.ContentSecurityPolicy != "" {
+				h.Set(headerContentSecurityPolicy, p.ContentSecurityPolicy)
+			}
+			if p.FrameOptions != "" {
+				h.Set(headerXFrameOptions, p.FrameOptions)
+			}
+			if p.ReferrerPolicy != "" {
+				h.Set(headerReferrerPolicy, p.ReferrerPolicy)
+			}
+			if p.StrictTransportSecurity != "" && r.TLS != nil {
+				h.Set(headerStrictTransportSecurity, p.StrictTransportSecurity)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type corsPolicy struct {
+	// "*" allows every origin.
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// Response headers the browsers let the allowed origins read.
+var corsExposedHeaders = []string{
+	headerETag,
+	headerIdempotentReplayed,
+	headerRetryAfter,
+	headerXRequestID,
+}
+
+// cors lets the allowed origins call the API from the browser and answers
+// their preflight requests. The origin is echoed back rather than "*" so the
+// credentials can be allowed.
+func cors(p corsPolicy) func(next http.Handler) http.Handler {
+	allowedMethods := strings.Join(p.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(p.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(corsExposedHeaders, ", ")
+	maxAge := strconv.FormatInt(int64(p.MaxAge.Seconds()), 10)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(p.AllowedOrigins) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add(headerVary, headerOrigin)
+
+			origin := r.Header.Get(headerOrigin)
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed := slices.Contains(p.AllowedOrigins, "*") || slices.Contains(p.AllowedOrigins, origin)
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get(headerAccessControlRequestMethod) != ""
+			if !preflight {
+				if allowed {
+					h.Set(headerAccessControlAllowOrigin, origin)
+					h.Set(headerAccessControlExposeHeaders, exposedHeaders)
+					if p.AllowCredentials {
+						h.Set(headerAccessControlAllowCredentials, "true")
+					}
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add(headerVary, headerAccessControlRequestMethod)
+			h.Add(headerVary, headerAccessControlRequestHeaders)
+
+			method := r.Header.Get(headerAccessControlRequestMethod)
+			if !allowed || !slices.Contains(p.AllowedMethods, method) {
+				writeError(w, huma.Error403Forbidden("Origin or method is not allowed"))
+				return
+			}
+
+			h.Set(headerAccessControlAllowOrigin, origin)
+			h.Set(headerAccessControlAllowMethods, allowedMethods)
+			h.Set(headerAccessControlAllowHeaders, allowedHeaders)
+			h.Set(headerAccessControlMaxAge, maxAge)
+			if p.AllowCredentials {
+				h.Set(headerAccessControlAllowCredentials, "true")
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
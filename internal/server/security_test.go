@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(securityHeaders(securityPolicy{
+		ContentSecurityPolicy: "default-src 'self'",
+		FrameOptions:          "DENY",
+	}))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "nosniff", w.Header().Get(headerXContentTypeOptions))
+	assert.Equal(t, "default-src 'self'", w.Header().Get(headerContentSecurityPolicy))
+	assert.Equal(t, "DENY", w.Header().Get(headerXFrameOptions))
+	assert.NotContains(t, w.Header(), headerReferrerPolicy)
+	assert.NotContains(t, w.Header(), headerStrictTransportSecurity)
+}
+
+func TestStrictTransportSecurity(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(securityHeaders(securityPolicy{
+		StrictTransportSecurity: "max-age=86400",
+	}))
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "https://example.com/", nil))
+	assert.Equal(t, "max-age=86400", w.Header().Get(headerStrictTransportSecurity))
+
+	// the browsers ignore it over HTTP
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	assert.NotContains(t, w.Header(), headerStrictTransportSecurity)
+}
+
+func TestCORS(t *testing.T) {
+	var calls int
+	newRouter := func(p corsPolicy) *chi.Mux {
+		router := chi.NewRouter()
+		router.Use(cors(p))
+		router.Get("/expenses", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+		})
+		return router
+	}
+
+	router := newRouter(corsPolicy{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders:   []string{"Content-Type", "If-Match"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	do := func(router http.Handler, method string, origin string, requestMethod string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/expenses", nil)
+		if origin != "" {
+			r.Header.Set(headerOrigin, origin)
+		}
+		if requestMethod != "" {
+			r.Header.Set(headerAccessControlRequestMethod, requestMethod)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("allowed origin", func(t *testing.T) {
+		calls = 0
+
+		w := do(router, http.MethodGet, "https://app.example.com", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get(headerAccessControlAllowOrigin))
+		assert.Equal(t, "true", w.Header().Get(headerAccessControlAllowCredentials))
+		assert.Contains(t, w.Header().Get(headerAccessControlExposeHeaders), headerETag)
+		assert.Equal(t, []string{headerOrigin}, w.Header().Values(headerVary))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		calls = 0
+
+		w := do(router, http.MethodGet, "https://evil.example.com", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Header(), headerAccessControlAllowOrigin)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		calls = 0
+
+		w := do(router, http.MethodOptions, "https://app.example.com", http.MethodPost)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get(headerAccessControlAllowOrigin))
+		assert.Equal(t, "GET, POST", w.Header().Get(headerAccessControlAllowMethods))
+		assert.Equal(t, "Content-Type, If-Match", w.Header().Get(headerAccessControlAllowHeaders))
+		assert.Equal(t, "600", w.Header().Get(headerAccessControlMaxAge))
+		assert.Equal(t, "true", w.Header().Get(headerAccessControlAllowCredentials))
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("preflight of other origin or method", func(t *testing.T) {
+		w := do(router, http.MethodOptions, "https://evil.example.com", http.MethodPost)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.NotContains(t, w.Header(), headerAccessControlAllowOrigin)
+
+		w = do(router, http.MethodOptions, "https://app.example.com", http.MethodDelete)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("any origin", func(t *testing.T) {
+		router := newRouter(corsPolicy{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet},
+		})
+
+		w := do(router, http.MethodGet, "https://other.example.com", "")
+		assert.Equal(t, "https://other.example.com", w.Header().Get(headerAccessControlAllowOrigin))
+		assert.NotContains(t, w.Header(), headerAccessControlAllowCredentials)
+	})
+
+	t.Run("turned off", func(t *testing.T) {
+		router := newRouter(corsPolicy{})
+
+		w := do(router, http.MethodOptions, "https://app.example.com", http.MethodGet)
+		assert.NotContains(t, w.Header(), headerAccessControlAllowOrigin)
+		assert.NotContains(t, w.Header(), headerVary)
+	})
+}
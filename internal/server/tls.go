@@ -0,0 +1,142 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/config"
+	"go.uber.org/zap"
+)
+
+// selfSignedValidity is how long a generated certificate is valid for. It's
+// generated again once it expires.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// loadCertificate loads the certificate of HTTPS from the files of the config.
+// In self-signed mode it's generated when the files don't exist, or are set
+// to an expired one, and saved to them if they are set.
+func loadCertificate(cfg config.Config, logger *zap.SugaredLogger) (tls.Certificate, error) {
+	if !cfg.TLSSelfSigned {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return tls.Certificate{}, fmt.Errorf("server.loadCertificate: LoadX509KeyPair: %w", err)
+		}
+		return cert, nil
+	}
+
+	saved := cfg.TLSCertFile != "" && cfg.TLSKeyFile != ""
+	if saved {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err == nil && time.Now().Before(cert.Leaf.NotAfter) {
+			return cert, nil
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return tls.Certificate{}, fmt.Errorf("server.loadCertificate: LoadX509KeyPair: %w", err)
+		}
+	}
+
+	certPEM, keyPEM, err := generateSelfSignedCertificate(cfg.TLSHosts, time.Now())
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("server.loadCertificate: %w", err)
+	}
+	logger.Infow("Generated self-signed certificate", "hosts", cfg.TLSHosts)
+
+	if saved {
+		if err := os.WriteFile(cfg.TLSCertFile, certPEM, 0o644); err != nil {
+			return tls.Certificate{}, fmt.Errorf("server.loadCertificate: WriteFile: %w", err)
+		}
+		if err := os.WriteFile(cfg.TLSKeyFile, keyPEM, 0o600); err != nil {
+			return tls.Certificate{}, fmt.Errorf("server.loadCertificate: WriteFile: %w", err)
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("server.loadCertificate: X509KeyPair: %w", err)
+	}
+	return cert, nil
+}
+
+// generateSelfSignedCertificate generates a certificate, valid from now, for
+// the host names and IP addresses. It's returned along with its key in PEM.
+func generateSelfSignedCertificate(hosts []string, now time.Time) ([]byte, []byte, error) {
+	if len(hosts) == 0 {
+		return nil, nil, errors.New("generateSelfSignedCertificate: no hosts")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generateSelfSignedCertificate: GenerateKey: %w", err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, fmt.Errorf("generateSelfSignedCertificate: Int: %w", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"Budget Tracker"},
+			CommonName:   hosts[0],
+		},
+		// a little earlier for the clocks that are behind
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generateSelfSignedCertificate: CreateCertificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generateSelfSignedCertificate: MarshalPKCS8PrivateKey: %w", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM, nil
+}
+
+// redirectToHTTPS permanently redirects the requests to the same URL on HTTPS
+// at the port.
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		} else if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+			// IPv6 addresses are bracketed in URLs
+			host = "[" + host + "]"
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
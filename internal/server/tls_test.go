@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/config"
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestGenerateSelfSignedCertificate(t *testing.T) {
+	now := time.Now()
+	certPEM, keyPEM, err := generateSelfSignedCertificate([]string{"budget.lan", "192.168.1.10", "::1"}, now)
+	assert.Nil(t, err)
+
+	_, err = tls.X509KeyPair(certPEM, keyPEM)
+	assert.Nil(t, err)
+
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"budget.lan"}, cert.DNSNames)
+	assert.Len(t, cert.IPAddresses, 2)
+	assert.Nil(t, cert.VerifyHostname("192.168.1.10"))
+	assert.Nil(t, cert.VerifyHostname("::1"))
+	assert.NotNil(t, cert.VerifyHostname("example.com"))
+	assert.True(t, cert.NotAfter.After(now.Add(364*24*time.Hour)))
+
+	_, _, err = generateSelfSignedCertificate(nil, now)
+	assert.NotNil(t, err)
+}
+
+func TestLoadCertificate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.Config{
+		TLSCertFile:   filepath.Join(dir, "cert.pem"),
+		TLSKeyFile:    filepath.Join(dir, "key.pem"),
+		TLSSelfSigned: true,
+		TLSHosts:      []string{"localhost"},
+	}
+	logger := zap.NewNop().Sugar()
+
+	generated, err := loadCertificate(cfg, logger)
+	assert.Nil(t, err)
+
+	// saved and reused
+	loaded, err := loadCertificate(cfg, logger)
+	assert.Nil(t, err)
+	assert.Equal(t, generated.Certificate, loaded.Certificate)
+
+	cfg.TLSSelfSigned = false
+	loaded, err = loadCertificate(cfg, logger)
+	assert.Nil(t, err)
+	assert.Equal(t, generated.Certificate, loaded.Certificate)
+
+	cfg.TLSCertFile = filepath.Join(dir, "missing.pem")
+	_, err = loadCertificate(cfg, logger)
+	assert.NotNil(t, err)
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort string
+		target    string
+		want      string
+	}{
+		{
+			name:      "default port",
+			httpsPort: "443",
+			target:    "http://budget.lan/api/expenses?limit=10",
+			want:      "https://budget.lan/api/expenses?limit=10",
+		},
+		{
+			name:      "other port",
+			httpsPort: "6969",
+			target:    "http://budget.lan:8080/",
+			want:      "https://budget.lan:6969/",
+		},
+		{
+			name:      "ipv6",
+			httpsPort: "6969",
+			target:    "http://[::1]:8080/",
+			want:      "https://[::1]:6969/",
+		},
+		{
+			name:      "ipv6 default port",
+			httpsPort: "443",
+			target:    "http://[::1]:8080/",
+			want:      "https://[::1]/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			redirectToHTTPS(tt.httpsPort).ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get(headerLocation))
+		})
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	cfg := config.Config{
+		TLSSelfSigned: true,
+		TLSHosts:      []string{"127.0.0.1"},
+		HSTSMaxAge:    24 * time.Hour,
+	}
+	s := NewServer(Resource{
+		Config:      cfg,
+		Logger:      zap.NewNop().Sugar(),
+		EventBroker: event.NewBroker(),
+	})
+
+	cert, err := loadCertificate(cfg, s.resource.Logger)
+	assert.Nil(t, err)
+	s.httpServer.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go s.serve(ln)
+	defer s.Shutdown(context.Background())
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+	}
+
+	resp, err := client.Get("https://" + ln.Addr().String() + "/api/csrf-token")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "max-age=86400", resp.Header.Get(headerStrictTransportSecurity))
+	cookies := resp.Cookies()
+	assert.Len(t, cookies, 1)
+	assert.True(t, cookies[0].Secure)
+}
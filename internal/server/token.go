@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type tokenResource struct {
+	tokenService token.Service
+}
+
+func (tr tokenResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/tokens", tr.listTokens)
+	huma.Post(h, "/tokens", tr.createToken)
+	huma.Delete(h, "/tokens/{id}", tr.revokeToken)
+}
+
+type tokenBody struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Prefix     string     `json:"prefix" doc:"Start of the secret to tell the tokens apart"`
+	Secret     string     `json:"secret,omitempty" doc:"Only returned on creation. Send it as 'Authorization: Bearer <secret>'"`
+	ExpiresAt  *time.Time `json:"expires_at" doc:"Null if the token never expires"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func toTokenBody(t token.Token) tokenBody {
+	scopes := make([]string, 0, len(t.Scopes))
+	for _, s := range t.Scopes {
+		scopes = append(scopes, string(s))
+	}
+
+	return tokenBody{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     scopes,
+		Prefix:     t.Prefix,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+type tokenOutput struct {
+	Body tokenBody
+}
+
+type listTokensOutput struct {
+	Body []tokenBody
+}
+
+func (tr tokenResource) listTokens(ctx context.Context, i *struct{}) (*listTokensOutput, error) {
+	tokens, err := tr.tokenService.ListTokens(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listTokensOutput{
+		Body: make([]tokenBody, 0, len(tokens)),
+	}
+	for _, t := range tokens {
+		resp.Body = append(resp.Body, toTokenBody(t))
+	}
+
+	return resp, nil
+}
+
+type createTokenInput struct {
+	Body struct {
+		Name      string     `json:"name" maxLength:"100"`
+		Scopes    []string   `json:"scopes" minItems:"1" doc:"read allows the GET requests, write allows every request. A token can only grant its own scopes"`
+		ExpiresAt *time.Time `json:"expires_at,omitempty" doc:"The token never expires if omitted. A token can only create tokens that expire no later than it"`
+	}
+}
+
+func (tr tokenResource) createToken(ctx context.Context, i *createTokenInput) (*tokenOutput, error) {
+	scopes := make([]token.Scope, 0, len(i.Body.Scopes))
+	for _, s := range i.Body.Scopes {
+		scopes = append(scopes, token.Scope(s))
+	}
+
+	t, err := tr.tokenService.CreateToken(ctx, token.CreateTokenReq{
+		Name:      i.Body.Name,
+		Scopes:    scopes,
+		ExpiresAt: i.Body.ExpiresAt,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &tokenOutput{Body: toTokenBody(t.Token)}
+	resp.Body.Secret = t.Secret
+	return resp, nil
+}
+
+type revokeTokenInput struct {
+	ID string `path:"id"`
+}
+
+func (tr tokenResource) revokeToken(ctx context.Context, i *revokeTokenInput) (*struct{}, error) {
+	if err := tr.tokenService.RevokeToken(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
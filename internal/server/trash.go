@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type trashResource struct {
+	expenseService  expense.Service
+	categoryService category.Service
+}
+
+func (tr trashResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/trash/expenses", tr.listDeletedExpenses)
+	huma.Post(h, "/trash/expenses/{id}/restore", tr.restoreExpense)
+	huma.Get(h, "/trash/categories", tr.listDeletedCategories)
+	huma.Post(h, "/trash/categories/{id}/restore", tr.restoreCategory)
+}
+
+// listTrashInput pages through one list of the trash, the expenses and the
+// categories are paged apart.
+type listTrashInput struct {
+	Limit  int `query:"limit" default:"20" minimum:"1" maximum:"100"`
+	Offset int `query:"offset" minimum:"0"`
+}
+
+type deletedExpenseBody struct {
+	expenseBody
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type listDeletedExpensesOutput struct {
+	Body []deletedExpenseBody
+}
+
+func (tr trashResource) listDeletedExpenses(ctx context.Context, i *listTrashInput) (*listDeletedExpensesOutput, error) {
+	expenses, err := tr.expenseService.ListDeletedExpenses(ctx, internal.ListOptions{
+		Limit:  i.Limit,
+		Offset: i.Offset,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listDeletedExpensesOutput{
+		Body: make([]deletedExpenseBody, 0, len(expenses)),
+	}
+	for _, e := range expenses {
+		resp.Body = append(resp.Body, deletedExpenseBody{
+			expenseBody: toExpenseBody(e.Expense),
+			DeletedAt:   e.DeletedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+type deletedCategoryBody struct {
+	categoryBody
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type listDeletedCategoriesOutput struct {
+	Body []deletedCategoryBody
+}
+
+func (tr trashResource) listDeletedCategories(ctx context.Context, i *listTrashInput) (*listDeletedCategoriesOutput, error) {
+	categories, err := tr.categoryService.ListDeletedCategories(ctx, internal.ListOptions{
+		Limit:  i.Limit,
+		Offset: i.Offset,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listDeletedCategoriesOutput{
+		Body: make([]deletedCategoryBody, 0, len(categories)),
+	}
+	for _, c := range categories {
+		resp.Body = append(resp.Body, deletedCategoryBody{
+			categoryBody: toCategoryBody(c.Category),
+			DeletedAt:    c.DeletedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+type restoreInput struct {
+	ID string `path:"id"`
+}
+
+type restoreExpenseOutput struct {
+	Body expenseBody
+}
+
+func (tr trashResource) restoreExpense(ctx context.Context, i *restoreInput) (*restoreExpenseOutput, error) {
+	e, err := tr.expenseService.RestoreExpense(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &restoreExpenseOutput{Body: toExpenseBody(e)}, nil
+}
+
+type restoreCategoryOutput struct {
+	Body categoryBody
+}
+
+func (tr trashResource) restoreCategory(ctx context.Context, i *restoreInput) (*restoreCategoryOutput, error) {
+	c, err := tr.categoryService.RestoreCategory(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &restoreCategoryOutput{Body: toCategoryBody(c)}, nil
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+
+	"github.com/cativovo/budget-tracker/internal/trend"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type trendResource struct {
+	trendService trend.Service
+}
+
+func (tr trendResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/trends/monthly", tr.listMonthlyTotals)
+	huma.Get(h, "/trends/year-over-year", tr.listYearOverYear)
+	huma.Get(h, "/trends/top-names", tr.listTopNames)
+}
+
+type listMonthlyTotalsInput struct {
+	StartMonth string `query:"start_month" required:"true" pattern:"^\\d{4}-\\d{2}$" example:"2006-01"`
+	EndMonth   string `query:"end_month" required:"true" pattern:"^\\d{4}-\\d{2}$" example:"2006-12"`
+}
+
+type monthlyTotalBody struct {
+	Category          categoryBody `json:"category"`
+	Month             string       `json:"month" example:"2006-01"`
+	Total             int64        `json:"total"`
+	PreviousYearTotal int64        `json:"previous_year_total" doc:"Spending in the same month of the previous year"`
+	RollingAverage3   float64      `json:"rolling_average_3" doc:"Average monthly spending over the last 3 months"`
+	RollingAverage6   float64      `json:"rolling_average_6" doc:"Average monthly spending over the last 6 months"`
+	RollingAverage12  float64      `json:"rolling_average_12" doc:"Average monthly spending over the last 12 months"`
+}
+
+type listMonthlyTotalsOutput struct {
+	Body []monthlyTotalBody
+}
+
+func (tr trendResource) listMonthlyTotals(ctx context.Context, i *listMonthlyTotalsInput) (*listMonthlyTotalsOutput, error) {
+	totals, err := tr.trendService.ListMonthlyTotals(ctx, trend.MonthlyTotalsReq{
+		StartMonth: i.StartMonth,
+		EndMonth:   i.EndMonth,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listMonthlyTotalsOutput{
+		Body: make([]monthlyTotalBody, 0, len(totals)),
+	}
+	for _, t := range totals {
+		resp.Body = append(resp.Body, monthlyTotalBody{
+			Category:          toCategoryBody(t.Category),
+			Month:             t.Month.Format("2006-01"),
+			Total:             t.Total,
+			PreviousYearTotal: t.PreviousYearTotal,
+			RollingAverage3:   t.RollingAverage3,
+			RollingAverage6:   t.RollingAverage6,
+			RollingAverage12:  t.RollingAverage12,
+		})
+	}
+
+	return resp, nil
+}
+
+type listYearOverYearInput struct {
+	Year         int `query:"year" required:"true" minimum:"1" maximum:"9999"`
+	ThroughMonth int `query:"through_month" minimum:"0" maximum:"12" doc:"Only compare the months up to this one, e.g. 3 compares January to March. Omit to compare the whole years"`
+}
+
+type yearOverYearBody struct {
+	Category      categoryBody `json:"category"`
+	Total         int64        `json:"total"`
+	PreviousTotal int64        `json:"previous_total"`
+	Change        *float64     `json:"change" doc:"Percentage change from the previous year, null if nothing was spent in the previous year"`
+}
+
+type listYearOverYearOutput struct {
+	Body []yearOverYearBody
+}
+
+func (tr trendResource) listYearOverYear(ctx context.Context, i *listYearOverYearInput) (*listYearOverYearOutput, error) {
+	comparisons, err := tr.trendService.ListYearOverYear(ctx, trend.YearOverYearReq{
+		Year:         i.Year,
+		ThroughMonth: i.ThroughMonth,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listYearOverYearOutput{
+		Body: make([]yearOverYearBody, 0, len(comparisons)),
+	}
+	for _, c := range comparisons {
+		resp.Body = append(resp.Body, yearOverYearBody{
+			Category:      toCategoryBody(c.Category),
+			Total:         c.Total,
+			PreviousTotal: c.PreviousTotal,
+			Change:        c.Change(),
+		})
+	}
+
+	return resp, nil
+}
+
+type listTopNamesInput struct {
+	StartDate string `query:"start_date" required:"true" format:"date"`
+	EndDate   string `query:"end_date" required:"true" format:"date"`
+	Limit     int    `query:"limit" default:"10" minimum:"1" maximum:"100"`
+}
+
+type nameTotalBody struct {
+	Name  string  `json:"name"`
+	Total int64   `json:"total"`
+	Count int64   `json:"count"`
+	Rank  int64   `json:"rank"`
+	Share float64 `json:"share" doc:"Percentage of the spending within the date range"`
+}
+
+type listTopNamesOutput struct {
+	Body []nameTotalBody
+}
+
+func (tr trendResource) listTopNames(ctx context.Context, i *listTopNamesInput) (*listTopNamesOutput, error) {
+	names, err := tr.trendService.ListTopNames(ctx, trend.TopNamesReq{
+		StartDate: i.StartDate,
+		EndDate:   i.EndDate,
+		Limit:     i.Limit,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listTopNamesOutput{
+		Body: make([]nameTotalBody, 0, len(names)),
+	}
+	for _, n := range names {
+		resp.Body = append(resp.Body, nameTotalBody(n))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/webhook"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+type webhookResource struct {
+	webhookService webhook.Service
+}
+
+func (wr webhookResource) mountRoutes(h huma.API) {
+	huma.Get(h, "/webhooks", wr.listWebhooks)
+	huma.Post(h, "/webhooks", wr.createWebhook)
+	huma.Get(h, "/webhooks/{id}", wr.webhookByID)
+	huma.Patch(h, "/webhooks/{id}", wr.updateWebhook)
+	huma.Delete(h, "/webhooks/{id}", wr.deleteWebhook)
+	huma.Get(h, "/webhooks/{id}/deliveries", wr.listDeliveries)
+	huma.Post(h, "/webhooks/{id}/test", wr.sendTestEvent)
+}
+
+type webhookBody struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty" doc:"Only returned on creation. Key of the HMAC-SHA256 in the X-Webhook-Signature header"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toWebhookBody(w webhook.Webhook) webhookBody {
+	return webhookBody{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    w.Events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+type webhookOutput struct {
+	Body webhookBody
+}
+
+type listWebhooksOutput struct {
+	Body []webhookBody
+}
+
+func (wr webhookResource) listWebhooks(ctx context.Context, i *struct{}) (*listWebhooksOutput, error) {
+	webhooks, err := wr.webhookService.ListWebhooks(ctx)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listWebhooksOutput{
+		Body: make([]webhookBody, 0, len(webhooks)),
+	}
+	for _, w := range webhooks {
+		resp.Body = append(resp.Body, toWebhookBody(w))
+	}
+
+	return resp, nil
+}
+
+type createWebhookInput struct {
+	Body struct {
+		URL    string   `json:"url" format:"uri"`
+		Events []string `json:"events" minItems:"1" doc:"expense.created, expense.updated, expense.deleted, expense_group.created, expense_group.updated, expense_group.deleted, category.created, category.updated or category.deleted"`
+	}
+}
+
+func (wr webhookResource) createWebhook(ctx context.Context, i *createWebhookInput) (*webhookOutput, error) {
+	w, err := wr.webhookService.CreateWebhook(ctx, webhook.CreateWebhookReq(i.Body))
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &webhookOutput{Body: toWebhookBody(w)}
+	resp.Body.Secret = w.Secret
+	return resp, nil
+}
+
+type webhookByIDInput struct {
+	ID string `path:"id"`
+}
+
+func (wr webhookResource) webhookByID(ctx context.Context, i *webhookByIDInput) (*webhookOutput, error) {
+	w, err := wr.webhookService.WebhookByID(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &webhookOutput{Body: toWebhookBody(w)}, nil
+}
+
+type updateWebhookInput struct {
+	ID   string `path:"id"`
+	Body struct {
+		URL    *string   `json:"url,omitempty" format:"uri"`
+		Events *[]string `json:"events,omitempty"`
+		Active *bool     `json:"active,omitempty" doc:"Inactive webhooks don't get new deliveries"`
+	}
+}
+
+func (wr webhookResource) updateWebhook(ctx context.Context, i *updateWebhookInput) (*webhookOutput, error) {
+	w, err := wr.webhookService.UpdateWebhook(ctx, webhook.UpdateWebhookReq{
+		ID:     i.ID,
+		URL:    i.Body.URL,
+		Events: i.Body.Events,
+		Active: i.Body.Active,
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &webhookOutput{Body: toWebhookBody(w)}, nil
+}
+
+type deleteWebhookInput struct {
+	ID string `path:"id"`
+}
+
+func (wr webhookResource) deleteWebhook(ctx context.Context, i *deleteWebhookInput) (*struct{}, error) {
+	if err := wr.webhookService.DeleteWebhook(ctx, i.ID); err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return nil, nil
+}
+
+type deliveryBody struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status" enum:"pending,succeeded,failed"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" doc:"Null once the delivery is done"`
+	ResponseStatus *int       `json:"response_status" doc:"Status code of the response to the last attempt"`
+	Error          *string    `json:"error" doc:"Why the last attempt failed"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func toDeliveryBody(d webhook.Delivery) deliveryBody {
+	var nextAttemptAt *time.Time
+	if d.Status == webhook.DeliveryStatusPending {
+		nextAttemptAt = &d.NextAttemptAt
+	}
+
+	return deliveryBody{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		Event:          d.Event,
+		Payload:        d.Payload,
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		Error:          d.Error,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+		UpdatedAt:      d.UpdatedAt,
+	}
+}
+
+type listDeliveriesInput struct {
+	ID     string `path:"id"`
+	Limit  int    `query:"limit" default:"20" minimum:"1" maximum:"100"`
+	Offset int    `query:"offset" minimum:"0"`
+}
+
+type listDeliveriesOutput struct {
+	Body []deliveryBody
+}
+
+func (wr webhookResource) listDeliveries(ctx context.Context, i *listDeliveriesInput) (*listDeliveriesOutput, error) {
+	deliveries, err := wr.webhookService.ListDeliveries(ctx, webhook.ListDeliveriesReq{
+		WebhookID: i.ID,
+		ListOptions: internal.ListOptions{
+			Limit:  i.Limit,
+			Offset: i.Offset,
+		},
+	})
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+
+	resp := &listDeliveriesOutput{
+		Body: make([]deliveryBody, 0, len(deliveries)),
+	}
+	for _, d := range deliveries {
+		resp.Body = append(resp.Body, toDeliveryBody(d))
+	}
+
+	return resp, nil
+}
+
+type sendTestEventInput struct {
+	ID string `path:"id"`
+}
+
+type deliveryOutput struct {
+	Body deliveryBody
+}
+
+func (wr webhookResource) sendTestEvent(ctx context.Context, i *sendTestEventInput) (*deliveryOutput, error) {
+	d, err := wr.webhookService.SendTestEvent(ctx, i.ID)
+	if err != nil {
+		return nil, toHumaError(ctx, err)
+	}
+	return &deliveryOutput{Body: toDeliveryBody(d)}, nil
+}
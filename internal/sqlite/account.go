@@ -0,0 +1,538 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountRepository struct {
+	db *DB
+}
+
+var _ account.Repository = (*AccountRepository)(nil)
+
+func NewAccountRepository(db *DB) AccountRepository {
+	return AccountRepository{
+		db: db,
+	}
+}
+
+func (ar *AccountRepository) AccountByID(ctx context.Context, id string) (account.Account, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(accountColumns...)
+	sb.From("account a")
+	sb.Where(
+		sb.And(
+			sb.EQ("a.id", id),
+			sb.EQ("a.user_id", u.ID),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find account by id",
+		"query", q,
+		"args", args,
+	)
+
+	var dst accountDst
+	if err := ar.db.reader.GetContext(ctx, &dst, q, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return account.Account{}, internal.NewError(internal.ErrorCodeNotFound, "Account not found")
+		}
+
+		return account.Account{}, fmt.Errorf("sqlite.AccountRepository.AccountByID: GetContext: %w", err)
+	}
+
+	return account.Account(dst), nil
+}
+
+func (ar *AccountRepository) ListAccounts(ctx context.Context) ([]account.Account, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(accountColumns...)
+	sb.From("account a")
+	sb.Where(sb.EQ("a.user_id", u.ID))
+	sb.OrderBy("a.name")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List accounts",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []accountDst
+	if err := ar.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.AccountRepository.ListAccounts: SelectContext: %w", err)
+	}
+
+	result := make([]account.Account, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, account.Account(v))
+	}
+
+	return result, nil
+}
+
+func (ar *AccountRepository) CreateAccount(ctx context.Context, c account.CreateAccountReq) (account.Account, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("account")
+	ib.Cols("name", "type", "opening_balance", "currency", "user_id")
+	ib.Values(c.Name, c.Type, c.OpeningBalance, c.Currency, u.ID)
+	ib.Returning(
+		"id",
+		"name",
+		"type",
+		"opening_balance",
+		"currency",
+		"opening_balance AS balance",
+		"created_at",
+		"updated_at",
+	)
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert account",
+		"query", q,
+		"args", args,
+	)
+
+	var dst accountDst
+	if err := ar.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return account.Account{}, fmt.Errorf("sqlite.AccountRepository.CreateAccount: GetContext: %w", err)
+	}
+
+	return account.Account(dst), nil
+}
+
+func (ar *AccountRepository) UpdateAccount(ctx context.Context, a account.UpdateAccountReq) (account.Account, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("account")
+	ub.Set(ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+
+	if a.Name != nil {
+		ub.SetMore(ub.Assign("name", a.Name))
+	}
+	if a.Type != nil {
+		ub.SetMore(ub.Assign("type", a.Type))
+	}
+	if a.OpeningBalance != nil {
+		ub.SetMore(ub.Assign("opening_balance", a.OpeningBalance))
+	}
+
+	ub.Where(
+		ub.And(
+			ub.EQ("id", a.ID),
+			ub.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Update account",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := ar.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return account.Account{}, fmt.Errorf("sqlite.AccountRepository.UpdateAccount: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return account.Account{}, fmt.Errorf("sqlite.AccountRepository.UpdateAccount: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return account.Account{}, internal.NewError(internal.ErrorCodeNotFound, "Account not found")
+	}
+
+	return ar.AccountByID(ctx, a.ID)
+}
+
+// DeleteAccount deletes an account without transfers. Its expenses are kept
+// without an account.
+func (ar *AccountRepository) DeleteAccount(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	return ar.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		sb := sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select("COUNT(*)")
+		sb.From("transfer")
+		sb.Where(
+			sb.And(
+				sb.EQ("user_id", u.ID),
+				sb.Or(
+					sb.EQ("from_account_id", id),
+					sb.EQ("to_account_id", id),
+				),
+			),
+		)
+
+		q, args := sb.Build()
+
+		logger.Infow(
+			"Count transfers of account",
+			"query", q,
+			"args", args,
+		)
+
+		var transfers int64
+		if err := tx.GetContext(ctx, &transfers, q, args...); err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteAccount: GetContext: %w", err)
+		}
+		if transfers > 0 {
+			return internal.NewError(internal.ErrorCodeConflict, "Account has transfers")
+		}
+
+		// the reconciled expenses would stay locked without their
+		// reconciliation
+		sb = sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select("COUNT(*)")
+		sb.From("reconciliation")
+		sb.Where(
+			sb.EQ("user_id", u.ID),
+			sb.EQ("account_id", id),
+		)
+
+		q, args = sb.Build()
+
+		logger.Infow(
+			"Count reconciliations of account",
+			"query", q,
+			"args", args,
+		)
+
+		var reconciliations int64
+		if err := tx.GetContext(ctx, &reconciliations, q, args...); err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteAccount: GetContext: %w", err)
+		}
+		if reconciliations > 0 {
+			return internal.NewError(internal.ErrorCodeConflict, "Account has reconciliations")
+		}
+
+		db := sqlbuilder.SQLite.NewDeleteBuilder()
+		db.DeleteFrom("account")
+		db.Where(
+			db.And(
+				db.EQ("id", id),
+				db.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args = db.Build()
+
+		logger.Infow(
+			"Delete account",
+			"query", q,
+			"args", args,
+		)
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteAccount: ExecContext: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteAccount: RowsAffected: %w", err)
+		}
+		if affected == 0 {
+			return internal.NewError(internal.ErrorCodeNotFound, "Account not found")
+		}
+
+		return nil
+	})
+}
+
+// ListTransfers lists the transfers from and to the account.
+func (ar *AccountRepository) ListTransfers(ctx context.Context, accountID string) ([]account.Transfer, error) {
+	if _, err := ar.AccountByID(ctx, accountID); err != nil {
+		return nil, fmt.Errorf("sqlite.AccountRepository.ListTransfers: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(transferColumns...)
+	sb.From("transfer")
+	sb.Where(
+		sb.And(
+			sb.EQ("user_id", u.ID),
+			sb.Or(
+				sb.EQ("from_account_id", accountID),
+				sb.EQ("to_account_id", accountID),
+			),
+		),
+	)
+	sb.OrderBy("date", "created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List transfers",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []transferDst
+	if err := ar.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.AccountRepository.ListTransfers: SelectContext: %w", err)
+	}
+
+	result := make([]account.Transfer, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, account.Transfer(v))
+	}
+
+	return result, nil
+}
+
+func (ar *AccountRepository) CreateTransfer(ctx context.Context, c account.CreateTransferReq) (account.Transfer, error) {
+	for _, id := range []string{c.FromAccountID, c.ToAccountID} {
+		if _, err := ar.AccountByID(ctx, id); err != nil {
+			return account.Transfer{}, fmt.Errorf("sqlite.AccountRepository.CreateTransfer: %w", err)
+		}
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("transfer")
+	ib.Cols("amount", "date", "note", "from_account_id", "to_account_id", "user_id")
+	ib.Values(c.Amount, c.Date, c.Note, c.FromAccountID, c.ToAccountID, u.ID)
+	ib.Returning(transferColumns...)
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert transfer",
+		"query", q,
+		"args", args,
+	)
+
+	var dst transferDst
+	if err := ar.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return account.Transfer{}, fmt.Errorf("sqlite.AccountRepository.CreateTransfer: GetContext: %w", err)
+	}
+
+	return account.Transfer(dst), nil
+}
+
+// DeleteTransfer deletes the transfer. A transfer that goal contributions are
+// linked to can't be deleted, the contributions have to be deleted first.
+func (ar *AccountRepository) DeleteTransfer(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	return ar.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		sb := sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select("COUNT(*)")
+		sb.From("goal_contribution")
+		sb.Where(
+			sb.EQ("source_type", goal.ContributionSourceTransfer),
+			sb.EQ("source_id", id),
+			sb.EQ("user_id", u.ID),
+		)
+
+		q, args := sb.Build()
+
+		logger.Infow(
+			"Count contributions of transfer",
+			"query", q,
+			"args", args,
+		)
+
+		var contributions int64
+		if err := tx.GetContext(ctx, &contributions, q, args...); err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteTransfer: GetContext: %w", err)
+		}
+		if contributions > 0 {
+			return internal.NewError(internal.ErrorCodeConflict, "Transfer is linked to goal contributions")
+		}
+
+		db := sqlbuilder.SQLite.NewDeleteBuilder()
+		db.DeleteFrom("transfer")
+		db.Where(
+			db.And(
+				db.EQ("id", id),
+				db.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args = db.Build()
+
+		logger.Infow(
+			"Delete transfer",
+			"query", q,
+			"args", args,
+		)
+
+		result, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteTransfer: ExecContext: %w", err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("sqlite.AccountRepository.DeleteTransfer: RowsAffected: %w", err)
+		}
+		if affected == 0 {
+			return internal.NewError(internal.ErrorCodeNotFound, "Transfer not found")
+		}
+
+		return nil
+	})
+}
+
+// ListLedgerEntries lists the expenses and transfers of the account with the
+// running balance after each of them. The running balance starts from the
+// opening balance even when the list is limited to a date range.
+func (ar *AccountRepository) ListLedgerEntries(ctx context.Context, l account.ListLedgerEntriesReq) ([]account.LedgerEntry, error) {
+	a, err := ar.AccountByID(ctx, l.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.AccountRepository.ListLedgerEntries: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	q := `
+		WITH entries AS (
+			SELECT 'expense' AS kind, id AS reference_id, name, -amount AS amount, date, created_at
+			FROM expense
+			WHERE account_id = ? AND user_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT 'transfer_out', id, note, -amount, date, created_at
+			FROM transfer
+			WHERE from_account_id = ? AND user_id = ?
+			UNION ALL
+			SELECT 'transfer_in', id, note, amount, date, created_at
+			FROM transfer
+			WHERE to_account_id = ? AND user_id = ?
+		),
+		ledger AS (
+			SELECT
+				kind,
+				reference_id,
+				name,
+				amount,
+				date,
+				created_at,
+				? + SUM(amount) OVER (ORDER BY date, created_at, reference_id ROWS UNBOUNDED PRECEDING) AS balance
+			FROM entries
+		)
+		SELECT kind, reference_id, name, amount, date, balance
+		FROM ledger
+		WHERE (? = '' OR date >= ?) AND (? = '' OR date <= ?)
+		ORDER BY date, created_at, reference_id`
+	args := []any{
+		a.ID, u.ID,
+		a.ID, u.ID,
+		a.ID, u.ID,
+		a.OpeningBalance,
+		l.StartDate, l.StartDate,
+		l.EndDate, l.EndDate,
+	}
+
+	logger.Infow(
+		"List ledger entries",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		Kind        account.LedgerEntryKind `db:"kind"`
+		ReferenceID string                  `db:"reference_id"`
+		Name        string                  `db:"name"`
+		Amount      int64                   `db:"amount"`
+		Date        time.Time               `db:"date"`
+		Balance     int64                   `db:"balance"`
+	}
+	if err := ar.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.AccountRepository.ListLedgerEntries: SelectContext: %w", err)
+	}
+
+	result := make([]account.LedgerEntry, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, account.LedgerEntry(v))
+	}
+
+	return result, nil
+}
+
+var accountColumns = []string{
+	"a.id",
+	"a.name",
+	"a.type",
+	"a.opening_balance",
+	"a.currency",
+	`a.opening_balance
+		+ COALESCE((SELECT SUM(t.amount) FROM transfer t WHERE t.to_account_id = a.id), 0)
+		- COALESCE((SELECT SUM(t.amount) FROM transfer t WHERE t.from_account_id = a.id), 0)
+		- COALESCE((SELECT SUM(e.amount) FROM expense e WHERE e.account_id = a.id AND e.deleted_at IS NULL), 0) AS balance`,
+	"a.created_at",
+	"a.updated_at",
+}
+
+type accountDst struct {
+	ID             string              `db:"id"`
+	Name           string              `db:"name"`
+	Type           account.AccountType `db:"type"`
+	OpeningBalance int64               `db:"opening_balance"`
+	Currency       string              `db:"currency"`
+	Balance        int64               `db:"balance"`
+	CreatedAt      time.Time           `db:"created_at"`
+	UpdatedAt      time.Time           `db:"updated_at"`
+}
+
+var transferColumns = []string{
+	"id",
+	"from_account_id",
+	"to_account_id",
+	"amount",
+	"date",
+	"note",
+	"created_at",
+	"updated_at",
+}
+
+type transferDst struct {
+	ID            string    `db:"id"`
+	FromAccountID string    `db:"from_account_id"`
+	ToAccountID   string    `db:"to_account_id"`
+	Amount        int64     `db:"amount"`
+	Date          time.Time `db:"date"`
+	Note          string    `db:"note"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
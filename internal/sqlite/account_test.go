@@ -0,0 +1,315 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccount(t *testing.T) {
+	dh := newDBHelper(t, "test_account.db")
+	defer dh.clean()
+
+	ar := sqlite.NewAccountRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	wallet, err := ar.CreateAccount(ctxWithUser1, account.CreateAccountReq{
+		Name:           "Wallet",
+		Type:           account.AccountTypeCash,
+		OpeningBalance: 1000,
+		Currency:       "PHP",
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, wallet.ID)
+	assert.Equal(t, "Wallet", wallet.Name)
+	assert.Equal(t, account.AccountTypeCash, wallet.Type)
+	assert.Equal(t, int64(1000), wallet.OpeningBalance)
+	assert.Equal(t, int64(1000), wallet.Balance)
+	assert.Equal(t, "PHP", wallet.Currency)
+	assert.WithinDuration(t, time.Now(), wallet.CreatedAt, time.Second*5)
+
+	bank, err := ar.CreateAccount(ctxWithUser1, account.CreateAccountReq{
+		Name:           "Bank",
+		Type:           account.AccountTypeBank,
+		OpeningBalance: 50000,
+		Currency:       "PHP",
+	})
+	assert.Nil(t, err)
+
+	t.Run("list accounts by name", func(t *testing.T) {
+		accounts, err := ar.ListAccounts(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.Equal(t, []account.Account{bank, wallet}, accounts)
+
+		accounts, err = ar.ListAccounts(ctxWithUser2)
+		assert.Nil(t, err)
+		assert.Empty(t, accounts)
+	})
+
+	t.Run("can't access account of other user", func(t *testing.T) {
+		_, err := ar.AccountByID(ctxWithUser2, wallet.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Account not found"), err)
+
+		_, err = ar.UpdateAccount(ctxWithUser2, account.UpdateAccountReq{ID: wallet.ID, Name: toPtr(t, "Mine")})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Account not found"), err)
+
+		err = ar.DeleteAccount(ctxWithUser2, wallet.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Account not found"), err)
+	})
+
+	t.Run("update account", func(t *testing.T) {
+		got, err := ar.UpdateAccount(ctxWithUser1, account.UpdateAccountReq{
+			ID:             wallet.ID,
+			Name:           toPtr(t, "Cash"),
+			OpeningBalance: toPtr(t, int64(2000)),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "Cash", got.Name)
+		assert.Equal(t, account.AccountTypeCash, got.Type)
+		assert.Equal(t, int64(2000), got.OpeningBalance)
+		assert.Equal(t, int64(2000), got.Balance)
+	})
+
+	t.Run("transfers", func(t *testing.T) {
+		transfer, err := ar.CreateTransfer(ctxWithUser1, account.CreateTransferReq{
+			FromAccountID: bank.ID,
+			ToAccountID:   wallet.ID,
+			Amount:        5000,
+			Date:          "2006-01-02",
+			Note:          "ATM",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, bank.ID, transfer.FromAccountID)
+		assert.Equal(t, wallet.ID, transfer.ToAccountID)
+		assert.Equal(t, time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC), transfer.Date)
+
+		_, err = ar.CreateTransfer(ctxWithUser2, account.CreateTransferReq{
+			FromAccountID: bank.ID,
+			ToAccountID:   wallet.ID,
+			Amount:        5000,
+			Date:          "2006-01-02",
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+
+		transfers, err := ar.ListTransfers(ctxWithUser1, wallet.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []account.Transfer{transfer}, transfers)
+
+		got, err := ar.AccountByID(ctxWithUser1, wallet.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7000), got.Balance)
+
+		got, err = ar.AccountByID(ctxWithUser1, bank.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(45000), got.Balance)
+
+		err = ar.DeleteAccount(ctxWithUser1, bank.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Account has transfers"), err)
+
+		err = ar.DeleteTransfer(ctxWithUser2, transfer.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Transfer not found"), err)
+
+		err = ar.DeleteTransfer(ctxWithUser1, transfer.ID)
+		assert.Nil(t, err)
+
+		got, err = ar.AccountByID(ctxWithUser1, bank.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(50000), got.Balance)
+	})
+
+	t.Run("delete account", func(t *testing.T) {
+		err := ar.DeleteAccount(ctxWithUser1, bank.ID)
+		assert.Nil(t, err)
+
+		_, err = ar.AccountByID(ctxWithUser1, bank.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+}
+
+func TestAccountLedger(t *testing.T) {
+	dh := newDBHelper(t, "test_account_ledger.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ar := sqlite.NewAccountRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+
+	bank, err := ar.CreateAccount(ctxWithUser, account.CreateAccountReq{
+		Name:           "Bank",
+		Type:           account.AccountTypeBank,
+		OpeningBalance: 10000,
+		Currency:       "PHP",
+	})
+	assert.Nil(t, err)
+
+	card, err := ar.CreateAccount(ctxWithUser, account.CreateAccountReq{
+		Name:     "Card",
+		Type:     account.AccountTypeCreditCard,
+		Currency: "PHP",
+	})
+	assert.Nil(t, err)
+
+	groceries, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Groceries",
+		Amount:     3000,
+		Date:       "2006-01-05",
+		CategoryID: categories[0].ID,
+		AccountID:  &card.ID,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, &card.ID, groceries.AccountID)
+
+	lunch, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Lunch",
+		Amount:     500,
+		Date:       "2006-01-02",
+		CategoryID: categories[0].ID,
+		AccountID:  &bank.ID,
+	})
+	assert.Nil(t, err)
+
+	payment, err := ar.CreateTransfer(ctxWithUser, account.CreateTransferReq{
+		FromAccountID: bank.ID,
+		ToAccountID:   card.ID,
+		Amount:        3000,
+		Date:          "2006-01-10",
+		Note:          "Card payment",
+	})
+	assert.Nil(t, err)
+
+	// Not paid with any account.
+	_, err = er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Coffee",
+		Amount:     200,
+		Date:       "2006-01-03",
+		CategoryID: categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	// Trashed expenses don't count.
+	trashed, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Trashed",
+		Amount:     100,
+		Date:       "2006-01-04",
+		CategoryID: categories[0].ID,
+		AccountID:  &bank.ID,
+	})
+	assert.Nil(t, err)
+	_, err = er.DeleteExpense(ctxWithUser, expense.DeleteExpenseReq{ID: trashed.ID})
+	assert.Nil(t, err)
+
+	t.Run("can't use account of other user", func(t *testing.T) {
+		otherCategories := createCategories(t, dh.db, users[1])
+		_, err := er.CreateExpense(user.ContextWithUser(ctxWithLogger, users[1]), expense.CreateExpenseReq{
+			Name:       "Other",
+			Amount:     100,
+			Date:       "2006-01-04",
+			CategoryID: otherCategories[0].ID,
+			AccountID:  &bank.ID,
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+		assert.Equal(t, "Account not found", internal.GetErrorMessage(err))
+	})
+
+	t.Run("running balance", func(t *testing.T) {
+		entries, err := ar.ListLedgerEntries(ctxWithUser, account.ListLedgerEntriesReq{AccountID: bank.ID})
+		assert.Nil(t, err)
+		assert.Equal(t, []account.LedgerEntry{
+			{
+				Kind:        account.LedgerEntryKindExpense,
+				ReferenceID: lunch.ID,
+				Name:        "Lunch",
+				Amount:      -500,
+				Date:        time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
+				Balance:     9500,
+			},
+			{
+				Kind:        account.LedgerEntryKindTransferOut,
+				ReferenceID: payment.ID,
+				Name:        "Card payment",
+				Amount:      -3000,
+				Date:        time.Date(2006, time.January, 10, 0, 0, 0, 0, time.UTC),
+				Balance:     6500,
+			},
+		}, entries)
+
+		entries, err = ar.ListLedgerEntries(ctxWithUser, account.ListLedgerEntriesReq{AccountID: card.ID})
+		assert.Nil(t, err)
+		assert.Equal(t, []account.LedgerEntry{
+			{
+				Kind:        account.LedgerEntryKindExpense,
+				ReferenceID: groceries.ID,
+				Name:        "Groceries",
+				Amount:      -3000,
+				Date:        time.Date(2006, time.January, 5, 0, 0, 0, 0, time.UTC),
+				Balance:     -3000,
+			},
+			{
+				Kind:        account.LedgerEntryKindTransferIn,
+				ReferenceID: payment.ID,
+				Name:        "Card payment",
+				Amount:      3000,
+				Date:        time.Date(2006, time.January, 10, 0, 0, 0, 0, time.UTC),
+				Balance:     0,
+			},
+		}, entries)
+	})
+
+	t.Run("running balance within dates", func(t *testing.T) {
+		entries, err := ar.ListLedgerEntries(ctxWithUser, account.ListLedgerEntriesReq{
+			AccountID: bank.ID,
+			StartDate: "2006-01-03",
+			EndDate:   "2006-01-31",
+		})
+		assert.Nil(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, payment.ID, entries[0].ReferenceID)
+		assert.Equal(t, int64(6500), entries[0].Balance)
+	})
+
+	t.Run("transfers are not spending", func(t *testing.T) {
+		totals, err := cr.ListCategoryTotals(ctxWithUser, category.CategoryTotalsReq{
+			StartDate: "2006-01-01",
+			EndDate:   "2006-01-31",
+		})
+		assert.Nil(t, err)
+		for _, total := range totals {
+			if total.ID == categories[0].ID {
+				assert.Equal(t, int64(3700), total.Total)
+			}
+		}
+	})
+
+	t.Run("account balance", func(t *testing.T) {
+		got, err := ar.AccountByID(ctxWithUser, bank.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(6500), got.Balance)
+	})
+
+	t.Run("expenses are kept when the account is deleted", func(t *testing.T) {
+		assert.Nil(t, ar.DeleteTransfer(ctxWithUser, payment.ID))
+		assert.Nil(t, ar.DeleteAccount(ctxWithUser, card.ID))
+
+		got, err := er.ExpenseByID(ctxWithUser, groceries.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, got.AccountID)
+	})
+}
@@ -0,0 +1,296 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+type AnomalyRepository struct {
+	db *DB
+}
+
+var _ anomaly.Repository = (*AnomalyRepository)(nil)
+
+func NewAnomalyRepository(db *DB) AnomalyRepository {
+	return AnomalyRepository{
+		db: db,
+	}
+}
+
+func (ar *AnomalyRepository) ListExpenses(ctx context.Context, l anomaly.ListExpensesReq) ([]anomaly.Expense, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"id",
+		"name",
+		"amount",
+		"date",
+		"category_id",
+	)
+	sb.From("expense")
+	sb.Where(
+		sb.And(
+			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
+			sb.Between("date", l.StartDate, l.EndDate),
+		),
+	)
+	sb.OrderBy("date", "created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List expenses for anomaly detection",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []anomalyExpenseDst
+	if err := ar.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.AnomalyRepository.ListExpenses: SelectContext: %w", err)
+	}
+
+	result := make([]anomaly.Expense, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, anomaly.Expense(v))
+	}
+
+	return result, nil
+}
+
+func (ar *AnomalyRepository) CreateFlags(ctx context.Context, expenseID string, findings []anomaly.Finding) ([]anomaly.Flag, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var result []anomaly.Flag
+	err := ar.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("expense_flag")
+		ib.Cols(
+			"kind",
+			"score",
+			"reason",
+			"expense_id",
+			"related_expense_id",
+			"user_id",
+		)
+		for _, f := range findings {
+			ib.Values(
+				f.Kind,
+				f.Score,
+				f.Reason,
+				expenseID,
+				f.RelatedExpenseID,
+				u.ID,
+			)
+		}
+		ib.Returning("id")
+
+		q, args := ib.Build()
+
+		logger.Infow(
+			"Insert expense flags",
+			"query", q,
+			"args", args,
+		)
+
+		var ids []string
+		if err := tx.SelectContext(ctx, &ids, q, args...); err != nil {
+			return fmt.Errorf("SelectContext: %w", err)
+		}
+
+		sb := flagsQuery()
+		sb.Where(
+			sb.And(
+				sb.In("f.id", sqlbuilder.List(ids)),
+				sb.EQ("f.user_id", u.ID),
+			),
+		)
+		sb.OrderBy("f.kind")
+
+		var err error
+		result, err = selectFlags(ctx, tx, sb)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.AnomalyRepository.CreateFlags: %w", err)
+	}
+
+	return result, nil
+}
+
+// ListFlags lists the flags, newest first, of the expenses that are not in
+// the trash.
+func (ar *AnomalyRepository) ListFlags(ctx context.Context, l anomaly.ListFlagsReq) ([]anomaly.Flag, error) {
+	u := user.FromContext(ctx)
+
+	sb := flagsQuery()
+	sb.Where(
+		sb.EQ("f.user_id", u.ID),
+		sb.IsNull("e.deleted_at"),
+	)
+	if l.Reviewed {
+		sb.Where(sb.IsNotNull("f.reviewed_at"))
+	} else {
+		sb.Where(sb.IsNull("f.reviewed_at"))
+	}
+	sb.OrderBy("f.created_at", "f.id").Desc()
+	sb.Limit(l.Limit)
+	sb.Offset(l.Offset)
+
+	result, err := selectFlags(ctx, ar.db.reader, sb)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.AnomalyRepository.ListFlags: %w", err)
+	}
+
+	return result, nil
+}
+
+func (ar *AnomalyRepository) ReviewFlag(ctx context.Context, id string) (anomaly.Flag, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("expense_flag")
+	ub.Set(
+		ub.Assign("reviewed_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+	)
+	ub.Where(
+		ub.And(
+			ub.EQ("id", id),
+			ub.EQ("user_id", u.ID),
+			ub.IsNull("reviewed_at"),
+		),
+	)
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Review expense flag",
+		"query", q,
+		"args", args,
+	)
+
+	// reviewing a flag twice keeps the time of the first review
+	if _, err := ar.db.readerWriter.ExecContext(ctx, q, args...); err != nil {
+		return anomaly.Flag{}, fmt.Errorf("sqlite.AnomalyRepository.ReviewFlag: ExecContext: %w", err)
+	}
+
+	sb := flagsQuery()
+	sb.Where(
+		sb.EQ("f.id", id),
+		sb.EQ("f.user_id", u.ID),
+		sb.IsNull("e.deleted_at"),
+	)
+
+	flags, err := selectFlags(ctx, ar.db.readerWriter, sb)
+	if err != nil {
+		return anomaly.Flag{}, fmt.Errorf("sqlite.AnomalyRepository.ReviewFlag: %w", err)
+	}
+	if len(flags) == 0 {
+		return anomaly.Flag{}, internal.NewError(internal.ErrorCodeNotFound, "Flag not found")
+	}
+
+	return flags[0], nil
+}
+
+func flagsQuery() *sqlbuilder.SelectBuilder {
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"f.id",
+		"f.kind",
+		"f.score",
+		"f.reason",
+		"f.related_expense_id",
+		"f.reviewed_at",
+		"f.created_at",
+		"f.updated_at",
+		sb.As("e.id", "expense_id"),
+		sb.As("e.name", "expense_name"),
+		sb.As("e.amount", "expense_amount"),
+		sb.As("e.date", "expense_date"),
+		sb.As("e.category_id", "expense_category_id"),
+	)
+	sb.From("expense_flag f")
+	sb.Join("expense e", "e.id = f.expense_id")
+	return sb
+}
+
+func selectFlags(ctx context.Context, q sqlx.QueryerContext, sb *sqlbuilder.SelectBuilder) ([]anomaly.Flag, error) {
+	logger := logger.FromContext(ctx)
+
+	query, args := sb.Build()
+
+	logger.Infow(
+		"List expense flags",
+		"query", query,
+		"args", args,
+	)
+
+	var dst []flagDst
+	if err := sqlx.SelectContext(ctx, q, &dst, query, args...); err != nil {
+		return nil, fmt.Errorf("SelectContext: %w", err)
+	}
+
+	result := make([]anomaly.Flag, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, v.toFlag())
+	}
+
+	return result, nil
+}
+
+type anomalyExpenseDst struct {
+	ID         string    `db:"id"`
+	Name       string    `db:"name"`
+	Amount     int64     `db:"amount"`
+	Date       time.Time `db:"date"`
+	CategoryID string    `db:"category_id"`
+}
+
+type flagDst struct {
+	ID                string       `db:"id"`
+	Kind              anomaly.Kind `db:"kind"`
+	Score             float64      `db:"score"`
+	Reason            string       `db:"reason"`
+	RelatedExpenseID  *string      `db:"related_expense_id"`
+	ReviewedAt        *time.Time   `db:"reviewed_at"`
+	CreatedAt         time.Time    `db:"created_at"`
+	UpdatedAt         time.Time    `db:"updated_at"`
+	ExpenseID         string       `db:"expense_id"`
+	ExpenseName       string       `db:"expense_name"`
+	ExpenseAmount     int64        `db:"expense_amount"`
+	ExpenseDate       time.Time    `db:"expense_date"`
+	ExpenseCategoryID string       `db:"expense_category_id"`
+}
+
+func (d flagDst) toFlag() anomaly.Flag {
+	return anomaly.Flag{
+		ID: d.ID,
+		Expense: anomaly.Expense{
+			ID:         d.ExpenseID,
+			Name:       d.ExpenseName,
+			Amount:     d.ExpenseAmount,
+			Date:       d.ExpenseDate,
+			CategoryID: d.ExpenseCategoryID,
+		},
+		Kind:             d.Kind,
+		Score:            d.Score,
+		Reason:           d.Reason,
+		RelatedExpenseID: d.RelatedExpenseID,
+		ReviewedAt:       d.ReviewedAt,
+		CreatedAt:        d.CreatedAt,
+		UpdatedAt:        d.UpdatedAt,
+	}
+}
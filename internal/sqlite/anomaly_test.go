@@ -0,0 +1,148 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/anomaly"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnomaly(t *testing.T) {
+	dh := newDBHelper(t, "test_anomaly.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ar := sqlite.NewAnomalyRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	createExpense := func(name string, amount int64, date string) expense.Expense {
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       name,
+			Amount:     amount,
+			Date:       date,
+			CategoryID: categories[0].ID,
+		})
+		assert.Nil(t, err)
+		return e
+	}
+
+	netflix := createExpense("Netflix", 500, "2006-01-28")
+	duplicate := createExpense("Netflix", 500, "2006-01-30")
+	party := createExpense("Party", 9000, "2006-01-31")
+
+	t.Run("list expenses within range", func(t *testing.T) {
+		expenses, err := ar.ListExpenses(ctxWithUser1, anomaly.ListExpensesReq{
+			StartDate: "2006-01-29",
+			EndDate:   "2006-01-31",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []anomaly.Expense{
+			{
+				ID:         duplicate.ID,
+				Name:       "Netflix",
+				Amount:     500,
+				Date:       duplicate.Date,
+				CategoryID: categories[0].ID,
+			},
+			{
+				ID:         party.ID,
+				Name:       "Party",
+				Amount:     9000,
+				Date:       party.Date,
+				CategoryID: categories[0].ID,
+			},
+		}, expenses)
+
+		expenses, err = ar.ListExpenses(ctxWithUser2, anomaly.ListExpensesReq{
+			StartDate: "2006-01-01",
+			EndDate:   "2006-01-31",
+		})
+		assert.Nil(t, err)
+		assert.Empty(t, expenses)
+	})
+
+	duplicateFlags, err := ar.CreateFlags(ctxWithUser1, duplicate.ID, []anomaly.Finding{
+		{
+			Kind:             anomaly.KindDuplicate,
+			Score:            1,
+			Reason:           "Same name and amount as an expense on 2006-01-28",
+			RelatedExpenseID: &netflix.ID,
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, duplicateFlags, 1)
+	assert.Equal(t, duplicate.ID, duplicateFlags[0].Expense.ID)
+	assert.Equal(t, "Netflix", duplicateFlags[0].Expense.Name)
+	assert.Equal(t, anomaly.KindDuplicate, duplicateFlags[0].Kind)
+	assert.Equal(t, &netflix.ID, duplicateFlags[0].RelatedExpenseID)
+	assert.Nil(t, duplicateFlags[0].ReviewedAt)
+	assert.WithinDuration(t, time.Now(), duplicateFlags[0].CreatedAt, time.Second*5)
+
+	partyFlags, err := ar.CreateFlags(ctxWithUser1, party.ID, []anomaly.Finding{
+		{
+			Kind:   anomaly.KindOutlier,
+			Score:  18,
+			Reason: "Amount is 18.00x the median of 500 for the category",
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, partyFlags, 1)
+	assert.Equal(t, 18.0, partyFlags[0].Score)
+	assert.Nil(t, partyFlags[0].RelatedExpenseID)
+
+	t.Run("review queue", func(t *testing.T) {
+		flags, err := ar.ListFlags(ctxWithUser1, anomaly.ListFlagsReq{ListOptions: internal.ListOptions{Limit: 10}})
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, append(duplicateFlags, partyFlags...), flags)
+
+		flags, err = ar.ListFlags(ctxWithUser2, anomaly.ListFlagsReq{ListOptions: internal.ListOptions{Limit: 10}})
+		assert.Nil(t, err)
+		assert.Empty(t, flags)
+	})
+
+	t.Run("can't review flag of other user", func(t *testing.T) {
+		_, err := ar.ReviewFlag(ctxWithUser2, partyFlags[0].ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Flag not found"), err)
+	})
+
+	t.Run("reviewed flags leave the queue", func(t *testing.T) {
+		reviewed, err := ar.ReviewFlag(ctxWithUser1, partyFlags[0].ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, reviewed.ReviewedAt)
+
+		// reviewing again keeps the first review
+		again, err := ar.ReviewFlag(ctxWithUser1, partyFlags[0].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, reviewed, again)
+
+		flags, err := ar.ListFlags(ctxWithUser1, anomaly.ListFlagsReq{ListOptions: internal.ListOptions{Limit: 10}})
+		assert.Nil(t, err)
+		assert.Equal(t, duplicateFlags, flags)
+
+		flags, err = ar.ListFlags(ctxWithUser1, anomaly.ListFlagsReq{Reviewed: true, ListOptions: internal.ListOptions{Limit: 10}})
+		assert.Nil(t, err)
+		assert.Equal(t, []anomaly.Flag{reviewed}, flags)
+	})
+
+	t.Run("flags of deleted expenses are hidden", func(t *testing.T) {
+		_, err := er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: duplicate.ID})
+		assert.Nil(t, err)
+
+		flags, err := ar.ListFlags(ctxWithUser1, anomaly.ListFlagsReq{ListOptions: internal.ListOptions{Limit: 10}})
+		assert.Nil(t, err)
+		assert.Empty(t, flags)
+	})
+}
@@ -0,0 +1,276 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/attachment"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+type AttachmentRepository struct {
+	db *DB
+}
+
+var _ attachment.Repository = (*AttachmentRepository)(nil)
+
+func NewAttachmentRepository(db *DB) AttachmentRepository {
+	return AttachmentRepository{
+		db: db,
+	}
+}
+
+func (ar *AttachmentRepository) AttachmentByID(ctx context.Context, id string) (attachment.Attachment, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(attachmentColumns...)
+	sb.From("attachment")
+	sb.Where(
+		sb.And(
+			sb.EQ("id", id),
+			sb.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find attachment by id",
+		"query", q,
+		"args", args,
+	)
+
+	var dst attachmentDst
+	if err := ar.db.reader.GetContext(ctx, &dst, q, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return attachment.Attachment{}, internal.NewError(internal.ErrorCodeNotFound, "Attachment not found")
+		}
+
+		return attachment.Attachment{}, fmt.Errorf("sqlite.AttachmentRepository.AttachmentByID: GetContext: %w", err)
+	}
+
+	return attachment.Attachment(dst), nil
+}
+
+func (ar *AttachmentRepository) ListAttachments(ctx context.Context, l attachment.ListAttachmentsReq) ([]attachment.Attachment, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(attachmentColumns...)
+	sb.From("attachment")
+	sb.Where(sb.EQ("user_id", u.ID))
+	if l.ExpenseID != "" {
+		sb.Where(sb.EQ("expense_id", l.ExpenseID))
+	} else {
+		sb.Where(sb.EQ("expense_group_id", l.ExpenseGroupID))
+	}
+	sb.OrderBy("created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List attachments",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []attachmentDst
+	if err := ar.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.AttachmentRepository.ListAttachments: SelectContext: %w", err)
+	}
+
+	result := make([]attachment.Attachment, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, attachment.Attachment(v))
+	}
+
+	return result, nil
+}
+
+// CreateAttachment only attaches to an expense or expense group of the user
+// that is not in the trash.
+func (ar *AttachmentRepository) CreateAttachment(ctx context.Context, c attachment.CreateAttachmentReq) (attachment.Attachment, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ownerTable, ownerID, notFound := "expense", c.ExpenseID, "Expense not found"
+	if c.ExpenseGroupID != "" {
+		ownerTable, ownerID, notFound = "expense_group", c.ExpenseGroupID, "Expense group not found"
+	}
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("COUNT(*)")
+	sb.From(ownerTable)
+	sb.Where(
+		sb.EQ("id", ownerID),
+		sb.EQ("user_id", u.ID),
+	)
+	if ownerTable == "expense" {
+		sb.Where(sb.IsNull("deleted_at"))
+	}
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find owner of attachment",
+		"query", q,
+		"args", args,
+	)
+
+	var count int64
+	if err := ar.db.reader.GetContext(ctx, &count, q, args...); err != nil {
+		return attachment.Attachment{}, fmt.Errorf("sqlite.AttachmentRepository.CreateAttachment: GetContext: %w", err)
+	}
+	if count == 0 {
+		return attachment.Attachment{}, internal.NewError(internal.ErrorCodeNotFound, notFound)
+	}
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("attachment")
+	ib.Cols(
+		"file_name",
+		"content_type",
+		"size",
+		"has_thumbnail",
+		"expense_id",
+		"expense_group_id",
+		"user_id",
+	)
+	ib.Values(
+		c.FileName,
+		c.ContentType,
+		c.Size,
+		c.HasThumbnail,
+		nullString(c.ExpenseID),
+		nullString(c.ExpenseGroupID),
+		u.ID,
+	)
+	ib.Returning(attachmentColumns...)
+
+	q, args = ib.Build()
+
+	logger.Infow(
+		"Insert attachment",
+		"query", q,
+		"args", args,
+	)
+
+	var dst attachmentDst
+	if err := ar.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return attachment.Attachment{}, fmt.Errorf("sqlite.AttachmentRepository.CreateAttachment: GetContext: %w", err)
+	}
+
+	return attachment.Attachment(dst), nil
+}
+
+func (ar *AttachmentRepository) DeleteAttachment(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("attachment")
+	db.Where(
+		db.And(
+			db.EQ("id", id),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete attachment",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := ar.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.AttachmentRepository.DeleteAttachment: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.AttachmentRepository.DeleteAttachment: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Attachment not found")
+	}
+
+	return nil
+}
+
+func (ar *AttachmentRepository) DeleteOrphanedAttachments(ctx context.Context) ([]attachment.Attachment, error) {
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("attachment")
+	db.Where(
+		db.And(
+			db.IsNull("expense_id"),
+			db.IsNull("expense_group_id"),
+		),
+	)
+	db.SQL("RETURNING " + strings.Join(attachmentColumns, ", "))
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete orphaned attachments",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []attachmentDst
+	if err := ar.db.readerWriter.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.AttachmentRepository.DeleteOrphanedAttachments: SelectContext: %w", err)
+	}
+
+	result := make([]attachment.Attachment, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, attachment.Attachment(v))
+	}
+
+	return result, nil
+}
+
+// nullString stores an empty string as null.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+var attachmentColumns = []string{
+	"id",
+	"expense_id",
+	"expense_group_id",
+	"file_name",
+	"content_type",
+	"size",
+	"has_thumbnail",
+	"created_at",
+	"updated_at",
+}
+
+type attachmentDst struct {
+	ID             string    `db:"id"`
+	ExpenseID      *string   `db:"expense_id"`
+	ExpenseGroupID *string   `db:"expense_group_id"`
+	FileName       string    `db:"file_name"`
+	ContentType    string    `db:"content_type"`
+	Size           int64     `db:"size"`
+	HasThumbnail   bool      `db:"has_thumbnail"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
@@ -0,0 +1,131 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/attachment"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachment(t *testing.T) {
+	dh := newDBHelper(t, "test_attachment.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ar := sqlite.NewAttachmentRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	lunch, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+		Name:       "Lunch",
+		Amount:     500,
+		Date:       "2006-01-02",
+		CategoryID: categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	receipt, err := ar.CreateAttachment(ctxWithUser1, attachment.CreateAttachmentReq{
+		ExpenseID:    lunch.ID,
+		FileName:     "receipt.png",
+		ContentType:  "image/png",
+		Size:         1024,
+		HasThumbnail: true,
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, receipt.ID)
+	assert.Equal(t, &lunch.ID, receipt.ExpenseID)
+	assert.Nil(t, receipt.ExpenseGroupID)
+	assert.Equal(t, "receipt.png", receipt.FileName)
+	assert.Equal(t, "image/png", receipt.ContentType)
+	assert.Equal(t, int64(1024), receipt.Size)
+	assert.True(t, receipt.HasThumbnail)
+	assert.WithinDuration(t, time.Now(), receipt.CreatedAt, time.Second*5)
+
+	invoice, err := ar.CreateAttachment(ctxWithUser1, attachment.CreateAttachmentReq{
+		ExpenseID:   lunch.ID,
+		FileName:    "invoice.pdf",
+		ContentType: "application/pdf",
+		Size:        2048,
+	})
+	assert.Nil(t, err)
+
+	t.Run("list attachments of expense", func(t *testing.T) {
+		attachments, err := ar.ListAttachments(ctxWithUser1, attachment.ListAttachmentsReq{ExpenseID: lunch.ID})
+		assert.Nil(t, err)
+		assert.Equal(t, []attachment.Attachment{receipt, invoice}, attachments)
+
+		attachments, err = ar.ListAttachments(ctxWithUser2, attachment.ListAttachmentsReq{ExpenseID: lunch.ID})
+		assert.Nil(t, err)
+		assert.Empty(t, attachments)
+	})
+
+	t.Run("can't attach to expense of other user", func(t *testing.T) {
+		_, err := ar.CreateAttachment(ctxWithUser2, attachment.CreateAttachmentReq{
+			ExpenseID:   lunch.ID,
+			FileName:    "receipt.png",
+			ContentType: "image/png",
+			Size:        1024,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+
+		_, err = ar.AttachmentByID(ctxWithUser2, receipt.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Attachment not found"), err)
+
+		err = ar.DeleteAttachment(ctxWithUser2, receipt.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Attachment not found"), err)
+	})
+
+	t.Run("delete attachment", func(t *testing.T) {
+		err := ar.DeleteAttachment(ctxWithUser1, invoice.ID)
+		assert.Nil(t, err)
+
+		_, err = ar.AttachmentByID(ctxWithUser1, invoice.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Attachment not found"), err)
+	})
+
+	t.Run("attachments of purged expenses are orphaned", func(t *testing.T) {
+		orphans, err := ar.DeleteOrphanedAttachments(ctxWithLogger)
+		assert.Nil(t, err)
+		assert.Empty(t, orphans)
+
+		_, err = er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: lunch.ID})
+		assert.Nil(t, err)
+
+		_, err = ar.CreateAttachment(ctxWithUser1, attachment.CreateAttachmentReq{
+			ExpenseID:   lunch.ID,
+			FileName:    "receipt.png",
+			ContentType: "image/png",
+			Size:        1024,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+
+		// still attached while the expense is in the trash
+		orphans, err = ar.DeleteOrphanedAttachments(ctxWithLogger)
+		assert.Nil(t, err)
+		assert.Empty(t, orphans)
+
+		_, err = er.PurgeDeletedExpenses(ctxWithLogger, time.Now().Add(time.Hour))
+		assert.Nil(t, err)
+
+		orphans, err = ar.DeleteOrphanedAttachments(ctxWithLogger)
+		assert.Nil(t, err)
+		assert.Len(t, orphans, 1)
+		assert.Equal(t, receipt.ID, orphans[0].ID)
+		assert.Nil(t, orphans[0].ExpenseID)
+
+		_, err = ar.AttachmentByID(ctxWithUser1, receipt.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Attachment not found"), err)
+	})
+}
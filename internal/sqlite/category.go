@@ -9,11 +9,17 @@ import (
 
 	"github.com/cativovo/budget-tracker/internal"
 	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
 	"github.com/cativovo/budget-tracker/internal/logger"
 	"github.com/cativovo/budget-tracker/internal/user"
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
 )
 
+// errCategoryChanged is returned when a category was changed since the version
+// the change expected.
+var errCategoryChanged = internal.NewError(internal.ErrorCodePreconditionFailed, "Category was changed by someone else")
+
 type CategoryRepository struct {
 	db *DB
 }
@@ -36,14 +42,17 @@ func (cr *CategoryRepository) CategoryByID(ctx context.Context, id string) (cate
 		"name",
 		"color",
 		"icon",
+		"version",
 		"created_at",
 		"updated_at",
+		"parent_id",
 	)
 	sb.From("category")
 	sb.Where(
 		sb.And(
 			sb.EQ("id", id),
 			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
 		),
 	)
 
@@ -77,14 +86,16 @@ func (cr *CategoryRepository) ListCategories(ctx context.Context, o internal.Lis
 		"name",
 		"color",
 		"icon",
+		"version",
 		"created_at",
 		"updated_at",
+		"parent_id",
 	)
 	sb.From("category")
 	sb.Where(
-		sb.EQ(
-			"user_id",
-			u.ID,
+		sb.And(
+			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
 		),
 	)
 	sb.Limit(o.Limit)
@@ -131,14 +142,17 @@ func (cr *CategoryRepository) categoryByName(ctx context.Context, name string) (
 		"name",
 		"color",
 		"icon",
+		"version",
 		"created_at",
 		"updated_at",
+		"parent_id",
 	)
 	sb.From("category")
 	sb.Where(
 		sb.And(
 			sb.EQ("name", name),
 			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
 		),
 	)
 
@@ -167,6 +181,15 @@ func (cr *CategoryRepository) CreateCategory(ctx context.Context, c category.Cre
 		return category.Category{}, internal.NewErrorf(internal.ErrorCodeConflict, "%s category already exists", c.Name)
 	}
 
+	if c.ParentID != nil {
+		if _, err := cr.CategoryByID(ctx, *c.ParentID); err != nil {
+			if internal.GetErrorCode(err) == internal.ErrorCodeNotFound {
+				return category.Category{}, internal.NewError(internal.ErrorCodeNotFound, "Parent category not found")
+			}
+			return category.Category{}, err
+		}
+	}
+
 	u := user.FromContext(ctx)
 	logger := logger.FromContext(ctx)
 
@@ -177,20 +200,24 @@ func (cr *CategoryRepository) CreateCategory(ctx context.Context, c category.Cre
 		"color",
 		"icon",
 		"user_id",
+		"parent_id",
 	)
 	ib.Values(
 		c.Name,
 		c.Color,
 		c.Icon,
 		u.ID,
+		c.ParentID,
 	)
 	ib.Returning(
 		"id",
 		"name",
 		"color",
 		"icon",
+		"version",
 		"created_at",
 		"updated_at",
+		"parent_id",
 	)
 
 	q, args := ib.Build()
@@ -206,6 +233,7 @@ func (cr *CategoryRepository) CreateCategory(ctx context.Context, c category.Cre
 		return category.Category{}, fmt.Errorf("sqlite.CategoryRepository.CreateCategory: GetContext: %w", err)
 	}
 
+	cr.db.publish(ctx, category.EventCategoryCreated, dst.ID)
 	return category.Category(dst), nil
 }
 
@@ -235,15 +263,18 @@ func (cr *CategoryRepository) UpdateCategory(ctx context.Context, c category.Upd
 	if c.Icon != nil {
 		ub.SetMore(ub.Assign("icon", c.Icon))
 	}
+	ub.SetMore(ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
 
 	ub.Where(
 		ub.And(
 			ub.EQ("id", c.ID),
 			ub.EQ("user_id", u.ID),
+			ub.IsNull("deleted_at"),
 		),
 	)
-	// https://github.com/huandu/go-sqlbuilder/issues/142
-	ub.SQL("RETURNING name, color, icon, created_at, updated_at")
+	if c.Version != nil {
+		ub.Where(ub.EQ("version", *c.Version))
+	}
 
 	q, args := ub.Build()
 
@@ -253,47 +284,1024 @@ func (cr *CategoryRepository) UpdateCategory(ctx context.Context, c category.Upd
 		"args", args,
 	)
 
-	var dst categoryDst
-	if err := cr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+	result, err := cr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return category.Category{}, fmt.Errorf("sqlite.CategoryRepository.UpdateCategory: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return category.Category{}, fmt.Errorf("sqlite.CategoryRepository.UpdateCategory: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		if _, err := cr.CategoryByID(ctx, c.ID); err != nil {
+			return category.Category{}, err
+		}
+		return category.Category{}, errCategoryChanged
+	}
+
+	cr.db.publish(ctx, category.EventCategoryUpdated, c.ID)
+
+	// read back since the version is bumped by a trigger after the update
+	return cr.CategoryByID(ctx, c.ID)
+}
+
+// DeleteCategory moves the category to the trash. Depending on the strategy,
+// its expenses are either moved to another category or moved to the trash
+// with it. Cascaded expenses share the deleted_at of the category so they can
+// be restored together.
+func (cr *CategoryRepository) DeleteCategory(ctx context.Context, d category.DeleteCategoryReq) (category.DeleteCategoryResult, error) {
+	var result category.DeleteCategoryResult
+	err := cr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		var err error
+		result, err = trashCategory(ctx, tx, d)
+		return err
+	})
+	if err != nil {
+		return category.DeleteCategoryResult{}, err
+	}
+
+	cr.db.publish(ctx, category.EventCategoryDeleted, d.ID)
+
+	expenseEvent := expense.EventExpenseUpdated
+	if d.Strategy == category.DeleteStrategyCascade {
+		expenseEvent = expense.EventExpenseDeleted
+	}
+	for _, id := range result.AffectedExpenseIDs {
+		cr.db.publish(ctx, expenseEvent, id)
+	}
+
+	return result, nil
+}
+
+// trashCategory moves the category to the trash within the transaction, see
+// DeleteCategory.
+func trashCategory(ctx context.Context, tx *sqlx.Tx, d category.DeleteCategoryReq) (category.DeleteCategoryResult, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var result category.DeleteCategoryResult
+
+	if _, err := activeCategoryName(ctx, tx, d.ID); err != nil {
+		return category.DeleteCategoryResult{}, err
+	}
+
+	// every strategy changes the expenses of the category
+	if err := checkNoReconciledExpenses(ctx, tx, d.ID); err != nil {
+		return category.DeleteCategoryResult{}, err
+	}
+
+	switch d.Strategy {
+	case category.DeleteStrategyReassign:
+		if _, err := activeCategoryName(ctx, tx, d.TargetCategoryID); err != nil {
+			if internal.GetErrorCode(err) == internal.ErrorCodeNotFound {
+				return category.DeleteCategoryResult{}, internal.NewError(internal.ErrorCodeNotFound, "Target category not found")
+			}
+			return category.DeleteCategoryResult{}, err
+		}
+
+		ids, err := moveExpenses(ctx, tx, d.ID, d.TargetCategoryID)
+		if err != nil {
+			return category.DeleteCategoryResult{}, err
+		}
+		result.AffectedExpenses = int64(len(ids))
+		result.AffectedExpenseIDs = ids
+
+		if _, err := moveRules(ctx, tx, d.ID, &d.TargetCategoryID); err != nil {
+			return category.DeleteCategoryResult{}, err
+		}
+	case category.DeleteStrategyUncategorized:
+		targetID, err := uncategorizedCategoryID(ctx, tx)
+		if err != nil {
+			return category.DeleteCategoryResult{}, err
+		}
+		if targetID == d.ID {
+			return category.DeleteCategoryResult{}, internal.NewErrorf(internal.ErrorCodeInvalid, "Can't move expenses of %s to itself", category.UncategorizedName)
+		}
+
+		ids, err := moveExpenses(ctx, tx, d.ID, targetID)
+		if err != nil {
+			return category.DeleteCategoryResult{}, err
+		}
+		result.AffectedExpenses = int64(len(ids))
+		result.AffectedExpenseIDs = ids
+
+		if _, err := moveRules(ctx, tx, d.ID, &targetID); err != nil {
+			return category.DeleteCategoryResult{}, err
+		}
+	case category.DeleteStrategyCascade:
+		if _, err := moveRules(ctx, tx, d.ID, nil); err != nil {
+			return category.DeleteCategoryResult{}, err
+		}
+	}
+
+	parentID := sqlbuilder.SQLite.NewSelectBuilder()
+	parentID.Select("parent_id")
+	parentID.From("category")
+	parentID.Where(parentID.EQ("id", d.ID))
+
+	// the subcategories remember the trashed category so restoring it moves
+	// them back, a subcategory that already moved up keeps its own parent
+	cub := sqlbuilder.SQLite.NewUpdateBuilder()
+	cub.Update("category")
+	cub.Set(
+		cub.Assign("parent_id", sqlbuilder.Buildf("(%v)", parentID)),
+		"trashed_parent_id = COALESCE(trashed_parent_id, "+cub.Var(d.ID)+")",
+	)
+	cub.Where(
+		cub.And(
+			cub.EQ("parent_id", d.ID),
+			cub.IsNull("deleted_at"),
+		),
+	)
+
+	q, args := cub.Build()
+
+	logger.Infow(
+		"Move subcategories to the parent of category",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return category.DeleteCategoryResult{}, fmt.Errorf("sqlite.trashCategory: ExecContext: %w", err)
+	}
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("category")
+	ub.Set(ub.Assign("deleted_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+	ub.Where(
+		ub.And(
+			ub.EQ("id", d.ID),
+			ub.EQ("user_id", u.ID),
+		),
+	)
+	if d.Version != nil {
+		ub.Where(ub.EQ("version", *d.Version))
+	}
+
+	q, args = ub.Build()
+
+	logger.Infow(
+		"Soft delete category",
+		"query", q,
+		"args", args,
+	)
+
+	r, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return category.DeleteCategoryResult{}, fmt.Errorf("sqlite.trashCategory: ExecContext: %w", err)
+	}
+
+	deleted, err := r.RowsAffected()
+	if err != nil {
+		return category.DeleteCategoryResult{}, fmt.Errorf("sqlite.trashCategory: RowsAffected: %w", err)
+	}
+	// the category exists, it was checked above
+	if deleted == 0 {
+		return category.DeleteCategoryResult{}, errCategoryChanged
+	}
+
+	if d.Strategy != category.DeleteStrategyCascade {
+		return result, nil
+	}
+
+	deletedAt := sqlbuilder.SQLite.NewSelectBuilder()
+	deletedAt.Select("deleted_at")
+	deletedAt.From("category")
+	deletedAt.Where(deletedAt.EQ("id", d.ID))
+
+	eub := sqlbuilder.SQLite.NewUpdateBuilder()
+	eub.Update("expense")
+	eub.Set(
+		eub.Assign("deleted_at", sqlbuilder.Buildf("(%v)", deletedAt)),
+		eub.Assign("reconciliation_id", nil),
+	)
+	eub.Where(
+		eub.And(
+			eub.EQ("category_id", d.ID),
+			eub.IsNull("deleted_at"),
+		),
+	)
+
+	eub.SQL("RETURNING id")
+
+	q, args = eub.Build()
+
+	logger.Infow(
+		"Soft delete expenses of category",
+		"query", q,
+		"args", args,
+	)
+
+	var ids []string
+	if err := tx.SelectContext(ctx, &ids, q, args...); err != nil {
+		return category.DeleteCategoryResult{}, fmt.Errorf("sqlite.trashCategory: SelectContext: %w", err)
+	}
+	result.AffectedExpenses = int64(len(ids))
+	result.AffectedExpenseIDs = ids
+
+	return result, nil
+}
+
+// activeCategoryName returns the name of the category if it belongs to the
+// user and is not in the trash.
+func activeCategoryName(ctx context.Context, tx *sqlx.Tx, id string) (string, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("name")
+	sb.From("category")
+	sb.Where(
+		sb.And(
+			sb.EQ("id", id),
+			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find category name by id",
+		"query", q,
+		"args", args,
+	)
+
+	var name string
+	if err := tx.GetContext(ctx, &name, q, args...); err != nil {
 		if err == sql.ErrNoRows {
-			return category.Category{}, internal.NewError(internal.ErrorCodeNotFound, "Category not found")
+			return "", internal.NewError(internal.ErrorCodeNotFound, "Category not found")
 		}
 
-		return category.Category{}, fmt.Errorf("sqlite.CategoryRepository.UpdateCategory: %w", err)
+		return "", fmt.Errorf("sqlite.activeCategoryName: GetContext: %w", err)
 	}
 
-	dst.ID = c.ID
+	return name, nil
+}
 
-	return category.Category(dst), nil
+// isDescendantCategory reports whether id is ancestorID itself or one of its
+// subcategories, at any depth.
+func isDescendantCategory(ctx context.Context, tx *sqlx.Tx, ancestorID, id string) (bool, error) {
+	logger := logger.FromContext(ctx)
+
+	q := `
+		WITH RECURSIVE descendant(id) AS (
+			SELECT id FROM category WHERE id = ?
+			UNION
+			SELECT c.id FROM category c JOIN descendant d ON c.parent_id = d.id
+		)
+		SELECT COUNT(*) FROM descendant WHERE id = ?`
+	args := []any{ancestorID, id}
+
+	logger.Infow(
+		"Count descendants of category",
+		"query", q,
+		"args", args,
+	)
+
+	var count int
+	if err := tx.GetContext(ctx, &count, q, args...); err != nil {
+		return false, fmt.Errorf("sqlite.isDescendantCategory: GetContext: %w", err)
+	}
+
+	return count > 0, nil
 }
 
-func (cr *CategoryRepository) DeleteCategory(ctx context.Context, id string) error {
-	user := user.FromContext(ctx)
+// uncategorizedCategoryID returns the ID of the Uncategorized category of the
+// user, creating it when it doesn't exist yet.
+func uncategorizedCategoryID(ctx context.Context, tx *sqlx.Tx) (string, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("id")
+	sb.From("category")
+	sb.Where(
+		sb.And(
+			sb.EQ("name", category.UncategorizedName),
+			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find uncategorized category",
+		"query", q,
+		"args", args,
+	)
+
+	var id string
+	err := tx.GetContext(ctx, &id, q, args...)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("sqlite.uncategorizedCategoryID: GetContext: %w", err)
+	}
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("category")
+	ib.Cols(
+		"name",
+		"color",
+		"icon",
+		"user_id",
+	)
+	ib.Values(
+		category.UncategorizedName,
+		"#808080",
+		"uncategorized",
+		u.ID,
+	)
+	ib.Returning("id")
+
+	q, args = ib.Build()
+
+	logger.Infow(
+		"Insert uncategorized category",
+		"query", q,
+		"args", args,
+	)
+
+	if err := tx.GetContext(ctx, &id, q, args...); err != nil {
+		return "", fmt.Errorf("sqlite.uncategorizedCategoryID: GetContext: %w", err)
+	}
+
+	return id, nil
+}
+
+// checkNoReconciledExpenses fails when the category has reconciled expenses,
+// they are locked so the category can't take them along when it's deleted.
+func checkNoReconciledExpenses(ctx context.Context, tx *sqlx.Tx, categoryID string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("1")
+	sb.From("expense")
+	sb.Where(
+		sb.And(
+			sb.EQ("category_id", categoryID),
+			sb.EQ("user_id", u.ID),
+			sb.IsNotNull("reconciled_at"),
+		),
+	)
+	sb.Limit(1)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find reconciled expenses of category",
+		"query", q,
+		"args", args,
+	)
+
+	var exists int
+	err := tx.GetContext(ctx, &exists, q, args...)
+	if err == nil {
+		return internal.NewError(internal.ErrorCodeConflict, "Category has reconciled expenses")
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("sqlite.checkNoReconciledExpenses: GetContext: %w", err)
+	}
+
+	return nil
+}
+
+// moveExpenses moves the expenses of a category to another category. The ones
+// in the trash are left alone, they can't be restored until their category
+// is. It returns the IDs of the moved expenses.
+func moveExpenses(ctx context.Context, tx *sqlx.Tx, fromCategoryID, toCategoryID string) ([]string, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("expense")
+	ub.Set(
+		ub.Assign("category_id", toCategoryID),
+		ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+	)
+	ub.Where(
+		ub.And(
+			ub.EQ("category_id", fromCategoryID),
+			ub.EQ("user_id", u.ID),
+			ub.IsNull("deleted_at"),
+		),
+	)
+
+	ub.SQL("RETURNING id")
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Move expenses to category",
+		"query", q,
+		"args", args,
+	)
+
+	var ids []string
+	if err := tx.SelectContext(ctx, &ids, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.moveExpenses: SelectContext: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ListAllCategories lists every category of the user that is not in the
+// trash.
+func (cr *CategoryRepository) ListAllCategories(ctx context.Context) ([]category.Category, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"id",
+		"name",
+		"color",
+		"icon",
+		"version",
+		"created_at",
+		"updated_at",
+		"parent_id",
+	)
+	sb.From("category")
+	sb.Where(
+		sb.And(
+			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
+		),
+	)
+	sb.OrderBy("name")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List all categories",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []categoryDst
+	if err := cr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.CategoryRepository.ListAllCategories: SelectContext: %w", err)
+	}
+
+	result := make([]category.Category, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, category.Category(v))
+	}
+
+	return result, nil
+}
+
+// MoveCategory changes the parent of the category. The subcategories move
+// with it. A category can't be moved under one of its own subcategories.
+func (cr *CategoryRepository) MoveCategory(ctx context.Context, m category.MoveCategoryReq) (category.Category, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	err := cr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := activeCategoryName(ctx, tx, m.ID); err != nil {
+			return err
+		}
+
+		if m.ParentID != nil {
+			if _, err := activeCategoryName(ctx, tx, *m.ParentID); err != nil {
+				if internal.GetErrorCode(err) == internal.ErrorCodeNotFound {
+					return internal.NewError(internal.ErrorCodeNotFound, "Parent category not found")
+				}
+				return err
+			}
+
+			descendant, err := isDescendantCategory(ctx, tx, m.ID, *m.ParentID)
+			if err != nil {
+				return err
+			}
+			if descendant {
+				return internal.NewError(internal.ErrorCodeInvalid, "Can't move a category under its own subcategory")
+			}
+		}
+
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("category")
+		ub.Set(
+			ub.Assign("parent_id", m.ParentID),
+			// moved on purpose, restoring its old parent leaves it here
+			ub.Assign("trashed_parent_id", nil),
+			ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		)
+		ub.Where(
+			ub.And(
+				ub.EQ("id", m.ID),
+				ub.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args := ub.Build()
+
+		logger.Infow(
+			"Move category",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.MoveCategory: ExecContext: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return category.Category{}, err
+	}
+
+	cr.db.publish(ctx, category.EventCategoryUpdated, m.ID)
+
+	// read back since the version is bumped by a trigger after the update
+	return cr.CategoryByID(ctx, m.ID)
+}
+
+// MergeCategories moves the expenses, rules, subcategories and budget of the
+// source category to the target category and then moves the source category
+// to the trash.
+func (cr *CategoryRepository) MergeCategories(ctx context.Context, m category.MergeCategoriesReq) (category.MergeCategoriesResult, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var result category.MergeCategoriesResult
+	err := cr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := activeCategoryName(ctx, tx, m.SourceID); err != nil {
+			return err
+		}
+
+		if _, err := activeCategoryName(ctx, tx, m.TargetID); err != nil {
+			if internal.GetErrorCode(err) == internal.ErrorCodeNotFound {
+				return internal.NewError(internal.ErrorCodeNotFound, "Target category not found")
+			}
+			return err
+		}
+
+		descendant, err := isDescendantCategory(ctx, tx, m.SourceID, m.TargetID)
+		if err != nil {
+			return err
+		}
+		if descendant {
+			return internal.NewError(internal.ErrorCodeInvalid, "Can't merge a category into its own subcategory")
+		}
+
+		if err := checkNoReconciledExpenses(ctx, tx, m.SourceID); err != nil {
+			return err
+		}
+
+		movedExpenses, err := moveExpenses(ctx, tx, m.SourceID, m.TargetID)
+		if err != nil {
+			return err
+		}
+		result.MovedExpenses = int64(len(movedExpenses))
+		result.MovedExpenseIDs = movedExpenses
+
+		movedRules, err := moveRules(ctx, tx, m.SourceID, &m.TargetID)
+		if err != nil {
+			return err
+		}
+		result.MovedRules = movedRules
+
+		movedBudgets, err := moveBudget(ctx, tx, m.SourceID, m.TargetID)
+		if err != nil {
+			return err
+		}
+		result.MovedBudgets = movedBudgets
+
+		cub := sqlbuilder.SQLite.NewUpdateBuilder()
+		cub.Update("category")
+		cub.Set(
+			cub.Assign("parent_id", m.TargetID),
+			cub.Assign("trashed_parent_id", nil),
+		)
+		cub.Where(
+			cub.And(
+				cub.EQ("parent_id", m.SourceID),
+				cub.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args := cub.Build()
+
+		logger.Infow(
+			"Move subcategories to category",
+			"query", q,
+			"args", args,
+		)
+
+		r, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.MergeCategories: ExecContext: %w", err)
+		}
+
+		movedSubcategories, err := r.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.MergeCategories: RowsAffected: %w", err)
+		}
+		result.MovedSubcategories = movedSubcategories
+
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("category")
+		ub.Set(ub.Assign("deleted_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+		ub.Where(
+			ub.And(
+				ub.EQ("id", m.SourceID),
+				ub.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args = ub.Build()
+
+		logger.Infow(
+			"Soft delete category",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.MergeCategories: ExecContext: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return category.MergeCategoriesResult{}, err
+	}
+
+	cr.db.publish(ctx, category.EventCategoryDeleted, m.SourceID)
+	for _, id := range result.MovedExpenseIDs {
+		cr.db.publish(ctx, expense.EventExpenseUpdated, id)
+	}
+
+	return result, nil
+}
+
+// ListCategoryTotals sums the expenses of every category within the date
+// range. The rollup total of a category includes the expenses of all its
+// subcategories.
+func (cr *CategoryRepository) ListCategoryTotals(ctx context.Context, t category.CategoryTotalsReq) ([]category.CategoryTotal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	q := `
+		WITH RECURSIVE tree(ancestor_id, id) AS (
+			SELECT id, id FROM category WHERE user_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT t.ancestor_id, c.id FROM category c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT
+			c.id,
+			c.name,
+			c.color,
+			c.icon,
+			c.version,
+			c.created_at,
+			c.updated_at,
+			c.parent_id,
+			COALESCE(SUM(CASE WHEN t.id = c.id THEN e.amount END), 0) AS total,
+			COALESCE(SUM(e.amount), 0) AS rollup_total
+		FROM category c
+		JOIN tree t ON t.ancestor_id = c.id
+		LEFT JOIN expense e ON e.category_id = t.id AND e.deleted_at IS NULL AND e.date BETWEEN ? AND ?
+		GROUP BY c.id
+		ORDER BY c.name`
+	args := []any{u.ID, t.StartDate, t.EndDate}
+
+	logger.Infow(
+		"List category totals",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		categoryDst
+		Total       int64 `db:"total"`
+		RollupTotal int64 `db:"rollup_total"`
+	}
+	if err := cr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.CategoryRepository.ListCategoryTotals: SelectContext: %w", err)
+	}
+
+	result := make([]category.CategoryTotal, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, category.CategoryTotal{
+			Category:    category.Category(v.categoryDst),
+			Total:       v.Total,
+			RollupTotal: v.RollupTotal,
+		})
+	}
+
+	return result, nil
+}
+
+func (cr *CategoryRepository) ListDeletedCategories(ctx context.Context, o internal.ListOptions) ([]category.DeletedCategory, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"id",
+		"name",
+		"color",
+		"icon",
+		"version",
+		"created_at",
+		"updated_at",
+		"parent_id",
+		"deleted_at",
+	)
+	sb.From("category")
+	sb.Where(
+		sb.And(
+			sb.EQ("user_id", u.ID),
+			sb.IsNotNull("deleted_at"),
+		),
+	)
+	sb.OrderBy("deleted_at").Desc()
+	sb.Limit(o.Limit)
+	sb.Offset(o.Offset)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List deleted categories",
+		"query", q,
+		"args", args,
+	)
+
+	rows, err := cr.db.reader.QueryxContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.CategoryRepository.ListDeletedCategories: QueryxContext: %w", err)
+	}
+	defer rows.Close()
+
+	var result []category.DeletedCategory
+	for rows.Next() {
+		var dst struct {
+			categoryDst
+			DeletedAt time.Time `db:"deleted_at"`
+		}
+		if err := rows.StructScan(&dst); err != nil {
+			return nil, fmt.Errorf("sqlite.CategoryRepository.ListDeletedCategories: StructScan: %w", err)
+		}
+
+		result = append(result, category.DeletedCategory{
+			Category:  category.Category(dst.categoryDst),
+			DeletedAt: dst.DeletedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// RestoreCategory takes the category out of the trash along with the expenses
+// that were trashed with it. The subcategories that moved up when it was
+// trashed move back under it.
+func (cr *CategoryRepository) RestoreCategory(ctx context.Context, id string) (category.Category, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var movedSubcategories []string
+	err := cr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		sb := sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select("name")
+		sb.From("category")
+		sb.Where(
+			sb.And(
+				sb.EQ("id", id),
+				sb.EQ("user_id", u.ID),
+				sb.IsNotNull("deleted_at"),
+			),
+		)
+
+		q, args := sb.Build()
+
+		logger.Infow(
+			"Find deleted category by id",
+			"query", q,
+			"args", args,
+		)
+
+		var name string
+		if err := tx.GetContext(ctx, &name, q, args...); err != nil {
+			if err == sql.ErrNoRows {
+				return internal.NewError(internal.ErrorCodeNotFound, "Category not found")
+			}
+
+			return fmt.Errorf("sqlite.CategoryRepository.RestoreCategory: GetContext: %w", err)
+		}
+
+		cb := sqlbuilder.SQLite.NewSelectBuilder()
+		cb.Select("COUNT(*)")
+		cb.From("category")
+		cb.Where(
+			cb.And(
+				cb.EQ("name", name),
+				cb.EQ("user_id", u.ID),
+				cb.IsNull("deleted_at"),
+			),
+		)
+
+		q, args = cb.Build()
+
+		logger.Infow(
+			"Count category by name",
+			"query", q,
+			"args", args,
+		)
+
+		var count int
+		if err := tx.GetContext(ctx, &count, q, args...); err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.RestoreCategory: GetContext: %w", err)
+		}
+		if count > 0 {
+			return internal.NewErrorf(internal.ErrorCodeConflict, "%s category already exists", name)
+		}
+
+		deletedAt := sqlbuilder.SQLite.NewSelectBuilder()
+		deletedAt.Select("deleted_at")
+		deletedAt.From("category")
+		deletedAt.Where(deletedAt.EQ("id", id))
+
+		eub := sqlbuilder.SQLite.NewUpdateBuilder()
+		eub.Update("expense")
+		eub.Set(eub.Assign("deleted_at", nil))
+		eub.Where(
+			eub.And(
+				eub.EQ("category_id", id),
+				eub.EQ("deleted_at", sqlbuilder.Buildf("(%v)", deletedAt)),
+			),
+		)
+
+		q, args = eub.Build()
+
+		logger.Infow(
+			"Restore expenses of category",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.RestoreCategory: ExecContext: %w", err)
+		}
+
+		// the parent may have been trashed meanwhile, the category goes under
+		// the nearest parent that is not in the trash until it's restored
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("category")
+		ub.Set(
+			ub.Assign("deleted_at", nil),
+			"trashed_parent_id = COALESCE(trashed_parent_id, parent_id)",
+		)
+		ub.Where(ub.EQ("id", id))
+
+		q, args = ub.Build()
+
+		logger.Infow(
+			"Restore category",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("sqlite.CategoryRepository.RestoreCategory: ExecContext: %w", err)
+		}
+
+		moved, err := moveBackSubcategories(ctx, tx)
+		if err != nil {
+			return err
+		}
+		movedSubcategories = moved
+
+		return nil
+	})
+	if err != nil {
+		return category.Category{}, err
+	}
+
+	cr.db.publish(ctx, category.EventCategoryCreated, id)
+	for _, v := range movedSubcategories {
+		if v != id {
+			cr.db.publish(ctx, category.EventCategoryUpdated, v)
+		}
+	}
+
+	// read back since the version is bumped by a trigger after the update
+	return cr.CategoryByID(ctx, id)
+}
+
+// moveBackSubcategories puts the categories of the user that moved up when
+// their parent was trashed under the nearest parent that is not in the trash
+// anymore. It returns the IDs of the moved categories.
+func moveBackSubcategories(ctx context.Context, tx *sqlx.Tx) ([]string, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	q := `
+		WITH RECURSIVE ancestor(id, ancestor_id) AS (
+			SELECT id, trashed_parent_id FROM category
+			WHERE user_id = ? AND deleted_at IS NULL AND trashed_parent_id IS NOT NULL
+			UNION ALL
+			SELECT a.id, c.parent_id FROM ancestor a JOIN category c ON c.id = a.ancestor_id WHERE c.deleted_at IS NOT NULL
+		)
+		SELECT c.id, c.trashed_parent_id, a.ancestor_id AS parent_id
+		FROM ancestor a
+		JOIN category c ON c.id = a.id
+		LEFT JOIN category p ON p.id = a.ancestor_id
+		WHERE (a.ancestor_id IS NULL OR p.deleted_at IS NULL)
+			AND (a.ancestor_id IS NOT c.parent_id OR a.ancestor_id IS c.trashed_parent_id)`
+	args := []any{u.ID}
+
+	logger.Infow(
+		"List subcategories to move back",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		ID              string  `db:"id"`
+		TrashedParentID string  `db:"trashed_parent_id"`
+		ParentID        *string `db:"parent_id"`
+	}
+	if err := tx.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.moveBackSubcategories: SelectContext: %w", err)
+	}
+
+	ids := make([]string, 0, len(dst))
+	for _, v := range dst {
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("category")
+		ub.Set(
+			ub.Assign("parent_id", v.ParentID),
+			ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		)
+		// back under the category it was in, otherwise it waits for the
+		// restore of the rest of the trashed parents
+		if v.ParentID != nil && *v.ParentID == v.TrashedParentID {
+			ub.SetMore(ub.Assign("trashed_parent_id", nil))
+		}
+		ub.Where(ub.EQ("id", v.ID))
+
+		q, args := ub.Build()
+
+		logger.Infow(
+			"Move back subcategory",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return nil, fmt.Errorf("sqlite.moveBackSubcategories: ExecContext: %w", err)
+		}
+
+		ids = append(ids, v.ID)
+	}
+
+	return ids, nil
+}
+
+// PurgeDeletedCategories permanently deletes the categories of every user
+// that were moved to the trash before the given time. Their expenses are
+// removed by the foreign key cascade.
+func (cr *CategoryRepository) PurgeDeletedCategories(ctx context.Context, before time.Time) (int64, error) {
 	logger := logger.FromContext(ctx)
 
 	db := sqlbuilder.SQLite.NewDeleteBuilder()
 	db.DeleteFrom("category")
 	db.Where(
 		db.And(
-			db.EQ("id", id),
-			db.EQ("user_id", user.ID),
+			db.IsNotNull("deleted_at"),
+			db.LT("deleted_at", before.UTC().Format(timestampLayout)),
 		),
 	)
 
 	q, args := db.Build()
 
 	logger.Infow(
-		"Delete category",
+		"Purge deleted categories",
 		"query", q,
 		"args", args,
 	)
 
-	_, err := cr.db.readerWriter.ExecContext(ctx, q, args...)
+	result, err := cr.db.readerWriter.ExecContext(ctx, q, args...)
 	if err != nil {
-		return fmt.Errorf("sqlite.CategoryRepository.DeleteCategory: ExecContext: %w", err)
+		return 0, fmt.Errorf("sqlite.CategoryRepository.PurgeDeletedCategories: ExecContext: %w", err)
 	}
 
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.CategoryRepository.PurgeDeletedCategories: RowsAffected: %w", err)
+	}
+
+	return affected, nil
 }
 
 type categoryDst struct {
@@ -301,6 +1309,8 @@ type categoryDst struct {
 	Name      string    `db:"name"`
 	Color     string    `db:"color"`
 	Icon      string    `db:"icon"`
+	Version   int64     `db:"version"`
 	CreatedAt time.Time `db:"created_at"`
 	UpdatedAt time.Time `db:"updated_at"`
+	ParentID  *string   `db:"parent_id"`
 }
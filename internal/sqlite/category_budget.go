@@ -0,0 +1,221 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+func (cr *CategoryRepository) SetBudget(ctx context.Context, b category.SetBudgetReq) (category.Budget, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	if _, err := cr.CategoryByID(ctx, b.CategoryID); err != nil {
+		return category.Budget{}, fmt.Errorf("sqlite.CategoryRepository.SetBudget: %w", err)
+	}
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("category_budget")
+	ib.Cols("category_id", "amount", "user_id")
+	ib.Values(b.CategoryID, b.Amount, u.ID)
+	ib.SQL("ON CONFLICT (category_id) DO UPDATE SET amount = excluded.amount, updated_at = CURRENT_TIMESTAMP")
+	ib.SQL("RETURNING category_id, amount, created_at, updated_at")
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Upsert category budget",
+		"query", q,
+		"args", args,
+	)
+
+	var dst budgetDst
+	if err := cr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return category.Budget{}, fmt.Errorf("sqlite.CategoryRepository.SetBudget: GetContext: %w", err)
+	}
+
+	return category.Budget(dst), nil
+}
+
+func (cr *CategoryRepository) DeleteBudget(ctx context.Context, categoryID string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("category_budget")
+	db.Where(
+		db.And(
+			db.EQ("category_id", categoryID),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete category budget",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := cr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.CategoryRepository.DeleteBudget: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.CategoryRepository.DeleteBudget: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Budget not found")
+	}
+
+	return nil
+}
+
+// moveBudget gives the budget of a category to another one that has none. The
+// budget is dropped otherwise, the target keeps its own.
+func moveBudget(ctx context.Context, tx *sqlx.Tx, fromCategoryID, toCategoryID string) (int64, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("category_budget")
+	ub.Set(
+		ub.Assign("category_id", toCategoryID),
+		ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+	)
+	ub.Where(
+		ub.And(
+			ub.EQ("category_id", fromCategoryID),
+			ub.EQ("user_id", u.ID),
+			// category_id is the primary key, the target can have one budget
+			ub.NotExists(sqlbuilder.Buildf("SELECT 1 FROM category_budget WHERE category_id = %v", toCategoryID)),
+		),
+	)
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Move category budget",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.moveBudget: ExecContext: %w", err)
+	}
+
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.moveBudget: RowsAffected: %w", err)
+	}
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("category_budget")
+	db.Where(
+		db.And(
+			db.EQ("category_id", fromCategoryID),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args = db.Build()
+
+	logger.Infow(
+		"Delete category budget",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return 0, fmt.Errorf("sqlite.moveBudget: ExecContext: %w", err)
+	}
+
+	return moved, nil
+}
+
+// ListBudgetStatuses lists the budgets of the categories that are not in the
+// trash. The spending of a budget includes the subcategories.
+func (cr *CategoryRepository) ListBudgetStatuses(ctx context.Context, b category.BudgetStatusesReq) ([]category.BudgetStatus, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	month, err := time.Parse("2006-01", b.Month)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.CategoryRepository.ListBudgetStatuses: Parse: %w", err)
+	}
+
+	q := `
+		WITH RECURSIVE tree(ancestor_id, id) AS (
+			SELECT category_id, category_id FROM category_budget WHERE user_id = ?
+			UNION ALL
+			SELECT t.ancestor_id, c.id FROM category c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT
+			c.id,
+			c.name,
+			c.color,
+			c.icon,
+			c.version,
+			c.created_at,
+			c.updated_at,
+			c.parent_id,
+			b.amount,
+			COALESCE(SUM(e.amount), 0) AS spent
+		FROM category_budget b
+		JOIN category c ON c.id = b.category_id AND c.deleted_at IS NULL
+		JOIN tree t ON t.ancestor_id = b.category_id
+		LEFT JOIN expense e ON e.category_id = t.id AND e.deleted_at IS NULL AND e.date BETWEEN ? AND ?
+		WHERE b.user_id = ?
+		GROUP BY b.category_id
+		ORDER BY c.name`
+	args := []any{
+		u.ID,
+		month.Format(time.DateOnly),
+		month.AddDate(0, 1, -1).Format(time.DateOnly),
+		u.ID,
+	}
+
+	logger.Infow(
+		"List budget statuses",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		categoryDst
+		Amount int64 `db:"amount"`
+		Spent  int64 `db:"spent"`
+	}
+	if err := cr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.CategoryRepository.ListBudgetStatuses: SelectContext: %w", err)
+	}
+
+	result := make([]category.BudgetStatus, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, category.BudgetStatus{
+			Category: category.Category(v.categoryDst),
+			Amount:   v.Amount,
+			Spent:    v.Spent,
+		})
+	}
+
+	return result, nil
+}
+
+type budgetDst struct {
+	CategoryID string    `db:"category_id"`
+	Amount     int64     `db:"amount"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
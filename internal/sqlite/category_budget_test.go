@@ -0,0 +1,85 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryBudget(t *testing.T) {
+	dh := newDBHelper(t, "test_category_budget.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	food := categories[0]
+	snacks, err := cr.CreateCategory(ctxWithUser1, category.CreateCategoryReq{
+		Name:     "snacks",
+		Color:    "#000000",
+		Icon:     "snacks-icon",
+		ParentID: &food.ID,
+	})
+	assert.Nil(t, err)
+
+	for _, v := range []expense.CreateExpenseReq{
+		{Name: "Groceries", Amount: 500, Date: "2006-01-02", CategoryID: food.ID},
+		{Name: "Chips", Amount: 300, Date: "2006-01-31", CategoryID: snacks.ID},
+		// outside of the month
+		{Name: "Groceries", Amount: 700, Date: "2006-02-01", CategoryID: food.ID},
+	} {
+		_, err := er.CreateExpense(ctxWithUser1, v)
+		assert.Nil(t, err)
+	}
+
+	t.Run("set budget of another user's category", func(t *testing.T) {
+		_, err := cr.SetBudget(ctxWithUser2, category.SetBudgetReq{CategoryID: food.ID, Amount: 1000})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("set and replace budget", func(t *testing.T) {
+		b, err := cr.SetBudget(ctxWithUser1, category.SetBudgetReq{CategoryID: food.ID, Amount: 500})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(500), b.Amount)
+
+		b, err = cr.SetBudget(ctxWithUser1, category.SetBudgetReq{CategoryID: food.ID, Amount: 1000})
+		assert.Nil(t, err)
+		assert.Equal(t, food.ID, b.CategoryID)
+		assert.Equal(t, int64(1000), b.Amount)
+	})
+
+	t.Run("list statuses includes subcategories", func(t *testing.T) {
+		statuses, err := cr.ListBudgetStatuses(ctxWithUser1, category.BudgetStatusesReq{Month: "2006-01"})
+		assert.Nil(t, err)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, food.ID, statuses[0].Category.ID)
+		assert.Equal(t, int64(1000), statuses[0].Amount)
+		assert.Equal(t, int64(800), statuses[0].Spent)
+		assert.Equal(t, 80.0, statuses[0].Percent())
+
+		statuses, err = cr.ListBudgetStatuses(ctxWithUser2, category.BudgetStatusesReq{Month: "2006-01"})
+		assert.Nil(t, err)
+		assert.Empty(t, statuses)
+	})
+
+	t.Run("delete budget", func(t *testing.T) {
+		err := cr.DeleteBudget(ctxWithUser1, food.ID)
+		assert.Nil(t, err)
+
+		err = cr.DeleteBudget(ctxWithUser1, food.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cativovo/budget-tracker/internal"
 	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
 	"github.com/cativovo/budget-tracker/internal/logger"
 	"github.com/cativovo/budget-tracker/internal/sqlite"
 	"github.com/cativovo/budget-tracker/internal/user"
@@ -341,6 +342,38 @@ func TestUpdateCategory(t *testing.T) {
 			assert.Equal(t, updated, found)
 		})
 	}
+
+	t.Run("can't update or delete category changed by someone else", func(t *testing.T) {
+		ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+		created, err := cr.CreateCategory(ctxWithUser, category.CreateCategoryReq{
+			Name:  "stale",
+			Color: "#000000",
+			Icon:  "icon",
+		})
+		assert.Nil(t, err)
+
+		updated, err := cr.UpdateCategory(ctxWithUser, category.UpdateCategoryReq{
+			ID:      created.ID,
+			Color:   toPtr(t, "#ffffff"),
+			Version: toPtr(t, created.Version),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, created.Version+1, updated.Version)
+
+		_, err = cr.UpdateCategory(ctxWithUser, category.UpdateCategoryReq{
+			ID:      created.ID,
+			Color:   toPtr(t, "#111111"),
+			Version: toPtr(t, created.Version),
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodePreconditionFailed, "Category was changed by someone else"), err)
+
+		_, err = cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:       created.ID,
+			Strategy: category.DeleteStrategyCascade,
+			Version:  toPtr(t, created.Version),
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodePreconditionFailed, "Category was changed by someone else"), err)
+	})
 }
 
 func TestDeleteCategory(t *testing.T) {
@@ -372,6 +405,7 @@ func TestDeleteCategory(t *testing.T) {
 		deleter     user.User
 		categoryID  string
 		shouldFound bool
+		err         error
 	}{
 		{
 			name:       fmt.Sprintf("%s deletes the category", uwc[0].user.Name),
@@ -385,13 +419,17 @@ func TestDeleteCategory(t *testing.T) {
 			deleter:     uwc[1].user,
 			categoryID:  uwc[0].categories[1].ID,
 			shouldFound: true,
+			err:         internal.NewError(internal.ErrorCodeNotFound, "Category not found"),
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			deleteErr := cr.DeleteCategory(user.ContextWithUser(ctxWithLogger, test.deleter), test.categoryID)
-			assert.Nil(t, deleteErr)
+			_, deleteErr := cr.DeleteCategory(user.ContextWithUser(ctxWithLogger, test.deleter), category.DeleteCategoryReq{
+				ID:       test.categoryID,
+				Strategy: category.DeleteStrategyCascade,
+			})
+			assert.Equal(t, test.err, deleteErr)
 
 			_, findErr := cr.CategoryByID(user.ContextWithUser(ctxWithLogger, test.user), test.categoryID)
 			if test.shouldFound {
@@ -484,3 +522,617 @@ func TestListCategories(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteRestoreCategory(t *testing.T) {
+	dh := newDBHelper(t, "test_delete_restore_category.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+
+	createdExpense, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Expense 1",
+		Amount:     6969,
+		Date:       "2006-01-02",
+		CategoryID: categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	_, err = cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+		ID:       categories[0].ID,
+		Strategy: category.DeleteStrategyCascade,
+	})
+	assert.Nil(t, err)
+
+	_, err = er.ExpenseByID(ctxWithUser, createdExpense.ID)
+	assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+
+	deletedCategories, err := cr.ListDeletedCategories(ctxWithUser, internal.ListOptions{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, deletedCategories, 1)
+	// deleting is a change too
+	categories[0].Version++
+	assert.Equal(t, categories[0], deletedCategories[0].Category)
+	assert.WithinDuration(t, time.Now(), deletedCategories[0].DeletedAt, time.Second*5)
+
+	deletedExpenses, err := er.ListDeletedExpenses(ctxWithUser, internal.ListOptions{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, deletedExpenses, 1)
+	assert.Equal(t, createdExpense.ID, deletedExpenses[0].ID)
+
+	t.Run("other user can't see the trash", func(t *testing.T) {
+		ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+		got, err := cr.ListDeletedCategories(ctxWithUser2, internal.ListOptions{Limit: 10})
+		assert.Nil(t, err)
+		assert.Empty(t, got)
+
+		_, err = cr.RestoreCategory(ctxWithUser2, categories[0].ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Category not found"), err)
+	})
+
+	t.Run("can't restore when the name is taken", func(t *testing.T) {
+		taken, err := cr.CreateCategory(ctxWithUser, category.CreateCategoryReq{
+			Name:  categories[0].Name,
+			Color: "#000000",
+			Icon:  "icon",
+		})
+		assert.Nil(t, err)
+
+		_, err = cr.RestoreCategory(ctxWithUser, categories[0].ID)
+		assert.Equal(t, internal.NewErrorf(internal.ErrorCodeConflict, "%s category already exists", categories[0].Name), err)
+
+		_, err = cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:       taken.ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+	})
+
+	t.Run("restore category with its expenses", func(t *testing.T) {
+		restored, err := cr.RestoreCategory(ctxWithUser, categories[0].ID)
+		assert.Nil(t, err)
+		categories[0].Version++
+		assert.Equal(t, categories[0], restored)
+
+		found, err := er.ExpenseByID(ctxWithUser, createdExpense.ID)
+		assert.Nil(t, err)
+		createdExpense.Version += 2
+		createdExpense.Category = restored
+		assert.Equal(t, createdExpense, found)
+
+		_, err = cr.RestoreCategory(ctxWithUser, categories[0].ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Category not found"), err)
+	})
+}
+
+func TestPurgeDeletedCategories(t *testing.T) {
+	dh := newDBHelper(t, "test_purge_deleted_categories.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+
+	_, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Expense 1",
+		Amount:     6969,
+		Date:       "2006-01-02",
+		CategoryID: categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	_, err = cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+		ID:       categories[0].ID,
+		Strategy: category.DeleteStrategyCascade,
+	})
+	assert.Nil(t, err)
+
+	purged, err := cr.PurgeDeletedCategories(ctxWithLogger, time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = cr.PurgeDeletedCategories(ctxWithLogger, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	deletedExpenses, err := er.ListDeletedExpenses(ctxWithUser, internal.ListOptions{Limit: 10})
+	assert.Nil(t, err)
+	assert.Empty(t, deletedExpenses)
+
+	remaining, err := cr.ListCategories(ctxWithUser, internal.ListOptions{Limit: 10})
+	assert.Nil(t, err)
+	assert.Equal(t, categories[1:], remaining)
+}
+
+func TestDeleteCategoryStrategies(t *testing.T) {
+	dh := newDBHelper(t, "test_delete_category_strategies.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+	otherCategories := createCategories(t, dh.db, users[1])
+
+	createCategoryWithExpenses := func(t *testing.T, name string, count int) (category.Category, []expense.Expense) {
+		t.Helper()
+
+		c, err := cr.CreateCategory(ctxWithUser, category.CreateCategoryReq{
+			Name:  name,
+			Color: "#000000",
+			Icon:  name + "-icon",
+		})
+		assert.Nil(t, err)
+
+		expenses := make([]expense.Expense, 0, count)
+		for i := range count {
+			e, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+				Name:       fmt.Sprintf("%s expense %d", name, i),
+				Amount:     6969,
+				Date:       "2006-01-02",
+				CategoryID: c.ID,
+			})
+			assert.Nil(t, err)
+			expenses = append(expenses, e)
+		}
+
+		return c, expenses
+	}
+
+	t.Run("reassign expenses to the target category", func(t *testing.T) {
+		source, expenses := createCategoryWithExpenses(t, "grocery", 3)
+		target, _ := createCategoryWithExpenses(t, "groceries", 1)
+
+		trashed := expenses[2]
+		expenses = expenses[:2]
+		_, err := er.DeleteExpense(ctxWithUser, expense.DeleteExpenseReq{ID: trashed.ID})
+		assert.Nil(t, err)
+
+		result, err := cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:               source.ID,
+			Strategy:         category.DeleteStrategyReassign,
+			TargetCategoryID: target.ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), result.AffectedExpenses)
+
+		for _, e := range expenses {
+			found, err := er.ExpenseByID(ctxWithUser, e.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, target, found.Category)
+		}
+
+		// the trashed expense stays with the deleted category
+		_, err = er.RestoreExpense(ctxWithUser, trashed.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Category of the expense is deleted"), err)
+
+		_, err = cr.CategoryByID(ctxWithUser, source.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Category not found"), err)
+	})
+
+	t.Run("can't reassign expenses to the category of other user", func(t *testing.T) {
+		source, expenses := createCategoryWithExpenses(t, "rent", 1)
+
+		_, err := cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:               source.ID,
+			Strategy:         category.DeleteStrategyReassign,
+			TargetCategoryID: otherCategories[0].ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Target category not found"), err)
+
+		found, err := er.ExpenseByID(ctxWithUser, expenses[0].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, source, found.Category)
+	})
+
+	t.Run("move expenses to uncategorized", func(t *testing.T) {
+		for _, name := range []string{"gaming", "drinks"} {
+			source, expenses := createCategoryWithExpenses(t, name, 3)
+
+			result, err := cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+				ID:       source.ID,
+				Strategy: category.DeleteStrategyUncategorized,
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, int64(3), result.AffectedExpenses)
+
+			for _, e := range expenses {
+				found, err := er.ExpenseByID(ctxWithUser, e.ID)
+				assert.Nil(t, err)
+				assert.Equal(t, category.UncategorizedName, found.Category.Name)
+			}
+		}
+
+		categories, err := cr.ListCategories(ctxWithUser, internal.ListOptions{Limit: 100})
+		assert.Nil(t, err)
+
+		var uncategorizedCount int
+		for _, c := range categories {
+			if c.Name == category.UncategorizedName {
+				uncategorizedCount++
+			}
+		}
+		assert.Equal(t, 1, uncategorizedCount)
+	})
+
+	t.Run("cascade expenses", func(t *testing.T) {
+		source, expenses := createCategoryWithExpenses(t, "food", 2)
+
+		result, err := cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:       source.ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), result.AffectedExpenses)
+
+		for _, e := range expenses {
+			_, err := er.ExpenseByID(ctxWithUser, e.ID)
+			assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+		}
+	})
+
+	t.Run("can't delete category with reconciled expenses", func(t *testing.T) {
+		source, expenses := createCategoryWithExpenses(t, "bills", 2)
+		target, _ := createCategoryWithExpenses(t, "utilities", 0)
+		reconcileExpense(t, dh.db, expenses[0].ID)
+
+		for _, d := range []category.DeleteCategoryReq{
+			{ID: source.ID, Strategy: category.DeleteStrategyReassign, TargetCategoryID: target.ID},
+			{ID: source.ID, Strategy: category.DeleteStrategyUncategorized},
+			{ID: source.ID, Strategy: category.DeleteStrategyCascade},
+		} {
+			_, err := cr.DeleteCategory(ctxWithUser, d)
+			assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Category has reconciled expenses"), err)
+		}
+
+		for _, e := range expenses {
+			found, err := er.ExpenseByID(ctxWithUser, e.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, source.ID, found.Category.ID)
+		}
+	})
+}
+
+func TestCategoryHierarchy(t *testing.T) {
+	dh := newDBHelper(t, "test_category_hierarchy.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+	otherCategories := createCategories(t, dh.db, users[1])
+
+	createCategory := func(t *testing.T, name string, parentID *string) category.Category {
+		t.Helper()
+
+		c, err := cr.CreateCategory(ctxWithUser, category.CreateCategoryReq{
+			Name:     name,
+			Color:    "#000000",
+			Icon:     name + "-icon",
+			ParentID: parentID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, parentID, c.ParentID)
+
+		return c
+	}
+
+	createExpense := func(t *testing.T, c category.Category, amount int64, date string) {
+		t.Helper()
+
+		_, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+			Name:       c.Name,
+			Amount:     amount,
+			Date:       date,
+			CategoryID: c.ID,
+		})
+		assert.Nil(t, err)
+	}
+
+	food := createCategory(t, "food", nil)
+	groceries := createCategory(t, "groceries", &food.ID)
+	restaurants := createCategory(t, "restaurants", &food.ID)
+	fastFood := createCategory(t, "fast food", &restaurants.ID)
+	rent := createCategory(t, "rent", nil)
+
+	createExpense(t, food, 100, "2006-01-02")
+	createExpense(t, groceries, 200, "2006-01-02")
+	createExpense(t, restaurants, 300, "2006-01-03")
+	createExpense(t, fastFood, 400, "2006-01-04")
+	createExpense(t, fastFood, 1000, "2006-02-01")
+	createExpense(t, rent, 500, "2006-01-05")
+
+	t.Run("can't create category under the category of other user", func(t *testing.T) {
+		_, err := cr.CreateCategory(ctxWithUser, category.CreateCategoryReq{
+			Name:     "drinks",
+			Color:    "#000000",
+			Icon:     "drinks-icon",
+			ParentID: &otherCategories[0].ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Parent category not found"), err)
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		categories, err := cr.ListAllCategories(ctxWithUser)
+		assert.Nil(t, err)
+
+		tree := category.NewTree(categories)
+		assert.Len(t, tree, 2)
+		assert.Equal(t, food.ID, tree[0].ID)
+		assert.Len(t, tree[0].Children, 2)
+		assert.Equal(t, groceries.ID, tree[0].Children[0].ID)
+		assert.Equal(t, restaurants.ID, tree[0].Children[1].ID)
+		assert.Len(t, tree[0].Children[1].Children, 1)
+		assert.Equal(t, fastFood.ID, tree[0].Children[1].Children[0].ID)
+		assert.Equal(t, rent.ID, tree[1].ID)
+		assert.Empty(t, tree[1].Children)
+	})
+
+	t.Run("rollup totals", func(t *testing.T) {
+		totals, err := cr.ListCategoryTotals(ctxWithUser, category.CategoryTotalsReq{
+			StartDate: "2006-01-01",
+			EndDate:   "2006-01-31",
+		})
+		assert.Nil(t, err)
+
+		got := make(map[string][2]int64)
+		for _, v := range totals {
+			got[v.ID] = [2]int64{v.Total, v.RollupTotal}
+		}
+
+		assert.Equal(t, map[string][2]int64{
+			food.ID:        {100, 1000},
+			groceries.ID:   {200, 200},
+			restaurants.ID: {300, 700},
+			fastFood.ID:    {400, 400},
+			rent.ID:        {500, 500},
+		}, got)
+	})
+
+	t.Run("can't move category under its subcategory", func(t *testing.T) {
+		_, err := cr.MoveCategory(ctxWithUser, category.MoveCategoryReq{
+			ID:       food.ID,
+			ParentID: &fastFood.ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeInvalid, "Can't move a category under its own subcategory"), err)
+	})
+
+	t.Run("move subtree", func(t *testing.T) {
+		moved, err := cr.MoveCategory(ctxWithUser, category.MoveCategoryReq{
+			ID:       restaurants.ID,
+			ParentID: &rent.ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, &rent.ID, moved.ParentID)
+
+		found, err := cr.CategoryByID(ctxWithUser, fastFood.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &restaurants.ID, found.ParentID)
+
+		moved, err = cr.MoveCategory(ctxWithUser, category.MoveCategoryReq{
+			ID: restaurants.ID,
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, moved.ParentID)
+	})
+
+	t.Run("subcategories move to the parent of the deleted category", func(t *testing.T) {
+		_, err := cr.MoveCategory(ctxWithUser, category.MoveCategoryReq{
+			ID:       restaurants.ID,
+			ParentID: &food.ID,
+		})
+		assert.Nil(t, err)
+
+		_, err = cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:       restaurants.ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+
+		found, err := cr.CategoryByID(ctxWithUser, fastFood.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &food.ID, found.ParentID)
+	})
+
+	t.Run("restore puts the subcategories back", func(t *testing.T) {
+		_, err := cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+			ID:       food.ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+
+		found, err := cr.CategoryByID(ctxWithUser, fastFood.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, found.ParentID)
+
+		// restaurants is still in the trash, fast food waits under food
+		restored, err := cr.RestoreCategory(ctxWithUser, food.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, restored.ParentID)
+
+		found, err = cr.CategoryByID(ctxWithUser, fastFood.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &food.ID, found.ParentID)
+
+		restored, err = cr.RestoreCategory(ctxWithUser, restaurants.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &food.ID, restored.ParentID)
+
+		found, err = cr.CategoryByID(ctxWithUser, fastFood.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &restaurants.ID, found.ParentID)
+
+		categories, err := cr.ListAllCategories(ctxWithUser)
+		assert.Nil(t, err)
+
+		tree := category.NewTree(categories)
+		assert.Len(t, tree, 2)
+		assert.Equal(t, food.ID, tree[0].ID)
+		assert.Len(t, tree[0].Children, 2)
+		assert.Equal(t, restaurants.ID, tree[0].Children[1].ID)
+		assert.Len(t, tree[0].Children[1].Children, 1)
+		assert.Equal(t, fastFood.ID, tree[0].Children[1].Children[0].ID)
+	})
+}
+
+func TestMergeCategories(t *testing.T) {
+	dh := newDBHelper(t, "test_merge_categories.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+	otherCategories := createCategories(t, dh.db, users[1])
+
+	createCategory := func(t *testing.T, name string, parentID *string) category.Category {
+		t.Helper()
+
+		c, err := cr.CreateCategory(ctxWithUser, category.CreateCategoryReq{
+			Name:     name,
+			Color:    "#000000",
+			Icon:     name + "-icon",
+			ParentID: parentID,
+		})
+		assert.Nil(t, err)
+
+		return c
+	}
+
+	groceries := createCategory(t, "groceries", nil)
+	grocery := createCategory(t, "grocery", nil)
+	produce := createCategory(t, "produce", &grocery.ID)
+
+	var expenses []expense.Expense
+	for i := range 3 {
+		e, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+			Name:       fmt.Sprintf("grocery %d", i),
+			Amount:     6969,
+			Date:       "2006-01-02",
+			CategoryID: grocery.ID,
+		})
+		assert.Nil(t, err)
+		expenses = append(expenses, e)
+	}
+
+	t.Run("can't merge into the category of other user", func(t *testing.T) {
+		_, err := cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+			SourceID: grocery.ID,
+			TargetID: otherCategories[0].ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Target category not found"), err)
+	})
+
+	t.Run("can't merge into its own subcategory", func(t *testing.T) {
+		_, err := cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+			SourceID: grocery.ID,
+			TargetID: produce.ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeInvalid, "Can't merge a category into its own subcategory"), err)
+	})
+
+	t.Run("can't merge category with reconciled expenses", func(t *testing.T) {
+		bills := createCategory(t, "bills", nil)
+		utilities := createCategory(t, "utilities", nil)
+
+		e, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+			Name:       "electricity",
+			Amount:     6969,
+			Date:       "2006-01-02",
+			CategoryID: bills.ID,
+		})
+		assert.Nil(t, err)
+		reconcileExpense(t, dh.db, e.ID)
+
+		_, err = cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+			SourceID: bills.ID,
+			TargetID: utilities.ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Category has reconciled expenses"), err)
+
+		found, err := er.ExpenseByID(ctxWithUser, e.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, bills.ID, found.Category.ID)
+
+		_, err = cr.CategoryByID(ctxWithUser, bills.ID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		result, err := cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+			SourceID: grocery.ID,
+			TargetID: groceries.ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, category.MergeCategoriesResult{
+			MovedExpenses:      3,
+			MovedExpenseIDs:    []string{expenses[0].ID, expenses[1].ID, expenses[2].ID},
+			MovedSubcategories: 1,
+		}, result)
+
+		for _, e := range expenses {
+			found, err := er.ExpenseByID(ctxWithUser, e.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, groceries.ID, found.Category.ID)
+		}
+
+		found, err := cr.CategoryByID(ctxWithUser, produce.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &groceries.ID, found.ParentID)
+
+		_, err = cr.CategoryByID(ctxWithUser, grocery.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Category not found"), err)
+	})
+
+	t.Run("merge budgets", func(t *testing.T) {
+		dining := createCategory(t, "dining", nil)
+		restaurants := createCategory(t, "restaurants", nil)
+		takeout := createCategory(t, "takeout", nil)
+
+		_, err := cr.SetBudget(ctxWithUser, category.SetBudgetReq{CategoryID: dining.ID, Amount: 500})
+		assert.Nil(t, err)
+		_, err = cr.SetBudget(ctxWithUser, category.SetBudgetReq{CategoryID: takeout.ID, Amount: 300})
+		assert.Nil(t, err)
+
+		// the target has no budget, it gets the one of the source
+		result, err := cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+			SourceID: dining.ID,
+			TargetID: restaurants.ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), result.MovedBudgets)
+
+		// the target keeps its budget, the one of the source is dropped
+		result, err = cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+			SourceID: takeout.ID,
+			TargetID: restaurants.ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), result.MovedBudgets)
+
+		statuses, err := cr.ListBudgetStatuses(ctxWithUser, category.BudgetStatusesReq{Month: "2006-01"})
+		assert.Nil(t, err)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, restaurants.ID, statuses[0].Category.ID)
+		assert.Equal(t, int64(500), statuses[0].Amount)
+
+		err = cr.DeleteBudget(ctxWithUser, takeout.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+}
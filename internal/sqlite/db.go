@@ -1,17 +1,27 @@
 package sqlite
 
 import (
+	"context"
 	"embed"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/cativovo/budget-tracker/internal/user"
 	"github.com/jmoiron/sqlx"
 	"github.com/pressly/goose/v3"
 	"go.uber.org/zap"
 )
 
+// timestampLayout matches the format sqlite uses for CURRENT_TIMESTAMP so
+// timestamps computed in Go can be compared with the stored ones.
+const timestampLayout = "2006-01-02 15:04:05"
+
 type DB struct {
 	reader       *sqlx.DB
 	readerWriter *sqlx.DB
+	publisher    event.Publisher
 }
 
 func NewDB(dbPath string) (*DB, error) {
@@ -49,9 +59,53 @@ func (r *DB) ReaderWriter() *sqlx.DB {
 	return r.readerWriter
 }
 
-func (r *DB) Close() {
-	r.reader.Close()
-	r.readerWriter.Close()
+// SetPublisher makes the repositories publish their changes to p after they
+// are written.
+func (r *DB) SetPublisher(p event.Publisher) {
+	r.publisher = p
+}
+
+// publish tells the subscribers of the user in ctx that the resource changed.
+// It must only be called once the change is committed.
+func (r *DB) publish(ctx context.Context, typ, resourceID string) {
+	if r.publisher == nil {
+		return
+	}
+
+	u := user.FromContext(ctx)
+	r.publisher.Publish(u.ID, typ, resourceID)
+}
+
+// pendingEvent is published once its transaction is committed.
+type pendingEvent struct {
+	typ        string
+	resourceID string
+}
+
+// withTx runs fn inside a transaction on the readerWriter connection. The
+// transaction is rolled back if fn returns an error and committed otherwise.
+func (r *DB) withTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := r.readerWriter.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlite.DB.withTx: BeginTxx: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite.DB.withTx: Commit: %w", err)
+	}
+
+	return nil
+}
+
+// Close closes both connection pools. The writes in progress must be done by
+// then, closing the readerWriter pool checkpoints the WAL.
+func (r *DB) Close() error {
+	return errors.Join(r.reader.Close(), r.readerWriter.Close())
 }
 
 //go:embed all:migrations
@@ -0,0 +1,155 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/event"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishChanges(t *testing.T) {
+	dh := newDBHelper(t, "test_publish_changes.db")
+	defer dh.clean()
+
+	broker := event.NewBroker()
+	dh.db.SetPublisher(broker)
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+	categories := createCategories(t, dh.db, users[0])
+
+	sub := broker.Subscribe(users[0].ID, 0)
+	defer sub.Close()
+
+	next := func(t *testing.T) event.Event {
+		t.Helper()
+
+		select {
+		case e := <-sub.Events():
+			return e
+		default:
+			t.Fatal("no event was published")
+			return event.Event{}
+		}
+	}
+
+	t.Run("expense changes", func(t *testing.T) {
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       "Groceries",
+			Amount:     500,
+			Date:       "2006-01-02",
+			CategoryID: categories[0].ID,
+		})
+		assert.Nil(t, err)
+		got := next(t)
+		assert.Equal(t, expense.EventExpenseCreated, got.Type)
+		assert.Equal(t, e.ID, got.ResourceID)
+
+		_, err = er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{
+			ID:     e.ID,
+			Amount: toPtr(t, int64(600)),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expense.EventExpenseUpdated, next(t).Type)
+
+		trashed, err := er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: e.ID})
+		assert.Nil(t, err)
+		assert.True(t, trashed)
+		assert.Equal(t, expense.EventExpenseDeleted, next(t).Type)
+
+		_, err = er.RestoreExpense(ctxWithUser1, e.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, expense.EventExpenseCreated, next(t).Type)
+	})
+
+	t.Run("category changes", func(t *testing.T) {
+		c, err := cr.CreateCategory(ctxWithUser1, category.CreateCategoryReq{
+			Name:  "travel",
+			Color: "#123456",
+			Icon:  "travel-icon",
+		})
+		assert.Nil(t, err)
+		got := next(t)
+		assert.Equal(t, category.EventCategoryCreated, got.Type)
+		assert.Equal(t, c.ID, got.ResourceID)
+
+		_, err = cr.DeleteCategory(ctxWithUser1, category.DeleteCategoryReq{
+			ID:       c.ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, category.EventCategoryDeleted, next(t).Type)
+	})
+
+	t.Run("expenses changed by category changes", func(t *testing.T) {
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       "Flight",
+			Amount:     500,
+			Date:       "2006-01-02",
+			CategoryID: categories[1].ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expense.EventExpenseCreated, next(t).Type)
+
+		_, err = cr.MergeCategories(ctxWithUser1, category.MergeCategoriesReq{
+			SourceID: categories[1].ID,
+			TargetID: categories[0].ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, category.EventCategoryDeleted, next(t).Type)
+		got := next(t)
+		assert.Equal(t, expense.EventExpenseUpdated, got.Type)
+		assert.Equal(t, e.ID, got.ResourceID)
+
+		_, err = cr.DeleteCategory(ctxWithUser1, category.DeleteCategoryReq{
+			ID:       categories[0].ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, category.EventCategoryDeleted, next(t).Type)
+		// the merged expense and the one of the expense changes
+		var deleted []string
+		for range 2 {
+			got := next(t)
+			assert.Equal(t, expense.EventExpenseDeleted, got.Type)
+			deleted = append(deleted, got.ResourceID)
+		}
+		assert.Contains(t, deleted, e.ID)
+		assert.Empty(t, sub.Events())
+	})
+
+	t.Run("failed writes are not published", func(t *testing.T) {
+		_, err := er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{
+			ID:   "missing",
+			Name: toPtr(t, "Nothing"),
+		})
+		assert.NotNil(t, err)
+		trashed, err := er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: "missing"})
+		assert.Nil(t, err)
+		assert.False(t, trashed)
+
+		assert.Empty(t, sub.Events())
+	})
+
+	t.Run("changes of other users are not published", func(t *testing.T) {
+		_, err := cr.CreateCategory(ctxWithUser2, category.CreateCategoryReq{
+			Name:  "travel",
+			Color: "#123456",
+			Icon:  "travel-icon",
+		})
+		assert.Nil(t, err)
+
+		assert.Empty(t, sub.Events())
+	})
+}
@@ -3,7 +3,9 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cativovo/budget-tracker/internal"
@@ -12,11 +14,13 @@ import (
 	"github.com/cativovo/budget-tracker/internal/logger"
 	"github.com/cativovo/budget-tracker/internal/user"
 	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
 )
 
 type ExpenseRepository struct {
 	db *DB
 	cr CategoryRepository
+	ar AccountRepository
 }
 
 var _ expense.Repository = (*ExpenseRepository)(nil)
@@ -25,29 +29,20 @@ func NewExpenseRepository(db *DB, cr CategoryRepository) ExpenseRepository {
 	return ExpenseRepository{
 		db: db,
 		cr: cr,
+		ar: NewAccountRepository(db),
 	}
 }
 
 func (er *ExpenseRepository) ExpenseByID(ctx context.Context, id string) (expense.Expense, error) {
+	return expenseByID(ctx, er.db.readerWriter, id)
+}
+
+func expenseByID(ctx context.Context, db sqlx.QueryerContext, id string) (expense.Expense, error) {
 	u := user.FromContext(ctx)
 	logger := logger.FromContext(ctx)
 
 	sb := sqlbuilder.SQLite.NewSelectBuilder()
-	sb.Select(
-		"e.id",
-		"e.name",
-		"e.amount",
-		"e.date",
-		"e.note",
-		"e.created_at",
-		"e.updated_at",
-		sb.As("c.id", "category_id"),
-		sb.As("c.name", "category_name"),
-		sb.As("c.color", "category_color"),
-		sb.As("c.icon", "category_icon"),
-		sb.As("c.created_at", "category_created_at"),
-		sb.As("c.updated_at", "category_updated_at"),
-	)
+	sb.Select(expenseColumns(sb)...)
 	sb.From("expense e")
 	sb.Join(
 		"category c",
@@ -57,6 +52,7 @@ func (er *ExpenseRepository) ExpenseByID(ctx context.Context, id string) (expens
 		sb.And(
 			sb.EQ("e.id", id),
 			sb.EQ("e.user_id", u.ID),
+			sb.IsNull("e.deleted_at"),
 		),
 	)
 
@@ -68,59 +64,128 @@ func (er *ExpenseRepository) ExpenseByID(ctx context.Context, id string) (expens
 		"args", args,
 	)
 
-	var dst struct {
-		ID                string    `db:"id"`
-		Name              string    `db:"name"`
-		Amount            int64     `db:"amount"`
-		Date              time.Time `db:"date"`
-		Note              string    `db:"note"`
-		Category          string    `db:"category"`
-		CreatedAt         time.Time `db:"created_at"`
-		UpdatedAt         time.Time `db:"updated_at"`
-		CategoryID        string    `db:"category_id"`
-		CategoryName      string    `db:"category_name"`
-		CategoryColor     string    `db:"category_color"`
-		CategoryIcon      string    `db:"category_icon"`
-		CategoryCreatedAt time.Time `db:"category_created_at"`
-		CategoryUpdatedAt time.Time `db:"category_updated_at"`
-	}
-	if err := er.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+	var dst expenseDst
+	if err := sqlx.GetContext(ctx, db, &dst, q, args...); err != nil {
 		if err == sql.ErrNoRows {
 			return expense.Expense{}, internal.NewError(internal.ErrorCodeNotFound, "Expense not found")
 		}
 
-		return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.ExpenseByID: GetContext: %w", err)
+		return expense.Expense{}, fmt.Errorf("sqlite.expenseByID: GetContext: %w", err)
 	}
 
-	return expense.Expense{
-		ID:     dst.ID,
-		Name:   dst.Name,
-		Amount: dst.Amount,
-		Date:   dst.Date,
-		Note:   dst.Note,
-		Category: category.Category{
-			ID:        dst.CategoryID,
-			Name:      dst.CategoryName,
-			Color:     dst.CategoryColor,
-			Icon:      dst.CategoryIcon,
-			CreatedAt: dst.CategoryCreatedAt,
-			UpdatedAt: dst.CategoryUpdatedAt,
-		},
-		CreatedAt: dst.CreatedAt,
-		UpdatedAt: dst.UpdatedAt,
-	}, nil
+	return dst.toExpense(), nil
 }
 
 func (er *ExpenseRepository) ListExpenseSummaries(ctx context.Context, lo internal.ListOptions) ([]expense.ExpenseSummary, error) {
 	panic("not yet implemented")
 }
 
+// ListExpenses lists the expenses within the date range, oldest first.
+func (er *ExpenseRepository) ListExpenses(ctx context.Context, l expense.ListExpensesReq) ([]expense.Expense, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(expenseColumns(sb)...)
+	sb.From("expense e")
+	sb.Join(
+		"category c",
+		"c.id = e.category_id",
+	)
+	sb.Where(
+		sb.And(
+			sb.EQ("e.user_id", u.ID),
+			sb.IsNull("e.deleted_at"),
+			sb.Between("e.date", l.StartDate, l.EndDate),
+		),
+	)
+	sb.OrderBy("e.date", "e.created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List expenses",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []expenseDst
+	if err := er.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.ExpenseRepository.ListExpenses: SelectContext: %w", err)
+	}
+
+	result := make([]expense.Expense, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, v.toExpense())
+	}
+
+	return result, nil
+}
+
 func (er *ExpenseRepository) CreateExpense(ctx context.Context, e expense.CreateExpenseReq) (expense.Expense, error) {
 	category, err := er.cr.CategoryByID(ctx, e.CategoryID)
 	if err != nil {
 		return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.CreateExpense: %w", err)
 	}
 
+	if e.AccountID != nil {
+		if _, err := er.ar.AccountByID(ctx, *e.AccountID); err != nil {
+			return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.CreateExpense: %w", err)
+		}
+	}
+
+	dst, err := insertExpense(ctx, er.db.readerWriter, e)
+	if err != nil {
+		return expense.Expense{}, err
+	}
+
+	er.db.publish(ctx, expense.EventExpenseCreated, dst.ID)
+	return expense.Expense{
+		ID:        dst.ID,
+		Name:      e.Name,
+		Amount:    e.Amount,
+		Date:      dst.Date,
+		Note:      e.Note,
+		Category:  category,
+		Tags:      e.Tags,
+		AccountID: e.AccountID,
+		Version:   dst.Version,
+		CreatedAt: dst.CreatedAt,
+		UpdatedAt: dst.UpdatedAt,
+	}, nil
+}
+
+func (er *ExpenseRepository) UpdateExpense(ctx context.Context, e expense.UpdateExpenseReq) (expense.Expense, error) {
+	if e.CategoryID != nil {
+		if _, err := er.cr.CategoryByID(ctx, *e.CategoryID); err != nil {
+			return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.UpdateExpense: %w", err)
+		}
+	}
+	if e.AccountID != nil {
+		if _, err := er.ar.AccountByID(ctx, *e.AccountID); err != nil {
+			return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.UpdateExpense: %w", err)
+		}
+	}
+
+	if err := updateExpense(ctx, er.db.readerWriter, e); err != nil {
+		return expense.Expense{}, err
+	}
+
+	er.db.publish(ctx, expense.EventExpenseUpdated, e.ID)
+
+	// read back since the version is bumped by a trigger after the update
+	return er.ExpenseByID(ctx, e.ID)
+}
+
+type insertedExpenseDst struct {
+	ID        string    `db:"id"`
+	Date      time.Time `db:"date"`
+	Version   int64     `db:"version"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func insertExpense(ctx context.Context, db sqlx.QueryerContext, e expense.CreateExpenseReq) (insertedExpenseDst, error) {
 	u := user.FromContext(ctx)
 	logger := logger.FromContext(ctx)
 
@@ -132,6 +197,8 @@ func (er *ExpenseRepository) CreateExpense(ctx context.Context, e expense.Create
 		"date",
 		"category_id",
 		"note",
+		"tags",
+		"account_id",
 		"user_id",
 	)
 	ib.Values(
@@ -140,11 +207,14 @@ func (er *ExpenseRepository) CreateExpense(ctx context.Context, e expense.Create
 		e.Date,
 		e.CategoryID,
 		e.Note,
+		jsonColumn[[]string]{V: e.Tags},
+		e.AccountID,
 		u.ID,
 	)
 	ib.Returning(
 		"id",
 		"date",
+		"version",
 		"created_at",
 		"updated_at",
 	)
@@ -157,29 +227,15 @@ func (er *ExpenseRepository) CreateExpense(ctx context.Context, e expense.Create
 		"args", args,
 	)
 
-	var dst struct {
-		ID        string    `db:"id"`
-		Date      time.Time `db:"date"`
-		CreatedAt time.Time `db:"created_at"`
-		UpdatedAt time.Time `db:"updated_at"`
-	}
-	if err := er.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
-		return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.CreateExpense: GetContext: %w", err)
+	var dst insertedExpenseDst
+	if err := sqlx.GetContext(ctx, db, &dst, q, args...); err != nil {
+		return insertedExpenseDst{}, fmt.Errorf("sqlite.insertExpense: GetContext: %w", err)
 	}
 
-	return expense.Expense{
-		ID:        dst.ID,
-		Name:      e.Name,
-		Amount:    e.Amount,
-		Date:      dst.Date,
-		Note:      e.Note,
-		Category:  category,
-		CreatedAt: dst.CreatedAt,
-		UpdatedAt: dst.UpdatedAt,
-	}, nil
+	return dst, nil
 }
 
-func (er *ExpenseRepository) UpdateExpense(ctx context.Context, e expense.UpdateExpenseReq) (expense.Expense, error) {
+func updateExpense(ctx context.Context, db sqlx.ExtContext, e expense.UpdateExpenseReq) error {
 	u := user.FromContext(ctx)
 	logger := logger.FromContext(ctx)
 
@@ -201,16 +257,35 @@ func (er *ExpenseRepository) UpdateExpense(ctx context.Context, e expense.Update
 	if e.Note != nil {
 		ub.SetMore(ub.Assign("note", e.Note))
 	}
+	if e.Tags != nil {
+		ub.SetMore(ub.Assign("tags", jsonColumn[[]string]{V: *e.Tags}))
+	}
+	if e.AccountID != nil {
+		ub.SetMore(ub.Assign("account_id", e.AccountID))
+	}
+	var stays []string
+	if e.Date != nil {
+		stays = append(stays, "date(date) IS date("+ub.Var(*e.Date)+")")
+	}
+	if e.AccountID != nil {
+		stays = append(stays, "account_id IS "+ub.Var(*e.AccountID))
+	}
+	if len(stays) > 0 {
+		ub.SetMore(unclearIfMoved(stays...))
+	}
+	ub.SetMore(ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
 
 	ub.Where(
 		ub.And(
 			ub.EQ("id", e.ID),
 			ub.EQ("user_id", u.ID),
+			ub.IsNull("deleted_at"),
+			ub.IsNull("reconciled_at"),
 		),
 	)
-
-	// https://github.com/huandu/go-sqlbuilder/issues/142
-	ub.SQL("RETURNING name, amount, date, category_id, note, created_at, updated_at")
+	if e.Version != nil {
+		ub.Where(ub.EQ("version", *e.Version))
+	}
 
 	q, args := ub.Build()
 
@@ -220,67 +295,539 @@ func (er *ExpenseRepository) UpdateExpense(ctx context.Context, e expense.Update
 		"args", args,
 	)
 
-	var dst struct {
-		Name       string    `db:"name"`
-		Amount     int64     `db:"amount"`
-		Date       time.Time `db:"date"`
-		CategoryID string    `db:"category_id"`
-		Note       string    `db:"note"`
-		CreatedAt  time.Time `db:"created_at"`
-		UpdatedAt  time.Time `db:"updated_at"`
+	result, err := db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.updateExpense: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.updateExpense: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return unchangedReason(ctx, db, e.ID)
+	}
+
+	return nil
+}
+
+// unclearIfMoved takes the expense out of the reconciliation it was cleared in
+// unless the conditions hold, an expense moved to another account or date no
+// longer counts toward the cleared balance.
+func unclearIfMoved(stays ...string) string {
+	return fmt.Sprintf("reconciliation_id = CASE WHEN %s THEN reconciliation_id END", strings.Join(stays, " AND "))
+}
+
+// DeleteExpense moves the expense to the trash.
+func (er *ExpenseRepository) DeleteExpense(ctx context.Context, d expense.DeleteExpenseReq) (bool, error) {
+	trashed, err := trashExpense(ctx, er.db.readerWriter, d)
+	if err != nil {
+		return false, err
+	}
+
+	if trashed {
+		er.db.publish(ctx, expense.EventExpenseDeleted, d.ID)
+	}
+	return trashed, nil
+}
+
+// trashExpense moves the expense to the trash. It reports whether the expense
+// was trashed, deleting an expense that doesn't exist is not an error.
+func trashExpense(ctx context.Context, db sqlx.ExtContext, d expense.DeleteExpenseReq) (bool, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("expense")
+	ub.Set(
+		ub.Assign("deleted_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		// trashed expenses don't count toward the cleared balance
+		ub.Assign("reconciliation_id", nil),
+	)
+	ub.Where(
+		ub.And(
+			ub.EQ("id", d.ID),
+			ub.EQ("user_id", u.ID),
+			ub.IsNull("deleted_at"),
+			ub.IsNull("reconciled_at"),
+		),
+	)
+	if d.Version != nil {
+		ub.Where(ub.EQ("version", *d.Version))
+	}
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Soft delete expense",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return false, fmt.Errorf("sqlite.trashExpense: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sqlite.trashExpense: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		if err := unchangedReason(ctx, db, d.ID); internal.GetErrorCode(err) != internal.ErrorCodeNotFound {
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// errBatchRolledBack rolls back the transaction of an all-or-nothing batch
+// with a failed operation.
+var errBatchRolledBack = errors.New("batch rolled back")
+
+// BatchExpenses runs every operation in a savepoint so a failed operation is
+// undone on its own. The expenses of a bulk update are selected within the
+// transaction.
+func (er *ExpenseRepository) BatchExpenses(ctx context.Context, b expense.BatchReq) ([]expense.BatchResult, error) {
+	logger := logger.FromContext(ctx)
+
+	var (
+		results []expense.BatchResult
+		events  []pendingEvent
+	)
+	err := er.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		operations := b.Operations
+		if b.Filter != nil {
+			ids, err := filteredExpenseIDs(ctx, tx, *b.Filter)
+			if err != nil {
+				return err
+			}
+
+			operations = make([]expense.BatchOperation, 0, len(ids))
+			for _, id := range ids {
+				u := b.Patch.UpdateReq(id)
+				operations = append(operations, expense.BatchOperation{
+					Type:   expense.BatchOperationUpdate,
+					Update: &u,
+				})
+			}
+		}
+
+		results = make([]expense.BatchResult, 0, len(operations))
+		failed := false
+		for i, o := range operations {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_operation"); err != nil {
+				return fmt.Errorf("sqlite.ExpenseRepository.BatchExpenses: ExecContext: %w", err)
+			}
+
+			result, event, err := batchOperation(ctx, tx, o)
+			if err != nil {
+				if internal.GetErrorCode(err) == internal.ErrorCodeInternal {
+					return err
+				}
+
+				if _, err := tx.ExecContext(ctx, "ROLLBACK TO batch_operation"); err != nil {
+					return fmt.Errorf("sqlite.ExpenseRepository.BatchExpenses: ExecContext: %w", err)
+				}
+
+				logger.Infow(
+					"Failed batch operation",
+					"index", i,
+					"type", o.Type,
+					"error", err,
+				)
+
+				message := internal.GetErrorMessage(err)
+				result = expense.BatchResult{
+					Type:   o.Type,
+					ID:     batchOperationID(o),
+					Status: expense.BatchStatusFailed,
+					Error:  &message,
+				}
+				failed = true
+			}
+
+			if _, err := tx.ExecContext(ctx, "RELEASE batch_operation"); err != nil {
+				return fmt.Errorf("sqlite.ExpenseRepository.BatchExpenses: ExecContext: %w", err)
+			}
+
+			results = append(results, result)
+			if event != nil {
+				events = append(events, *event)
+			}
+		}
+
+		if failed && b.AllOrNothing {
+			return errBatchRolledBack
+		}
+		return nil
+	})
+	if errors.Is(err, errBatchRolledBack) {
+		for i, r := range results {
+			if r.Status == expense.BatchStatusSucceeded {
+				results[i].Status = expense.BatchStatusRolledBack
+				results[i].Expense = nil
+				if r.Type == expense.BatchOperationCreate {
+					results[i].ID = ""
+				}
+			}
+		}
+
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range events {
+		er.db.publish(ctx, e.typ, e.resourceID)
+	}
+
+	return results, nil
+}
+
+// batchOperation runs the operation of a batch. It returns the event to
+// publish if the operation changed an expense.
+func batchOperation(ctx context.Context, tx *sqlx.Tx, o expense.BatchOperation) (expense.BatchResult, *pendingEvent, error) {
+	result := expense.BatchResult{
+		Type:   o.Type,
+		Status: expense.BatchStatusSucceeded,
+	}
+
+	switch o.Type {
+	case expense.BatchOperationCreate:
+		if err := checkExpenseReferences(ctx, tx, &o.Create.CategoryID, o.Create.AccountID); err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+
+		dst, err := insertExpense(ctx, tx, *o.Create)
+		if err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+		result.ID = dst.ID
+
+		e, err := expenseByID(ctx, tx, dst.ID)
+		if err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+		result.Expense = &e
+
+		return result, &pendingEvent{typ: expense.EventExpenseCreated, resourceID: dst.ID}, nil
+	case expense.BatchOperationUpdate:
+		result.ID = o.Update.ID
+
+		if err := checkExpenseReferences(ctx, tx, o.Update.CategoryID, o.Update.AccountID); err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+
+		if err := updateExpense(ctx, tx, *o.Update); err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+
+		// read back since the version is bumped by a trigger after the update
+		e, err := expenseByID(ctx, tx, o.Update.ID)
+		if err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+		result.Expense = &e
+
+		return result, &pendingEvent{typ: expense.EventExpenseUpdated, resourceID: o.Update.ID}, nil
+	case expense.BatchOperationDelete:
+		result.ID = o.Delete.ID
+
+		trashed, err := trashExpense(ctx, tx, *o.Delete)
+		if err != nil {
+			return expense.BatchResult{}, nil, err
+		}
+		if !trashed {
+			return result, nil, nil
+		}
+
+		return result, &pendingEvent{typ: expense.EventExpenseDeleted, resourceID: o.Delete.ID}, nil
+	default:
+		return expense.BatchResult{}, nil, fmt.Errorf("sqlite.batchOperation: unknown operation %s", o.Type)
+	}
+}
+
+func batchOperationID(o expense.BatchOperation) string {
+	switch {
+	case o.Update != nil:
+		return o.Update.ID
+	case o.Delete != nil:
+		return o.Delete.ID
+	default:
+		return ""
+	}
+}
+
+// checkExpenseReferences fails if the category or the account of an expense
+// doesn't belong to the user. Nil references are not checked.
+func checkExpenseReferences(ctx context.Context, tx *sqlx.Tx, categoryID *string, accountID *string) error {
+	if categoryID != nil {
+		if _, err := activeCategoryName(ctx, tx, *categoryID); err != nil {
+			return err
+		}
+	}
+
+	if accountID != nil {
+		found, err := ownedRowExists(ctx, tx, "account", *accountID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return internal.NewError(internal.ErrorCodeNotFound, "Account not found")
+		}
+	}
+
+	return nil
+}
+
+// filteredExpenseIDs lists the expenses a bulk update changes, oldest first.
+func filteredExpenseIDs(ctx context.Context, tx *sqlx.Tx, f expense.ExpenseFilter) ([]string, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("id")
+	sb.From("expense")
+	sb.Where(
+		sb.EQ("user_id", u.ID),
+		sb.IsNull("deleted_at"),
+		sb.IsNull("reconciled_at"),
+	)
+	if f.StartDate != "" {
+		sb.Where(sb.GTE("date", f.StartDate))
+	}
+	if f.EndDate != "" {
+		sb.Where(sb.LTE("date", f.EndDate))
+	}
+	if f.CategoryID != "" {
+		sb.Where(sb.EQ("category_id", f.CategoryID))
+	}
+	if f.AccountID != "" {
+		sb.Where(sb.EQ("account_id", f.AccountID))
+	}
+	if f.Search != "" {
+		sb.Where(fmt.Sprintf("instr(lower(name), lower(%s)) > 0", sb.Var(f.Search)))
+	}
+	sb.OrderBy("date", "created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List filtered expense ids",
+		"query", q,
+		"args", args,
+	)
+
+	var ids []string
+	if err := tx.SelectContext(ctx, &ids, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.filteredExpenseIDs: SelectContext: %w", err)
 	}
-	if err := er.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+
+	return ids, nil
+}
+
+// unchangedReason tells why an expense was not changed. Reconciled expenses
+// are locked, an expense that is still around was changed since the version
+// the change expected, otherwise the expense doesn't exist.
+func unchangedReason(ctx context.Context, db sqlx.QueryerContext, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("reconciled_at IS NOT NULL")
+	sb.From("expense")
+	sb.Where(
+		sb.And(
+			sb.EQ("id", id),
+			sb.EQ("user_id", u.ID),
+			sb.IsNull("deleted_at"),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find if expense is reconciled",
+		"query", q,
+		"args", args,
+	)
+
+	var reconciled bool
+	if err := sqlx.GetContext(ctx, db, &reconciled, q, args...); err != nil {
 		if err == sql.ErrNoRows {
-			return expense.Expense{}, internal.NewError(internal.ErrorCodeNotFound, "Expense not found")
+			return internal.NewError(internal.ErrorCodeNotFound, "Expense not found")
 		}
 
-		return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.UpdateExpense: GetContext: %w", err)
+		return fmt.Errorf("sqlite.unchangedReason: GetContext: %w", err)
+	}
+	if reconciled {
+		return internal.NewError(internal.ErrorCodeConflict, "Expense is reconciled")
 	}
 
-	c, err := er.cr.CategoryByID(ctx, dst.CategoryID)
+	return internal.NewError(internal.ErrorCodePreconditionFailed, "Expense was changed by someone else")
+}
+
+func (er *ExpenseRepository) ListDeletedExpenses(ctx context.Context, lo internal.ListOptions) ([]expense.DeletedExpense, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(append(expenseColumns(sb), "e.deleted_at")...)
+	sb.From("expense e")
+	sb.Join(
+		"category c",
+		"c.id = e.category_id",
+	)
+	sb.Where(
+		sb.And(
+			sb.EQ("e.user_id", u.ID),
+			sb.IsNotNull("e.deleted_at"),
+		),
+	)
+	sb.OrderBy("e.deleted_at").Desc()
+	sb.Limit(lo.Limit)
+	sb.Offset(lo.Offset)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List deleted expenses",
+		"query", q,
+		"args", args,
+	)
+
+	rows, err := er.db.reader.QueryxContext(ctx, q, args...)
 	if err != nil {
-		return expense.Expense{}, fmt.Errorf("sqlite.ExpenseRepository.UpdateExpense: %w", err)
+		return nil, fmt.Errorf("sqlite.ExpenseRepository.ListDeletedExpenses: QueryxContext: %w", err)
 	}
+	defer rows.Close()
 
-	return expense.Expense{
-		ID:        e.ID,
-		Name:      dst.Name,
-		Amount:    dst.Amount,
-		Date:      dst.Date,
-		Note:      dst.Note,
-		Category:  c,
-		CreatedAt: dst.CreatedAt,
-		UpdatedAt: dst.UpdatedAt,
-	}, nil
+	var result []expense.DeletedExpense
+	for rows.Next() {
+		var dst struct {
+			expenseDst
+			DeletedAt time.Time `db:"deleted_at"`
+		}
+		if err := rows.StructScan(&dst); err != nil {
+			return nil, fmt.Errorf("sqlite.ExpenseRepository.ListDeletedExpenses: StructScan: %w", err)
+		}
+
+		result = append(result, expense.DeletedExpense{
+			Expense:   dst.toExpense(),
+			DeletedAt: dst.DeletedAt,
+		})
+	}
+
+	return result, nil
 }
 
-func (er *ExpenseRepository) DeleteExpense(ctx context.Context, id string) error {
+// RestoreExpense takes the expense out of the trash. An expense whose
+// category is still in the trash can't be restored on its own.
+func (er *ExpenseRepository) RestoreExpense(ctx context.Context, id string) (expense.Expense, error) {
 	u := user.FromContext(ctx)
 	logger := logger.FromContext(ctx)
 
+	err := er.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		sb := sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select(sb.As("c.deleted_at IS NOT NULL", "category_deleted"))
+		sb.From("expense e")
+		sb.Join(
+			"category c",
+			"c.id = e.category_id",
+		)
+		sb.Where(
+			sb.And(
+				sb.EQ("e.id", id),
+				sb.EQ("e.user_id", u.ID),
+				sb.IsNotNull("e.deleted_at"),
+			),
+		)
+
+		q, args := sb.Build()
+
+		logger.Infow(
+			"Find deleted expense by id",
+			"query", q,
+			"args", args,
+		)
+
+		var categoryDeleted bool
+		if err := tx.GetContext(ctx, &categoryDeleted, q, args...); err != nil {
+			if err == sql.ErrNoRows {
+				return internal.NewError(internal.ErrorCodeNotFound, "Expense not found")
+			}
+
+			return fmt.Errorf("sqlite.ExpenseRepository.RestoreExpense: GetContext: %w", err)
+		}
+		if categoryDeleted {
+			return internal.NewError(internal.ErrorCodeConflict, "Category of the expense is deleted")
+		}
+
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("expense")
+		ub.Set(ub.Assign("deleted_at", nil))
+		ub.Where(ub.EQ("id", id))
+
+		q, args = ub.Build()
+
+		logger.Infow(
+			"Restore expense",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("sqlite.ExpenseRepository.RestoreExpense: ExecContext: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return expense.Expense{}, err
+	}
+
+	er.db.publish(ctx, expense.EventExpenseCreated, id)
+	return er.ExpenseByID(ctx, id)
+}
+
+// PurgeDeletedExpenses permanently deletes the expenses of every user that
+// were moved to the trash before the given time.
+func (er *ExpenseRepository) PurgeDeletedExpenses(ctx context.Context, before time.Time) (int64, error) {
+	logger := logger.FromContext(ctx)
+
 	db := sqlbuilder.SQLite.NewDeleteBuilder()
 	db.DeleteFrom("expense")
 	db.Where(
 		db.And(
-			db.EQ("id", id),
-			db.EQ("user_id", u.ID),
+			db.IsNotNull("deleted_at"),
+			db.LT("deleted_at", before.UTC().Format(timestampLayout)),
 		),
 	)
 
 	q, args := db.Build()
 
 	logger.Infow(
-		"Delete expense",
+		"Purge deleted expenses",
 		"query", q,
 		"args", args,
 	)
 
-	_, err := er.db.readerWriter.ExecContext(ctx, q, args...)
+	result, err := er.db.readerWriter.ExecContext(ctx, q, args...)
 	if err != nil {
-		return fmt.Errorf("sqlite.ExpenseRepository.DeleteExpense: ExecContext: %w", err)
+		return 0, fmt.Errorf("sqlite.ExpenseRepository.PurgeDeletedExpenses: ExecContext: %w", err)
 	}
 
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.ExpenseRepository.PurgeDeletedExpenses: RowsAffected: %w", err)
+	}
+
+	return affected, nil
 }
 
 func (er *ExpenseRepository) ExpenseGroupByID(ctx context.Context, id string) (expense.ExpenseGroup, error) {
@@ -298,3 +845,75 @@ func (er *ExpenseRepository) UpdateExpenseGroup(ctx context.Context, e expense.U
 func (er *ExpenseRepository) DeleteExpenseGroup(ctx context.Context, id string) error {
 	panic("not yet implemented")
 }
+
+func expenseColumns(sb *sqlbuilder.SelectBuilder) []string {
+	return []string{
+		"e.id",
+		"e.name",
+		"e.amount",
+		"e.date",
+		"e.note",
+		"e.tags",
+		"e.account_id",
+		"e.reconciled_at",
+		"e.version",
+		"e.created_at",
+		"e.updated_at",
+		sb.As("c.id", "category_id"),
+		sb.As("c.name", "category_name"),
+		sb.As("c.color", "category_color"),
+		sb.As("c.icon", "category_icon"),
+		sb.As("c.version", "category_version"),
+		sb.As("c.created_at", "category_created_at"),
+		sb.As("c.updated_at", "category_updated_at"),
+		sb.As("c.parent_id", "category_parent_id"),
+	}
+}
+
+type expenseDst struct {
+	ID                string               `db:"id"`
+	Name              string               `db:"name"`
+	Amount            int64                `db:"amount"`
+	Date              time.Time            `db:"date"`
+	Note              string               `db:"note"`
+	Tags              jsonColumn[[]string] `db:"tags"`
+	AccountID         *string              `db:"account_id"`
+	ReconciledAt      *time.Time           `db:"reconciled_at"`
+	Version           int64                `db:"version"`
+	CreatedAt         time.Time            `db:"created_at"`
+	UpdatedAt         time.Time            `db:"updated_at"`
+	CategoryID        string               `db:"category_id"`
+	CategoryName      string               `db:"category_name"`
+	CategoryColor     string               `db:"category_color"`
+	CategoryIcon      string               `db:"category_icon"`
+	CategoryVersion   int64                `db:"category_version"`
+	CategoryCreatedAt time.Time            `db:"category_created_at"`
+	CategoryUpdatedAt time.Time            `db:"category_updated_at"`
+	CategoryParentID  *string              `db:"category_parent_id"`
+}
+
+func (d expenseDst) toExpense() expense.Expense {
+	return expense.Expense{
+		ID:           d.ID,
+		Name:         d.Name,
+		Amount:       d.Amount,
+		Date:         d.Date,
+		Note:         d.Note,
+		Tags:         d.Tags.V,
+		AccountID:    d.AccountID,
+		ReconciledAt: d.ReconciledAt,
+		Version:      d.Version,
+		Category: category.Category{
+			ID:        d.CategoryID,
+			Name:      d.CategoryName,
+			Color:     d.CategoryColor,
+			Icon:      d.CategoryIcon,
+			Version:   d.CategoryVersion,
+			CreatedAt: d.CategoryCreatedAt,
+			UpdatedAt: d.CategoryUpdatedAt,
+			ParentID:  d.CategoryParentID,
+		},
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
 	"github.com/cativovo/budget-tracker/internal/expense"
 	"github.com/cativovo/budget-tracker/internal/logger"
 	"github.com/cativovo/budget-tracker/internal/sqlite"
@@ -52,6 +53,7 @@ func TestCreateFindExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense 1 Note",
 				Category:  user1Categories[0],
+				Version:   1,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -70,6 +72,28 @@ func TestCreateFindExpense(t *testing.T) {
 				Amount:    7070,
 				Date:      time.Date(2006, time.January, 3, 0, 0, 0, 0, time.UTC),
 				Category:  user1Categories[0],
+				Version:   1,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			},
+		},
+		{
+			name: fmt.Sprintf("%s's expense 3 with tags", user1.Name),
+			user: user1,
+			input: expense.CreateExpenseReq{
+				Name:       "Expense 3",
+				Amount:     7171,
+				Date:       "2006-01-04",
+				CategoryID: user1Categories[1].ID,
+				Tags:       []string{"coffee", "treat"},
+			},
+			want: expense.Expense{
+				Name:      "Expense 3",
+				Amount:    7171,
+				Date:      time.Date(2006, time.January, 4, 0, 0, 0, 0, time.UTC),
+				Category:  user1Categories[1],
+				Tags:      []string{"coffee", "treat"},
+				Version:   1,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -90,6 +114,7 @@ func TestCreateFindExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense 1 Note",
 				Category:  user2Categories[0],
+				Version:   1,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -168,6 +193,7 @@ func TestUpdateExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense 1 Note",
 				Category:  user1Categories[0],
+				Version:   2,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -191,6 +217,7 @@ func TestUpdateExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense 1 Note",
 				Category:  user1Categories[0],
+				Version:   2,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -214,6 +241,7 @@ func TestUpdateExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 10, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense 1 Note",
 				Category:  user1Categories[0],
+				Version:   2,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -237,6 +265,7 @@ func TestUpdateExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense 1 Note",
 				Category:  user1Categories[1],
+				Version:   2,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -260,6 +289,33 @@ func TestUpdateExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense Uno Noto",
 				Category:  user1Categories[0],
+				Version:   2,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			},
+		},
+		{
+			name: fmt.Sprintf("update %s's expense tags", user1.Name),
+			user: user1,
+			expense: expense.CreateExpenseReq{
+				Name:       "Expense 1",
+				Amount:     6969,
+				Date:       "2006-01-02",
+				CategoryID: user1Categories[0].ID,
+				Note:       "Expense 1 Note",
+				Tags:       []string{"coffee"},
+			},
+			input: expense.UpdateExpenseReq{
+				Tags: &[]string{"coffee", "treat"},
+			},
+			want: expense.Expense{
+				Name:      "Expense 1",
+				Amount:    6969,
+				Date:      time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC),
+				Note:      "Expense 1 Note",
+				Category:  user1Categories[0],
+				Tags:      []string{"coffee", "treat"},
+				Version:   2,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -287,6 +343,7 @@ func TestUpdateExpense(t *testing.T) {
 				Date:      time.Date(2006, time.January, 10, 0, 0, 0, 0, time.UTC),
 				Note:      "Expense Uno Noto",
 				Category:  user1Categories[1],
+				Version:   2,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			},
@@ -324,6 +381,33 @@ func TestUpdateExpense(t *testing.T) {
 		assert.Equal(t, expense.Expense{}, foundExpense)
 		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
 	})
+
+	t.Run("can't update or delete expense changed by someone else", func(t *testing.T) {
+		ctxWithUser := user.ContextWithUser(ctxWithLogger, user1)
+		createdExpense, err := er.CreateExpense(ctxWithUser, tests[0].expense)
+		assert.Nil(t, err)
+
+		updatedExpense, err := er.UpdateExpense(ctxWithUser, expense.UpdateExpenseReq{
+			ID:      createdExpense.ID,
+			Name:    toPtr(t, "Foo"),
+			Version: toPtr(t, createdExpense.Version),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, createdExpense.Version+1, updatedExpense.Version)
+
+		_, err = er.UpdateExpense(ctxWithUser, expense.UpdateExpenseReq{
+			ID:      createdExpense.ID,
+			Name:    toPtr(t, "Bar"),
+			Version: toPtr(t, createdExpense.Version),
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodePreconditionFailed, "Expense was changed by someone else"), err)
+
+		_, err = er.DeleteExpense(ctxWithUser, expense.DeleteExpenseReq{ID: createdExpense.ID, Version: toPtr(t, createdExpense.Version)})
+		assert.Equal(t, internal.NewError(internal.ErrorCodePreconditionFailed, "Expense was changed by someone else"), err)
+
+		_, err = er.DeleteExpense(ctxWithUser, expense.DeleteExpenseReq{ID: createdExpense.ID, Version: toPtr(t, updatedExpense.Version)})
+		assert.Nil(t, err)
+	})
 }
 
 func TestDeleteExpense(t *testing.T) {
@@ -377,7 +461,7 @@ func TestDeleteExpense(t *testing.T) {
 			createdExpense, err := er.CreateExpense(ctxWithUser, test.expense)
 			assert.Nil(t, err)
 
-			err = er.DeleteExpense(ctxWithUser, createdExpense.ID)
+			_, err = er.DeleteExpense(ctxWithUser, expense.DeleteExpenseReq{ID: createdExpense.ID})
 			assert.Nil(t, err)
 
 			_, err = er.ExpenseByID(ctxWithUser, createdExpense.ID)
@@ -391,8 +475,9 @@ func TestDeleteExpense(t *testing.T) {
 		assert.Nil(t, err)
 
 		ctxWithUser2 := user.ContextWithUser(ctxWithLogger, user2)
-		err = er.DeleteExpense(ctxWithUser2, createdExpense.ID)
+		trashed, err := er.DeleteExpense(ctxWithUser2, expense.DeleteExpenseReq{ID: createdExpense.ID})
 		assert.Nil(t, err)
+		assert.False(t, trashed)
 
 		foundExpense, err := er.ExpenseByID(ctxWithUser1, createdExpense.ID)
 		assert.Nil(t, err)
@@ -400,6 +485,197 @@ func TestDeleteExpense(t *testing.T) {
 	})
 }
 
+func TestBatchExpenses(t *testing.T) {
+	dh := newDBHelper(t, "test_batch_expenses.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+
+	user1 := users[0]
+	user1Categories := createCategories(t, dh.db, user1)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, user1)
+
+	createExpense := func(t *testing.T, name string, date string, categoryID string) expense.Expense {
+		t.Helper()
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       name,
+			Amount:     6969,
+			Date:       date,
+			CategoryID: categoryID,
+		})
+		assert.Nil(t, err)
+		return e
+	}
+
+	t.Run("operations", func(t *testing.T) {
+		toUpdate := createExpense(t, "Update me", "2006-01-02", user1Categories[0].ID)
+		toDelete := createExpense(t, "Delete me", "2006-01-02", user1Categories[0].ID)
+
+		results, err := er.BatchExpenses(ctxWithUser1, expense.BatchReq{
+			Operations: []expense.BatchOperation{
+				{
+					Type: expense.BatchOperationCreate,
+					Create: &expense.CreateExpenseReq{
+						Name:       "Created",
+						Amount:     100,
+						Date:       "2006-01-03",
+						CategoryID: user1Categories[1].ID,
+					},
+				},
+				{
+					Type:   expense.BatchOperationUpdate,
+					Update: &expense.UpdateExpenseReq{ID: toUpdate.ID, CategoryID: &user1Categories[1].ID, Version: &toUpdate.Version},
+				},
+				{
+					Type:   expense.BatchOperationDelete,
+					Delete: &expense.DeleteExpenseReq{ID: toDelete.ID},
+				},
+				{
+					Type:   expense.BatchOperationUpdate,
+					Update: &expense.UpdateExpenseReq{ID: "123", Name: toPtr(t, "Foo")},
+				},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, results, 4)
+
+		assert.Equal(t, expense.BatchStatusSucceeded, results[0].Status)
+		assert.NotEmpty(t, results[0].ID)
+		created, err := er.ExpenseByID(ctxWithUser1, results[0].ID)
+		assert.Nil(t, err)
+		assert.Equal(t, &created, results[0].Expense)
+
+		assert.Equal(t, expense.BatchStatusSucceeded, results[1].Status)
+		updated, err := er.ExpenseByID(ctxWithUser1, toUpdate.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, user1Categories[1], updated.Category)
+		assert.Equal(t, &updated, results[1].Expense)
+
+		assert.Equal(t, expense.BatchResult{Type: expense.BatchOperationDelete, ID: toDelete.ID, Status: expense.BatchStatusSucceeded}, results[2])
+		_, err = er.ExpenseByID(ctxWithUser1, toDelete.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+
+		assert.Equal(t, expense.BatchResult{
+			Type:   expense.BatchOperationUpdate,
+			ID:     "123",
+			Status: expense.BatchStatusFailed,
+			Error:  toPtr(t, "Expense not found"),
+		}, results[3])
+	})
+
+	t.Run("all or nothing", func(t *testing.T) {
+		stale := createExpense(t, "Stale", "2006-01-02", user1Categories[0].ID)
+		_, err := er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: stale.ID, Name: toPtr(t, "Changed")})
+		assert.Nil(t, err)
+
+		results, err := er.BatchExpenses(ctxWithUser1, expense.BatchReq{
+			Operations: []expense.BatchOperation{
+				{
+					Type: expense.BatchOperationCreate,
+					Create: &expense.CreateExpenseReq{
+						Name:       "Rolled back",
+						Amount:     100,
+						Date:       "2006-01-03",
+						CategoryID: user1Categories[1].ID,
+					},
+				},
+				{
+					Type:   expense.BatchOperationDelete,
+					Delete: &expense.DeleteExpenseReq{ID: stale.ID, Version: &stale.Version},
+				},
+			},
+			AllOrNothing: true,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []expense.BatchResult{
+			{
+				Type:   expense.BatchOperationCreate,
+				Status: expense.BatchStatusRolledBack,
+			},
+			{
+				Type:   expense.BatchOperationDelete,
+				ID:     stale.ID,
+				Status: expense.BatchStatusFailed,
+				Error:  toPtr(t, "Expense was changed by someone else"),
+			},
+		}, results)
+
+		expenses, err := er.ListExpenses(ctxWithUser1, expense.ListExpensesReq{StartDate: "2006-01-03", EndDate: "2006-01-03"})
+		assert.Nil(t, err)
+		for _, e := range expenses {
+			assert.NotEqual(t, "Rolled back", e.Name)
+		}
+	})
+
+	t.Run("bulk update", func(t *testing.T) {
+		matched := []expense.Expense{
+			createExpense(t, "Coffee", "2007-01-01", user1Categories[2].ID),
+			createExpense(t, "Iced coffee", "2007-01-02", user1Categories[2].ID),
+		}
+		createExpense(t, "Coffee beans", "2008-01-01", user1Categories[2].ID)
+		createExpense(t, "Tea", "2007-01-01", user1Categories[2].ID)
+
+		results, err := er.BatchExpenses(ctxWithUser1, expense.BatchReq{
+			Filter: &expense.ExpenseFilter{
+				StartDate:  "2007-01-01",
+				EndDate:    "2007-12-31",
+				CategoryID: user1Categories[2].ID,
+				Search:     "COFFEE",
+			},
+			Patch: &expense.ExpensePatch{
+				CategoryID: &user1Categories[0].ID,
+				Tags:       &[]string{"coffee"},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, results, len(matched))
+
+		for i, e := range matched {
+			assert.Equal(t, e.ID, results[i].ID)
+			assert.Equal(t, expense.BatchStatusSucceeded, results[i].Status)
+
+			found, err := er.ExpenseByID(ctxWithUser1, e.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, user1Categories[0], found.Category)
+			assert.Equal(t, []string{"coffee"}, found.Tags)
+		}
+	})
+
+	t.Run("can't change expense of other user", func(t *testing.T) {
+		e := createExpense(t, "Mine", "2006-01-02", user1Categories[0].ID)
+
+		ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+		results, err := er.BatchExpenses(ctxWithUser2, expense.BatchReq{
+			Operations: []expense.BatchOperation{
+				{
+					Type:   expense.BatchOperationDelete,
+					Delete: &expense.DeleteExpenseReq{ID: e.ID, Version: &e.Version},
+				},
+				{
+					Type: expense.BatchOperationCreate,
+					Create: &expense.CreateExpenseReq{
+						Name:       "Theirs",
+						Amount:     100,
+						Date:       "2006-01-03",
+						CategoryID: user1Categories[0].ID,
+					},
+				},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expense.BatchStatusSucceeded, results[0].Status)
+		assert.Equal(t, expense.BatchStatusFailed, results[1].Status)
+		assert.Equal(t, toPtr(t, "Category not found"), results[1].Error)
+
+		_, err = er.ExpenseByID(ctxWithUser1, e.ID)
+		assert.Nil(t, err)
+	})
+}
+
 func assertExpense(t *testing.T, want, got expense.Expense) {
 	t.Helper()
 
@@ -419,3 +695,167 @@ func assertExpense(t *testing.T, want, got expense.Expense) {
 
 	assert.Equal(t, want, got)
 }
+
+func TestRestoreExpense(t *testing.T) {
+	dh := newDBHelper(t, "test_restore_expense.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+
+	user1 := users[0]
+	user1Categories := createCategories(t, dh.db, user1)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, user1)
+
+	user2 := users[1]
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, user2)
+
+	createdExpense, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+		Name:       "Expense 1",
+		Amount:     6969,
+		Date:       "2006-01-02",
+		CategoryID: user1Categories[0].ID,
+		Note:       "Expense 1 Note",
+	})
+	assert.Nil(t, err)
+
+	_, err = er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: createdExpense.ID})
+	assert.Nil(t, err)
+
+	deletedExpenses, err := er.ListDeletedExpenses(ctxWithUser1, internal.ListOptions{Limit: 10})
+	assert.Nil(t, err)
+	assert.Len(t, deletedExpenses, 1)
+	// deleting is a change too
+	createdExpense.Version++
+	assert.Equal(t, createdExpense, deletedExpenses[0].Expense)
+	assert.WithinDuration(t, time.Now(), deletedExpenses[0].DeletedAt, time.Second*5)
+
+	t.Run("can't restore expense of other user", func(t *testing.T) {
+		_, err := er.RestoreExpense(ctxWithUser2, createdExpense.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+	})
+
+	t.Run("can't update deleted expense", func(t *testing.T) {
+		_, err := er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: createdExpense.ID, Name: toPtr(t, "Foo")})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+	})
+
+	t.Run("restore expense", func(t *testing.T) {
+		restored, err := er.RestoreExpense(ctxWithUser1, createdExpense.ID)
+		assert.Nil(t, err)
+		createdExpense.Version++
+		assert.Equal(t, createdExpense, restored)
+
+		_, err = er.RestoreExpense(ctxWithUser1, createdExpense.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+	})
+
+	t.Run("can't restore expense of deleted category", func(t *testing.T) {
+		_, err := er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: createdExpense.ID})
+		assert.Nil(t, err)
+
+		_, err = cr.DeleteCategory(ctxWithUser1, category.DeleteCategoryReq{
+			ID:       user1Categories[0].ID,
+			Strategy: category.DeleteStrategyCascade,
+		})
+		assert.Nil(t, err)
+
+		_, err = er.RestoreExpense(ctxWithUser1, createdExpense.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Category of the expense is deleted"), err)
+	})
+}
+
+func TestPurgeDeletedExpenses(t *testing.T) {
+	dh := newDBHelper(t, "test_purge_deleted_expenses.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+
+	kept, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Expense 1",
+		Amount:     6969,
+		Date:       "2006-01-02",
+		CategoryID: categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	deleted, err := er.CreateExpense(ctxWithUser, expense.CreateExpenseReq{
+		Name:       "Expense 2",
+		Amount:     7070,
+		Date:       "2006-01-02",
+		CategoryID: categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	_, err = er.DeleteExpense(ctxWithUser, expense.DeleteExpenseReq{ID: deleted.ID})
+	assert.Nil(t, err)
+
+	purged, err := er.PurgeDeletedExpenses(ctxWithLogger, time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), purged)
+
+	purged, err = er.PurgeDeletedExpenses(ctxWithLogger, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = er.RestoreExpense(ctxWithUser, deleted.ID)
+	assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+
+	found, err := er.ExpenseByID(ctxWithUser, kept.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, kept, found)
+}
+
+func TestListExpenses(t *testing.T) {
+	dh := newDBHelper(t, "test_list_expenses.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	otherCategories := createCategories(t, dh.db, users[1])
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	var expenses []expense.Expense
+	for _, date := range []string{"2006-01-03", "2006-01-01", "2006-01-02", "2006-02-01"} {
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       "Expense " + date,
+			Amount:     6969,
+			Date:       date,
+			CategoryID: categories[0].ID,
+		})
+		assert.Nil(t, err)
+		expenses = append(expenses, e)
+	}
+
+	_, err := er.CreateExpense(ctxWithUser2, expense.CreateExpenseReq{
+		Name:       "Other",
+		Amount:     6969,
+		Date:       "2006-01-02",
+		CategoryID: otherCategories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	_, err = er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: expenses[0].ID})
+	assert.Nil(t, err)
+
+	got, err := er.ListExpenses(ctxWithUser1, expense.ListExpensesReq{
+		StartDate: "2006-01-01",
+		EndDate:   "2006-01-31",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []expense.Expense{expenses[1], expenses[2]}, got)
+}
@@ -0,0 +1,424 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+type GoalRepository struct {
+	db *DB
+}
+
+var _ goal.Repository = (*GoalRepository)(nil)
+
+func NewGoalRepository(db *DB) GoalRepository {
+	return GoalRepository{
+		db: db,
+	}
+}
+
+func (gr *GoalRepository) GoalByID(ctx context.Context, id string) (goal.Goal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(goalColumns...)
+	sb.From("goal g")
+	sb.Where(
+		sb.And(
+			sb.EQ("g.id", id),
+			sb.EQ("g.user_id", u.ID),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find goal by id",
+		"query", q,
+		"args", args,
+	)
+
+	var dst goalDst
+	if err := gr.db.reader.GetContext(ctx, &dst, q, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return goal.Goal{}, internal.NewError(internal.ErrorCodeNotFound, "Goal not found")
+		}
+
+		return goal.Goal{}, fmt.Errorf("sqlite.GoalRepository.GoalByID: GetContext: %w", err)
+	}
+
+	return goal.Goal(dst), nil
+}
+
+// ListGoals lists the goals of the user, nearest deadline first.
+func (gr *GoalRepository) ListGoals(ctx context.Context) ([]goal.Goal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(goalColumns...)
+	sb.From("goal g")
+	sb.Where(sb.EQ("g.user_id", u.ID))
+	sb.OrderBy("g.deadline", "g.created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List goals",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []goalDst
+	if err := gr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.GoalRepository.ListGoals: SelectContext: %w", err)
+	}
+
+	result := make([]goal.Goal, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, goal.Goal(v))
+	}
+
+	return result, nil
+}
+
+func (gr *GoalRepository) CreateGoal(ctx context.Context, c goal.CreateGoalReq) (goal.Goal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("goal")
+	ib.Cols("name", "target_amount", "deadline", "user_id")
+	ib.Values(c.Name, c.TargetAmount, c.Deadline, u.ID)
+	ib.Returning("id", "name", "target_amount", "deadline", "created_at", "updated_at")
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert goal",
+		"query", q,
+		"args", args,
+	)
+
+	var dst goalDst
+	if err := gr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return goal.Goal{}, fmt.Errorf("sqlite.GoalRepository.CreateGoal: GetContext: %w", err)
+	}
+
+	return goal.Goal(dst), nil
+}
+
+func (gr *GoalRepository) UpdateGoal(ctx context.Context, g goal.UpdateGoalReq) (goal.Goal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("goal")
+	ub.Set(ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+
+	if g.Name != nil {
+		ub.SetMore(ub.Assign("name", g.Name))
+	}
+	if g.TargetAmount != nil {
+		ub.SetMore(ub.Assign("target_amount", g.TargetAmount))
+	}
+	if g.Deadline != nil {
+		ub.SetMore(ub.Assign("deadline", g.Deadline))
+	}
+
+	ub.Where(
+		ub.And(
+			ub.EQ("id", g.ID),
+			ub.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Update goal",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := gr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return goal.Goal{}, fmt.Errorf("sqlite.GoalRepository.UpdateGoal: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return goal.Goal{}, fmt.Errorf("sqlite.GoalRepository.UpdateGoal: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return goal.Goal{}, internal.NewError(internal.ErrorCodeNotFound, "Goal not found")
+	}
+
+	return gr.GoalByID(ctx, g.ID)
+}
+
+// DeleteGoal deletes the goal along with its contributions. The income and
+// transfer records of the contributions are kept.
+func (gr *GoalRepository) DeleteGoal(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("goal")
+	db.Where(
+		db.And(
+			db.EQ("id", id),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete goal",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := gr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.GoalRepository.DeleteGoal: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.GoalRepository.DeleteGoal: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Goal not found")
+	}
+
+	return nil
+}
+
+func (gr *GoalRepository) ListContributions(ctx context.Context, goalID string) ([]goal.Contribution, error) {
+	if _, err := gr.GoalByID(ctx, goalID); err != nil {
+		return nil, fmt.Errorf("sqlite.GoalRepository.ListContributions: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(contributionColumns...)
+	sb.From("goal_contribution")
+	sb.Where(
+		sb.And(
+			sb.EQ("goal_id", goalID),
+			sb.EQ("user_id", u.ID),
+		),
+	)
+	sb.OrderBy("date", "created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List goal contributions",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []contributionDst
+	if err := gr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.GoalRepository.ListContributions: SelectContext: %w", err)
+	}
+
+	result := make([]goal.Contribution, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, goal.Contribution(v))
+	}
+
+	return result, nil
+}
+
+// CreateContribution links the contribution to its source. The contributions
+// of a transfer, across every goal, can't add up to more than the transfer.
+// Incomes aren't tracked so their amount is unknown, an income can only be
+// linked once.
+func (gr *GoalRepository) CreateContribution(ctx context.Context, c goal.CreateContributionReq) (goal.Contribution, error) {
+	if _, err := gr.GoalByID(ctx, c.GoalID); err != nil {
+		return goal.Contribution{}, fmt.Errorf("sqlite.GoalRepository.CreateContribution: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var dst contributionDst
+	err := gr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		switch c.SourceType {
+		case goal.ContributionSourceTransfer:
+			q := `
+				SELECT t.amount - COALESCE((
+					SELECT SUM(gc.amount) FROM goal_contribution gc
+					WHERE gc.source_type = ? AND gc.source_id = t.id
+				), 0)
+				FROM transfer t
+				WHERE t.id = ? AND t.user_id = ?`
+			args := []any{goal.ContributionSourceTransfer, c.SourceID, u.ID}
+
+			logger.Infow(
+				"Find remaining amount of transfer",
+				"query", q,
+				"args", args,
+			)
+
+			var remaining int64
+			if err := tx.GetContext(ctx, &remaining, q, args...); err != nil {
+				if err == sql.ErrNoRows {
+					return internal.NewError(internal.ErrorCodeNotFound, "Transfer not found")
+				}
+				return fmt.Errorf("sqlite.GoalRepository.CreateContribution: GetContext: %w", err)
+			}
+			if c.Amount > remaining {
+				return internal.NewErrorf(internal.ErrorCodeConflict, "Only %d of the transfer is left to contribute", remaining)
+			}
+		case goal.ContributionSourceIncome:
+			sb := sqlbuilder.SQLite.NewSelectBuilder()
+			sb.Select("COUNT(*)")
+			sb.From("goal_contribution")
+			sb.Where(
+				sb.EQ("source_type", goal.ContributionSourceIncome),
+				sb.EQ("source_id", c.SourceID),
+				sb.EQ("user_id", u.ID),
+			)
+
+			q, args := sb.Build()
+
+			logger.Infow(
+				"Count contributions of income",
+				"query", q,
+				"args", args,
+			)
+
+			var count int64
+			if err := tx.GetContext(ctx, &count, q, args...); err != nil {
+				return fmt.Errorf("sqlite.GoalRepository.CreateContribution: GetContext: %w", err)
+			}
+			if count > 0 {
+				return internal.NewError(internal.ErrorCodeConflict, "Income is already linked to a contribution")
+			}
+		}
+
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("goal_contribution")
+		ib.Cols("amount", "date", "note", "source_type", "source_id", "goal_id", "user_id")
+		ib.Values(c.Amount, c.Date, c.Note, c.SourceType, c.SourceID, c.GoalID, u.ID)
+		ib.Returning(contributionColumns...)
+
+		q, args := ib.Build()
+
+		logger.Infow(
+			"Insert goal contribution",
+			"query", q,
+			"args", args,
+		)
+
+		if err := tx.GetContext(ctx, &dst, q, args...); err != nil {
+			return fmt.Errorf("sqlite.GoalRepository.CreateContribution: GetContext: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return goal.Contribution{}, err
+	}
+
+	return goal.Contribution(dst), nil
+}
+
+func (gr *GoalRepository) DeleteContribution(ctx context.Context, d goal.DeleteContributionReq) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("goal_contribution")
+	db.Where(
+		db.And(
+			db.EQ("id", d.ID),
+			db.EQ("goal_id", d.GoalID),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete goal contribution",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := gr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.GoalRepository.DeleteContribution: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.GoalRepository.DeleteContribution: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Contribution not found")
+	}
+
+	return nil
+}
+
+var goalColumns = []string{
+	"g.id",
+	"g.name",
+	"g.target_amount",
+	"g.deadline",
+	"COALESCE((SELECT SUM(gc.amount) FROM goal_contribution gc WHERE gc.goal_id = g.id), 0) AS saved_amount",
+	"g.created_at",
+	"g.updated_at",
+}
+
+type goalDst struct {
+	ID           string    `db:"id"`
+	Name         string    `db:"name"`
+	TargetAmount int64     `db:"target_amount"`
+	Deadline     time.Time `db:"deadline"`
+	SavedAmount  int64     `db:"saved_amount"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+var contributionColumns = []string{
+	"id",
+	"goal_id",
+	"amount",
+	"date",
+	"note",
+	"source_type",
+	"source_id",
+	"created_at",
+	"updated_at",
+}
+
+type contributionDst struct {
+	ID         string                  `db:"id"`
+	GoalID     string                  `db:"goal_id"`
+	Amount     int64                   `db:"amount"`
+	Date       time.Time               `db:"date"`
+	Note       string                  `db:"note"`
+	SourceType goal.ContributionSource `db:"source_type"`
+	SourceID   string                  `db:"source_id"`
+	CreatedAt  time.Time               `db:"created_at"`
+	UpdatedAt  time.Time               `db:"updated_at"`
+}
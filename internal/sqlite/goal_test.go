@@ -0,0 +1,200 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/goal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoal(t *testing.T) {
+	dh := newDBHelper(t, "test_goal.db")
+	defer dh.clean()
+
+	gr := sqlite.NewGoalRepository(dh.db)
+	ar := sqlite.NewAccountRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	car, err := gr.CreateGoal(ctxWithUser1, goal.CreateGoalReq{
+		Name:         "Car",
+		TargetAmount: 100000,
+		Deadline:     "2007-01-01",
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, car.ID)
+	assert.Equal(t, "Car", car.Name)
+	assert.Equal(t, int64(100000), car.TargetAmount)
+	assert.Equal(t, time.Date(2007, time.January, 1, 0, 0, 0, 0, time.UTC), car.Deadline)
+	assert.Equal(t, int64(0), car.SavedAmount)
+	assert.WithinDuration(t, time.Now(), car.CreatedAt, time.Second*5)
+
+	emergency, err := gr.CreateGoal(ctxWithUser1, goal.CreateGoalReq{
+		Name:         "Emergency fund",
+		TargetAmount: 50000,
+		Deadline:     "2006-06-01",
+	})
+	assert.Nil(t, err)
+
+	t.Run("list goals by deadline", func(t *testing.T) {
+		goals, err := gr.ListGoals(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.Equal(t, []goal.Goal{emergency, car}, goals)
+
+		goals, err = gr.ListGoals(ctxWithUser2)
+		assert.Nil(t, err)
+		assert.Empty(t, goals)
+	})
+
+	t.Run("can't access goal of other user", func(t *testing.T) {
+		_, err := gr.GoalByID(ctxWithUser2, car.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Goal not found"), err)
+
+		_, err = gr.CreateContribution(ctxWithUser2, goal.CreateContributionReq{
+			GoalID:     car.ID,
+			Amount:     100,
+			Date:       "2006-01-02",
+			SourceType: goal.ContributionSourceIncome,
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+
+		err = gr.DeleteGoal(ctxWithUser2, car.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Goal not found"), err)
+	})
+
+	t.Run("contributions add up to the saved amount", func(t *testing.T) {
+		salary, err := gr.CreateContribution(ctxWithUser1, goal.CreateContributionReq{
+			GoalID:     car.ID,
+			Amount:     20000,
+			Date:       "2006-01-02",
+			Note:       "From salary",
+			SourceType: goal.ContributionSourceIncome,
+			SourceID:   "income-1",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, car.ID, salary.GoalID)
+		assert.Equal(t, goal.ContributionSourceIncome, salary.SourceType)
+		assert.Equal(t, "income-1", salary.SourceID)
+		assert.Equal(t, time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC), salary.Date)
+
+		_, err = gr.CreateContribution(ctxWithUser1, goal.CreateContributionReq{
+			GoalID:     emergency.ID,
+			Amount:     100,
+			Date:       "2006-01-02",
+			SourceType: goal.ContributionSourceIncome,
+			SourceID:   "income-1",
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Income is already linked to a contribution"), err)
+
+		createAccount := func(name string) account.Account {
+			a, err := ar.CreateAccount(ctxWithUser1, account.CreateAccountReq{
+				Name:     name,
+				Type:     account.AccountTypeBank,
+				Currency: "PHP",
+			})
+			assert.Nil(t, err)
+			return a
+		}
+		savings, err := ar.CreateTransfer(ctxWithUser1, account.CreateTransferReq{
+			FromAccountID: createAccount("Bank").ID,
+			ToAccountID:   createAccount("Savings").ID,
+			Amount:        5000,
+			Date:          "2006-01-01",
+		})
+		assert.Nil(t, err)
+
+		_, err = gr.CreateContribution(ctxWithUser1, goal.CreateContributionReq{
+			GoalID:     car.ID,
+			Amount:     5000,
+			Date:       "2006-01-01",
+			SourceType: goal.ContributionSourceTransfer,
+			SourceID:   "transfer-1",
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Transfer not found"), err)
+
+		transfer, err := gr.CreateContribution(ctxWithUser1, goal.CreateContributionReq{
+			GoalID:     car.ID,
+			Amount:     3000,
+			Date:       "2006-01-01",
+			SourceType: goal.ContributionSourceTransfer,
+			SourceID:   savings.ID,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, savings.ID, transfer.SourceID)
+
+		// the rest of the transfer can go to another goal
+		_, err = gr.CreateContribution(ctxWithUser1, goal.CreateContributionReq{
+			GoalID:     emergency.ID,
+			Amount:     2001,
+			Date:       "2006-01-01",
+			SourceType: goal.ContributionSourceTransfer,
+			SourceID:   savings.ID,
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Only 2000 of the transfer is left to contribute"), err)
+
+		_, err = gr.CreateContribution(ctxWithUser1, goal.CreateContributionReq{
+			GoalID:     emergency.ID,
+			Amount:     2000,
+			Date:       "2006-01-01",
+			SourceType: goal.ContributionSourceTransfer,
+			SourceID:   savings.ID,
+		})
+		assert.Nil(t, err)
+
+		err = ar.DeleteTransfer(ctxWithUser1, savings.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Transfer is linked to goal contributions"), err)
+
+		contributions, err := gr.ListContributions(ctxWithUser1, car.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []goal.Contribution{transfer, salary}, contributions)
+
+		got, err := gr.GoalByID(ctxWithUser1, car.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(23000), got.SavedAmount)
+		assert.Equal(t, 23.0, got.Progress())
+
+		err = gr.DeleteContribution(ctxWithUser1, goal.DeleteContributionReq{GoalID: emergency.ID, ID: transfer.ID})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Contribution not found"), err)
+
+		err = gr.DeleteContribution(ctxWithUser1, goal.DeleteContributionReq{GoalID: car.ID, ID: transfer.ID})
+		assert.Nil(t, err)
+
+		got, err = gr.GoalByID(ctxWithUser1, car.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(20000), got.SavedAmount)
+	})
+
+	t.Run("update goal", func(t *testing.T) {
+		got, err := gr.UpdateGoal(ctxWithUser1, goal.UpdateGoalReq{
+			ID:           car.ID,
+			TargetAmount: toPtr(t, int64(40000)),
+			Deadline:     toPtr(t, "2006-12-01"),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "Car", got.Name)
+		assert.Equal(t, int64(40000), got.TargetAmount)
+		assert.Equal(t, time.Date(2006, time.December, 1, 0, 0, 0, 0, time.UTC), got.Deadline)
+		assert.Equal(t, int64(20000), got.SavedAmount)
+
+		_, err = gr.UpdateGoal(ctxWithUser2, goal.UpdateGoalReq{ID: car.ID, Name: toPtr(t, "Mine")})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Goal not found"), err)
+	})
+
+	t.Run("delete goal", func(t *testing.T) {
+		err := gr.DeleteGoal(ctxWithUser1, car.ID)
+		assert.Nil(t, err)
+
+		_, err = gr.ListContributions(ctxWithUser1, car.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+}
@@ -135,8 +135,10 @@ func createCategories(t *testing.T, db *sqlite.DB, u user.User) []category.Categ
 		Name      string    `db:"name"`
 		Color     string    `db:"color"`
 		Icon      string    `db:"icon"`
+		Version   int64     `db:"version"`
 		CreatedAt time.Time `db:"created_at"`
 		UpdatedAt time.Time `db:"updated_at"`
+		ParentID  *string   `db:"parent_id"`
 	}
 
 	sb := sqlbuilder.SQLite.NewSelectBuilder()
@@ -145,8 +147,10 @@ func createCategories(t *testing.T, db *sqlite.DB, u user.User) []category.Categ
 		"name",
 		"color",
 		"icon",
+		"version",
 		"created_at",
 		"updated_at",
+		"parent_id",
 	)
 	sb.From("category")
 	sb.Where(
@@ -165,6 +169,21 @@ func createCategories(t *testing.T, db *sqlite.DB, u user.User) []category.Categ
 	return categories
 }
 
+// reconcileExpense marks the expense reconciled without going through a
+// reconciliation session.
+func reconcileExpense(t *testing.T, db *sqlite.DB, id string) {
+	t.Helper()
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("expense")
+	ub.Set(ub.Assign("reconciled_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+	ub.Where(ub.EQ("id", id))
+
+	q, args := ub.Build()
+
+	db.ReaderWriter().MustExec(q, args...)
+}
+
 func toPtr[T any](t *testing.T, v T) *T {
 	t.Helper()
 	return &v
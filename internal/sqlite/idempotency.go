@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/idempotency"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	db *DB
+}
+
+var _ idempotency.Repository = (*IdempotencyRepository)(nil)
+
+func NewIdempotencyRepository(db *DB) IdempotencyRepository {
+	return IdempotencyRepository{
+		db: db,
+	}
+}
+
+var idempotencyKeyColumns = []string{
+	"key",
+	"request_hash",
+	"status_code",
+	"header",
+	"body",
+	"expires_at",
+	"created_at",
+}
+
+func (ir *IdempotencyRepository) ReserveKey(ctx context.Context, r idempotency.ReserveKeyReq) (idempotency.Record, bool, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var (
+		record   idempotency.Record
+		reserved bool
+	)
+	err := ir.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("idempotency_key")
+		ib.Cols(
+			"key",
+			"request_hash",
+			"expires_at",
+			"user_id",
+		)
+		ib.Values(
+			r.Key,
+			r.RequestHash,
+			r.ExpiresAt.UTC().Format(timestampLayout),
+			u.ID,
+		)
+		// the key is taken over when it expired or its request was abandoned
+		ib.SQL(fmt.Sprintf(`ON CONFLICT (user_id, key) DO UPDATE SET
+			request_hash = excluded.request_hash,
+			status_code = NULL,
+			header = NULL,
+			body = NULL,
+			expires_at = excluded.expires_at,
+			created_at = CURRENT_TIMESTAMP
+		WHERE idempotency_key.expires_at <= CURRENT_TIMESTAMP
+			OR (idempotency_key.status_code IS NULL AND idempotency_key.created_at < %s)`,
+			ib.Var(r.AbandonedBefore.UTC().Format(timestampLayout)),
+		))
+		ib.SQL("RETURNING key")
+
+		q, args := ib.Build()
+
+		logger.Infow(
+			"Reserve idempotency key",
+			"query", q,
+			"args", args,
+		)
+
+		var key string
+		err := tx.GetContext(ctx, &key, q, args...)
+		if err == nil {
+			reserved = true
+			return nil
+		}
+		// nothing is returned when the key is still in use
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("sqlite.IdempotencyRepository.ReserveKey: GetContext: %w", err)
+		}
+
+		sb := sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select(idempotencyKeyColumns...)
+		sb.From("idempotency_key")
+		sb.Where(
+			sb.EQ("user_id", u.ID),
+			sb.EQ("key", r.Key),
+		)
+
+		q, args = sb.Build()
+
+		logger.Infow(
+			"Get idempotency key",
+			"query", q,
+			"args", args,
+		)
+
+		var dst idempotencyKeyDst
+		if err := tx.GetContext(ctx, &dst, q, args...); err != nil {
+			return fmt.Errorf("sqlite.IdempotencyRepository.ReserveKey: GetContext: %w", err)
+		}
+		record = dst.toRecord()
+
+		return nil
+	})
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	return record, reserved, nil
+}
+
+func (ir *IdempotencyRepository) SaveResponse(ctx context.Context, key string, r idempotency.Response) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("idempotency_key")
+	ub.Set(
+		ub.Assign("status_code", r.StatusCode),
+		ub.Assign("header", jsonColumn[http.Header]{V: r.Header}),
+		ub.Assign("body", r.Body),
+	)
+	ub.Where(
+		ub.EQ("user_id", u.ID),
+		ub.EQ("key", key),
+	)
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Save idempotency key response",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := ir.db.readerWriter.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.IdempotencyRepository.SaveResponse: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (ir *IdempotencyRepository) DeleteKey(ctx context.Context, key string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("idempotency_key")
+	db.Where(
+		db.EQ("user_id", u.ID),
+		db.EQ("key", key),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete idempotency key",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := ir.db.readerWriter.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.IdempotencyRepository.DeleteKey: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (ir *IdempotencyRepository) PurgeExpiredKeys(ctx context.Context, before time.Time) (int64, error) {
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("idempotency_key")
+	db.Where(db.LT("expires_at", before.UTC().Format(timestampLayout)))
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Purge expired idempotency keys",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := ir.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.IdempotencyRepository.PurgeExpiredKeys: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.IdempotencyRepository.PurgeExpiredKeys: RowsAffected: %w", err)
+	}
+
+	return affected, nil
+}
+
+type idempotencyKeyDst struct {
+	Key         string                  `db:"key"`
+	RequestHash string                  `db:"request_hash"`
+	StatusCode  sql.NullInt64           `db:"status_code"`
+	Header      jsonColumn[http.Header] `db:"header"`
+	Body        []byte                  `db:"body"`
+	ExpiresAt   time.Time               `db:"expires_at"`
+	CreatedAt   time.Time               `db:"created_at"`
+}
+
+func (d idempotencyKeyDst) toRecord() idempotency.Record {
+	r := idempotency.Record{
+		Key:         d.Key,
+		RequestHash: d.RequestHash,
+		ExpiresAt:   d.ExpiresAt,
+		CreatedAt:   d.CreatedAt,
+	}
+	if d.StatusCode.Valid {
+		r.Response = &idempotency.Response{
+			StatusCode: int(d.StatusCode.Int64),
+			Header:     d.Header.V,
+			Body:       d.Body,
+		}
+	}
+	return r
+}
@@ -0,0 +1,108 @@
+package sqlite_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/idempotency"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	dh := newDBHelper(t, "test_idempotency.db")
+	defer dh.clean()
+
+	ir := sqlite.NewIdempotencyRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	now := time.Now()
+	reserve := func(key string, hash string) idempotency.ReserveKeyReq {
+		return idempotency.ReserveKeyReq{
+			Key:             key,
+			RequestHash:     hash,
+			ExpiresAt:       now.Add(time.Hour),
+			AbandonedBefore: now.Add(-time.Minute),
+		}
+	}
+
+	t.Run("reserve", func(t *testing.T) {
+		_, reserved, err := ir.ReserveKey(ctxWithUser1, reserve("a", "hash1"))
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+
+		record, reserved, err := ir.ReserveKey(ctxWithUser1, reserve("a", "hash2"))
+		assert.Nil(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, "hash1", record.RequestHash)
+		assert.Nil(t, record.Response)
+
+		// keys are per user
+		_, reserved, err = ir.ReserveKey(ctxWithUser2, reserve("a", "hash2"))
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("save response", func(t *testing.T) {
+		err := ir.SaveResponse(ctxWithUser1, "a", idempotency.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"id":"1"}`),
+		})
+		assert.Nil(t, err)
+
+		record, reserved, err := ir.ReserveKey(ctxWithUser1, reserve("a", "hash1"))
+		assert.Nil(t, err)
+		assert.False(t, reserved)
+		assert.Equal(t, &idempotency.Response{
+			StatusCode: http.StatusCreated,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       []byte(`{"id":"1"}`),
+		}, record.Response)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		err := ir.DeleteKey(ctxWithUser1, "a")
+		assert.Nil(t, err)
+
+		_, reserved, err := ir.ReserveKey(ctxWithUser1, reserve("a", "hash3"))
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+	})
+
+	t.Run("take over abandoned and expired keys", func(t *testing.T) {
+		// still pending after the request was abandoned
+		req := reserve("a", "hash4")
+		req.AbandonedBefore = now.Add(time.Minute)
+		_, reserved, err := ir.ReserveKey(ctxWithUser1, req)
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+
+		req = reserve("b", "hash1")
+		req.ExpiresAt = now.Add(-time.Hour)
+		_, reserved, err = ir.ReserveKey(ctxWithUser1, req)
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+		err = ir.SaveResponse(ctxWithUser1, "b", idempotency.Response{StatusCode: http.StatusNoContent})
+		assert.Nil(t, err)
+
+		record, reserved, err := ir.ReserveKey(ctxWithUser1, reserve("b", "hash2"))
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+		assert.Empty(t, record.RequestHash)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		count, err := ir.PurgeExpiredKeys(ctxWithLogger, now.Add(2*time.Hour))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), count)
+	})
+}
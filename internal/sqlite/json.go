@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// jsonColumn stores V as JSON text. Empty arrays and nulls are scanned as the
+// zero value of T.
+type jsonColumn[T any] struct {
+	V T
+}
+
+func (j jsonColumn[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.jsonColumn.Value: %w", err)
+	}
+
+	if string(b) == "null" {
+		return "[]", nil
+	}
+
+	return string(b), nil
+}
+
+func (j *jsonColumn[T]) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		return fmt.Errorf("sqlite.jsonColumn.Scan: unsupported type %T", src)
+	}
+
+	if s := string(b); s == "[]" || s == "null" || s == "" {
+		return nil
+	}
+
+	if err := json.Unmarshal(b, &j.V); err != nil {
+		return fmt.Errorf("sqlite.jsonColumn.Scan: %w", err)
+	}
+
+	return nil
+}
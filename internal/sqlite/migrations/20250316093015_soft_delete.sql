@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE category ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE expense ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_category_deleted_at ON category(deleted_at);
+CREATE INDEX idx_expense_deleted_at ON expense(deleted_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_category_deleted_at;
+DROP INDEX idx_expense_deleted_at;
+
+ALTER TABLE category DROP COLUMN deleted_at;
+ALTER TABLE expense DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
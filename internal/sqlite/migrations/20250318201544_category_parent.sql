@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE category ADD COLUMN parent_id TEXT REFERENCES category(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_category_parent_id ON category(parent_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_category_parent_id;
+
+ALTER TABLE category DROP COLUMN parent_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE expense ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';
+
+CREATE TABLE rule (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	name TEXT NOT NULL,
+	-- lower value runs first
+	priority INTEGER NOT NULL,
+	name_contains TEXT NOT NULL,
+	note_contains TEXT NOT NULL,
+	name_regex TEXT NOT NULL,
+	min_amount INTEGER,
+	max_amount INTEGER,
+	days_of_week TEXT NOT NULL DEFAULT '[]',
+	category_id TEXT REFERENCES category(id) ON DELETE SET NULL,
+	rename TEXT,
+	append_tags TEXT NOT NULL DEFAULT '[]',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_rule_user_id ON rule(user_id);
+CREATE INDEX idx_rule_category_id ON rule(category_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_rule_user_id;
+DROP INDEX idx_rule_category_id;
+DROP TABLE rule;
+
+ALTER TABLE expense DROP COLUMN tags;
+
+-- +goose StatementEnd
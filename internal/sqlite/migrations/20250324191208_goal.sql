@@ -0,0 +1,46 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE goal (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	name TEXT NOT NULL,
+	target_amount INTEGER NOT NULL,
+	deadline DATE NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_goal_user_id ON goal(user_id);
+
+CREATE TABLE goal_contribution (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	amount INTEGER NOT NULL,
+	date DATE NOT NULL,
+	note TEXT NOT NULL,
+	-- 'income' or 'transfer'
+	source_type TEXT NOT NULL,
+	-- id of the income or transfer record the money came from
+	source_id TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	goal_id TEXT NOT NULL REFERENCES goal(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_goal_contribution_goal_id ON goal_contribution(goal_id);
+CREATE INDEX idx_goal_contribution_user_id ON goal_contribution(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_goal_contribution_goal_id;
+DROP INDEX idx_goal_contribution_user_id;
+DROP TABLE goal_contribution;
+
+DROP INDEX idx_goal_user_id;
+DROP TABLE goal;
+
+-- +goose StatementEnd
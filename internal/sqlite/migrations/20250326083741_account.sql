@@ -0,0 +1,56 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE account (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	name TEXT NOT NULL,
+	-- 'cash', 'bank' or 'credit_card'
+	type TEXT NOT NULL,
+	opening_balance INTEGER NOT NULL,
+	-- ISO 4217 currency code
+	currency TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_account_user_id ON account(user_id);
+
+CREATE TABLE transfer (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	amount INTEGER NOT NULL,
+	date DATE NOT NULL,
+	note TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	from_account_id TEXT NOT NULL REFERENCES account(id),
+	to_account_id TEXT NOT NULL REFERENCES account(id),
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_transfer_from_account_id ON transfer(from_account_id);
+CREATE INDEX idx_transfer_to_account_id ON transfer(to_account_id);
+CREATE INDEX idx_transfer_user_id ON transfer(user_id);
+
+-- null for the expenses recorded before accounts existed
+ALTER TABLE expense ADD COLUMN account_id TEXT REFERENCES account(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_expense_account_id ON expense(account_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_expense_account_id;
+ALTER TABLE expense DROP COLUMN account_id;
+
+DROP INDEX idx_transfer_from_account_id;
+DROP INDEX idx_transfer_to_account_id;
+DROP INDEX idx_transfer_user_id;
+DROP TABLE transfer;
+
+DROP INDEX idx_account_user_id;
+DROP TABLE account;
+
+-- +goose StatementEnd
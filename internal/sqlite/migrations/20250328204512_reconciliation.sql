@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE reconciliation (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	statement_date DATE NOT NULL,
+	statement_balance INTEGER NOT NULL,
+	-- null while the reconciliation is open
+	completed_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	account_id TEXT NOT NULL REFERENCES account(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reconciliation_account_id ON reconciliation(account_id);
+CREATE INDEX idx_reconciliation_user_id ON reconciliation(user_id);
+
+-- reconciliation where the expense was cleared
+ALTER TABLE expense ADD COLUMN reconciliation_id TEXT REFERENCES reconciliation(id) ON DELETE SET NULL;
+-- set when the reconciliation is completed, reconciled expenses can't be changed
+ALTER TABLE expense ADD COLUMN reconciled_at TIMESTAMP;
+
+CREATE INDEX idx_expense_reconciliation_id ON expense(reconciliation_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_expense_reconciliation_id;
+ALTER TABLE expense DROP COLUMN reconciled_at;
+ALTER TABLE expense DROP COLUMN reconciliation_id;
+
+DROP INDEX idx_reconciliation_account_id;
+DROP INDEX idx_reconciliation_user_id;
+DROP TABLE reconciliation;
+
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE attachment (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	file_name TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	has_thumbnail INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- both are set to null when the owner is permanently deleted so the
+	-- stored files can be cleaned up
+	expense_id TEXT REFERENCES expense(id) ON DELETE SET NULL,
+	expense_group_id TEXT REFERENCES expense_group(id) ON DELETE SET NULL,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_attachment_expense_id ON attachment(expense_id);
+CREATE INDEX idx_attachment_expense_group_id ON attachment(expense_group_id);
+CREATE INDEX idx_attachment_user_id ON attachment(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_attachment_expense_id;
+DROP INDEX idx_attachment_expense_group_id;
+DROP INDEX idx_attachment_user_id;
+DROP TABLE attachment;
+
+-- +goose StatementEnd
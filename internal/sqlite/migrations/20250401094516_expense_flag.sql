@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE expense_flag (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	-- 'outlier' or 'duplicate'
+	kind TEXT NOT NULL,
+	score REAL NOT NULL,
+	reason TEXT NOT NULL,
+	reviewed_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	expense_id TEXT NOT NULL REFERENCES expense(id) ON DELETE CASCADE,
+	-- the expense a duplicate looks like
+	related_expense_id TEXT REFERENCES expense(id) ON DELETE SET NULL,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_expense_flag_expense_id ON expense_flag(expense_id);
+CREATE INDEX idx_expense_flag_related_expense_id ON expense_flag(related_expense_id);
+CREATE INDEX idx_expense_flag_user_id_reviewed_at ON expense_flag(user_id, reviewed_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_expense_flag_expense_id;
+DROP INDEX idx_expense_flag_related_expense_id;
+DROP INDEX idx_expense_flag_user_id_reviewed_at;
+DROP TABLE expense_flag;
+
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE category_budget (
+	category_id TEXT NOT NULL PRIMARY KEY REFERENCES category(id) ON DELETE CASCADE,
+	-- monthly amount
+	amount INTEGER NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_category_budget_user_id ON category_budget(user_id);
+
+CREATE TABLE notification_preference (
+	user_id TEXT NOT NULL PRIMARY KEY REFERENCES user(id) ON DELETE CASCADE,
+	budget_alerts INTEGER NOT NULL,
+	bill_reminders INTEGER NOT NULL,
+	bill_reminder_days INTEGER NOT NULL,
+	-- 0 turns the reminder off
+	inactivity_days INTEGER NOT NULL,
+	email_enabled INTEGER NOT NULL,
+	-- empty turns the webhook off
+	webhook_url TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE notification (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	-- 'budget', 'bill_due' or 'inactivity'
+	kind TEXT NOT NULL,
+	title TEXT NOT NULL,
+	message TEXT NOT NULL,
+	-- a notification with the same key is only sent once
+	dedup_key TEXT NOT NULL,
+	read_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+	UNIQUE (user_id, dedup_key)
+);
+
+CREATE INDEX idx_notification_user_id_read_at ON notification(user_id, read_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_notification_user_id_read_at;
+DROP TABLE notification;
+DROP TABLE notification_preference;
+DROP INDEX idx_category_budget_user_id;
+DROP TABLE category_budget;
+
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE webhook (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	url TEXT NOT NULL,
+	-- key of the HMAC-SHA256 signature of the payloads
+	secret TEXT NOT NULL,
+	-- JSON array of the subscribed events
+	events TEXT NOT NULL,
+	active INTEGER NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_user_id ON webhook(user_id);
+
+CREATE TABLE webhook_delivery (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	webhook_id TEXT NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	-- 'pending', 'succeeded' or 'failed'
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- result of the last attempt
+	response_status INTEGER,
+	error TEXT,
+	delivered_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_delivery_webhook_id ON webhook_delivery(webhook_id);
+CREATE INDEX idx_webhook_delivery_status_next_attempt_at ON webhook_delivery(status, next_attempt_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_webhook_delivery_status_next_attempt_at;
+DROP INDEX idx_webhook_delivery_webhook_id;
+DROP TABLE webhook_delivery;
+DROP INDEX idx_webhook_user_id;
+DROP TABLE webhook;
+
+-- +goose StatementEnd
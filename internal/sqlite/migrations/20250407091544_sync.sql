@@ -0,0 +1,134 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- version is bumped on every write. version_vector holds the counters of the
+-- devices that pushed changes to the row, the server's counter is version.
+ALTER TABLE category ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE category ADD COLUMN version_vector TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE expense_group ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE expense_group ADD COLUMN version_vector TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE expense ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE expense ADD COLUMN version_vector TEXT NOT NULL DEFAULT '{}';
+
+-- latest change of every synced row. seq orders the changes of all users,
+-- the change token given to the clients is the seq of the last change seen.
+CREATE TABLE sync_change (
+	resource TEXT NOT NULL,
+	resource_id TEXT NOT NULL,
+	seq INTEGER NOT NULL,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+	PRIMARY KEY (resource, resource_id)
+);
+
+CREATE UNIQUE INDEX idx_sync_change_seq ON sync_change(seq);
+CREATE INDEX idx_sync_change_user_id_seq ON sync_change(user_id, seq);
+
+INSERT INTO sync_change (resource, resource_id, seq, user_id)
+SELECT 'category', id, ROW_NUMBER() OVER (ORDER BY rowid), user_id FROM category;
+
+INSERT INTO sync_change (resource, resource_id, seq, user_id)
+SELECT 'expense_group', id, (SELECT COALESCE(MAX(seq), 0) FROM sync_change) + ROW_NUMBER() OVER (ORDER BY rowid), user_id FROM expense_group;
+
+INSERT INTO sync_change (resource, resource_id, seq, user_id)
+SELECT 'expense', id, (SELECT COALESCE(MAX(seq), 0) FROM sync_change) + ROW_NUMBER() OVER (ORDER BY rowid), user_id FROM expense;
+
+CREATE TRIGGER category_sync_insert AFTER INSERT ON category
+BEGIN
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	VALUES ('category', NEW.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), NEW.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER category_sync_update AFTER UPDATE ON category
+WHEN NEW.version = OLD.version
+BEGIN
+	UPDATE category SET version = OLD.version + 1 WHERE id = NEW.id;
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	VALUES ('category', NEW.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), NEW.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+-- rows deleted along with their user are not recorded
+CREATE TRIGGER category_sync_delete AFTER DELETE ON category
+BEGIN
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	SELECT 'category', OLD.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), OLD.user_id
+	WHERE EXISTS (SELECT 1 FROM user WHERE id = OLD.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER expense_group_sync_insert AFTER INSERT ON expense_group
+BEGIN
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	VALUES ('expense_group', NEW.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), NEW.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER expense_group_sync_update AFTER UPDATE ON expense_group
+WHEN NEW.version = OLD.version
+BEGIN
+	UPDATE expense_group SET version = OLD.version + 1 WHERE id = NEW.id;
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	VALUES ('expense_group', NEW.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), NEW.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER expense_group_sync_delete AFTER DELETE ON expense_group
+BEGIN
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	SELECT 'expense_group', OLD.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), OLD.user_id
+	WHERE EXISTS (SELECT 1 FROM user WHERE id = OLD.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER expense_sync_insert AFTER INSERT ON expense
+BEGIN
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	VALUES ('expense', NEW.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), NEW.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER expense_sync_update AFTER UPDATE ON expense
+WHEN NEW.version = OLD.version
+BEGIN
+	UPDATE expense SET version = OLD.version + 1 WHERE id = NEW.id;
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	VALUES ('expense', NEW.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), NEW.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+CREATE TRIGGER expense_sync_delete AFTER DELETE ON expense
+BEGIN
+	INSERT INTO sync_change (resource, resource_id, seq, user_id)
+	SELECT 'expense', OLD.id, (SELECT COALESCE(MAX(seq), 0) + 1 FROM sync_change), OLD.user_id
+	WHERE EXISTS (SELECT 1 FROM user WHERE id = OLD.user_id)
+	ON CONFLICT (resource, resource_id) DO UPDATE SET seq = excluded.seq;
+END;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER expense_sync_delete;
+DROP TRIGGER expense_sync_update;
+DROP TRIGGER expense_sync_insert;
+DROP TRIGGER expense_group_sync_delete;
+DROP TRIGGER expense_group_sync_update;
+DROP TRIGGER expense_group_sync_insert;
+DROP TRIGGER category_sync_delete;
+DROP TRIGGER category_sync_update;
+DROP TRIGGER category_sync_insert;
+
+DROP INDEX idx_sync_change_user_id_seq;
+DROP INDEX idx_sync_change_seq;
+DROP TABLE sync_change;
+
+ALTER TABLE expense DROP COLUMN version_vector;
+ALTER TABLE expense DROP COLUMN version;
+ALTER TABLE expense_group DROP COLUMN version_vector;
+ALTER TABLE expense_group DROP COLUMN version;
+ALTER TABLE category DROP COLUMN version_vector;
+ALTER TABLE category DROP COLUMN version;
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE idempotency_key (
+	key TEXT NOT NULL,
+	-- hash of the method, path and body of the request
+	request_hash TEXT NOT NULL,
+	-- response of the request, null while the request is being handled
+	status_code INTEGER,
+	-- JSON object of the response headers
+	header TEXT,
+	body BLOB,
+	expires_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE,
+	PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idx_idempotency_key_expires_at ON idempotency_key(expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_idempotency_key_expires_at;
+DROP TABLE idempotency_key;
+
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE personal_access_token (
+    id TEXT NOT NULL PRIMARY KEY DEFAULT (hex(randomblob(8))),
+	name TEXT NOT NULL,
+	-- JSON array of the scopes, 'read' or 'write'
+	scopes TEXT NOT NULL,
+	-- SHA-256 of the secret, the secret itself is not stored
+	token_hash TEXT NOT NULL UNIQUE,
+	-- start of the secret to tell the tokens apart
+	prefix TEXT NOT NULL,
+	-- null if the token never expires
+	expires_at TIMESTAMP,
+	last_used_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	user_id TEXT NOT NULL REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_personal_access_token_user_id ON personal_access_token(user_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_personal_access_token_user_id;
+DROP TABLE personal_access_token;
+
+-- +goose StatementEnd
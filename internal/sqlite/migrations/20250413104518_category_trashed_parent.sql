@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- set on a subcategory that moved up when its parent was moved to the trash,
+-- restoring the parent moves the subcategory back under it
+ALTER TABLE category ADD COLUMN trashed_parent_id TEXT REFERENCES category(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_category_trashed_parent_id ON category(trashed_parent_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX idx_category_trashed_parent_id;
+
+ALTER TABLE category DROP COLUMN trashed_parent_id;
+
+-- +goose StatementEnd
@@ -0,0 +1,267 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+type NotificationRepository struct {
+	db *DB
+}
+
+var _ notification.Repository = (*NotificationRepository)(nil)
+
+func NewNotificationRepository(db *DB) NotificationRepository {
+	return NotificationRepository{
+		db: db,
+	}
+}
+
+func (nr *NotificationRepository) PreferencesOf(ctx context.Context) (notification.Preferences, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"budget_alerts",
+		"bill_reminders",
+		"bill_reminder_days",
+		"inactivity_days",
+		"email_enabled",
+		"webhook_url",
+	)
+	sb.From("notification_preference")
+	sb.Where(sb.EQ("user_id", u.ID))
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find notification preferences",
+		"query", q,
+		"args", args,
+	)
+
+	var dst preferencesDst
+	if err := nr.db.reader.GetContext(ctx, &dst, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notification.DefaultPreferences, nil
+		}
+
+		return notification.Preferences{}, fmt.Errorf("sqlite.NotificationRepository.PreferencesOf: GetContext: %w", err)
+	}
+
+	return notification.Preferences(dst), nil
+}
+
+func (nr *NotificationRepository) UpdatePreferences(ctx context.Context, p notification.UpdatePreferencesReq) (notification.Preferences, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("notification_preference")
+	ib.Cols(
+		"budget_alerts",
+		"bill_reminders",
+		"bill_reminder_days",
+		"inactivity_days",
+		"email_enabled",
+		"webhook_url",
+		"user_id",
+	)
+	ib.Values(
+		p.BudgetAlerts,
+		p.BillReminders,
+		p.BillReminderDays,
+		p.InactivityDays,
+		p.EmailEnabled,
+		p.WebhookURL,
+		u.ID,
+	)
+	ib.SQL(`ON CONFLICT (user_id) DO UPDATE SET
+		budget_alerts = excluded.budget_alerts,
+		bill_reminders = excluded.bill_reminders,
+		bill_reminder_days = excluded.bill_reminder_days,
+		inactivity_days = excluded.inactivity_days,
+		email_enabled = excluded.email_enabled,
+		webhook_url = excluded.webhook_url,
+		updated_at = CURRENT_TIMESTAMP`)
+	ib.SQL("RETURNING budget_alerts, bill_reminders, bill_reminder_days, inactivity_days, email_enabled, webhook_url")
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Upsert notification preferences",
+		"query", q,
+		"args", args,
+	)
+
+	var dst preferencesDst
+	if err := nr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return notification.Preferences{}, fmt.Errorf("sqlite.NotificationRepository.UpdatePreferences: GetContext: %w", err)
+	}
+
+	return notification.Preferences(dst), nil
+}
+
+func (nr *NotificationRepository) CreateNotification(ctx context.Context, n notification.Notification) (notification.Notification, bool, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("notification")
+	ib.Cols(
+		"kind",
+		"title",
+		"message",
+		"dedup_key",
+		"user_id",
+	)
+	ib.Values(
+		n.Kind,
+		n.Title,
+		n.Message,
+		n.DedupKey,
+		u.ID,
+	)
+	ib.SQL("ON CONFLICT (user_id, dedup_key) DO NOTHING")
+	ib.SQL("RETURNING id, kind, title, message, dedup_key, read_at, created_at, updated_at")
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert notification",
+		"query", q,
+		"args", args,
+	)
+
+	var dst notificationDst
+	if err := nr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		// nothing is returned when the notification already exists
+		if errors.Is(err, sql.ErrNoRows) {
+			return notification.Notification{}, false, nil
+		}
+
+		return notification.Notification{}, false, fmt.Errorf("sqlite.NotificationRepository.CreateNotification: GetContext: %w", err)
+	}
+
+	return notification.Notification(dst), true, nil
+}
+
+// ListNotifications lists the notifications, newest first.
+func (nr *NotificationRepository) ListNotifications(ctx context.Context, l notification.ListNotificationsReq) ([]notification.Notification, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := notificationsQuery()
+	sb.Where(sb.EQ("user_id", u.ID))
+	if l.Unread {
+		sb.Where(sb.IsNull("read_at"))
+	}
+	sb.OrderBy("created_at", "id").Desc()
+	sb.Limit(l.Limit)
+	sb.Offset(l.Offset)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List notifications",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []notificationDst
+	if err := nr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.NotificationRepository.ListNotifications: SelectContext: %w", err)
+	}
+
+	result := make([]notification.Notification, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, notification.Notification(v))
+	}
+
+	return result, nil
+}
+
+func (nr *NotificationRepository) MarkRead(ctx context.Context, id string) (notification.Notification, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	// marking a notification as read twice keeps the time of the first read
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("notification")
+	ub.Set(
+		ub.Assign("read_at", sqlbuilder.Raw("COALESCE(read_at, CURRENT_TIMESTAMP)")),
+		ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+	)
+	ub.Where(
+		ub.And(
+			ub.EQ("id", id),
+			ub.EQ("user_id", u.ID),
+		),
+	)
+	ub.SQL("RETURNING id, kind, title, message, dedup_key, read_at, created_at, updated_at")
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Mark notification as read",
+		"query", q,
+		"args", args,
+	)
+
+	var dst notificationDst
+	if err := nr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notification.Notification{}, internal.NewError(internal.ErrorCodeNotFound, "Notification not found")
+		}
+
+		return notification.Notification{}, fmt.Errorf("sqlite.NotificationRepository.MarkRead: GetContext: %w", err)
+	}
+
+	return notification.Notification(dst), nil
+}
+
+func notificationsQuery() *sqlbuilder.SelectBuilder {
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"id",
+		"kind",
+		"title",
+		"message",
+		"dedup_key",
+		"read_at",
+		"created_at",
+		"updated_at",
+	)
+	sb.From("notification")
+	return sb
+}
+
+type preferencesDst struct {
+	BudgetAlerts     bool   `db:"budget_alerts"`
+	BillReminders    bool   `db:"bill_reminders"`
+	BillReminderDays int    `db:"bill_reminder_days"`
+	InactivityDays   int    `db:"inactivity_days"`
+	EmailEnabled     bool   `db:"email_enabled"`
+	WebhookURL       string `db:"webhook_url"`
+}
+
+type notificationDst struct {
+	ID        string            `db:"id"`
+	Kind      notification.Kind `db:"kind"`
+	Title     string            `db:"title"`
+	Message   string            `db:"message"`
+	DedupKey  string            `db:"dedup_key"`
+	ReadAt    *time.Time        `db:"read_at"`
+	CreatedAt time.Time         `db:"created_at"`
+	UpdatedAt time.Time         `db:"updated_at"`
+}
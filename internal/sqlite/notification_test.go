@@ -0,0 +1,114 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/notification"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotification(t *testing.T) {
+	dh := newDBHelper(t, "test_notification.db")
+	defer dh.clean()
+
+	nr := sqlite.NewNotificationRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	t.Run("preferences", func(t *testing.T) {
+		p, err := nr.PreferencesOf(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.Equal(t, notification.DefaultPreferences, p)
+
+		want := notification.Preferences{
+			BillReminders:    true,
+			BillReminderDays: 5,
+			InactivityDays:   7,
+			EmailEnabled:     true,
+			WebhookURL:       "https://example.com/hook",
+		}
+		for range 2 {
+			p, err = nr.UpdatePreferences(ctxWithUser1, notification.UpdatePreferencesReq(want))
+			assert.Nil(t, err)
+			assert.Equal(t, want, p)
+		}
+
+		p, err = nr.PreferencesOf(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.Equal(t, want, p)
+
+		p, err = nr.PreferencesOf(ctxWithUser2)
+		assert.Nil(t, err)
+		assert.Equal(t, notification.DefaultPreferences, p)
+	})
+
+	draft := notification.Notification{
+		Kind:     notification.KindBudget,
+		Title:    "food is over its budget",
+		Message:  "1200 of 1000 has been spent on food this month.",
+		DedupKey: "budget:1:2006-01:100",
+	}
+
+	created, ok, err := nr.CreateNotification(ctxWithUser1, draft)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, draft.DedupKey, created.DedupKey)
+	assert.Nil(t, created.ReadAt)
+
+	t.Run("same dedup key is not created again", func(t *testing.T) {
+		_, ok, err := nr.CreateNotification(ctxWithUser1, draft)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+
+		// keys are per user
+		_, ok, err = nr.CreateNotification(ctxWithUser2, draft)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+	})
+
+	other, _, err := nr.CreateNotification(ctxWithUser1, notification.Notification{
+		Kind:     notification.KindInactivity,
+		Title:    "No expenses logged lately",
+		Message:  "Nothing has been logged since 2006-01-01.",
+		DedupKey: "inactivity:2006-01-01",
+	})
+	assert.Nil(t, err)
+
+	t.Run("mark read", func(t *testing.T) {
+		n, err := nr.MarkRead(ctxWithUser1, created.ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, n.ReadAt)
+
+		again, err := nr.MarkRead(ctxWithUser1, created.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, n.ReadAt, again.ReadAt)
+
+		_, err = nr.MarkRead(ctxWithUser2, created.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("list notifications", func(t *testing.T) {
+		notifications, err := nr.ListNotifications(ctxWithUser1, notification.ListNotificationsReq{
+			ListOptions: internal.ListOptions{Limit: 10},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, notifications, 2)
+
+		notifications, err = nr.ListNotifications(ctxWithUser1, notification.ListNotificationsReq{
+			Unread:      true,
+			ListOptions: internal.ListOptions{Limit: 10},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, notifications, 1)
+		assert.Equal(t, other.ID, notifications[0].ID)
+	})
+}
@@ -0,0 +1,945 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/offline"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+// The version and the change feed of the synced rows are kept up to date by
+// the triggers of the sync migration, so every write is seen by the devices
+// no matter which repository made it.
+type OfflineRepository struct {
+	db *DB
+}
+
+var _ offline.Repository = (*OfflineRepository)(nil)
+
+func NewOfflineRepository(db *DB) OfflineRepository {
+	return OfflineRepository{
+		db: db,
+	}
+}
+
+type syncDst struct {
+	Version       int64                             `db:"version"`
+	VersionVector jsonColumn[offline.VersionVector] `db:"version_vector"`
+	UpdatedAt     time.Time                         `db:"updated_at"`
+	DeletedAt     *time.Time                        `db:"deleted_at"`
+}
+
+func (s syncDst) record(r offline.Resource, id string) offline.Record {
+	return offline.Record{
+		Resource:      r,
+		ID:            id,
+		Deleted:       s.DeletedAt != nil,
+		VersionVector: s.VersionVector.V.Merge(offline.VersionVector{offline.ServerReplica: s.Version}),
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
+
+// deletedRecord is the record of a row that was deleted for good.
+func deletedRecord(r offline.Resource, id string) offline.Record {
+	return offline.Record{
+		Resource:      r,
+		ID:            id,
+		Deleted:       true,
+		VersionVector: offline.VersionVector{},
+	}
+}
+
+// listRecords returns the records of the user with the given IDs. Rows that
+// don't exist are left out.
+func listRecords(ctx context.Context, db sqlx.QueryerContext, r offline.Resource, ids []string) (map[string]offline.Record, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	result := make(map[string]offline.Record, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	switch r {
+	case offline.ResourceExpense:
+		sb.Select(
+			"id",
+			"name",
+			"amount",
+			"date",
+			"note",
+			"category_id",
+			"tags",
+			"account_id",
+			"expense_group_id",
+			"version",
+			"version_vector",
+			"updated_at",
+			"deleted_at",
+		)
+	case offline.ResourceExpenseGroup:
+		sb.Select(
+			"id",
+			"name",
+			"date",
+			"note",
+			"version",
+			"version_vector",
+			"updated_at",
+			sb.As("NULL", "deleted_at"),
+		)
+	case offline.ResourceCategory:
+		sb.Select(
+			"id",
+			"name",
+			"color",
+			"icon",
+			"parent_id",
+			"version",
+			"version_vector",
+			"updated_at",
+			"deleted_at",
+		)
+	default:
+		return nil, fmt.Errorf("sqlite.listRecords: unknown resource %s", r)
+	}
+	sb.From(string(r))
+	sb.Where(
+		sb.And(
+			sb.In("id", sqlbuilder.List(ids)),
+			sb.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List records",
+		"resource", r,
+		"query", q,
+		"args", args,
+	)
+
+	switch r {
+	case offline.ResourceExpense:
+		var dst []struct {
+			ID             string               `db:"id"`
+			Name           string               `db:"name"`
+			Amount         int64                `db:"amount"`
+			Date           time.Time            `db:"date"`
+			Note           string               `db:"note"`
+			CategoryID     string               `db:"category_id"`
+			Tags           jsonColumn[[]string] `db:"tags"`
+			AccountID      *string              `db:"account_id"`
+			ExpenseGroupID *string              `db:"expense_group_id"`
+			syncDst
+		}
+		if err := sqlx.SelectContext(ctx, db, &dst, q, args...); err != nil {
+			return nil, fmt.Errorf("sqlite.listRecords: SelectContext: %w", err)
+		}
+
+		for _, v := range dst {
+			record := v.record(r, v.ID)
+			if !record.Deleted {
+				record.Expense = &offline.Expense{
+					Name:           v.Name,
+					Amount:         v.Amount,
+					Date:           v.Date.Format(time.DateOnly),
+					Note:           v.Note,
+					CategoryID:     v.CategoryID,
+					Tags:           v.Tags.V,
+					AccountID:      v.AccountID,
+					ExpenseGroupID: v.ExpenseGroupID,
+				}
+			}
+			result[v.ID] = record
+		}
+	case offline.ResourceExpenseGroup:
+		var dst []struct {
+			ID   string    `db:"id"`
+			Name string    `db:"name"`
+			Date time.Time `db:"date"`
+			Note string    `db:"note"`
+			syncDst
+		}
+		if err := sqlx.SelectContext(ctx, db, &dst, q, args...); err != nil {
+			return nil, fmt.Errorf("sqlite.listRecords: SelectContext: %w", err)
+		}
+
+		for _, v := range dst {
+			record := v.record(r, v.ID)
+			record.ExpenseGroup = &offline.ExpenseGroup{
+				Name: v.Name,
+				Date: v.Date.Format(time.DateOnly),
+				Note: v.Note,
+			}
+			result[v.ID] = record
+		}
+	case offline.ResourceCategory:
+		var dst []struct {
+			ID       string  `db:"id"`
+			Name     string  `db:"name"`
+			Color    string  `db:"color"`
+			Icon     string  `db:"icon"`
+			ParentID *string `db:"parent_id"`
+			syncDst
+		}
+		if err := sqlx.SelectContext(ctx, db, &dst, q, args...); err != nil {
+			return nil, fmt.Errorf("sqlite.listRecords: SelectContext: %w", err)
+		}
+
+		for _, v := range dst {
+			record := v.record(r, v.ID)
+			if !record.Deleted {
+				record.Category = &offline.Category{
+					Name:     v.Name,
+					Color:    v.Color,
+					Icon:     v.Icon,
+					ParentID: v.ParentID,
+				}
+			}
+			result[v.ID] = record
+		}
+	}
+
+	return result, nil
+}
+
+func (or *OfflineRepository) ListChanges(ctx context.Context, after int64, limit int) ([]offline.Change, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"resource",
+		"resource_id",
+		"seq",
+	)
+	sb.From("sync_change")
+	sb.Where(
+		sb.And(
+			sb.EQ("user_id", u.ID),
+			sb.GT("seq", after),
+		),
+	)
+	sb.OrderBy("seq")
+	sb.Limit(limit)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List changes",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		Resource   offline.Resource `db:"resource"`
+		ResourceID string           `db:"resource_id"`
+		Seq        int64            `db:"seq"`
+	}
+	if err := or.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.OfflineRepository.ListChanges: SelectContext: %w", err)
+	}
+
+	ids := make(map[offline.Resource][]string)
+	for _, v := range dst {
+		ids[v.Resource] = append(ids[v.Resource], v.ResourceID)
+	}
+
+	records := make(map[offline.Resource]map[string]offline.Record, len(ids))
+	for r, v := range ids {
+		found, err := listRecords(ctx, or.db.reader, r, v)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite.OfflineRepository.ListChanges: %w", err)
+		}
+		records[r] = found
+	}
+
+	result := make([]offline.Change, 0, len(dst))
+	for _, v := range dst {
+		record, ok := records[v.Resource][v.ResourceID]
+		if !ok {
+			record = deletedRecord(v.Resource, v.ResourceID)
+		}
+
+		result = append(result, offline.Change{
+			Seq:    v.Seq,
+			Record: record,
+		})
+	}
+
+	return result, nil
+}
+
+func (or *OfflineRepository) PushChanges(ctx context.Context, p offline.PushReq) ([]offline.ChangeResult, error) {
+	logger := logger.FromContext(ctx)
+
+	results := make([]offline.ChangeResult, 0, len(p.Changes))
+	var events []pendingEvent
+
+	err := or.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		for _, c := range p.Changes {
+			// a rejected change is rolled back on its own
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT push_change"); err != nil {
+				return fmt.Errorf("sqlite.OfflineRepository.PushChanges: ExecContext: %w", err)
+			}
+
+			result, event, err := pushChange(ctx, tx, p.DeviceID, c)
+			if err != nil {
+				if internal.GetErrorCode(err) == internal.ErrorCodeInternal {
+					return err
+				}
+
+				if _, err := tx.ExecContext(ctx, "ROLLBACK TO push_change"); err != nil {
+					return fmt.Errorf("sqlite.OfflineRepository.PushChanges: ExecContext: %w", err)
+				}
+
+				logger.Infow(
+					"Rejected change",
+					"resource", c.Resource,
+					"id", c.ID,
+					"error", err,
+				)
+
+				result, err = rejectedChange(ctx, tx, c, err)
+				if err != nil {
+					return err
+				}
+			}
+
+			if _, err := tx.ExecContext(ctx, "RELEASE push_change"); err != nil {
+				return fmt.Errorf("sqlite.OfflineRepository.PushChanges: ExecContext: %w", err)
+			}
+
+			results = append(results, result)
+			if event != nil && result.Status != offline.ChangeStatusRejected {
+				events = append(events, *event)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range events {
+		or.db.publish(ctx, e.typ, e.resourceID)
+	}
+
+	return results, nil
+}
+
+func rejectedChange(ctx context.Context, tx *sqlx.Tx, c offline.PushChange, reason error) (offline.ChangeResult, error) {
+	message := internal.GetErrorMessage(reason)
+	result := offline.ChangeResult{
+		Resource: c.Resource,
+		ID:       c.ID,
+		Status:   offline.ChangeStatusRejected,
+		Error:    &message,
+	}
+
+	records, err := listRecords(ctx, tx, c.Resource, []string{c.ID})
+	if err != nil {
+		return offline.ChangeResult{}, fmt.Errorf("sqlite.rejectedChange: %w", err)
+	}
+	if record, ok := records[c.ID]; ok {
+		result.Record = &record
+	}
+
+	return result, nil
+}
+
+// pushChange applies the change if Decide lets it. It returns the event to
+// publish if the change was written.
+func pushChange(ctx context.Context, tx *sqlx.Tx, deviceID string, c offline.PushChange) (offline.ChangeResult, *pendingEvent, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	// a clock that is ahead would win every later conflict
+	if now := time.Now(); c.UpdatedAt.After(now) {
+		c.UpdatedAt = now
+	}
+
+	deletedAt := "deleted_at"
+	if c.Resource == offline.ResourceExpenseGroup {
+		// expense groups are deleted for good
+		deletedAt = "NULL AS deleted_at"
+	}
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"user_id",
+		"version",
+		"version_vector",
+		"updated_at",
+		deletedAt,
+	)
+	sb.From(string(c.Resource))
+	sb.Where(sb.EQ("id", c.ID))
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find synced row by id",
+		"query", q,
+		"args", args,
+	)
+
+	var dst struct {
+		UserID string `db:"user_id"`
+		syncDst
+	}
+	state := offline.ServerState{Exists: true}
+	if err := tx.GetContext(ctx, &dst, q, args...); err != nil {
+		if err != sql.ErrNoRows {
+			return offline.ChangeResult{}, nil, fmt.Errorf("sqlite.pushChange: GetContext: %w", err)
+		}
+		state = offline.ServerState{}
+	}
+
+	if state.Exists {
+		if dst.UserID != u.ID {
+			return offline.ChangeResult{}, nil, internal.NewError(internal.ErrorCodeConflict, "ID is already taken")
+		}
+
+		record := dst.record(c.Resource, c.ID)
+		state.Deleted = record.Deleted
+		state.VersionVector = record.VersionVector
+		state.UpdatedAt = record.UpdatedAt
+	}
+
+	result := offline.ChangeResult{
+		Resource: c.Resource,
+		ID:       c.ID,
+		Status:   offline.ChangeStatusApplied,
+	}
+
+	var event *pendingEvent
+	switch offline.Decide(state, c, deviceID) {
+	case offline.DecisionSkip:
+	case offline.DecisionServerWins:
+		resolution := offline.ResolutionServer
+		result.Status = offline.ChangeStatusConflict
+		result.Resolution = &resolution
+	case offline.DecisionClientWins:
+		resolution := offline.ResolutionClient
+		result.Status = offline.ChangeStatusConflict
+		result.Resolution = &resolution
+
+		fallthrough
+	case offline.DecisionApply:
+		versionVector := c.VersionVector.Devices()
+		if state.Exists {
+			versionVector = dst.VersionVector.V.Merge(versionVector)
+		}
+
+		typ, err := writeChange(ctx, tx, c, state.Exists, versionVector)
+		if err != nil {
+			return offline.ChangeResult{}, nil, err
+		}
+		event = &pendingEvent{typ: typ, resourceID: c.ID}
+		result.Event = typ
+	}
+
+	records, err := listRecords(ctx, tx, c.Resource, []string{c.ID})
+	if err != nil {
+		return offline.ChangeResult{}, nil, fmt.Errorf("sqlite.pushChange: %w", err)
+	}
+
+	record, ok := records[c.ID]
+	if !ok {
+		record = deletedRecord(c.Resource, c.ID)
+	}
+	result.Record = &record
+
+	return result, event, nil
+}
+
+// writeChange writes the change to the row, creating it if it doesn't exist.
+// It returns the type of the event of the change.
+func writeChange(ctx context.Context, tx *sqlx.Tx, c offline.PushChange, exists bool, versionVector offline.VersionVector) (string, error) {
+	updatedAt := c.UpdatedAt.UTC().Format(timestampLayout)
+
+	switch c.Resource {
+	case offline.ResourceExpense:
+		if c.Deleted {
+			return expense.EventExpenseDeleted, trashSyncedExpense(ctx, tx, c.ID, versionVector, updatedAt)
+		}
+		if exists {
+			return expense.EventExpenseUpdated, writeSyncedExpense(ctx, tx, c, exists, versionVector, updatedAt)
+		}
+		return expense.EventExpenseCreated, writeSyncedExpense(ctx, tx, c, exists, versionVector, updatedAt)
+	case offline.ResourceExpenseGroup:
+		if c.Deleted {
+			return expense.EventExpenseGroupDeleted, deleteSyncedExpenseGroup(ctx, tx, c.ID)
+		}
+		if exists {
+			return expense.EventExpenseGroupUpdated, writeSyncedExpenseGroup(ctx, tx, c, exists, versionVector, updatedAt)
+		}
+		return expense.EventExpenseGroupCreated, writeSyncedExpenseGroup(ctx, tx, c, exists, versionVector, updatedAt)
+	case offline.ResourceCategory:
+		if c.Deleted {
+			return category.EventCategoryDeleted, trashSyncedCategory(ctx, tx, c.ID, versionVector, updatedAt)
+		}
+		if exists {
+			return category.EventCategoryUpdated, writeSyncedCategory(ctx, tx, c, exists, versionVector, updatedAt)
+		}
+		return category.EventCategoryCreated, writeSyncedCategory(ctx, tx, c, exists, versionVector, updatedAt)
+	default:
+		return "", fmt.Errorf("sqlite.writeChange: unknown resource %s", c.Resource)
+	}
+}
+
+// ownedRowExists reports whether the row belongs to the user. Rows in the
+// trash don't count.
+func ownedRowExists(ctx context.Context, tx *sqlx.Tx, table string, id string) (bool, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("COUNT(*)")
+	sb.From(table)
+	sb.Where(
+		sb.EQ("id", id),
+		sb.EQ("user_id", u.ID),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Count owned rows by id",
+		"table", table,
+		"query", q,
+		"args", args,
+	)
+
+	var count int
+	if err := tx.GetContext(ctx, &count, q, args...); err != nil {
+		return false, fmt.Errorf("sqlite.ownedRowExists: GetContext: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+func writeSyncedExpense(ctx context.Context, tx *sqlx.Tx, c offline.PushChange, exists bool, versionVector offline.VersionVector, updatedAt string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+	e := c.Expense
+
+	if exists {
+		if err := checkNotReconciled(ctx, tx, c.ID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := activeCategoryName(ctx, tx, e.CategoryID); err != nil {
+		return err
+	}
+
+	if e.AccountID != nil {
+		found, err := ownedRowExists(ctx, tx, "account", *e.AccountID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return internal.NewError(internal.ErrorCodeNotFound, "Account not found")
+		}
+	}
+
+	if e.ExpenseGroupID != nil {
+		found, err := ownedRowExists(ctx, tx, "expense_group", *e.ExpenseGroupID)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return internal.NewError(internal.ErrorCodeNotFound, "Expense group not found")
+		}
+	}
+
+	var q string
+	var args []any
+	if exists {
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("expense")
+		ub.Set(
+			ub.Assign("name", e.Name),
+			ub.Assign("amount", e.Amount),
+			ub.Assign("date", e.Date),
+			ub.Assign("note", e.Note),
+			ub.Assign("category_id", e.CategoryID),
+			ub.Assign("tags", jsonColumn[[]string]{V: e.Tags}),
+			ub.Assign("account_id", e.AccountID),
+			ub.Assign("expense_group_id", e.ExpenseGroupID),
+			ub.Assign("version_vector", jsonColumn[offline.VersionVector]{V: versionVector}),
+			ub.Assign("updated_at", updatedAt),
+			unclearIfMoved(
+				"date(date) IS date("+ub.Var(e.Date)+")",
+				"account_id IS "+ub.Var(e.AccountID),
+			),
+		)
+		ub.Where(ub.EQ("id", c.ID))
+
+		q, args = ub.Build()
+	} else {
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("expense")
+		ib.Cols(
+			"id",
+			"name",
+			"amount",
+			"date",
+			"note",
+			"category_id",
+			"tags",
+			"account_id",
+			"expense_group_id",
+			"version_vector",
+			"created_at",
+			"updated_at",
+			"user_id",
+		)
+		ib.Values(
+			c.ID,
+			e.Name,
+			e.Amount,
+			e.Date,
+			e.Note,
+			e.CategoryID,
+			jsonColumn[[]string]{V: e.Tags},
+			e.AccountID,
+			e.ExpenseGroupID,
+			jsonColumn[offline.VersionVector]{V: versionVector},
+			updatedAt,
+			updatedAt,
+			u.ID,
+		)
+
+		q, args = ib.Build()
+	}
+
+	logger.Infow(
+		"Write synced expense",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.writeSyncedExpense: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// checkNotReconciled fails if the expense is locked by a reconciliation.
+func checkNotReconciled(ctx context.Context, tx *sqlx.Tx, id string) error {
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select("reconciled_at IS NOT NULL")
+	sb.From("expense")
+	sb.Where(sb.EQ("id", id))
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find if expense is reconciled",
+		"query", q,
+		"args", args,
+	)
+
+	var reconciled bool
+	if err := tx.GetContext(ctx, &reconciled, q, args...); err != nil {
+		return fmt.Errorf("sqlite.checkNotReconciled: GetContext: %w", err)
+	}
+	if reconciled {
+		return internal.NewError(internal.ErrorCodeConflict, "Expense is reconciled")
+	}
+
+	return nil
+}
+
+func trashSyncedExpense(ctx context.Context, tx *sqlx.Tx, id string, versionVector offline.VersionVector, updatedAt string) error {
+	logger := logger.FromContext(ctx)
+
+	if err := checkNotReconciled(ctx, tx, id); err != nil {
+		return err
+	}
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("expense")
+	ub.Set(
+		ub.Assign("deleted_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		ub.Assign("reconciliation_id", nil),
+		ub.Assign("version_vector", jsonColumn[offline.VersionVector]{V: versionVector}),
+		ub.Assign("updated_at", updatedAt),
+	)
+	ub.Where(ub.EQ("id", id))
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Soft delete synced expense",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.trashSyncedExpense: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func writeSyncedExpenseGroup(ctx context.Context, tx *sqlx.Tx, c offline.PushChange, exists bool, versionVector offline.VersionVector, updatedAt string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+	g := c.ExpenseGroup
+
+	var q string
+	var args []any
+	if exists {
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("expense_group")
+		ub.Set(
+			ub.Assign("name", g.Name),
+			ub.Assign("date", g.Date),
+			ub.Assign("note", g.Note),
+			ub.Assign("version_vector", jsonColumn[offline.VersionVector]{V: versionVector}),
+			ub.Assign("updated_at", updatedAt),
+		)
+		ub.Where(ub.EQ("id", c.ID))
+
+		q, args = ub.Build()
+	} else {
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("expense_group")
+		ib.Cols(
+			"id",
+			"name",
+			"date",
+			"note",
+			"version_vector",
+			"created_at",
+			"updated_at",
+			"user_id",
+		)
+		ib.Values(
+			c.ID,
+			g.Name,
+			g.Date,
+			g.Note,
+			jsonColumn[offline.VersionVector]{V: versionVector},
+			updatedAt,
+			updatedAt,
+			u.ID,
+		)
+
+		q, args = ib.Build()
+	}
+
+	logger.Infow(
+		"Write synced expense group",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.writeSyncedExpenseGroup: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// deleteSyncedExpenseGroup deletes the expense group for good, its expenses
+// are kept without a group.
+func deleteSyncedExpenseGroup(ctx context.Context, tx *sqlx.Tx, id string) error {
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("expense")
+	ub.Set(ub.Assign("expense_group_id", nil))
+	ub.Where(ub.EQ("expense_group_id", id))
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Ungroup expenses of expense group",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.deleteSyncedExpenseGroup: ExecContext: %w", err)
+	}
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("expense_group")
+	db.Where(db.EQ("id", id))
+
+	q, args = db.Build()
+
+	logger.Infow(
+		"Delete expense group",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.deleteSyncedExpenseGroup: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func writeSyncedCategory(ctx context.Context, tx *sqlx.Tx, c offline.PushChange, exists bool, versionVector offline.VersionVector, updatedAt string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+	cat := c.Category
+
+	cb := sqlbuilder.SQLite.NewSelectBuilder()
+	cb.Select("COUNT(*)")
+	cb.From("category")
+	cb.Where(
+		cb.EQ("name", cat.Name),
+		cb.EQ("user_id", u.ID),
+		cb.NE("id", c.ID),
+		cb.IsNull("deleted_at"),
+	)
+
+	q, args := cb.Build()
+
+	logger.Infow(
+		"Count category by name",
+		"query", q,
+		"args", args,
+	)
+
+	var count int
+	if err := tx.GetContext(ctx, &count, q, args...); err != nil {
+		return fmt.Errorf("sqlite.writeSyncedCategory: GetContext: %w", err)
+	}
+	if count > 0 {
+		return internal.NewErrorf(internal.ErrorCodeConflict, "%s category already exists", cat.Name)
+	}
+
+	if cat.ParentID != nil {
+		if _, err := activeCategoryName(ctx, tx, *cat.ParentID); err != nil {
+			if internal.GetErrorCode(err) == internal.ErrorCodeNotFound {
+				return internal.NewError(internal.ErrorCodeNotFound, "Parent category not found")
+			}
+			return err
+		}
+
+		descendant, err := isDescendantCategory(ctx, tx, c.ID, *cat.ParentID)
+		if err != nil {
+			return err
+		}
+		if descendant {
+			return internal.NewError(internal.ErrorCodeInvalid, "Can't move a category under its own subcategory")
+		}
+	}
+
+	if exists {
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("category")
+		ub.Set(
+			ub.Assign("name", cat.Name),
+			ub.Assign("color", cat.Color),
+			ub.Assign("icon", cat.Icon),
+			ub.Assign("parent_id", cat.ParentID),
+			"trashed_parent_id = CASE WHEN parent_id IS "+ub.Var(cat.ParentID)+" THEN trashed_parent_id END",
+			ub.Assign("version_vector", jsonColumn[offline.VersionVector]{V: versionVector}),
+			ub.Assign("updated_at", updatedAt),
+		)
+		ub.Where(ub.EQ("id", c.ID))
+
+		q, args = ub.Build()
+	} else {
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("category")
+		ib.Cols(
+			"id",
+			"name",
+			"color",
+			"icon",
+			"parent_id",
+			"version_vector",
+			"created_at",
+			"updated_at",
+			"user_id",
+		)
+		ib.Values(
+			c.ID,
+			cat.Name,
+			cat.Color,
+			cat.Icon,
+			cat.ParentID,
+			jsonColumn[offline.VersionVector]{V: versionVector},
+			updatedAt,
+			updatedAt,
+			u.ID,
+		)
+
+		q, args = ib.Build()
+	}
+
+	logger.Infow(
+		"Write synced category",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.writeSyncedCategory: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// trashSyncedCategory moves the category to the trash along with its
+// expenses.
+func trashSyncedCategory(ctx context.Context, tx *sqlx.Tx, id string, versionVector offline.VersionVector, updatedAt string) error {
+	logger := logger.FromContext(ctx)
+
+	_, err := trashCategory(ctx, tx, category.DeleteCategoryReq{
+		ID:       id,
+		Strategy: category.DeleteStrategyCascade,
+	})
+	if err != nil {
+		return err
+	}
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("category")
+	ub.Set(
+		ub.Assign("version_vector", jsonColumn[offline.VersionVector]{V: versionVector}),
+		ub.Assign("updated_at", updatedAt),
+	)
+	ub.Where(ub.EQ("id", id))
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Update version vector of synced category",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.trashSyncedCategory: ExecContext: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/offline"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffline(t *testing.T) {
+	dh := newDBHelper(t, "test_offline.db")
+	defer dh.clean()
+
+	or := sqlite.NewOfflineRepository(dh.db)
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+	user1Categories := createCategories(t, dh.db, users[0])
+	user2Categories := createCategories(t, dh.db, users[1])
+
+	food := user1Categories[0]
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var token int64
+	t.Run("pull every record", func(t *testing.T) {
+		changes, err := or.ListChanges(ctxWithUser1, 0, 100)
+		assert.Nil(t, err)
+		assert.Len(t, changes, len(user1Categories))
+
+		for i, c := range changes {
+			assert.Equal(t, offline.ResourceCategory, c.Record.Resource)
+			assert.Equal(t, user1Categories[i].ID, c.Record.ID)
+			assert.Equal(t, user1Categories[i].Name, c.Record.Category.Name)
+			assert.Equal(t, offline.VersionVector{offline.ServerReplica: 1}, c.Record.VersionVector)
+		}
+		token = changes[len(changes)-1].Seq
+	})
+
+	push := offline.PushReq{
+		DeviceID: "phone",
+		Changes: []offline.PushChange{
+			{
+				Resource:      offline.ResourceExpenseGroup,
+				ID:            "phone-group-1",
+				VersionVector: offline.VersionVector{"phone": 1},
+				UpdatedAt:     now,
+				ExpenseGroup: &offline.ExpenseGroup{
+					Name: "Trip",
+					Date: "2006-01-02",
+				},
+			},
+			{
+				Resource:      offline.ResourceExpense,
+				ID:            "phone-expense-1",
+				VersionVector: offline.VersionVector{"phone": 1},
+				UpdatedAt:     now,
+				Expense: &offline.Expense{
+					Name:           "Dinner",
+					Amount:         500,
+					Date:           "2006-01-02",
+					CategoryID:     food.ID,
+					Tags:           []string{"trip"},
+					ExpenseGroupID: toPtr(t, "phone-group-1"),
+				},
+			},
+			{
+				Resource:      offline.ResourceExpense,
+				ID:            "phone-expense-2",
+				VersionVector: offline.VersionVector{"phone": 1},
+				UpdatedAt:     now,
+				Expense: &offline.Expense{
+					Name:       "Stolen",
+					Amount:     500,
+					Date:       "2006-01-02",
+					CategoryID: user2Categories[0].ID,
+				},
+			},
+		},
+	}
+
+	t.Run("push new records", func(t *testing.T) {
+		results, err := or.PushChanges(ctxWithUser1, push)
+		assert.Nil(t, err)
+		assert.Len(t, results, 3)
+
+		assert.Equal(t, offline.ChangeStatusApplied, results[0].Status)
+		assert.Equal(t, expense.EventExpenseGroupCreated, results[0].Event)
+		assert.Equal(t, offline.ChangeStatusApplied, results[1].Status)
+		assert.Equal(t, expense.EventExpenseCreated, results[1].Event)
+		assert.Equal(t, offline.VersionVector{offline.ServerReplica: 1, "phone": 1}, results[1].Record.VersionVector)
+		assert.Equal(t, "Dinner", results[1].Record.Expense.Name)
+		assert.Equal(t, now, results[1].Record.UpdatedAt)
+
+		// the category of another user doesn't affect the rest of the push
+		assert.Equal(t, offline.ChangeStatusRejected, results[2].Status)
+		assert.Equal(t, "Category not found", *results[2].Error)
+		assert.Empty(t, results[2].Event)
+		assert.Nil(t, results[2].Record)
+
+		e, err := er.ExpenseByID(ctxWithUser1, "phone-expense-1")
+		assert.Nil(t, err)
+		assert.Equal(t, "Dinner", e.Name)
+	})
+
+	t.Run("pull the pushed records", func(t *testing.T) {
+		changes, err := or.ListChanges(ctxWithUser1, token, 100)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, "phone-group-1", changes[0].Record.ID)
+		assert.Equal(t, "phone-expense-1", changes[1].Record.ID)
+		token = changes[1].Seq
+
+		changes, err = or.ListChanges(ctxWithUser1, token, 100)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+
+		changes, err = or.ListChanges(ctxWithUser2, 0, 100)
+		assert.Nil(t, err)
+		assert.Len(t, changes, len(user2Categories))
+	})
+
+	t.Run("push again", func(t *testing.T) {
+		results, err := or.PushChanges(ctxWithUser1, push)
+		assert.Nil(t, err)
+		assert.Equal(t, offline.ChangeStatusApplied, results[1].Status)
+		assert.Empty(t, results[1].Event)
+		assert.Equal(t, offline.VersionVector{offline.ServerReplica: 1, "phone": 1}, results[1].Record.VersionVector)
+
+		changes, err := or.ListChanges(ctxWithUser1, token, 100)
+		assert.Nil(t, err)
+		assert.Empty(t, changes)
+	})
+
+	t.Run("ID of another user", func(t *testing.T) {
+		results, err := or.PushChanges(ctxWithUser2, offline.PushReq{
+			DeviceID: "tablet",
+			Changes: []offline.PushChange{
+				{
+					Resource:      offline.ResourceExpenseGroup,
+					ID:            "phone-group-1",
+					VersionVector: offline.VersionVector{"tablet": 1},
+					UpdatedAt:     now,
+					ExpenseGroup:  &offline.ExpenseGroup{Name: "Mine", Date: "2006-01-02"},
+				},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, offline.ChangeStatusRejected, results[0].Status)
+		assert.Equal(t, "ID is already taken", *results[0].Error)
+		assert.Nil(t, results[0].Record)
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		// changed on the server while the phone was offline
+		_, err := er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{
+			ID:     "phone-expense-1",
+			Amount: toPtr(t, int64(600)),
+		})
+		assert.Nil(t, err)
+
+		change := func(amount int64, phone int64, updatedAt time.Time) offline.PushReq {
+			return offline.PushReq{
+				DeviceID: "phone",
+				Changes: []offline.PushChange{
+					{
+						Resource:      offline.ResourceExpense,
+						ID:            "phone-expense-1",
+						VersionVector: offline.VersionVector{offline.ServerReplica: 1, "phone": phone},
+						UpdatedAt:     updatedAt,
+						Expense: &offline.Expense{
+							Name:       "Dinner",
+							Amount:     amount,
+							Date:       "2006-01-02",
+							CategoryID: food.ID,
+						},
+					},
+				},
+			}
+		}
+
+		results, err := or.PushChanges(ctxWithUser1, change(700, 2, now.Add(-time.Hour)))
+		assert.Nil(t, err)
+		assert.Equal(t, offline.ChangeStatusConflict, results[0].Status)
+		assert.Equal(t, offline.ResolutionServer, *results[0].Resolution)
+		assert.Equal(t, int64(600), results[0].Record.Expense.Amount)
+		assert.Equal(t, int64(2), results[0].Record.VersionVector[offline.ServerReplica])
+
+		results, err = or.PushChanges(ctxWithUser1, change(800, 3, time.Now().Add(time.Hour)))
+		assert.Nil(t, err)
+		assert.Equal(t, offline.ChangeStatusConflict, results[0].Status)
+		assert.Equal(t, offline.ResolutionClient, *results[0].Resolution)
+		assert.Equal(t, int64(800), results[0].Record.Expense.Amount)
+		assert.Equal(t, offline.VersionVector{offline.ServerReplica: 3, "phone": 3}, results[0].Record.VersionVector)
+		// the clock of the phone is ahead, it doesn't keep winning
+		assert.WithinDuration(t, time.Now(), results[0].Record.UpdatedAt, time.Second*5)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		results, err := or.PushChanges(ctxWithUser1, offline.PushReq{
+			DeviceID: "laptop",
+			Changes: []offline.PushChange{
+				{
+					Resource:      offline.ResourceExpenseGroup,
+					ID:            "phone-group-1",
+					Deleted:       true,
+					VersionVector: offline.VersionVector{offline.ServerReplica: 1, "laptop": 1},
+					UpdatedAt:     now,
+				},
+				{
+					Resource:      offline.ResourceCategory,
+					ID:            food.ID,
+					Deleted:       true,
+					VersionVector: offline.VersionVector{offline.ServerReplica: 1, "laptop": 1},
+					UpdatedAt:     now,
+				},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, offline.ChangeStatusApplied, results[0].Status)
+		assert.True(t, results[0].Record.Deleted)
+		assert.Equal(t, expense.EventExpenseGroupDeleted, results[0].Event)
+		assert.Equal(t, offline.ChangeStatusApplied, results[1].Status)
+		assert.True(t, results[1].Record.Deleted)
+		assert.Nil(t, results[1].Record.Category)
+
+		changes, err := or.ListChanges(ctxWithUser1, token, 100)
+		assert.Nil(t, err)
+
+		deleted := map[string]bool{}
+		for _, c := range changes {
+			deleted[c.Record.ID] = c.Record.Deleted
+		}
+		// the expense was trashed along with its category
+		assert.Equal(t, map[string]bool{
+			"phone-group-1":   true,
+			"phone-expense-1": true,
+			food.ID:           true,
+		}, deleted)
+	})
+
+	t.Run("update a deleted record", func(t *testing.T) {
+		results, err := or.PushChanges(ctxWithUser1, offline.PushReq{
+			DeviceID: "phone",
+			Changes: []offline.PushChange{
+				{
+					Resource:      offline.ResourceExpenseGroup,
+					ID:            "phone-group-1",
+					VersionVector: offline.VersionVector{offline.ServerReplica: 1, "phone": 2},
+					UpdatedAt:     time.Now().Add(time.Hour),
+					ExpenseGroup:  &offline.ExpenseGroup{Name: "Trip", Date: "2006-01-03"},
+				},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, offline.ChangeStatusConflict, results[0].Status)
+		assert.Equal(t, offline.ResolutionServer, *results[0].Resolution)
+		assert.True(t, results[0].Record.Deleted)
+	})
+}
@@ -0,0 +1,506 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/reconciliation"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+type ReconciliationRepository struct {
+	db *DB
+	ar AccountRepository
+}
+
+var _ reconciliation.Repository = (*ReconciliationRepository)(nil)
+
+func NewReconciliationRepository(db *DB, ar AccountRepository) ReconciliationRepository {
+	return ReconciliationRepository{
+		db: db,
+		ar: ar,
+	}
+}
+
+func (rr *ReconciliationRepository) SessionByID(ctx context.Context, id string) (reconciliation.Session, error) {
+	s, err := sessionByID(ctx, rr.db.reader, id)
+	if err != nil {
+		return reconciliation.Session{}, fmt.Errorf("sqlite.ReconciliationRepository.SessionByID: %w", err)
+	}
+	return s, nil
+}
+
+// ListSessions lists the reconciliations of the account, latest statement
+// first.
+func (rr *ReconciliationRepository) ListSessions(ctx context.Context, accountID string) ([]reconciliation.Session, error) {
+	if _, err := rr.ar.AccountByID(ctx, accountID); err != nil {
+		return nil, fmt.Errorf("sqlite.ReconciliationRepository.ListSessions: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(sessionColumns...)
+	sb.From("reconciliation r")
+	sb.Join("account a", "a.id = r.account_id")
+	sb.Where(
+		sb.And(
+			sb.EQ("r.account_id", accountID),
+			sb.EQ("r.user_id", u.ID),
+		),
+	)
+	sb.OrderBy("r.statement_date").Desc()
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List reconciliations",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []sessionDst
+	if err := rr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.ReconciliationRepository.ListSessions: SelectContext: %w", err)
+	}
+
+	result := make([]reconciliation.Session, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, reconciliation.Session(v))
+	}
+
+	return result, nil
+}
+
+// CreateSession starts a reconciliation. An account can only have one open
+// reconciliation and the statement has to be after the statements that were
+// already reconciled.
+func (rr *ReconciliationRepository) CreateSession(ctx context.Context, c reconciliation.CreateSessionReq) (reconciliation.Session, error) {
+	if _, err := rr.ar.AccountByID(ctx, c.AccountID); err != nil {
+		return reconciliation.Session{}, fmt.Errorf("sqlite.ReconciliationRepository.CreateSession: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var result reconciliation.Session
+	err := rr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		sb := sqlbuilder.SQLite.NewSelectBuilder()
+		sb.Select(
+			sb.As("COUNT(*) FILTER (WHERE completed_at IS NULL)", "open_count"),
+			sb.As("COALESCE(MAX(statement_date) FILTER (WHERE completed_at IS NOT NULL), '')", "last_statement_date"),
+		)
+		sb.From("reconciliation")
+		sb.Where(
+			sb.And(
+				sb.EQ("account_id", c.AccountID),
+				sb.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args := sb.Build()
+
+		logger.Infow(
+			"Find reconciliations of account",
+			"query", q,
+			"args", args,
+		)
+
+		var existing struct {
+			OpenCount         int64  `db:"open_count"`
+			LastStatementDate string `db:"last_statement_date"`
+		}
+		if err := tx.GetContext(ctx, &existing, q, args...); err != nil {
+			return fmt.Errorf("GetContext: %w", err)
+		}
+		if existing.OpenCount > 0 {
+			return internal.NewError(internal.ErrorCodeConflict, "Account has an open reconciliation")
+		}
+		if existing.LastStatementDate != "" && c.StatementDate <= existing.LastStatementDate {
+			return internal.NewError(internal.ErrorCodeInvalid, "'statement_date' must be after the last reconciled statement")
+		}
+
+		ib := sqlbuilder.SQLite.NewInsertBuilder()
+		ib.InsertInto("reconciliation")
+		ib.Cols("statement_date", "statement_balance", "account_id", "user_id")
+		ib.Values(c.StatementDate, c.StatementBalance, c.AccountID, u.ID)
+		ib.Returning("id")
+
+		q, args = ib.Build()
+
+		logger.Infow(
+			"Insert reconciliation",
+			"query", q,
+			"args", args,
+		)
+
+		var id string
+		if err := tx.GetContext(ctx, &id, q, args...); err != nil {
+			return fmt.Errorf("GetContext: %w", err)
+		}
+
+		s, err := sessionByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		result = s
+		return nil
+	})
+	if err != nil {
+		return reconciliation.Session{}, fmt.Errorf("sqlite.ReconciliationRepository.CreateSession: %w", err)
+	}
+
+	return result, nil
+}
+
+func (rr *ReconciliationRepository) DeleteSession(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	err := rr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		s, err := sessionByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if s.Completed() {
+			return internal.NewError(internal.ErrorCodeConflict, "Can't delete a completed reconciliation")
+		}
+
+		db := sqlbuilder.SQLite.NewDeleteBuilder()
+		db.DeleteFrom("reconciliation")
+		db.Where(
+			db.And(
+				db.EQ("id", id),
+				db.EQ("user_id", u.ID),
+			),
+		)
+
+		q, args := db.Build()
+
+		logger.Infow(
+			"Delete reconciliation",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("ExecContext: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite.ReconciliationRepository.DeleteSession: %w", err)
+	}
+
+	return nil
+}
+
+// ListItems lists the expenses that were reconciled in a completed session,
+// or the expenses that can still be cleared in an open session.
+func (rr *ReconciliationRepository) ListItems(ctx context.Context, sessionID string) ([]reconciliation.Item, error) {
+	s, err := sessionByID(ctx, rr.db.reader, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.ReconciliationRepository.ListItems: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"id",
+		"name",
+		"amount",
+		"date",
+		sb.As(fmt.Sprintf("COALESCE(reconciliation_id = %s, 0)", sb.Var(s.ID)), "cleared"),
+	)
+	sb.From("expense")
+	if s.Completed() {
+		sb.Where(
+			sb.And(
+				sb.EQ("reconciliation_id", s.ID),
+				sb.EQ("user_id", u.ID),
+				sb.IsNull("deleted_at"),
+			),
+		)
+	} else {
+		sb.Where(
+			sb.And(
+				sb.EQ("account_id", s.AccountID),
+				sb.EQ("user_id", u.ID),
+				sb.IsNull("deleted_at"),
+				sb.IsNull("reconciled_at"),
+				sb.LTE("date", s.StatementDate.Format(time.DateOnly)),
+			),
+		)
+	}
+	sb.OrderBy("date", "created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List reconciliation items",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		ID      string    `db:"id"`
+		Name    string    `db:"name"`
+		Amount  int64     `db:"amount"`
+		Date    time.Time `db:"date"`
+		Cleared bool      `db:"cleared"`
+	}
+	if err := rr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.ReconciliationRepository.ListItems: SelectContext: %w", err)
+	}
+
+	result := make([]reconciliation.Item, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, reconciliation.Item{
+			ExpenseID: v.ID,
+			Name:      v.Name,
+			Amount:    v.Amount,
+			Date:      v.Date,
+			Cleared:   v.Cleared,
+		})
+	}
+
+	return result, nil
+}
+
+// MarkCleared only clears the expenses of the account up to the statement
+// date that are not yet reconciled. Nothing is changed if one of the expenses
+// can't be cleared.
+func (rr *ReconciliationRepository) MarkCleared(ctx context.Context, m reconciliation.MarkClearedReq) (reconciliation.Session, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ids := slices.Clone(m.ExpenseIDs)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	var result reconciliation.Session
+	err := rr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		s, err := sessionByID(ctx, tx, m.SessionID)
+		if err != nil {
+			return err
+		}
+		if s.Completed() {
+			return internal.NewError(internal.ErrorCodeConflict, "Reconciliation is completed")
+		}
+
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("expense")
+		if m.Cleared {
+			ub.Set(ub.Assign("reconciliation_id", s.ID))
+			ub.Where(
+				ub.In("id", sqlbuilder.Flatten(ids)...),
+				ub.EQ("user_id", u.ID),
+				ub.EQ("account_id", s.AccountID),
+				ub.IsNull("deleted_at"),
+				ub.IsNull("reconciled_at"),
+				ub.LTE("date", s.StatementDate.Format(time.DateOnly)),
+			)
+		} else {
+			ub.Set(ub.Assign("reconciliation_id", nil))
+			ub.Where(
+				ub.In("id", sqlbuilder.Flatten(ids)...),
+				ub.EQ("user_id", u.ID),
+				ub.EQ("reconciliation_id", s.ID),
+				ub.IsNull("reconciled_at"),
+			)
+		}
+
+		q, args := ub.Build()
+
+		logger.Infow(
+			"Mark expenses as cleared",
+			"query", q,
+			"args", args,
+		)
+
+		r, err := tx.ExecContext(ctx, q, args...)
+		if err != nil {
+			return fmt.Errorf("ExecContext: %w", err)
+		}
+
+		affected, err := r.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("RowsAffected: %w", err)
+		}
+		if affected != int64(len(ids)) {
+			return internal.NewError(internal.ErrorCodeNotFound, "Expense not found")
+		}
+
+		s, err = sessionByID(ctx, tx, s.ID)
+		if err != nil {
+			return err
+		}
+
+		result = s
+		return nil
+	})
+	if err != nil {
+		return reconciliation.Session{}, fmt.Errorf("sqlite.ReconciliationRepository.MarkCleared: %w", err)
+	}
+
+	return result, nil
+}
+
+func (rr *ReconciliationRepository) CompleteSession(ctx context.Context, id string) (reconciliation.Session, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var result reconciliation.Session
+	err := rr.db.withTx(ctx, func(tx *sqlx.Tx) error {
+		s, err := sessionByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if s.Completed() {
+			return internal.NewError(internal.ErrorCodeConflict, "Reconciliation is completed")
+		}
+		if s.Difference() != 0 {
+			return internal.NewErrorf(internal.ErrorCodeConflict, "Cleared balance is off from the statement balance by %d", s.Difference())
+		}
+
+		ub := sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("expense")
+		ub.Set(ub.Assign("reconciled_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+		// the expenses that count toward the cleared balance
+		ub.Where(
+			ub.EQ("reconciliation_id", s.ID),
+			ub.EQ("user_id", u.ID),
+			ub.EQ("account_id", s.AccountID),
+			ub.IsNull("deleted_at"),
+			ub.LTE("date", s.StatementDate.Format(time.DateOnly)),
+		)
+
+		q, args := ub.Build()
+
+		logger.Infow(
+			"Lock reconciled expenses",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("ExecContext: %w", err)
+		}
+
+		ub = sqlbuilder.SQLite.NewUpdateBuilder()
+		ub.Update("reconciliation")
+		ub.Set(
+			ub.Assign("completed_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+			ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		)
+		ub.Where(
+			ub.EQ("id", s.ID),
+			ub.EQ("user_id", u.ID),
+		)
+
+		q, args = ub.Build()
+
+		logger.Infow(
+			"Complete reconciliation",
+			"query", q,
+			"args", args,
+		)
+
+		if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("ExecContext: %w", err)
+		}
+
+		s, err = sessionByID(ctx, tx, s.ID)
+		if err != nil {
+			return err
+		}
+
+		result = s
+		return nil
+	})
+	if err != nil {
+		return reconciliation.Session{}, fmt.Errorf("sqlite.ReconciliationRepository.CompleteSession: %w", err)
+	}
+
+	return result, nil
+}
+
+func sessionByID(ctx context.Context, q sqlx.QueryerContext, id string) (reconciliation.Session, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(sessionColumns...)
+	sb.From("reconciliation r")
+	sb.Join("account a", "a.id = r.account_id")
+	sb.Where(
+		sb.And(
+			sb.EQ("r.id", id),
+			sb.EQ("r.user_id", u.ID),
+		),
+	)
+
+	query, args := sb.Build()
+
+	logger.Infow(
+		"Find reconciliation by id",
+		"query", query,
+		"args", args,
+	)
+
+	var dst sessionDst
+	if err := sqlx.GetContext(ctx, q, &dst, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return reconciliation.Session{}, internal.NewError(internal.ErrorCodeNotFound, "Reconciliation not found")
+		}
+
+		return reconciliation.Session{}, fmt.Errorf("sqlite.sessionByID: GetContext: %w", err)
+	}
+
+	return reconciliation.Session(dst), nil
+}
+
+// The expenses that count toward the cleared balance are the ones cleared in
+// the session and the ones reconciled before, up to the statement date.
+var sessionColumns = []string{
+	"r.id",
+	"r.account_id",
+	"r.statement_date",
+	"r.statement_balance",
+	`a.opening_balance
+		+ COALESCE((SELECT SUM(t.amount) FROM transfer t WHERE t.to_account_id = a.id AND t.date <= r.statement_date), 0)
+		- COALESCE((SELECT SUM(t.amount) FROM transfer t WHERE t.from_account_id = a.id AND t.date <= r.statement_date), 0)
+		- COALESCE((
+			SELECT SUM(e.amount) FROM expense e
+			WHERE e.account_id = a.id
+				AND e.deleted_at IS NULL
+				AND e.date <= r.statement_date
+				AND (e.reconciliation_id = r.id OR e.reconciled_at IS NOT NULL)
+		), 0) AS cleared_balance`,
+	"r.completed_at",
+	"r.created_at",
+	"r.updated_at",
+}
+
+type sessionDst struct {
+	ID               string     `db:"id"`
+	AccountID        string     `db:"account_id"`
+	StatementDate    time.Time  `db:"statement_date"`
+	StatementBalance int64      `db:"statement_balance"`
+	ClearedBalance   int64      `db:"cleared_balance"`
+	CompletedAt      *time.Time `db:"completed_at"`
+	CreatedAt        time.Time  `db:"created_at"`
+	UpdatedAt        time.Time  `db:"updated_at"`
+}
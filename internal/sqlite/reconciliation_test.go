@@ -0,0 +1,286 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/account"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/reconciliation"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconciliation(t *testing.T) {
+	dh := newDBHelper(t, "test_reconciliation.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	ar := sqlite.NewAccountRepository(dh.db)
+	rr := sqlite.NewReconciliationRepository(dh.db, ar)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	bank, err := ar.CreateAccount(ctxWithUser1, account.CreateAccountReq{
+		Name:           "Bank",
+		Type:           account.AccountTypeBank,
+		OpeningBalance: 10000,
+		Currency:       "PHP",
+	})
+	assert.Nil(t, err)
+
+	createExpense := func(name string, amount int64, date string) expense.Expense {
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       name,
+			Amount:     amount,
+			Date:       date,
+			CategoryID: categories[0].ID,
+			AccountID:  &bank.ID,
+		})
+		assert.Nil(t, err)
+		return e
+	}
+
+	rent := createExpense("Rent", 5000, "2006-01-01")
+	groceries := createExpense("Groceries", 1500, "2006-01-15")
+	// After the statement date.
+	lunch := createExpense("Lunch", 500, "2006-02-02")
+
+	session, err := rr.CreateSession(ctxWithUser1, reconciliation.CreateSessionReq{
+		AccountID:        bank.ID,
+		StatementDate:    "2006-01-31",
+		StatementBalance: 5000,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, bank.ID, session.AccountID)
+	assert.Equal(t, time.Date(2006, time.January, 31, 0, 0, 0, 0, time.UTC), session.StatementDate)
+	assert.Equal(t, int64(10000), session.ClearedBalance)
+	assert.Equal(t, int64(-5000), session.Difference())
+	assert.False(t, session.Completed())
+
+	t.Run("only one open session per account", func(t *testing.T) {
+		_, err := rr.CreateSession(ctxWithUser1, reconciliation.CreateSessionReq{
+			AccountID:        bank.ID,
+			StatementDate:    "2006-02-28",
+			StatementBalance: 5000,
+		})
+		assert.Equal(t, internal.ErrorCodeConflict, internal.GetErrorCode(err))
+		assert.Equal(t, "Account has an open reconciliation", internal.GetErrorMessage(err))
+	})
+
+	t.Run("can't access session of other user", func(t *testing.T) {
+		_, err := rr.SessionByID(ctxWithUser2, session.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+
+		_, err = rr.MarkCleared(ctxWithUser2, reconciliation.MarkClearedReq{
+			SessionID:  session.ID,
+			ExpenseIDs: []string{rent.ID},
+			Cleared:    true,
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("items up to the statement date", func(t *testing.T) {
+		items, err := rr.ListItems(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, []reconciliation.Item{
+			{ExpenseID: rent.ID, Name: "Rent", Amount: 5000, Date: rent.Date},
+			{ExpenseID: groceries.ID, Name: "Groceries", Amount: 1500, Date: groceries.Date},
+		}, items)
+	})
+
+	t.Run("can't clear expense after the statement date", func(t *testing.T) {
+		_, err := rr.MarkCleared(ctxWithUser1, reconciliation.MarkClearedReq{
+			SessionID:  session.ID,
+			ExpenseIDs: []string{rent.ID, lunch.ID},
+			Cleared:    true,
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+		assert.Equal(t, "Expense not found", internal.GetErrorMessage(err))
+
+		got, err := rr.SessionByID(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(10000), got.ClearedBalance)
+	})
+
+	t.Run("mark cleared", func(t *testing.T) {
+		got, err := rr.MarkCleared(ctxWithUser1, reconciliation.MarkClearedReq{
+			SessionID:  session.ID,
+			ExpenseIDs: []string{rent.ID, groceries.ID, rent.ID},
+			Cleared:    true,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3500), got.ClearedBalance)
+		assert.Equal(t, int64(1500), got.Difference())
+
+		got, err = rr.MarkCleared(ctxWithUser1, reconciliation.MarkClearedReq{
+			SessionID:  session.ID,
+			ExpenseIDs: []string{groceries.ID},
+			Cleared:    false,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5000), got.ClearedBalance)
+		assert.Equal(t, int64(0), got.Difference())
+
+		items, err := rr.ListItems(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		assert.True(t, items[0].Cleared)
+		assert.False(t, items[1].Cleared)
+	})
+
+	t.Run("cleared expenses can still be changed before completing", func(t *testing.T) {
+		_, err := er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: rent.ID, Note: toPtr(t, "January")})
+		assert.Nil(t, err)
+	})
+
+	var coffee, tea expense.Expense
+	t.Run("moved or trashed expenses are no longer cleared", func(t *testing.T) {
+		wallet, err := ar.CreateAccount(ctxWithUser1, account.CreateAccountReq{
+			Name:     "Wallet",
+			Type:     account.AccountTypeCash,
+			Currency: "PHP",
+		})
+		assert.Nil(t, err)
+
+		coffee = createExpense("Coffee", 100, "2006-01-10")
+		tea = createExpense("Tea", 100, "2006-01-11")
+		got, err := rr.MarkCleared(ctxWithUser1, reconciliation.MarkClearedReq{
+			SessionID:  session.ID,
+			ExpenseIDs: []string{coffee.ID, tea.ID},
+			Cleared:    true,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4800), got.ClearedBalance)
+
+		_, err = er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: coffee.ID, AccountID: &wallet.ID})
+		assert.Nil(t, err)
+		_, err = er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: tea.ID})
+		assert.Nil(t, err)
+
+		got, err = rr.SessionByID(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5000), got.ClearedBalance)
+
+		items, err := rr.ListItems(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		for _, item := range items {
+			assert.NotEqual(t, tea.ID, item.ExpenseID)
+			assert.NotEqual(t, coffee.ID, item.ExpenseID)
+		}
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		got, err := rr.CompleteSession(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		assert.True(t, got.Completed())
+		assert.WithinDuration(t, time.Now(), *got.CompletedAt, time.Second*5)
+
+		_, err = rr.CompleteSession(ctxWithUser1, session.ID)
+		assert.Equal(t, internal.ErrorCodeConflict, internal.GetErrorCode(err))
+		assert.Equal(t, "Reconciliation is completed", internal.GetErrorMessage(err))
+
+		err = rr.DeleteSession(ctxWithUser1, session.ID)
+		assert.Equal(t, internal.ErrorCodeConflict, internal.GetErrorCode(err))
+
+		items, err := rr.ListItems(ctxWithUser1, session.ID)
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, rent.ID, items[0].ExpenseID)
+		assert.True(t, items[0].Cleared)
+	})
+
+	t.Run("only the cleared balance is locked", func(t *testing.T) {
+		got, err := er.ExpenseByID(ctxWithUser1, coffee.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, got.ReconciledAt)
+
+		got, err = er.RestoreExpense(ctxWithUser1, tea.ID)
+		assert.Nil(t, err)
+		assert.Nil(t, got.ReconciledAt)
+
+		_, err = er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: tea.ID})
+		assert.Nil(t, err)
+	})
+
+	t.Run("reconciled expenses are locked", func(t *testing.T) {
+		got, err := er.ExpenseByID(ctxWithUser1, rent.ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, got.ReconciledAt)
+
+		_, err = er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: rent.ID, Amount: toPtr(t, int64(1))})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Expense is reconciled"), err)
+
+		_, err = er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: rent.ID})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Expense is reconciled"), err)
+
+		_, err = er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: groceries.ID, Amount: toPtr(t, int64(1000))})
+		assert.Nil(t, err)
+
+		_, err = er.UpdateExpense(ctxWithUser2, expense.UpdateExpenseReq{ID: rent.ID, Amount: toPtr(t, int64(1))})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Expense not found"), err)
+	})
+
+	t.Run("next session", func(t *testing.T) {
+		_, err := rr.CreateSession(ctxWithUser1, reconciliation.CreateSessionReq{
+			AccountID:        bank.ID,
+			StatementDate:    "2006-01-31",
+			StatementBalance: 0,
+		})
+		assert.Equal(t, internal.ErrorCodeInvalid, internal.GetErrorCode(err))
+
+		next, err := rr.CreateSession(ctxWithUser1, reconciliation.CreateSessionReq{
+			AccountID:        bank.ID,
+			StatementDate:    "2006-02-28",
+			StatementBalance: 3000,
+		})
+		assert.Nil(t, err)
+		// The reconciled rent is already part of the cleared balance.
+		assert.Equal(t, int64(5000), next.ClearedBalance)
+
+		items, err := rr.ListItems(ctxWithUser1, next.ID)
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, groceries.ID, items[0].ExpenseID)
+		assert.Equal(t, lunch.ID, items[1].ExpenseID)
+
+		_, err = rr.MarkCleared(ctxWithUser1, reconciliation.MarkClearedReq{
+			SessionID:  next.ID,
+			ExpenseIDs: []string{groceries.ID, lunch.ID},
+			Cleared:    true,
+		})
+		assert.Nil(t, err)
+
+		_, err = rr.CompleteSession(ctxWithUser1, next.ID)
+		assert.Equal(t, internal.ErrorCodeConflict, internal.GetErrorCode(err))
+		assert.Equal(t, "Cleared balance is off from the statement balance by -500", internal.GetErrorMessage(err))
+
+		err = rr.DeleteSession(ctxWithUser1, next.ID)
+		assert.Nil(t, err)
+
+		_, err = er.UpdateExpense(ctxWithUser1, expense.UpdateExpenseReq{ID: lunch.ID, Amount: toPtr(t, int64(1000))})
+		assert.Nil(t, err)
+
+		sessions, err := rr.ListSessions(ctxWithUser1, bank.ID)
+		assert.Nil(t, err)
+		assert.Len(t, sessions, 1)
+		assert.Equal(t, session.ID, sessions[0].ID)
+	})
+
+	t.Run("can't delete account with reconciliations", func(t *testing.T) {
+		err := ar.DeleteAccount(ctxWithUser1, bank.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeConflict, "Account has reconciliations"), err)
+
+		got, err := er.ExpenseByID(ctxWithUser1, rent.ID)
+		assert.Nil(t, err)
+		assert.NotNil(t, got.ReconciledAt)
+	})
+}
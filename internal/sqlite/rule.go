@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/rule"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+	"github.com/jmoiron/sqlx"
+)
+
+type RuleRepository struct {
+	db *DB
+	cr CategoryRepository
+}
+
+var _ rule.Repository = (*RuleRepository)(nil)
+
+func NewRuleRepository(db *DB, cr CategoryRepository) RuleRepository {
+	return RuleRepository{
+		db: db,
+		cr: cr,
+	}
+}
+
+// ListRules lists the rules of the user in the order they are applied.
+func (rr *RuleRepository) ListRules(ctx context.Context) ([]rule.Rule, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(ruleColumns...)
+	sb.From("rule")
+	sb.Where(sb.EQ("user_id", u.ID))
+	sb.OrderBy("priority", "created_at")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List rules",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []ruleDst
+	if err := rr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.RuleRepository.ListRules: SelectContext: %w", err)
+	}
+
+	result := make([]rule.Rule, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, v.toRule())
+	}
+
+	return result, nil
+}
+
+func (rr *RuleRepository) CreateRule(ctx context.Context, c rule.CreateRuleReq) (rule.Rule, error) {
+	if c.CategoryID != nil {
+		if _, err := rr.cr.CategoryByID(ctx, *c.CategoryID); err != nil {
+			return rule.Rule{}, fmt.Errorf("sqlite.RuleRepository.CreateRule: %w", err)
+		}
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("rule")
+	ib.Cols(
+		"name",
+		"priority",
+		"name_contains",
+		"note_contains",
+		"name_regex",
+		"min_amount",
+		"max_amount",
+		"days_of_week",
+		"category_id",
+		"rename",
+		"append_tags",
+		"user_id",
+	)
+	ib.Values(
+		c.Name,
+		c.Priority,
+		c.NameContains,
+		c.NoteContains,
+		c.NameRegex,
+		c.MinAmount,
+		c.MaxAmount,
+		jsonColumn[[]time.Weekday]{V: c.DaysOfWeek},
+		c.CategoryID,
+		c.Rename,
+		jsonColumn[[]string]{V: c.AppendTags},
+		u.ID,
+	)
+	ib.Returning(ruleColumns...)
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert rule",
+		"query", q,
+		"args", args,
+	)
+
+	var dst ruleDst
+	if err := rr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return rule.Rule{}, fmt.Errorf("sqlite.RuleRepository.CreateRule: GetContext: %w", err)
+	}
+
+	return dst.toRule(), nil
+}
+
+func (rr *RuleRepository) DeleteRule(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("rule")
+	db.Where(
+		db.And(
+			db.EQ("id", id),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete rule",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := rr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.RuleRepository.DeleteRule: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.RuleRepository.DeleteRule: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Rule not found")
+	}
+
+	return nil
+}
+
+// moveRules points the rules that assign the category to another category.
+// A nil toCategoryID makes the rules stop assigning a category.
+func moveRules(ctx context.Context, tx *sqlx.Tx, fromCategoryID string, toCategoryID *string) (int64, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("rule")
+	ub.Set(
+		ub.Assign("category_id", toCategoryID),
+		ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+	)
+	ub.Where(
+		ub.And(
+			ub.EQ("category_id", fromCategoryID),
+			ub.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Move rules to category",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := tx.ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.moveRules: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite.moveRules: RowsAffected: %w", err)
+	}
+
+	return affected, nil
+}
+
+var ruleColumns = []string{
+	"id",
+	"name",
+	"priority",
+	"name_contains",
+	"note_contains",
+	"name_regex",
+	"min_amount",
+	"max_amount",
+	"days_of_week",
+	"category_id",
+	"rename",
+	"append_tags",
+	"created_at",
+	"updated_at",
+}
+
+type ruleDst struct {
+	ID           string                     `db:"id"`
+	Name         string                     `db:"name"`
+	Priority     int                        `db:"priority"`
+	NameContains string                     `db:"name_contains"`
+	NoteContains string                     `db:"note_contains"`
+	NameRegex    string                     `db:"name_regex"`
+	MinAmount    *int64                     `db:"min_amount"`
+	MaxAmount    *int64                     `db:"max_amount"`
+	DaysOfWeek   jsonColumn[[]time.Weekday] `db:"days_of_week"`
+	CategoryID   *string                    `db:"category_id"`
+	Rename       *string                    `db:"rename"`
+	AppendTags   jsonColumn[[]string]       `db:"append_tags"`
+	CreatedAt    time.Time                  `db:"created_at"`
+	UpdatedAt    time.Time                  `db:"updated_at"`
+}
+
+func (d ruleDst) toRule() rule.Rule {
+	return rule.Rule{
+		ID:       d.ID,
+		Name:     d.Name,
+		Priority: d.Priority,
+		Conditions: rule.Conditions{
+			NameContains: d.NameContains,
+			NoteContains: d.NoteContains,
+			NameRegex:    d.NameRegex,
+			MinAmount:    d.MinAmount,
+			MaxAmount:    d.MaxAmount,
+			DaysOfWeek:   d.DaysOfWeek.V,
+		},
+		Actions: rule.Actions{
+			CategoryID: d.CategoryID,
+			Rename:     d.Rename,
+			AppendTags: d.AppendTags.V,
+		},
+		CreatedAt: d.CreatedAt,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
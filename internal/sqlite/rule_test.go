@@ -0,0 +1,145 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/rule"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateListDeleteRule(t *testing.T) {
+	dh := newDBHelper(t, "test_create_list_delete_rule.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	rr := sqlite.NewRuleRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	otherCategories := createCategories(t, dh.db, users[1])
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	coffee, err := rr.CreateRule(ctxWithUser1, rule.CreateRuleReq{
+		Name:         "coffee",
+		Priority:     2,
+		NameContains: "starbucks",
+		NoteContains: "latte",
+		NameRegex:    "^Star",
+		MinAmount:    toPtr(t, int64(100)),
+		MaxAmount:    toPtr(t, int64(1000)),
+		DaysOfWeek:   []time.Weekday{time.Monday, time.Friday},
+		CategoryID:   &categories[0].ID,
+		Rename:       toPtr(t, "Starbucks"),
+		AppendTags:   []string{"coffee", "treat"},
+	})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, coffee.ID)
+	assert.Equal(t, rule.Conditions{
+		NameContains: "starbucks",
+		NoteContains: "latte",
+		NameRegex:    "^Star",
+		MinAmount:    toPtr(t, int64(100)),
+		MaxAmount:    toPtr(t, int64(1000)),
+		DaysOfWeek:   []time.Weekday{time.Monday, time.Friday},
+	}, coffee.Conditions)
+	assert.Equal(t, rule.Actions{
+		CategoryID: &categories[0].ID,
+		Rename:     toPtr(t, "Starbucks"),
+		AppendTags: []string{"coffee", "treat"},
+	}, coffee.Actions)
+	assert.WithinDuration(t, time.Now(), coffee.CreatedAt, time.Second*5)
+
+	rent, err := rr.CreateRule(ctxWithUser1, rule.CreateRuleReq{
+		Name:         "rent",
+		Priority:     1,
+		NameContains: "rent",
+		AppendTags:   []string{"monthly"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, rule.Actions{AppendTags: []string{"monthly"}}, rent.Actions)
+
+	t.Run("can't assign the category of other user", func(t *testing.T) {
+		_, err := rr.CreateRule(ctxWithUser1, rule.CreateRuleReq{
+			Name:         "other",
+			NameContains: "other",
+			CategoryID:   &otherCategories[0].ID,
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+		assert.Equal(t, "Category not found", internal.GetErrorMessage(err))
+	})
+
+	t.Run("list rules by priority", func(t *testing.T) {
+		rules, err := rr.ListRules(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.Equal(t, []rule.Rule{rent, coffee}, rules)
+
+		rules, err = rr.ListRules(ctxWithUser2)
+		assert.Nil(t, err)
+		assert.Empty(t, rules)
+	})
+
+	t.Run("can't delete rule of other user", func(t *testing.T) {
+		err := rr.DeleteRule(ctxWithUser2, rent.ID)
+		assert.Equal(t, internal.NewError(internal.ErrorCodeNotFound, "Rule not found"), err)
+	})
+
+	t.Run("delete rule", func(t *testing.T) {
+		err := rr.DeleteRule(ctxWithUser1, rent.ID)
+		assert.Nil(t, err)
+
+		rules, err := rr.ListRules(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.Equal(t, []rule.Rule{coffee}, rules)
+	})
+}
+
+func TestRulesFollowCategory(t *testing.T) {
+	dh := newDBHelper(t, "test_rules_follow_category.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	rr := sqlite.NewRuleRepository(dh.db, cr)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	ctxWithUser := user.ContextWithUser(ctxWithLogger, users[0])
+
+	r, err := rr.CreateRule(ctxWithUser, rule.CreateRuleReq{
+		Name:         "food",
+		NameContains: "food",
+		CategoryID:   &categories[0].ID,
+	})
+	assert.Nil(t, err)
+
+	result, err := cr.MergeCategories(ctxWithUser, category.MergeCategoriesReq{
+		SourceID: categories[0].ID,
+		TargetID: categories[1].ID,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), result.MovedRules)
+
+	rules, err := rr.ListRules(ctxWithUser)
+	assert.Nil(t, err)
+	assert.Equal(t, r.ID, rules[0].ID)
+	assert.Equal(t, &categories[1].ID, rules[0].Actions.CategoryID)
+
+	_, err = cr.DeleteCategory(ctxWithUser, category.DeleteCategoryReq{
+		ID:       categories[1].ID,
+		Strategy: category.DeleteStrategyCascade,
+	})
+	assert.Nil(t, err)
+
+	rules, err = rr.ListRules(ctxWithUser)
+	assert.Nil(t, err)
+	assert.Nil(t, rules[0].Actions.CategoryID)
+}
@@ -0,0 +1,242 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+type TokenRepository struct {
+	db *DB
+}
+
+var _ token.Repository = (*TokenRepository)(nil)
+
+func NewTokenRepository(db *DB) TokenRepository {
+	return TokenRepository{
+		db: db,
+	}
+}
+
+var tokenColumns = []string{
+	"id",
+	"name",
+	"scopes",
+	"prefix",
+	"expires_at",
+	"last_used_at",
+	"created_at",
+}
+
+func (tr *TokenRepository) ListTokens(ctx context.Context) ([]token.Token, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(tokenColumns...)
+	sb.From("personal_access_token")
+	sb.Where(sb.EQ("user_id", u.ID))
+	sb.OrderBy("created_at", "id")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List personal access tokens",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []tokenDst
+	if err := tr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.TokenRepository.ListTokens: SelectContext: %w", err)
+	}
+
+	result := make([]token.Token, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, v.toToken())
+	}
+
+	return result, nil
+}
+
+func (tr *TokenRepository) CreateToken(ctx context.Context, c token.CreateTokenReq, hash string, prefix string) (token.Token, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	var expiresAt *string
+	if c.ExpiresAt != nil {
+		v := c.ExpiresAt.UTC().Format(timestampLayout)
+		expiresAt = &v
+	}
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("personal_access_token")
+	ib.Cols(
+		"name",
+		"scopes",
+		"token_hash",
+		"prefix",
+		"expires_at",
+		"user_id",
+	)
+	ib.Values(
+		c.Name,
+		jsonColumn[[]token.Scope]{V: c.Scopes},
+		hash,
+		prefix,
+		expiresAt,
+		u.ID,
+	)
+	ib.Returning(tokenColumns...)
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert personal access token",
+		"query", q,
+		// the hash is not logged
+		"name", c.Name,
+		"scopes", c.Scopes,
+		"expires_at", c.ExpiresAt,
+	)
+
+	var dst tokenDst
+	if err := tr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return token.Token{}, fmt.Errorf("sqlite.TokenRepository.CreateToken: GetContext: %w", err)
+	}
+
+	return dst.toToken(), nil
+}
+
+func (tr *TokenRepository) DeleteToken(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("personal_access_token")
+	db.Where(
+		db.And(
+			db.EQ("id", id),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete personal access token",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := tr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.TokenRepository.DeleteToken: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.TokenRepository.DeleteToken: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Token not found")
+	}
+
+	return nil
+}
+
+func (tr *TokenRepository) TokenByHash(ctx context.Context, hash string) (token.Token, user.User, error) {
+	logger := logger.FromContext(ctx)
+
+	columns := make([]string, 0, len(tokenColumns)+3)
+	for _, c := range tokenColumns {
+		columns = append(columns, "t."+c)
+	}
+	columns = append(columns, "u.id AS user_id", "u.name AS user_name", "u.email AS user_email")
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(columns...)
+	sb.From("personal_access_token t")
+	sb.Join("user u", "u.id = t.user_id")
+	sb.Where(sb.EQ("t.token_hash", hash))
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find personal access token by hash",
+		"query", q,
+	)
+
+	var dst struct {
+		tokenDst
+		UserID    string `db:"user_id"`
+		UserName  string `db:"user_name"`
+		UserEmail string `db:"user_email"`
+	}
+	if err := tr.db.reader.GetContext(ctx, &dst, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return token.Token{}, user.User{}, internal.NewError(internal.ErrorCodeNotFound, "Token not found")
+		}
+
+		return token.Token{}, user.User{}, fmt.Errorf("sqlite.TokenRepository.TokenByHash: GetContext: %w", err)
+	}
+
+	return dst.toToken(), user.User{
+		ID:    dst.UserID,
+		Name:  dst.UserName,
+		Email: dst.UserEmail,
+	}, nil
+}
+
+func (tr *TokenRepository) MarkTokenUsed(ctx context.Context, id string, usedAt time.Time) error {
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("personal_access_token")
+	ub.Set(ub.Assign("last_used_at", usedAt.UTC().Format(timestampLayout)))
+	ub.Where(ub.EQ("id", id))
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Mark personal access token used",
+		"query", q,
+		"args", args,
+	)
+
+	if _, err := tr.db.readerWriter.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("sqlite.TokenRepository.MarkTokenUsed: ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+type tokenDst struct {
+	ID         string                    `db:"id"`
+	Name       string                    `db:"name"`
+	Scopes     jsonColumn[[]token.Scope] `db:"scopes"`
+	Prefix     string                    `db:"prefix"`
+	ExpiresAt  *time.Time                `db:"expires_at"`
+	LastUsedAt *time.Time                `db:"last_used_at"`
+	CreatedAt  time.Time                 `db:"created_at"`
+}
+
+func (t tokenDst) toToken() token.Token {
+	return token.Token{
+		ID:         t.ID,
+		Name:       t.Name,
+		Scopes:     t.Scopes.V,
+		Prefix:     t.Prefix,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
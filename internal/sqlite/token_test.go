@@ -0,0 +1,89 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToken(t *testing.T) {
+	dh := newDBHelper(t, "test_token.db")
+	defer dh.clean()
+
+	tr := sqlite.NewTokenRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	readOnly, err := tr.CreateToken(ctxWithUser1, token.CreateTokenReq{
+		Name:      "read only",
+		Scopes:    []token.Scope{token.ScopeRead},
+		ExpiresAt: &expiresAt,
+	}, "hash1", "bt_aaaaaa")
+	assert.Nil(t, err)
+	assert.Equal(t, "read only", readOnly.Name)
+	assert.Equal(t, []token.Scope{token.ScopeRead}, readOnly.Scopes)
+	assert.Equal(t, "bt_aaaaaa", readOnly.Prefix)
+	assert.Equal(t, expiresAt, *readOnly.ExpiresAt)
+	assert.Nil(t, readOnly.LastUsedAt)
+
+	readWrite, err := tr.CreateToken(ctxWithUser1, token.CreateTokenReq{
+		Name:   "read write",
+		Scopes: []token.Scope{token.ScopeRead, token.ScopeWrite},
+	}, "hash2", "bt_bbbbbb")
+	assert.Nil(t, err)
+	assert.Nil(t, readWrite.ExpiresAt)
+
+	other, err := tr.CreateToken(ctxWithUser2, token.CreateTokenReq{
+		Name:   "other",
+		Scopes: []token.Scope{token.ScopeRead},
+	}, "hash3", "bt_cccccc")
+	assert.Nil(t, err)
+
+	t.Run("list tokens", func(t *testing.T) {
+		tokens, err := tr.ListTokens(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []token.Token{readOnly, readWrite}, tokens)
+	})
+
+	t.Run("token by hash", func(t *testing.T) {
+		got, u, err := tr.TokenByHash(ctxWithLogger, "hash3")
+		assert.Nil(t, err)
+		assert.Equal(t, other, got)
+		assert.Equal(t, users[1], u)
+
+		_, _, err = tr.TokenByHash(ctxWithLogger, "hash4")
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("mark token used", func(t *testing.T) {
+		usedAt := time.Now().UTC().Truncate(time.Second)
+		err := tr.MarkTokenUsed(ctxWithLogger, readWrite.ID, usedAt)
+		assert.Nil(t, err)
+
+		got, _, err := tr.TokenByHash(ctxWithLogger, "hash2")
+		assert.Nil(t, err)
+		assert.Equal(t, usedAt, *got.LastUsedAt)
+	})
+
+	t.Run("delete token", func(t *testing.T) {
+		err := tr.DeleteToken(ctxWithUser2, readOnly.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+
+		err = tr.DeleteToken(ctxWithUser1, readOnly.ID)
+		assert.Nil(t, err)
+
+		_, _, err = tr.TokenByHash(ctxWithLogger, "hash1")
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+}
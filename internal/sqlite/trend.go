@@ -0,0 +1,266 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/trend"
+	"github.com/cativovo/budget-tracker/internal/user"
+)
+
+type TrendRepository struct {
+	db *DB
+}
+
+var _ trend.Repository = (*TrendRepository)(nil)
+
+func NewTrendRepository(db *DB) TrendRepository {
+	return TrendRepository{
+		db: db,
+	}
+}
+
+// ListMonthlyTotals lists the spending of every category that has expenses
+// within the range for each month of the range, months without expenses
+// included. The series starts 12 months early so the previous year and the
+// rolling averages of the first months are complete.
+func (tr *TrendRepository) ListMonthlyTotals(ctx context.Context, m trend.MonthlyTotalsReq) ([]trend.MonthlyTotal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	start, err := time.Parse("2006-01", m.StartMonth)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.TrendRepository.ListMonthlyTotals: Parse: %w", err)
+	}
+	end, err := time.Parse("2006-01", m.EndMonth)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite.TrendRepository.ListMonthlyTotals: Parse: %w", err)
+	}
+
+	seriesStart := start.AddDate(-1, 0, 0).Format(time.DateOnly)
+	rangeStart := start.Format(time.DateOnly)
+	rangeEnd := end.AddDate(0, 1, -1).Format(time.DateOnly)
+
+	q := `
+		WITH RECURSIVE months(month) AS (
+			SELECT ?
+			UNION ALL
+			SELECT date(month, '+1 month') FROM months WHERE month < ?
+		),
+		totals AS (
+			SELECT category_id, strftime('%Y-%m-01', date) AS month, SUM(amount) AS total
+			FROM expense
+			WHERE user_id = ? AND date BETWEEN ? AND ? AND deleted_at IS NULL
+			GROUP BY category_id, month
+		),
+		series AS (
+			SELECT ids.category_id, m.month, COALESCE(t.total, 0) AS total
+			FROM (SELECT DISTINCT category_id FROM totals WHERE month >= ?) ids
+			CROSS JOIN months m
+			LEFT JOIN totals t ON t.category_id = ids.category_id AND t.month = m.month
+		),
+		windowed AS (
+			SELECT
+				category_id,
+				month,
+				total,
+				LAG(total, 12, 0) OVER w AS previous_year_total,
+				ROUND(AVG(total) OVER (w ROWS BETWEEN 2 PRECEDING AND CURRENT ROW), 2) AS rolling_average_3,
+				ROUND(AVG(total) OVER (w ROWS BETWEEN 5 PRECEDING AND CURRENT ROW), 2) AS rolling_average_6,
+				ROUND(AVG(total) OVER (w ROWS BETWEEN 11 PRECEDING AND CURRENT ROW), 2) AS rolling_average_12
+			FROM series
+			WINDOW w AS (PARTITION BY category_id ORDER BY month)
+		)
+		SELECT
+			c.id,
+			c.name,
+			c.color,
+			c.icon,
+			c.version,
+			c.created_at,
+			c.updated_at,
+			c.parent_id,
+			w.month,
+			w.total,
+			w.previous_year_total,
+			w.rolling_average_3,
+			w.rolling_average_6,
+			w.rolling_average_12
+		FROM windowed w
+		JOIN category c ON c.id = w.category_id
+		WHERE w.month >= ?
+		ORDER BY w.month, c.name`
+	args := []any{seriesStart, end.Format(time.DateOnly), u.ID, seriesStart, rangeEnd, rangeStart, rangeStart}
+
+	logger.Infow(
+		"List monthly totals",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		categoryDst
+		Month            string  `db:"month"`
+		Total            int64   `db:"total"`
+		PreviousYear     int64   `db:"previous_year_total"`
+		RollingAverage3  float64 `db:"rolling_average_3"`
+		RollingAverage6  float64 `db:"rolling_average_6"`
+		RollingAverage12 float64 `db:"rolling_average_12"`
+	}
+	if err := tr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.TrendRepository.ListMonthlyTotals: SelectContext: %w", err)
+	}
+
+	result := make([]trend.MonthlyTotal, 0, len(dst))
+	for _, v := range dst {
+		month, err := time.Parse(time.DateOnly, v.Month)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite.TrendRepository.ListMonthlyTotals: Parse: %w", err)
+		}
+
+		result = append(result, trend.MonthlyTotal{
+			Category:          category.Category(v.categoryDst),
+			Month:             month,
+			Total:             v.Total,
+			PreviousYearTotal: v.PreviousYear,
+			RollingAverage3:   v.RollingAverage3,
+			RollingAverage6:   v.RollingAverage6,
+			RollingAverage12:  v.RollingAverage12,
+		})
+	}
+
+	return result, nil
+}
+
+// ListYearOverYear lists every category that has expenses in either of the
+// two years.
+func (tr *TrendRepository) ListYearOverYear(ctx context.Context, y trend.YearOverYearReq) ([]trend.YearOverYear, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	q := `
+		WITH yearly AS (
+			SELECT category_id, CAST(strftime('%Y', date) AS INTEGER) AS year, SUM(amount) AS total
+			FROM expense
+			WHERE user_id = ?
+				AND date BETWEEN ? AND ?
+				AND deleted_at IS NULL
+				AND CAST(strftime('%m', date) AS INTEGER) <= ?
+			GROUP BY category_id, year
+		),
+		series AS (
+			SELECT ids.category_id, years.year, COALESCE(y.total, 0) AS total
+			FROM (SELECT DISTINCT category_id FROM yearly) ids
+			CROSS JOIN (SELECT ? AS year UNION ALL SELECT ?) years
+			LEFT JOIN yearly y ON y.category_id = ids.category_id AND y.year = years.year
+		),
+		windowed AS (
+			SELECT
+				category_id,
+				year,
+				total,
+				LAG(total, 1, 0) OVER (PARTITION BY category_id ORDER BY year) AS previous_total
+			FROM series
+		)
+		SELECT
+			c.id,
+			c.name,
+			c.color,
+			c.icon,
+			c.version,
+			c.created_at,
+			c.updated_at,
+			c.parent_id,
+			w.total,
+			w.previous_total
+		FROM windowed w
+		JOIN category c ON c.id = w.category_id
+		WHERE w.year = ?
+		ORDER BY w.total DESC, c.name`
+	args := []any{
+		u.ID,
+		fmt.Sprintf("%04d-01-01", y.Year-1),
+		fmt.Sprintf("%04d-12-31", y.Year),
+		y.ThroughMonth,
+		y.Year - 1,
+		y.Year,
+		y.Year,
+	}
+
+	logger.Infow(
+		"List year over year",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		categoryDst
+		Total         int64 `db:"total"`
+		PreviousTotal int64 `db:"previous_total"`
+	}
+	if err := tr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.TrendRepository.ListYearOverYear: SelectContext: %w", err)
+	}
+
+	result := make([]trend.YearOverYear, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, trend.YearOverYear{
+			Category:      category.Category(v.categoryDst),
+			Total:         v.Total,
+			PreviousTotal: v.PreviousTotal,
+		})
+	}
+
+	return result, nil
+}
+
+func (tr *TrendRepository) ListTopNames(ctx context.Context, t trend.TopNamesReq) ([]trend.NameTotal, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	// name is a bare column so SQLite takes it from the row with MAX(date)
+	q := `
+		WITH names AS (
+			SELECT name, MAX(date) AS last_date, SUM(amount) AS total, COUNT(*) AS count
+			FROM expense
+			WHERE user_id = ? AND date BETWEEN ? AND ? AND deleted_at IS NULL
+			GROUP BY LOWER(TRIM(name))
+		)
+		SELECT
+			name,
+			total,
+			count,
+			RANK() OVER (ORDER BY total DESC) AS rank,
+			ROUND(total * 100.0 / SUM(total) OVER (), 2) AS share
+		FROM names
+		ORDER BY rank, name
+		LIMIT ?`
+	args := []any{u.ID, t.StartDate, t.EndDate, t.Limit}
+
+	logger.Infow(
+		"List top names",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		Name  string  `db:"name"`
+		Total int64   `db:"total"`
+		Count int64   `db:"count"`
+		Rank  int64   `db:"rank"`
+		Share float64 `db:"share"`
+	}
+	if err := tr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.TrendRepository.ListTopNames: SelectContext: %w", err)
+	}
+
+	result := make([]trend.NameTotal, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, trend.NameTotal(v))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,136 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/trend"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrend(t *testing.T) {
+	dh := newDBHelper(t, "test_trend.db")
+	defer dh.clean()
+
+	cr := sqlite.NewCategoryRepository(dh.db)
+	er := sqlite.NewExpenseRepository(dh.db, cr)
+	tr := sqlite.NewTrendRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	categories := createCategories(t, dh.db, users[0])
+	food, rent, gaming := categories[0], categories[1], categories[2]
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	createExpense := func(name string, amount int64, date, categoryID string) expense.Expense {
+		e, err := er.CreateExpense(ctxWithUser1, expense.CreateExpenseReq{
+			Name:       name,
+			Amount:     amount,
+			Date:       date,
+			CategoryID: categoryID,
+		})
+		assert.Nil(t, err)
+		return e
+	}
+
+	createExpense("Groceries", 100, "2005-01-10", food.ID)
+	createExpense("Groceries", 300, "2005-03-05", food.ID)
+	createExpense("Game", 50, "2005-06-01", gaming.ID)
+	createExpense("Groceries", 200, "2006-01-15", food.ID)
+	createExpense("GROCERIES", 400, "2006-02-10", food.ID)
+	createExpense("Dinner", 200, "2006-02-20", food.ID)
+	createExpense("Rent", 1000, "2006-01-01", rent.ID)
+	createExpense("Rent", 1000, "2006-02-01", rent.ID)
+	createExpense("Rent", 1000, "2006-03-01", rent.ID)
+
+	deleted := createExpense("Rent", 1000, "2006-03-02", rent.ID)
+	_, err := er.DeleteExpense(ctxWithUser1, expense.DeleteExpenseReq{ID: deleted.ID})
+	assert.Nil(t, err)
+
+	t.Run("monthly totals with rolling averages", func(t *testing.T) {
+		totals, err := tr.ListMonthlyTotals(ctxWithUser1, trend.MonthlyTotalsReq{
+			StartMonth: "2006-01",
+			EndMonth:   "2006-03",
+		})
+		assert.Nil(t, err)
+
+		type row struct {
+			Category                                           string
+			Month                                              string
+			Total, PreviousYearTotal                           int64
+			RollingAverage3, RollingAverage6, RollingAverage12 float64
+		}
+		got := make([]row, 0, len(totals))
+		for _, v := range totals {
+			got = append(got, row{
+				Category:          v.Category.Name,
+				Month:             v.Month.Format("2006-01"),
+				Total:             v.Total,
+				PreviousYearTotal: v.PreviousYearTotal,
+				RollingAverage3:   v.RollingAverage3,
+				RollingAverage6:   v.RollingAverage6,
+				RollingAverage12:  v.RollingAverage12,
+			})
+		}
+
+		assert.Equal(t, []row{
+			{"food", "2006-01", 200, 100, 66.67, 33.33, 41.67},
+			{"rent", "2006-01", 1000, 0, 333.33, 166.67, 83.33},
+			{"food", "2006-02", 600, 0, 266.67, 133.33, 91.67},
+			{"rent", "2006-02", 1000, 0, 666.67, 333.33, 166.67},
+			{"food", "2006-03", 0, 300, 266.67, 133.33, 66.67},
+			{"rent", "2006-03", 1000, 0, 1000, 500, 250},
+		}, got)
+
+		totals, err = tr.ListMonthlyTotals(ctxWithUser2, trend.MonthlyTotalsReq{
+			StartMonth: "2006-01",
+			EndMonth:   "2006-03",
+		})
+		assert.Nil(t, err)
+		assert.Empty(t, totals)
+	})
+
+	t.Run("year over year", func(t *testing.T) {
+		comparisons, err := tr.ListYearOverYear(ctxWithUser1, trend.YearOverYearReq{
+			Year:         2006,
+			ThroughMonth: 12,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []trend.YearOverYear{
+			{Category: rent, Total: 3000, PreviousTotal: 0},
+			{Category: food, Total: 800, PreviousTotal: 400},
+			{Category: gaming, Total: 0, PreviousTotal: 50},
+		}, comparisons)
+		assert.Nil(t, comparisons[0].Change())
+		assert.Equal(t, toPtr(t, 100.0), comparisons[1].Change())
+		assert.Equal(t, toPtr(t, -100.0), comparisons[2].Change())
+
+		comparisons, err = tr.ListYearOverYear(ctxWithUser1, trend.YearOverYearReq{
+			Year:         2006,
+			ThroughMonth: 1,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []trend.YearOverYear{
+			{Category: rent, Total: 1000, PreviousTotal: 0},
+			{Category: food, Total: 200, PreviousTotal: 100},
+		}, comparisons)
+	})
+
+	t.Run("top names", func(t *testing.T) {
+		names, err := tr.ListTopNames(ctxWithUser1, trend.TopNamesReq{
+			StartDate: "2006-01-01",
+			EndDate:   "2006-03-31",
+			Limit:     2,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []trend.NameTotal{
+			{Name: "Rent", Total: 3000, Count: 3, Rank: 1, Share: 78.95},
+			{Name: "GROCERIES", Total: 600, Count: 2, Rank: 2, Share: 15.79},
+		}, names)
+	})
+}
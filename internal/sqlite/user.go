@@ -122,3 +122,39 @@ func (ur *UserRepository) DeleteUser(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (ur *UserRepository) ListUsers(ctx context.Context) ([]user.User, error) {
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(
+		"id",
+		"name",
+		"email",
+	)
+	sb.From("user")
+	sb.OrderBy("id")
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List users",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		ID    string `db:"id"`
+		Name  string `db:"name"`
+		Email string `db:"email"`
+	}
+	if err := ur.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.UserRepository.ListUsers: %w", err)
+	}
+
+	result := make([]user.User, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, user.User(v))
+	}
+
+	return result, nil
+}
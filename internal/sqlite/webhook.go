@@ -0,0 +1,483 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/cativovo/budget-tracker/internal/webhook"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+type WebhookRepository struct {
+	db *DB
+}
+
+var _ webhook.Repository = (*WebhookRepository)(nil)
+
+func NewWebhookRepository(db *DB) WebhookRepository {
+	return WebhookRepository{
+		db: db,
+	}
+}
+
+var webhookColumns = []string{
+	"id",
+	"url",
+	"secret",
+	"events",
+	"active",
+	"created_at",
+	"updated_at",
+}
+
+var deliveryColumns = []string{
+	"id",
+	"webhook_id",
+	"event",
+	"payload",
+	"status",
+	"attempts",
+	"next_attempt_at",
+	"response_status",
+	"error",
+	"delivered_at",
+	"created_at",
+	"updated_at",
+}
+
+func (wr *WebhookRepository) WebhookByID(ctx context.Context, id string) (webhook.Webhook, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(webhookColumns...)
+	sb.From("webhook")
+	sb.Where(
+		sb.And(
+			sb.EQ("id", id),
+			sb.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"Find webhook by id",
+		"query", q,
+		"args", args,
+	)
+
+	var dst webhookDst
+	if err := wr.db.reader.GetContext(ctx, &dst, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return webhook.Webhook{}, internal.NewError(internal.ErrorCodeNotFound, "Webhook not found")
+		}
+
+		return webhook.Webhook{}, fmt.Errorf("sqlite.WebhookRepository.WebhookByID: GetContext: %w", err)
+	}
+
+	return dst.toWebhook(), nil
+}
+
+func (wr *WebhookRepository) ListWebhooks(ctx context.Context) ([]webhook.Webhook, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(webhookColumns...)
+	sb.From("webhook")
+	sb.Where(sb.EQ("user_id", u.ID))
+	sb.OrderBy("created_at", "id")
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List webhooks",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []webhookDst
+	if err := wr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.WebhookRepository.ListWebhooks: SelectContext: %w", err)
+	}
+
+	result := make([]webhook.Webhook, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, v.toWebhook())
+	}
+
+	return result, nil
+}
+
+func (wr *WebhookRepository) CreateWebhook(ctx context.Context, c webhook.CreateWebhookReq, secret string) (webhook.Webhook, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("webhook")
+	ib.Cols(
+		"url",
+		"secret",
+		"events",
+		"user_id",
+	)
+	ib.Values(
+		c.URL,
+		secret,
+		jsonColumn[[]string]{V: c.Events},
+		u.ID,
+	)
+	ib.Returning(webhookColumns...)
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert webhook",
+		"query", q,
+		// the secret is not logged
+		"url", c.URL,
+		"events", c.Events,
+	)
+
+	var dst webhookDst
+	if err := wr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return webhook.Webhook{}, fmt.Errorf("sqlite.WebhookRepository.CreateWebhook: GetContext: %w", err)
+	}
+
+	return dst.toWebhook(), nil
+}
+
+func (wr *WebhookRepository) UpdateWebhook(ctx context.Context, w webhook.UpdateWebhookReq) (webhook.Webhook, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("webhook")
+	ub.Set(ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+
+	if w.URL != nil {
+		ub.SetMore(ub.Assign("url", *w.URL))
+	}
+	if w.Events != nil {
+		ub.SetMore(ub.Assign("events", jsonColumn[[]string]{V: *w.Events}))
+	}
+	if w.Active != nil {
+		ub.SetMore(ub.Assign("active", *w.Active))
+	}
+
+	ub.Where(
+		ub.And(
+			ub.EQ("id", w.ID),
+			ub.EQ("user_id", u.ID),
+		),
+	)
+	ub.SQL("RETURNING " + strings.Join(webhookColumns, ", "))
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Update webhook",
+		"query", q,
+		"args", args,
+	)
+
+	var dst webhookDst
+	if err := wr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return webhook.Webhook{}, internal.NewError(internal.ErrorCodeNotFound, "Webhook not found")
+		}
+
+		return webhook.Webhook{}, fmt.Errorf("sqlite.WebhookRepository.UpdateWebhook: GetContext: %w", err)
+	}
+
+	return dst.toWebhook(), nil
+}
+
+// DeleteWebhook deletes the webhook along with its deliveries.
+func (wr *WebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	db := sqlbuilder.SQLite.NewDeleteBuilder()
+	db.DeleteFrom("webhook")
+	db.Where(
+		db.And(
+			db.EQ("id", id),
+			db.EQ("user_id", u.ID),
+		),
+	)
+
+	q, args := db.Build()
+
+	logger.Infow(
+		"Delete webhook",
+		"query", q,
+		"args", args,
+	)
+
+	result, err := wr.db.readerWriter.ExecContext(ctx, q, args...)
+	if err != nil {
+		return fmt.Errorf("sqlite.WebhookRepository.DeleteWebhook: ExecContext: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("sqlite.WebhookRepository.DeleteWebhook: RowsAffected: %w", err)
+	}
+	if affected == 0 {
+		return internal.NewError(internal.ErrorCodeNotFound, "Webhook not found")
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) CreateDeliveries(ctx context.Context, event string, payload string) ([]webhook.Delivery, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	q := `
+		INSERT INTO webhook_delivery (webhook_id, event, payload, user_id)
+		SELECT id, ?, ?, user_id
+		FROM webhook
+		WHERE user_id = ?
+			AND active = 1
+			AND EXISTS (SELECT 1 FROM json_each(webhook.events) WHERE value = ?)
+		RETURNING ` + strings.Join(deliveryColumns, ", ")
+	args := []any{event, payload, u.ID, event}
+
+	logger.Infow(
+		"Insert webhook deliveries",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []deliveryDst
+	if err := wr.db.readerWriter.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.WebhookRepository.CreateDeliveries: SelectContext: %w", err)
+	}
+
+	result := make([]webhook.Delivery, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, webhook.Delivery(v))
+	}
+
+	return result, nil
+}
+
+func (wr *WebhookRepository) CreateDelivery(ctx context.Context, webhookID string, event string, payload string, nextAttemptAt time.Time) (webhook.Delivery, error) {
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	ib := sqlbuilder.SQLite.NewInsertBuilder()
+	ib.InsertInto("webhook_delivery")
+	ib.Cols(
+		"webhook_id",
+		"event",
+		"payload",
+		"next_attempt_at",
+		"user_id",
+	)
+	ib.Values(
+		webhookID,
+		event,
+		payload,
+		nextAttemptAt.UTC().Format(timestampLayout),
+		u.ID,
+	)
+	ib.Returning(deliveryColumns...)
+
+	q, args := ib.Build()
+
+	logger.Infow(
+		"Insert webhook delivery",
+		"query", q,
+		"args", args,
+	)
+
+	var dst deliveryDst
+	if err := wr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		return webhook.Delivery{}, fmt.Errorf("sqlite.WebhookRepository.CreateDelivery: GetContext: %w", err)
+	}
+
+	return webhook.Delivery(dst), nil
+}
+
+// ListDeliveries lists the deliveries of the webhook, newest first.
+func (wr *WebhookRepository) ListDeliveries(ctx context.Context, l webhook.ListDeliveriesReq) ([]webhook.Delivery, error) {
+	if _, err := wr.WebhookByID(ctx, l.WebhookID); err != nil {
+		return nil, fmt.Errorf("sqlite.WebhookRepository.ListDeliveries: %w", err)
+	}
+
+	u := user.FromContext(ctx)
+	logger := logger.FromContext(ctx)
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(deliveryColumns...)
+	sb.From("webhook_delivery")
+	sb.Where(
+		sb.EQ("webhook_id", l.WebhookID),
+		sb.EQ("user_id", u.ID),
+	)
+	sb.OrderBy("created_at", "id").Desc()
+	sb.Limit(l.Limit)
+	sb.Offset(l.Offset)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List webhook deliveries",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []deliveryDst
+	if err := wr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.WebhookRepository.ListDeliveries: SelectContext: %w", err)
+	}
+
+	result := make([]webhook.Delivery, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, webhook.Delivery(v))
+	}
+
+	return result, nil
+}
+
+func (wr *WebhookRepository) ListDueDeliveries(ctx context.Context, limit int) ([]webhook.DueDelivery, error) {
+	logger := logger.FromContext(ctx)
+
+	columns := make([]string, 0, len(deliveryColumns)+2)
+	for _, c := range deliveryColumns {
+		columns = append(columns, "d."+c)
+	}
+	columns = append(columns, "w.url", "w.secret")
+
+	sb := sqlbuilder.SQLite.NewSelectBuilder()
+	sb.Select(columns...)
+	sb.From("webhook_delivery d")
+	sb.Join("webhook w", "w.id = d.webhook_id")
+	sb.Where(
+		sb.EQ("d.status", webhook.DeliveryStatusPending),
+		sb.LE("d.next_attempt_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+		// deactivating a webhook stops its retries
+		sb.EQ("w.active", true),
+	)
+	sb.OrderBy("d.next_attempt_at", "d.id")
+	sb.Limit(limit)
+
+	q, args := sb.Build()
+
+	logger.Infow(
+		"List due webhook deliveries",
+		"query", q,
+		"args", args,
+	)
+
+	var dst []struct {
+		deliveryDst
+		URL    string `db:"url"`
+		Secret string `db:"secret"`
+	}
+	if err := wr.db.reader.SelectContext(ctx, &dst, q, args...); err != nil {
+		return nil, fmt.Errorf("sqlite.WebhookRepository.ListDueDeliveries: SelectContext: %w", err)
+	}
+
+	result := make([]webhook.DueDelivery, 0, len(dst))
+	for _, v := range dst {
+		result = append(result, webhook.DueDelivery{
+			Delivery: webhook.Delivery(v.deliveryDst),
+			URL:      v.URL,
+			Secret:   v.Secret,
+		})
+	}
+
+	return result, nil
+}
+
+func (wr *WebhookRepository) RecordAttempt(ctx context.Context, r webhook.RecordAttemptReq) (webhook.Delivery, error) {
+	logger := logger.FromContext(ctx)
+
+	ub := sqlbuilder.SQLite.NewUpdateBuilder()
+	ub.Update("webhook_delivery")
+	ub.Set(
+		ub.Incr("attempts"),
+		ub.Assign("status", r.Status),
+		ub.Assign("response_status", r.ResponseStatus),
+		ub.Assign("error", r.Error),
+		ub.Assign("updated_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")),
+	)
+
+	switch r.Status {
+	case webhook.DeliveryStatusSucceeded:
+		ub.SetMore(ub.Assign("delivered_at", sqlbuilder.Raw("CURRENT_TIMESTAMP")))
+	case webhook.DeliveryStatusPending:
+		ub.SetMore(ub.Assign("next_attempt_at", r.NextAttemptAt.UTC().Format(timestampLayout)))
+	}
+
+	ub.Where(ub.EQ("id", r.ID))
+	ub.SQL("RETURNING " + strings.Join(deliveryColumns, ", "))
+
+	q, args := ub.Build()
+
+	logger.Infow(
+		"Record webhook delivery attempt",
+		"query", q,
+		"args", args,
+	)
+
+	var dst deliveryDst
+	if err := wr.db.readerWriter.GetContext(ctx, &dst, q, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return webhook.Delivery{}, internal.NewError(internal.ErrorCodeNotFound, "Delivery not found")
+		}
+
+		return webhook.Delivery{}, fmt.Errorf("sqlite.WebhookRepository.RecordAttempt: GetContext: %w", err)
+	}
+
+	return webhook.Delivery(dst), nil
+}
+
+type webhookDst struct {
+	ID        string               `db:"id"`
+	URL       string               `db:"url"`
+	Secret    string               `db:"secret"`
+	Events    jsonColumn[[]string] `db:"events"`
+	Active    bool                 `db:"active"`
+	CreatedAt time.Time            `db:"created_at"`
+	UpdatedAt time.Time            `db:"updated_at"`
+}
+
+func (w webhookDst) toWebhook() webhook.Webhook {
+	return webhook.Webhook{
+		ID:        w.ID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		Events:    w.Events.V,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+type deliveryDst struct {
+	ID             string                 `db:"id"`
+	WebhookID      string                 `db:"webhook_id"`
+	Event          string                 `db:"event"`
+	Payload        string                 `db:"payload"`
+	Status         webhook.DeliveryStatus `db:"status"`
+	Attempts       int                    `db:"attempts"`
+	NextAttemptAt  time.Time              `db:"next_attempt_at"`
+	ResponseStatus *int                   `db:"response_status"`
+	Error          *string                `db:"error"`
+	DeliveredAt    *time.Time             `db:"delivered_at"`
+	CreatedAt      time.Time              `db:"created_at"`
+	UpdatedAt      time.Time              `db:"updated_at"`
+}
@@ -0,0 +1,187 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/logger"
+	"github.com/cativovo/budget-tracker/internal/sqlite"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/cativovo/budget-tracker/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	dh := newDBHelper(t, "test_webhook.db")
+	defer dh.clean()
+
+	wr := sqlite.NewWebhookRepository(dh.db)
+	ctxWithLogger := logger.ContextWithLogger(context.Background(), zapLogger)
+
+	users := createUsers(t, dh.db)
+	ctxWithUser1 := user.ContextWithUser(ctxWithLogger, users[0])
+	ctxWithUser2 := user.ContextWithUser(ctxWithLogger, users[1])
+
+	expenses, err := wr.CreateWebhook(ctxWithUser1, webhook.CreateWebhookReq{
+		URL:    "https://example.com/expenses",
+		Events: []string{expense.EventExpenseCreated, expense.EventExpenseDeleted},
+	}, "secret1")
+	assert.Nil(t, err)
+	assert.Equal(t, "secret1", expenses.Secret)
+	assert.Equal(t, []string{expense.EventExpenseCreated, expense.EventExpenseDeleted}, expenses.Events)
+	assert.True(t, expenses.Active)
+
+	categories, err := wr.CreateWebhook(ctxWithUser1, webhook.CreateWebhookReq{
+		URL:    "https://example.com/categories",
+		Events: []string{category.EventCategoryCreated},
+	}, "secret2")
+	assert.Nil(t, err)
+
+	other, err := wr.CreateWebhook(ctxWithUser2, webhook.CreateWebhookReq{
+		URL:    "https://example.com/other",
+		Events: []string{expense.EventExpenseCreated},
+	}, "secret3")
+	assert.Nil(t, err)
+
+	t.Run("list webhooks", func(t *testing.T) {
+		webhooks, err := wr.ListWebhooks(ctxWithUser1)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []webhook.Webhook{expenses, categories}, webhooks)
+
+		_, err = wr.WebhookByID(ctxWithUser2, expenses.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("deliveries go to the subscribed webhooks of the user", func(t *testing.T) {
+		deliveries, err := wr.CreateDeliveries(ctxWithUser1, expense.EventExpenseCreated, `{}`)
+		assert.Nil(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, expenses.ID, deliveries[0].WebhookID)
+		assert.Equal(t, webhook.DeliveryStatusPending, deliveries[0].Status)
+		assert.Equal(t, 0, deliveries[0].Attempts)
+
+		deliveries, err = wr.CreateDeliveries(ctxWithUser1, expense.EventExpenseUpdated, `{}`)
+		assert.Nil(t, err)
+		assert.Empty(t, deliveries)
+
+		deliveries, err = wr.CreateDeliveries(ctxWithUser2, expense.EventExpenseCreated, `{}`)
+		assert.Nil(t, err)
+		assert.Len(t, deliveries, 1)
+	})
+
+	t.Run("inactive webhooks get no deliveries", func(t *testing.T) {
+		_, err := wr.UpdateWebhook(ctxWithUser1, webhook.UpdateWebhookReq{
+			ID:     categories.ID,
+			Active: toPtr(t, false),
+		})
+		assert.Nil(t, err)
+
+		deliveries, err := wr.CreateDeliveries(ctxWithUser1, category.EventCategoryCreated, `{}`)
+		assert.Nil(t, err)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run("update webhook of another user", func(t *testing.T) {
+		_, err := wr.UpdateWebhook(ctxWithUser2, webhook.UpdateWebhookReq{
+			ID:  expenses.ID,
+			URL: toPtr(t, "https://example.com/stolen"),
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("record attempts", func(t *testing.T) {
+		// due deliveries are listed across users
+		due, err := wr.ListDueDeliveries(ctxWithLogger, 10)
+		assert.Nil(t, err)
+		assert.Len(t, due, 2)
+
+		var d webhook.DueDelivery
+		for _, v := range due {
+			if v.WebhookID == expenses.ID {
+				d = v
+			}
+		}
+		assert.Equal(t, "https://example.com/expenses", d.URL)
+		assert.Equal(t, "secret1", d.Secret)
+
+		failed, err := wr.RecordAttempt(ctxWithLogger, webhook.RecordAttemptReq{
+			ID:             d.ID,
+			Status:         webhook.DeliveryStatusPending,
+			ResponseStatus: toPtr(t, 500),
+			Error:          toPtr(t, "unexpected status 500"),
+			NextAttemptAt:  time.Now().Add(time.Hour),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, failed.Attempts)
+		assert.Equal(t, webhook.DeliveryStatusPending, failed.Status)
+		assert.Equal(t, 500, *failed.ResponseStatus)
+
+		// not due until the backoff is over
+		due, err = wr.ListDueDeliveries(ctxWithLogger, 10)
+		assert.Nil(t, err)
+		assert.Len(t, due, 1)
+		assert.NotEqual(t, d.ID, due[0].ID)
+
+		succeeded, err := wr.RecordAttempt(ctxWithLogger, webhook.RecordAttemptReq{
+			ID:             d.ID,
+			Status:         webhook.DeliveryStatusSucceeded,
+			ResponseStatus: toPtr(t, 200),
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, succeeded.Attempts)
+		assert.Equal(t, webhook.DeliveryStatusSucceeded, succeeded.Status)
+		assert.Nil(t, succeeded.Error)
+		assert.NotNil(t, succeeded.DeliveredAt)
+	})
+
+	t.Run("test event ignores the subscriptions", func(t *testing.T) {
+		d, err := wr.CreateDelivery(ctxWithUser1, categories.ID, webhook.EventTest, `{}`, time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, categories.ID, d.WebhookID)
+		assert.Equal(t, webhook.EventTest, d.Event)
+
+		// the webhook is inactive
+		due, err := wr.ListDueDeliveries(ctxWithLogger, 10)
+		assert.Nil(t, err)
+		for _, v := range due {
+			assert.NotEqual(t, d.ID, v.ID)
+		}
+
+		// claimed while it's sent
+		d, err = wr.CreateDelivery(ctxWithUser2, other.ID, webhook.EventTest, `{}`, time.Now().Add(time.Minute))
+		assert.Nil(t, err)
+		due, err = wr.ListDueDeliveries(ctxWithLogger, 10)
+		assert.Nil(t, err)
+		for _, v := range due {
+			assert.NotEqual(t, d.ID, v.ID)
+		}
+	})
+
+	t.Run("list deliveries", func(t *testing.T) {
+		deliveries, err := wr.ListDeliveries(ctxWithUser1, webhook.ListDeliveriesReq{
+			WebhookID:   expenses.ID,
+			ListOptions: internal.ListOptions{Limit: 10},
+		})
+		assert.Nil(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, webhook.DeliveryStatusSucceeded, deliveries[0].Status)
+
+		_, err = wr.ListDeliveries(ctxWithUser2, webhook.ListDeliveriesReq{
+			WebhookID:   expenses.ID,
+			ListOptions: internal.ListOptions{Limit: 10},
+		})
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+		err := wr.DeleteWebhook(ctxWithUser1, expenses.ID)
+		assert.Nil(t, err)
+
+		err = wr.DeleteWebhook(ctxWithUser1, expenses.ID)
+		assert.Equal(t, internal.ErrorCodeNotFound, internal.GetErrorCode(err))
+	})
+}
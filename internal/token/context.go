@@ -0,0 +1,20 @@
+package token
+
+import (
+	"context"
+
+	"github.com/cativovo/budget-tracker/internal"
+)
+
+const ContextKeyToken internal.ContextKey = "token"
+
+// ContextWithToken records that the request was authenticated with the token.
+func ContextWithToken(ctx context.Context, t Token) context.Context {
+	return context.WithValue(ctx, ContextKeyToken, t)
+}
+
+// FromContext returns the token the request was authenticated with, if any.
+func FromContext(ctx context.Context) (Token, bool) {
+	t, ok := ctx.Value(ContextKeyToken).(Token)
+	return t, ok
+}
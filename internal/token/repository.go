@@ -0,0 +1,19 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/user"
+)
+
+type Repository interface {
+	ListTokens(ctx context.Context) ([]Token, error)
+	CreateToken(ctx context.Context, c CreateTokenReq, hash string, prefix string) (Token, error)
+	DeleteToken(ctx context.Context, id string) error
+	// TokenByHash finds the token of any user by the hash of its secret,
+	// along with the user it belongs to.
+	TokenByHash(ctx context.Context, hash string) (Token, user.User, error)
+	// MarkTokenUsed sets when any user's token was last used.
+	MarkTokenUsed(ctx context.Context, id string, usedAt time.Time) error
+}
@@ -0,0 +1,141 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/user"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	ListTokens(ctx context.Context) ([]Token, error)
+	// CreateToken generates the secret of the token. It can't be retrieved
+	// afterwards. When the request was authenticated with a token, the new
+	// token gets at most its scopes and expires no later than it.
+	CreateToken(ctx context.Context, c CreateTokenReq) (CreatedToken, error)
+	// RevokeToken deletes the token, the requests using it are rejected right
+	// away.
+	RevokeToken(ctx context.Context, id string) error
+	// Authenticate finds the unexpired token of the secret and the user it
+	// belongs to.
+	Authenticate(ctx context.Context, secret string) (Token, user.User, error)
+}
+
+type CreateTokenReq struct {
+	Name   string  `json:"name" validate:"required,max=100"`
+	Scopes []Scope `json:"scopes" validate:"required,min=1,dive,oneof=read write"`
+	// Nil if the token never expires.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Length of the start of the secret, after SecretPrefix, that is kept to
+// tell the tokens apart.
+const prefixLength = 6
+
+// lastUsedPrecision is how stale LastUsedAt can be. It saves a write on every
+// request.
+const lastUsedPrecision = time.Minute
+
+type service struct {
+	r Repository
+	v *validator.Validator
+}
+
+func NewService(r Repository, v *validator.Validator) Service {
+	return &service{
+		r: r,
+		v: v,
+	}
+}
+
+func (s *service) ListTokens(ctx context.Context) ([]Token, error) {
+	return s.r.ListTokens(ctx)
+}
+
+func (s *service) CreateToken(ctx context.Context, c CreateTokenReq) (CreatedToken, error) {
+	if err := s.v.Struct(c); err != nil {
+		return CreatedToken{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if c.ExpiresAt != nil && !c.ExpiresAt.After(time.Now()) {
+		return CreatedToken{}, internal.NewError(internal.ErrorCodeInvalid, "'expires_at' must be in the future")
+	}
+
+	// a token can't create one with more scopes or that outlives it, a leaked
+	// token can't be turned into a permanent one
+	if caller, ok := FromContext(ctx); ok {
+		for _, scope := range c.Scopes {
+			if !caller.Allows(scope) {
+				return CreatedToken{}, internal.NewErrorf(internal.ErrorCodeInvalid, "Can't grant the %s scope, the token doesn't have it", scope)
+			}
+		}
+		if caller.ExpiresAt != nil && (c.ExpiresAt == nil || c.ExpiresAt.After(*caller.ExpiresAt)) {
+			c.ExpiresAt = caller.ExpiresAt
+		}
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return CreatedToken{}, fmt.Errorf("token.Service.CreateToken: %w", err)
+	}
+
+	t, err := s.r.CreateToken(ctx, c, Hash(secret), secret[:len(SecretPrefix)+prefixLength])
+	if err != nil {
+		return CreatedToken{}, fmt.Errorf("token.Service.CreateToken: %w", err)
+	}
+
+	return CreatedToken{
+		Token:  t,
+		Secret: secret,
+	}, nil
+}
+
+func (s *service) RevokeToken(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteToken(ctx, id)
+}
+
+func (s *service) Authenticate(ctx context.Context, secret string) (Token, user.User, error) {
+	invalid := internal.NewError(internal.ErrorCodeUnauthorized, "Token is invalid or expired")
+
+	if !IsSecret(secret) {
+		return Token{}, user.User{}, invalid
+	}
+
+	t, u, err := s.r.TokenByHash(ctx, Hash(secret))
+	if err != nil {
+		if internal.GetErrorCode(err) == internal.ErrorCodeNotFound {
+			return Token{}, user.User{}, invalid
+		}
+		return Token{}, user.User{}, fmt.Errorf("token.Service.Authenticate: %w", err)
+	}
+
+	now := time.Now()
+	if t.Expired(now) {
+		return Token{}, user.User{}, invalid
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= lastUsedPrecision {
+		if err := s.r.MarkTokenUsed(ctx, t.ID, now); err != nil {
+			return Token{}, user.User{}, fmt.Errorf("token.Service.Authenticate: %w", err)
+		}
+		t.LastUsedAt = &now
+	}
+
+	return t, u, nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return SecretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,82 @@
+package token_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/cativovo/budget-tracker/internal/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRepository returns the tokens as they are created.
+type memoryRepository struct {
+	token.Repository
+}
+
+func (r *memoryRepository) CreateToken(ctx context.Context, c token.CreateTokenReq, hash string, prefix string) (token.Token, error) {
+	return token.Token{
+		Name:      c.Name,
+		Scopes:    c.Scopes,
+		Prefix:    prefix,
+		ExpiresAt: c.ExpiresAt,
+	}, nil
+}
+
+func TestCreateToken(t *testing.T) {
+	s := token.NewService(&memoryRepository{}, validator.NewValidator())
+
+	t.Run("secret", func(t *testing.T) {
+		created, err := s.CreateToken(context.Background(), token.CreateTokenReq{
+			Name:   "cli",
+			Scopes: []token.Scope{token.ScopeWrite},
+		})
+		assert.Nil(t, err)
+		assert.True(t, token.IsSecret(created.Secret))
+		assert.Equal(t, created.Secret[:len(created.Prefix)], created.Prefix)
+		assert.Nil(t, created.ExpiresAt)
+	})
+
+	expiresAt := time.Now().Add(time.Hour)
+	readOnly := token.ContextWithToken(context.Background(), token.Token{
+		Scopes:    []token.Scope{token.ScopeRead},
+		ExpiresAt: &expiresAt,
+	})
+
+	t.Run("token can't grant more scopes than it has", func(t *testing.T) {
+		_, err := s.CreateToken(readOnly, token.CreateTokenReq{
+			Name:   "cli",
+			Scopes: []token.Scope{token.ScopeWrite},
+		})
+		assert.Equal(t, internal.NewError(internal.ErrorCodeInvalid, "Can't grant the write scope, the token doesn't have it"), err)
+	})
+
+	t.Run("token can't outlive the token that created it", func(t *testing.T) {
+		created, err := s.CreateToken(readOnly, token.CreateTokenReq{
+			Name:   "cli",
+			Scopes: []token.Scope{token.ScopeRead},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expiresAt, *created.ExpiresAt)
+
+		later := expiresAt.Add(time.Hour)
+		created, err = s.CreateToken(readOnly, token.CreateTokenReq{
+			Name:      "cli",
+			Scopes:    []token.Scope{token.ScopeRead},
+			ExpiresAt: &later,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expiresAt, *created.ExpiresAt)
+
+		sooner := expiresAt.Add(-time.Minute)
+		created, err = s.CreateToken(readOnly, token.CreateTokenReq{
+			Name:      "cli",
+			Scopes:    []token.Scope{token.ScopeRead},
+			ExpiresAt: &sooner,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, sooner, *created.ExpiresAt)
+	})
+}
@@ -0,0 +1,65 @@
+package token
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+)
+
+type Scope string
+
+const (
+	// ScopeRead allows the read only operations.
+	ScopeRead Scope = "read"
+	// ScopeWrite allows every operation, reads included.
+	ScopeWrite Scope = "write"
+)
+
+// SecretPrefix starts every secret so leaked tokens are easy to spot.
+const SecretPrefix = "bt_"
+
+// Token is a personal access token. Only the hash of its secret is stored,
+// the secret itself is shown once when the token is created.
+type Token struct {
+	ID     string
+	Name   string
+	Scopes []Scope
+	// Start of the secret to tell the tokens apart.
+	Prefix string
+	// Nil if the token never expires.
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Allows reports whether the scopes of the token cover the scope.
+func (t Token) Allows(s Scope) bool {
+	if slices.Contains(t.Scopes, ScopeWrite) {
+		return true
+	}
+	return slices.Contains(t.Scopes, s)
+}
+
+func (t Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// CreatedToken is a new token along with its secret.
+type CreatedToken struct {
+	Token
+	Secret string
+}
+
+// Hash is what is stored in place of the secret. The secrets are random
+// enough that a plain SHA-256 can't be reversed.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsSecret reports whether the value looks like the secret of a token.
+func IsSecret(value string) bool {
+	return strings.HasPrefix(value, SecretPrefix)
+}
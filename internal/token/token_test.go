@@ -0,0 +1,34 @@
+package token_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllows(t *testing.T) {
+	read := token.Token{Scopes: []token.Scope{token.ScopeRead}}
+	assert.True(t, read.Allows(token.ScopeRead))
+	assert.False(t, read.Allows(token.ScopeWrite))
+
+	write := token.Token{Scopes: []token.Scope{token.ScopeWrite}}
+	assert.True(t, write.Allows(token.ScopeRead))
+	assert.True(t, write.Allows(token.ScopeWrite))
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Second)
+
+	assert.False(t, token.Token{}.Expired(now))
+	assert.True(t, token.Token{ExpiresAt: &past}.Expired(now))
+	assert.True(t, token.Token{ExpiresAt: &now}.Expired(now))
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, token.Hash("bt_secret"), token.Hash("bt_secret"))
+	assert.NotEqual(t, token.Hash("bt_secret"), token.Hash("bt_other"))
+	assert.NotContains(t, token.Hash("bt_secret"), "secret")
+}
@@ -0,0 +1,9 @@
+package trend
+
+import "context"
+
+type Repository interface {
+	ListMonthlyTotals(ctx context.Context, m MonthlyTotalsReq) ([]MonthlyTotal, error)
+	ListYearOverYear(ctx context.Context, y YearOverYearReq) ([]YearOverYear, error)
+	ListTopNames(ctx context.Context, t TopNamesReq) ([]NameTotal, error)
+}
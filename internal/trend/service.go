@@ -0,0 +1,87 @@
+package trend
+
+import (
+	"context"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+// Longest range, in months, of the monthly totals.
+const maxMonths = 120
+
+type Service interface {
+	ListMonthlyTotals(ctx context.Context, m MonthlyTotalsReq) ([]MonthlyTotal, error)
+	ListYearOverYear(ctx context.Context, y YearOverYearReq) ([]YearOverYear, error)
+	ListTopNames(ctx context.Context, t TopNamesReq) ([]NameTotal, error)
+}
+
+type MonthlyTotalsReq struct {
+	StartMonth string `json:"start_month" validate:"required,datetime=2006-01"`
+	EndMonth   string `json:"end_month" validate:"required,datetime=2006-01"`
+}
+
+type YearOverYearReq struct {
+	Year int `json:"year" validate:"min=1,max=9999"`
+	// Only the months up to this one are compared, e.g. 3 compares January to
+	// March of both years. 0 compares the whole years.
+	ThroughMonth int `json:"through_month" validate:"min=0,max=12"`
+}
+
+type TopNamesReq struct {
+	StartDate string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	EndDate   string `json:"end_date" validate:"required,datetime=2006-01-02"`
+	Limit     int    `json:"limit" validate:"min=1,max=100"`
+}
+
+type service struct {
+	r Repository
+	v *validator.Validator
+}
+
+func NewService(r Repository, v *validator.Validator) Service {
+	return &service{
+		r: r,
+		v: v,
+	}
+}
+
+func (s *service) ListMonthlyTotals(ctx context.Context, m MonthlyTotalsReq) ([]MonthlyTotal, error) {
+	if err := s.v.Struct(m); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	start, _ := time.Parse("2006-01", m.StartMonth)
+	end, _ := time.Parse("2006-01", m.EndMonth)
+	if end.Before(start) {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'start_month' must be before 'end_month'")
+	}
+	if end.After(start.AddDate(0, maxMonths-1, 0)) {
+		return nil, internal.NewErrorf(internal.ErrorCodeInvalid, "The range can't be longer than %d months", maxMonths)
+	}
+
+	return s.r.ListMonthlyTotals(ctx, m)
+}
+
+func (s *service) ListYearOverYear(ctx context.Context, y YearOverYearReq) ([]YearOverYear, error) {
+	if err := s.v.Struct(y); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if y.ThroughMonth == 0 {
+		y.ThroughMonth = 12
+	}
+
+	return s.r.ListYearOverYear(ctx, y)
+}
+
+func (s *service) ListTopNames(ctx context.Context, t TopNamesReq) ([]NameTotal, error) {
+	if err := s.v.Struct(t); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	if t.StartDate > t.EndDate {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, "'start_date' must be before 'end_date'")
+	}
+	return s.r.ListTopNames(ctx, t)
+}
@@ -0,0 +1,58 @@
+package trend
+
+import (
+	"math"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+)
+
+// MonthlyTotal is the spending of a category in a month along with the
+// averages of the months leading up to it.
+type MonthlyTotal struct {
+	Category category.Category
+	// First day of the month.
+	Month time.Time
+	Total int64
+	// Spending of the category in the same month of the previous year.
+	PreviousYearTotal int64
+	// Average monthly spending over the last 3, 6 and 12 months, including
+	// this month.
+	RollingAverage3  float64
+	RollingAverage6  float64
+	RollingAverage12 float64
+}
+
+// YearOverYear compares the spending of a category in a year with the
+// previous year over the same months.
+type YearOverYear struct {
+	Category      category.Category
+	Total         int64
+	PreviousTotal int64
+}
+
+// Change is the percentage the spending went up, or down if negative,
+// compared with the previous year. It's nil if nothing was spent in the
+// previous year.
+func (y YearOverYear) Change() *float64 {
+	if y.PreviousTotal == 0 {
+		return nil
+	}
+
+	change := float64(y.Total-y.PreviousTotal) / float64(y.PreviousTotal) * 100
+	change = math.Round(change*100) / 100
+	return &change
+}
+
+// NameTotal is the spending on a merchant or expense name. Names that only
+// differ in case or surrounding spaces are counted together.
+type NameTotal struct {
+	// Name of the latest expense.
+	Name  string
+	Total int64
+	Count int64
+	// Position by total, ties share the same rank.
+	Rank int64
+	// Percentage of the spending within the date range.
+	Share float64
+}
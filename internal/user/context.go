@@ -19,3 +19,10 @@ func FromContext(ctx context.Context) User {
 	}
 	return u
 }
+
+// FromContextOK is like FromContext but reports whether the context has a user
+// instead of panicking, for the code that also serves anonymous requests.
+func FromContextOK(ctx context.Context) (User, bool) {
+	u, ok := ctx.Value(ContextKeyUser).(User)
+	return u, ok
+}
@@ -20,3 +20,13 @@ func TestSetAndGetUserFromContext(t *testing.T) {
 
 	assert.Equal(t, u, gotUser)
 }
+
+func TestFromContextOK(t *testing.T) {
+	_, ok := user.FromContextOK(context.Background())
+	assert.False(t, ok)
+
+	u := user.User{ID: "123"}
+	gotUser, ok := user.FromContextOK(user.ContextWithUser(context.Background(), u))
+	assert.True(t, ok)
+	assert.Equal(t, u, gotUser)
+}
@@ -4,6 +4,7 @@ import "context"
 
 type Repository interface {
 	UserByID(ctx context.Context, id string) (User, error)
+	ListUsers(ctx context.Context) ([]User, error)
 	CreateUser(ctx context.Context, u CreateUserReq) (User, error)
 	DeleteUser(ctx context.Context, id string) error
 }
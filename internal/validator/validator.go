@@ -43,16 +43,30 @@ func (v *Validator) Struct(s any) error {
 						e = fmt.Errorf("'%s' is required with '%s'", err.Field(), jsonTag)
 					}
 				}
+			case "required_if":
+				e = fmt.Errorf("'%s' is required", err.Field())
+			case "oneof":
+				e = fmt.Errorf("'%s' must be one of '%s'", err.Field(), err.Param())
 			case "number":
 				e = fmt.Errorf("'%s' must have a valid numeric value", err.Field())
 			case "hexcolor":
 				e = fmt.Errorf("'%s' must have a valid hex color value", err.Field())
 			case "datetime":
 				e = fmt.Errorf("'%s' must have a valid date value", err.Field())
+			case "iso4217":
+				e = fmt.Errorf("'%s' must be a valid currency code", err.Field())
+			case "nefield":
+				e = fmt.Errorf("'%s' must be different from '%s'", err.Field(), jsonFieldName(s, err.Param()))
+			case "min":
+				e = fmt.Errorf("'%s' must have a length of at least %s", err.Field(), err.Param())
+			case "ne":
+				e = fmt.Errorf("'%s' must not be '%s'", err.Field(), err.Param())
 			case "gte":
 				e = fmt.Errorf("'%s' must be greater than or equal to %s", err.Field(), err.Param())
 			case "gt":
 				e = fmt.Errorf("'%s' must be greater than %s", err.Field(), err.Param())
+			case "http_url":
+				e = fmt.Errorf("'%s' must be a valid URL", err.Field())
 			default:
 				e = fmt.Errorf("'%s': '%v' must satisfy '%s' '%v' criteria", err.Field(), err.Value(), err.Tag(), err.Param())
 			}
@@ -65,6 +79,23 @@ func (v *Validator) Struct(s any) error {
 	return nil
 }
 
+// jsonFieldName returns the json name of a field of the struct, or the Go name
+// if it has none.
+func jsonFieldName(s any, name string) string {
+	t := reflect.TypeOf(s)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if field, ok := t.FieldByName(name); ok {
+		if jsonTag, ok := field.Tag.Lookup("json"); ok {
+			return strings.SplitN(jsonTag, ",", 2)[0]
+		}
+	}
+
+	return name
+}
+
 func (v *Validator) Var(f any, tag string) error {
 	return v.validator.Var(f, tag)
 }
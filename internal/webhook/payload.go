@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+)
+
+// Payload is the JSON body of a delivery. Data is nil for the delete events.
+type Payload struct {
+	Event      string    `json:"event"`
+	ResourceID string    `json:"resource_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Data       any       `json:"data"`
+}
+
+type categoryPayload struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	Icon      string    `json:"icon"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ParentID  *string   `json:"parent_id"`
+}
+
+type expensePayload struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Amount    int64           `json:"amount"`
+	Date      string          `json:"date"`
+	Note      string          `json:"note"`
+	Category  categoryPayload `json:"category"`
+	Tags      []string        `json:"tags"`
+	AccountID *string         `json:"account_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type expenseGroupPayload struct {
+	ID        string           `json:"id"`
+	Name      string           `json:"name"`
+	Date      string           `json:"date"`
+	Expenses  []expensePayload `json:"expenses"`
+	Note      string           `json:"note"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+func toCategoryPayload(c category.Category) categoryPayload {
+	return categoryPayload{
+		ID:        c.ID,
+		Name:      c.Name,
+		Color:     c.Color,
+		Icon:      c.Icon,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		ParentID:  c.ParentID,
+	}
+}
+
+func toExpensePayload(e expense.Expense) expensePayload {
+	return expensePayload{
+		ID:        e.ID,
+		Name:      e.Name,
+		Amount:    e.Amount,
+		Date:      e.Date.Format(time.DateOnly),
+		Note:      e.Note,
+		Category:  toCategoryPayload(e.Category),
+		Tags:      e.Tags,
+		AccountID: e.AccountID,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+// MarshalPayload encodes the payload of the event. Data must be one of the
+// resources the events are about, or nil.
+func MarshalPayload(event string, resourceID string, createdAt time.Time, data any) (string, error) {
+	p := Payload{
+		Event:      event,
+		ResourceID: resourceID,
+		CreatedAt:  createdAt,
+	}
+
+	switch v := data.(type) {
+	case nil:
+	case expense.Expense:
+		p.Data = toExpensePayload(v)
+	case expense.ExpenseGroup:
+		expenses := make([]expensePayload, 0, len(v.Expenses))
+		for _, e := range v.Expenses {
+			expenses = append(expenses, toExpensePayload(e))
+		}
+		p.Data = expenseGroupPayload{
+			ID:        v.ID,
+			Name:      v.Name,
+			Date:      v.Date.Format(time.DateOnly),
+			Expenses:  expenses,
+			Note:      v.Note,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+		}
+	case category.Category:
+		p.Data = toCategoryPayload(v)
+	default:
+		return "", fmt.Errorf("webhook.MarshalPayload: unsupported data %T", data)
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("webhook.MarshalPayload: Marshal: %w", err)
+	}
+
+	return string(b), nil
+}
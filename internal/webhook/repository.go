@@ -0,0 +1,26 @@
+package webhook
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	WebhookByID(ctx context.Context, id string) (Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, c CreateWebhookReq, secret string) (Webhook, error)
+	UpdateWebhook(ctx context.Context, u UpdateWebhookReq) (Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// CreateDeliveries queues the event for every active webhook of the user
+	// that subscribed to it.
+	CreateDeliveries(ctx context.Context, event string, payload string) ([]Delivery, error)
+	// CreateDelivery queues the event for the webhook regardless of its
+	// subscriptions. It isn't due before nextAttemptAt.
+	CreateDelivery(ctx context.Context, webhookID string, event string, payload string, nextAttemptAt time.Time) (Delivery, error)
+	ListDeliveries(ctx context.Context, l ListDeliveriesReq) ([]Delivery, error)
+	// ListDueDeliveries lists the pending deliveries of the active webhooks,
+	// of every user, whose next attempt is due, oldest first.
+	ListDueDeliveries(ctx context.Context, limit int) ([]DueDelivery, error)
+	// RecordAttempt saves the result of an attempt of any user's delivery.
+	RecordAttempt(ctx context.Context, r RecordAttemptReq) (Delivery, error)
+}
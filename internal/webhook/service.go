@@ -0,0 +1,291 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal"
+	"github.com/cativovo/budget-tracker/internal/validator"
+)
+
+type Service interface {
+	WebhookByID(ctx context.Context, id string) (Webhook, error)
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// CreateWebhook generates the secret the payloads are signed with.
+	CreateWebhook(ctx context.Context, c CreateWebhookReq) (Webhook, error)
+	UpdateWebhook(ctx context.Context, u UpdateWebhookReq) (Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// Dispatch queues the event for the webhooks of the user that subscribed
+	// to it. The deliveries are sent by DeliverDue.
+	Dispatch(ctx context.Context, event string, resourceID string, data any) error
+	// DeliverDue attempts the pending deliveries of every user whose next
+	// attempt is due. It returns how many deliveries were attempted.
+	DeliverDue(ctx context.Context) (int, error)
+	ListDeliveries(ctx context.Context, l ListDeliveriesReq) ([]Delivery, error)
+	// SendTestEvent sends a test event to the webhook right away and returns
+	// the result of the attempt. A failed test event is retried like any
+	// other delivery.
+	SendTestEvent(ctx context.Context, id string) (Delivery, error)
+}
+
+type CreateWebhookReq struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"required"`
+}
+
+type UpdateWebhookReq struct {
+	ID     string    `json:"id" validate:"required"`
+	URL    *string   `json:"url" validate:"omitempty,http_url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+type ListDeliveriesReq struct {
+	WebhookID string `json:"webhook_id" validate:"required"`
+	internal.ListOptions
+}
+
+// DueDelivery is a delivery along with where it is sent to.
+type DueDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+type RecordAttemptReq struct {
+	ID             string
+	Status         DeliveryStatus
+	ResponseStatus *int
+	Error          *string
+	// Only used if the delivery is still pending.
+	NextAttemptAt time.Time
+}
+
+// How many deliveries DeliverDue attempts at most.
+const deliveryBatchSize = 50
+
+// sendingClaim keeps DeliverDue from picking up a delivery while it's sent
+// right away. It's retried after it if the attempt is never recorded.
+const sendingClaim = time.Minute
+
+type service struct {
+	r      Repository
+	client *http.Client
+	v      *validator.Validator
+}
+
+func NewService(r Repository, client *http.Client, v *validator.Validator) Service {
+	return &service{
+		r:      r,
+		client: client,
+		v:      v,
+	}
+}
+
+func (s *service) WebhookByID(ctx context.Context, id string) (Webhook, error) {
+	if id == "" {
+		return Webhook{}, internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.WebhookByID(ctx, id)
+}
+
+func (s *service) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	return s.r.ListWebhooks(ctx)
+}
+
+func (s *service) CreateWebhook(ctx context.Context, c CreateWebhookReq) (Webhook, error) {
+	if err := s.v.Struct(c); err != nil {
+		return Webhook{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if err := validateEvents(c.Events); err != nil {
+		return Webhook{}, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Webhook{}, fmt.Errorf("webhook.Service.CreateWebhook: %w", err)
+	}
+
+	return s.r.CreateWebhook(ctx, c, secret)
+}
+
+func (s *service) UpdateWebhook(ctx context.Context, u UpdateWebhookReq) (Webhook, error) {
+	if err := s.v.Struct(u); err != nil {
+		return Webhook{}, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+
+	if u.URL == nil && u.Events == nil && u.Active == nil {
+		return Webhook{}, internal.NewError(internal.ErrorCodeInvalid, "Must update at least one field")
+	}
+
+	if u.Events != nil {
+		if err := validateEvents(*u.Events); err != nil {
+			return Webhook{}, err
+		}
+	}
+
+	return s.r.UpdateWebhook(ctx, u)
+}
+
+func (s *service) DeleteWebhook(ctx context.Context, id string) error {
+	if id == "" {
+		return internal.NewError(internal.ErrorCodeInvalid, "ID is required")
+	}
+	return s.r.DeleteWebhook(ctx, id)
+}
+
+func (s *service) Dispatch(ctx context.Context, event string, resourceID string, data any) error {
+	payload, err := MarshalPayload(event, resourceID, time.Now().UTC(), data)
+	if err != nil {
+		return fmt.Errorf("webhook.Service.Dispatch: %w", err)
+	}
+
+	if _, err := s.r.CreateDeliveries(ctx, event, payload); err != nil {
+		return fmt.Errorf("webhook.Service.Dispatch: %w", err)
+	}
+
+	return nil
+}
+
+func (s *service) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.r.ListDueDeliveries(ctx, deliveryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("webhook.Service.DeliverDue: %w", err)
+	}
+
+	for _, d := range deliveries {
+		if _, err := s.attempt(ctx, d); err != nil {
+			return 0, fmt.Errorf("webhook.Service.DeliverDue: %w", err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+func (s *service) ListDeliveries(ctx context.Context, l ListDeliveriesReq) ([]Delivery, error) {
+	if err := s.v.Struct(l); err != nil {
+		return nil, internal.NewError(internal.ErrorCodeInvalid, err.Error())
+	}
+	return s.r.ListDeliveries(ctx, l)
+}
+
+func (s *service) SendTestEvent(ctx context.Context, id string) (Delivery, error) {
+	w, err := s.WebhookByID(ctx, id)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	payload, err := MarshalPayload(EventTest, w.ID, time.Now().UTC(), nil)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("webhook.Service.SendTestEvent: %w", err)
+	}
+
+	d, err := s.r.CreateDelivery(ctx, w.ID, EventTest, payload, time.Now().Add(sendingClaim))
+	if err != nil {
+		return Delivery{}, fmt.Errorf("webhook.Service.SendTestEvent: %w", err)
+	}
+
+	result, err := s.attempt(ctx, DueDelivery{
+		Delivery: d,
+		URL:      w.URL,
+		Secret:   w.Secret,
+	})
+	if err != nil {
+		return Delivery{}, fmt.Errorf("webhook.Service.SendTestEvent: %w", err)
+	}
+
+	return result, nil
+}
+
+// attempt sends the delivery and records the result. A failed delivery is
+// retried after the backoff until it runs out of attempts.
+func (s *service) attempt(ctx context.Context, d DueDelivery) (Delivery, error) {
+	r := RecordAttemptReq{
+		ID:     d.ID,
+		Status: DeliveryStatusSucceeded,
+	}
+
+	status, err := s.post(ctx, d)
+	if status != 0 {
+		r.ResponseStatus = &status
+	}
+	if err != nil {
+		msg := err.Error()
+		r.Error = &msg
+
+		attempts := d.Attempts + 1
+		if attempts >= MaxAttempts {
+			r.Status = DeliveryStatusFailed
+		} else {
+			r.Status = DeliveryStatusPending
+			r.NextAttemptAt = time.Now().Add(Backoff(attempts))
+		}
+	}
+
+	return s.r.RecordAttempt(ctx, r)
+}
+
+// post returns the status code of the response, 0 if there was none, and an
+// error if the delivery didn't succeed.
+func (s *service) post(ctx context.Context, d DueDelivery) (int, error) {
+	body := []byte(d.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderSignature, Sign(d.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// the response is not used but reading it lets the connection be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func validateEvents(events []string) error {
+	if len(events) == 0 {
+		return internal.NewError(internal.ErrorCodeInvalid, "'events' is required")
+	}
+
+	for _, e := range events {
+		if !slices.Contains(Events, e) {
+			return internal.NewErrorf(
+				internal.ErrorCodeInvalid,
+				"'events' must only contain '%s'",
+				strings.Join(Events, " "),
+			)
+		}
+	}
+
+	return nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+)
+
+// EventTest is only sent by the test endpoint.
+const EventTest = "webhook.test"
+
+// Events lists the events a webhook can subscribe to.
+var Events = []string{
+	expense.EventExpenseCreated,
+	expense.EventExpenseUpdated,
+	expense.EventExpenseDeleted,
+	expense.EventExpenseGroupCreated,
+	expense.EventExpenseGroupUpdated,
+	expense.EventExpenseGroupDeleted,
+	category.EventCategoryCreated,
+	category.EventCategoryUpdated,
+	category.EventCategoryDeleted,
+}
+
+const (
+	// A delivery is given up after this many failed attempts.
+	MaxAttempts = 10
+	// Wait before the first retry, doubled after every failed attempt.
+	initialBackoff = 30 * time.Second
+	maxBackoff     = 12 * time.Hour
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type Webhook struct {
+	ID  string
+	URL string
+	// Shared with the receiver to verify the signature of the payloads.
+	Secret string
+	Events []string
+	// Inactive webhooks don't get new deliveries.
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	// The delivery was given up after MaxAttempts.
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// Delivery is an event queued for a webhook. Deliveries are kept after they
+// are done as the delivery log.
+type Delivery struct {
+	ID        string
+	WebhookID string
+	Event     string
+	// JSON body that is POSTed to the webhook.
+	Payload  string
+	Status   DeliveryStatus
+	Attempts int
+	// When the delivery is attempted next. Only meaningful while pending.
+	NextAttemptAt time.Time
+	// Status code of the response to the last attempt. Nil if there was no
+	// response.
+	ResponseStatus *int
+	// Why the last attempt failed.
+	Error       *string
+	DeliveredAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Backoff is how long to wait before retrying a delivery that failed the
+// given number of attempts.
+func Backoff(attempts int) time.Duration {
+	d := initialBackoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// Sign returns the value of the signature header of the payload. The
+// receiver computes the HMAC-SHA256, with the secret of the webhook as the
+// key, of the timestamp, a dot and the raw body, then compares it with v1.
+// The timestamp lets the receiver reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return fmt.Sprintf("t=%s,v1=%s", t, hex.EncodeToString(mac.Sum(nil)))
+}
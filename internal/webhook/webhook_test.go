@@ -0,0 +1,130 @@
+package webhook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cativovo/budget-tracker/internal/category"
+	"github.com/cativovo/budget-tracker/internal/expense"
+	"github.com/cativovo/budget-tracker/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	secret := "secret"
+	timestamp := time.Unix(1136214245, 0)
+	body := []byte(`{"event":"expense.created"}`)
+
+	got := webhook.Sign(secret, timestamp, body)
+
+	t.Run("receiver can verify", func(t *testing.T) {
+		parts := strings.Split(got, ",")
+		assert.Len(t, parts, 2)
+		assert.Equal(t, "t=1136214245", parts[0])
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("1136214245." + string(body)))
+		want := "v1=" + hex.EncodeToString(mac.Sum(nil))
+
+		assert.Equal(t, want, parts[1])
+	})
+
+	t.Run("depends on the secret, timestamp and body", func(t *testing.T) {
+		assert.NotEqual(t, got, webhook.Sign("other", timestamp, body))
+		assert.NotEqual(t, got, webhook.Sign(secret, timestamp.Add(time.Second), body))
+		assert.NotEqual(t, got, webhook.Sign(secret, timestamp, []byte(`{}`)))
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[string]struct {
+		attempts int
+		want     time.Duration
+	}{
+		"first retry": {
+			attempts: 1,
+			want:     30 * time.Second,
+		},
+		"doubles": {
+			attempts: 3,
+			want:     2 * time.Minute,
+		},
+		"capped": {
+			attempts: 20,
+			want:     12 * time.Hour,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, webhook.Backoff(test.attempts))
+		})
+	}
+}
+
+func TestMarshalPayload(t *testing.T) {
+	createdAt := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	food := category.Category{ID: "1", Name: "food", Color: "#000000", Icon: "food-icon"}
+
+	tests := map[string]struct {
+		event   string
+		data    any
+		want    string
+		wantErr bool
+	}{
+		"expense": {
+			event: expense.EventExpenseCreated,
+			data: expense.Expense{
+				ID:       "2",
+				Name:     "Groceries",
+				Amount:   500,
+				Date:     createdAt,
+				Category: food,
+				Tags:     []string{"weekly"},
+			},
+			want: `{"id":"2","name":"Groceries","amount":500,"date":"2006-01-02","note":"","category":{"id":"1","name":"food","color":"#000000","icon":"food-icon","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","parent_id":null},"tags":["weekly"],"account_id":null,"created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z"}`,
+		},
+		"category": {
+			event: category.EventCategoryUpdated,
+			data:  food,
+			want:  `{"id":"1","name":"food","color":"#000000","icon":"food-icon","created_at":"0001-01-01T00:00:00Z","updated_at":"0001-01-01T00:00:00Z","parent_id":null}`,
+		},
+		"deleted": {
+			event: category.EventCategoryDeleted,
+			want:  `null`,
+		},
+		"unsupported data": {
+			event:   category.EventCategoryCreated,
+			data:    "food",
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := webhook.MarshalPayload(test.event, "1", createdAt, test.data)
+			if test.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			var p struct {
+				Event      string          `json:"event"`
+				ResourceID string          `json:"resource_id"`
+				CreatedAt  time.Time       `json:"created_at"`
+				Data       json.RawMessage `json:"data"`
+			}
+			assert.Nil(t, json.Unmarshal([]byte(got), &p))
+			assert.Equal(t, test.event, p.Event)
+			assert.Equal(t, "1", p.ResourceID)
+			assert.Equal(t, createdAt, p.CreatedAt)
+			assert.JSONEq(t, test.want, string(p.Data))
+		})
+	}
+}